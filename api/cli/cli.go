@@ -8,6 +8,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -35,9 +36,12 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		Assets:                    kingpin.Flag("assets", "Path to the assets").Default(defaultAssetsDirectory).Short('a').String(),
 		Data:                      kingpin.Flag("data", "Path to the folder where the data is stored").Default(defaultDataDirectory).Short('d').String(),
 		DemoEnvironment:           kingpin.Flag("demo", "Demo environment").Bool(),
+		DemoBlockedRoutes:         kingpin.Flag("demo-blocked-route", "Route blocked in demo environments in addition to the default destructive routes, as METHOD /api/path, e.g. \"DELETE /api/stacks/{id}\"").Strings(),
 		EndpointURL:               kingpin.Flag("host", "Environment URL").Short('H').String(),
 		FeatureFlags:              kingpin.Flag("feat", "List of feature flags").Strings(),
 		EnableEdgeComputeFeatures: kingpin.Flag("edge-compute", "Enable Edge Compute features").Bool(),
+		EdgeEnforceHTTPS:          kingpin.Flag("edge-enforce-https", "Require the URL exposed to edge agents to use https").Bool(),
+		JWTSigningKeyMode:         kingpin.Flag("jwt-signing-key-mode", "Whether the JWT signing key is regenerated on each start, which logs out every user, or persisted encrypted with the database encryption key so sessions survive restarts (requires an encryption secret key)").Default(portainer.JWTSigningKeyRegenerate).Enum(portainer.JWTSigningKeyRegenerate, portainer.JWTSigningKeyPersist),
 		NoAnalytics:               kingpin.Flag("no-analytics", "Disable Analytics in app (deprecated)").Bool(),
 		TLS:                       kingpin.Flag("tlsverify", "TLS support").Default(defaultTLS).Bool(),
 		TLSSkipVerify:             kingpin.Flag("tlsskipverify", "Disable TLS server verification").Default(defaultTLSSkipVerify).Bool(),
@@ -60,6 +64,7 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		InitialMmapSize:           kingpin.Flag("initial-mmap-size", "Initial mmap size of the database in bytes").Int(),
 		MaxBatchSize:              kingpin.Flag("max-batch-size", "Maximum size of a batch").Int(),
 		MaxBatchDelay:             kingpin.Flag("max-batch-delay", "Maximum delay before a batch starts").Duration(),
+		MaxUserSessionTimeout:     kingpin.Flag("max-user-session-timeout", "Longest user session timeout that can be configured in the settings").Default(portainer.DefaultMaxUserSessionTimeout.String()).Duration(),
 		SecretKeyName:             kingpin.Flag("secret-key-name", "Secret key name for encryption and will be used as /run/secrets/<secret-key-name>.").Default(defaultSecretKeyName).String(),
 		LogLevel:                  kingpin.Flag("log-level", "Set the minimum logging level to show").Default("INFO").Enum("DEBUG", "INFO", "WARN", "ERROR"),
 		LogMode:                   kingpin.Flag("log-mode", "Set the logging output mode").Default("PRETTY").Enum("PRETTY", "JSON"),
@@ -97,6 +102,11 @@ func (*Service) ValidateFlags(flags *portainer.CLIFlags) error {
 		return errAdminPassExcludeAdminPassFile
 	}
 
+	_, err = middlewares.DemoBlockedRoutes(*flags.DemoBlockedRoutes)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -29,6 +29,8 @@ import (
 	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/proxy"
 	kubeproxy "github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/internal/authorization"
@@ -189,12 +191,23 @@ func initAPIKeyService(datastore dataservices.DataStore) apikey.APIKeyService {
 	return apikey.NewAPIKeyService(datastore.APIKeyRepository(), datastore.User())
 }
 
-func initJWTService(userSessionTimeout string, dataStore dataservices.DataStore) (dataservices.JWTService, error) {
+func initJWTService(userSessionTimeout string, signingKeyMode string, encryptionKey []byte, dataStore dataservices.DataStore) (dataservices.JWTService, error) {
 	if userSessionTimeout == "" {
 		userSessionTimeout = portainer.DefaultUserSessionTimeout
 	}
 
-	jwtService, err := jwt.NewService(userSessionTimeout, dataStore)
+	newService := jwt.NewService
+	if signingKeyMode == portainer.JWTSigningKeyPersist {
+		if encryptionKey != nil {
+			newService = func(userSessionTimeout string, dataStore dataservices.DataStore) (*jwt.Service, error) {
+				return jwt.NewServiceWithPersistedSecret(userSessionTimeout, dataStore, encryptionKey)
+			}
+		} else {
+			log.Warn().Msg("the JWT signing key is only persisted when the database is encrypted, a new key is generated and every session is invalidated")
+		}
+	}
+
+	jwtService, err := newService(userSessionTimeout, dataStore)
 	if err != nil {
 		return nil, err
 	}
@@ -206,8 +219,11 @@ func initDigitalSignatureService() portainer.DigitalSignatureService {
 	return crypto.NewECDSAService(os.Getenv("AGENT_SECRET"))
 }
 
-func initCryptoService() portainer.CryptoService {
-	return &crypto.Service{}
+func initCryptoService(hashCost int) portainer.CryptoService {
+	cryptoService := &crypto.Service{}
+	cryptoService.SetHashCost(hashCost)
+
+	return cryptoService
 }
 
 func initLDAPService() portainer.LDAPService {
@@ -323,6 +339,47 @@ func updateSettingsFromFlags(dataStore dataservices.DataStore, flags *portainer.
 	return dataStore.SSLSettings().UpdateSettings(sslSettings)
 }
 
+// settingsOverrides lists the settings whose stored values are replaced at startup by updateSettingsFromFlags
+func settingsOverrides(flags *portainer.CLIFlags) []portainer.SettingsOverride {
+	overrides := []portainer.SettingsOverride{}
+
+	override := func(field, source, name string, value any) {
+		overrides = append(overrides, portainer.SettingsOverride{Field: field, Source: source, Name: name, Value: value})
+	}
+
+	if *flags.SnapshotInterval != "" {
+		override("SnapshotInterval", portainer.SettingsOverrideSourceFlag, "--snapshot-interval", *flags.SnapshotInterval)
+	}
+
+	if *flags.Logo != "" {
+		override("LogoURL", portainer.SettingsOverrideSourceFlag, "--logo", *flags.Logo)
+	}
+
+	if *flags.EnableEdgeComputeFeatures {
+		override("EnableEdgeComputeFeatures", portainer.SettingsOverrideSourceFlag, "--edge-compute", true)
+	}
+
+	if *flags.Templates != "" {
+		override("TemplatesURL", portainer.SettingsOverrideSourceFlag, "--templates", *flags.Templates)
+	}
+
+	if *flags.Labels != nil {
+		override("BlackListedLabels", portainer.SettingsOverrideSourceFlag, "--hide-label", *flags.Labels)
+	}
+
+	if _, ok := os.LookupEnv("AGENT_SECRET"); ok {
+		override("AgentSecret", portainer.SettingsOverrideSourceEnv, "AGENT_SECRET", portainer.SettingsOverrideRedacted)
+	}
+
+	if *flags.HTTPDisabled {
+		override("SSLSettings.HTTPEnabled", portainer.SettingsOverrideSourceFlag, "--http-disabled", false)
+	} else if *flags.HTTPEnabled {
+		override("SSLSettings.HTTPEnabled", portainer.SettingsOverrideSourceFlag, "--http-enabled", true)
+	}
+
+	return overrides
+}
+
 func loadAndParseKeyPair(fileService portainer.FileService, signatureService portainer.DigitalSignatureService) error {
 	private, public, err := fileService.LoadKeyPair()
 	if err != nil {
@@ -376,6 +433,10 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		featureflags.Parse(*flags.FeatureFlags, portainer.SupportedFeatureFlags)
 	}
 
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		log.Warn().Str("feature", portainer.FeatureNoTx).Msg("the feature flag is deprecated and will be removed, the database updates will only run in transactions")
+	}
+
 	fileService := initFileService(*flags.Data)
 	encryptionKey := loadEncryptionSecretKey(*flags.SecretKeyName)
 	if encryptionKey == nil {
@@ -400,7 +461,12 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		log.Fatal().Err(err).Msg("")
 	}
 
-	jwtService, err := initJWTService(settings.UserSessionTimeout, dataStore)
+	err = client.SetOutboundProxy(settings.OutboundProxyURL)
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid outbound proxy URL, falling back to the proxy defined by the environment")
+	}
+
+	jwtService, err := initJWTService(settings.UserSessionTimeout, *flags.JWTSigningKeyMode, encryptionKey, dataStore)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed initializing JWT service")
 	}
@@ -413,7 +479,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 
 	openAMTService := openamt.NewService()
 
-	cryptoService := initCryptoService()
+	cryptoService := initCryptoService(settings.InternalAuthSettings.PasswordHashCost)
 
 	digitalSignatureService := initDigitalSignatureService()
 
@@ -492,6 +558,11 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		}
 	}
 
+	demoBlockedRoutes, err := middlewares.DemoBlockedRoutes(*flags.DemoBlockedRoutes)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed parsing the demo blocked routes")
+	}
+
 	// channel to control when the admin user is created
 	adminCreationDone := make(chan struct{}, 1)
 
@@ -546,6 +617,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	scheduler := scheduler.NewScheduler(shutdownCtx)
 	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, dockerClientFactory, dataStore)
 	deployments.StartStackSchedules(scheduler, stackDeployer, dataStore, gitService)
+	ldap.StartCAExpiryMonitor(scheduler, dataStore)
 
 	sslDBSettings, err := dataStore.SSLSettings().Settings()
 	if err != nil {
@@ -577,6 +649,9 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		BindAddress:                 *flags.Addr,
 		BindAddressHTTPS:            *flags.AddrHTTPS,
 		HTTPEnabled:                 sslDBSettings.HTTPEnabled,
+		EdgeEnforceHTTPS:            *flags.EdgeEnforceHTTPS,
+		MaxUserSessionTimeout:       *flags.MaxUserSessionTimeout,
+		SettingsOverrides:           settingsOverrides(flags),
 		AssetsPath:                  *flags.Assets,
 		DataStore:                   dataStore,
 		EdgeStacksService:           edgeStacksService,
@@ -605,6 +680,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		ShutdownTrigger:             shutdownTrigger,
 		StackDeployer:               stackDeployer,
 		DemoService:                 demoService,
+		DemoBlockedRoutes:           demoBlockedRoutes,
 		UpgradeService:              upgradeService,
 		AdminCreationDone:           adminCreationDone,
 	}
@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AesGcmEncryptAndDecrypt(t *testing.T) {
+	is := assert.New(t)
+
+	key := bytes.Repeat([]byte{1}, 32)
+	plaintext := []byte("signing key")
+
+	encrypted, err := AesGcmEncrypt(plaintext, key)
+	is.NoError(err)
+	is.NotContains(string(encrypted), string(plaintext))
+
+	decrypted, err := AesGcmDecrypt(encrypted, key)
+	is.NoError(err)
+	is.Equal(plaintext, decrypted)
+
+	_, err = AesGcmDecrypt(encrypted, bytes.Repeat([]byte{2}, 32))
+	is.Error(err, "the data cannot be decrypted with another key")
+
+	_, err = AesGcmDecrypt([]byte("short"), key)
+	is.Error(err)
+}
@@ -1,15 +1,37 @@
 package crypto
 
 import (
+	"sync/atomic"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// MaxDataLength is the number of bytes of the data hashed by bcrypt. bcrypt ignores the bytes beyond it, so longer
+// passwords are rejected rather than pre-hashed, the existing hashes stay valid and no byte is silently dropped
+const MaxDataLength = 72
+
 // Service represents a service for encrypting/hashing data.
-type Service struct{}
+type Service struct {
+	hashCost atomic.Int32
+}
+
+// SetHashCost sets the bcrypt cost of the hashes created from now on, 0 uses the default cost.
+// The hashes created with another cost can still be compared since the cost is stored in the hash
+func (service *Service) SetHashCost(cost int) {
+	service.hashCost.Store(int32(cost))
+}
+
+func (service *Service) cost() int {
+	if cost := int(service.hashCost.Load()); cost != 0 {
+		return cost
+	}
+
+	return bcrypt.DefaultCost
+}
 
 // Hash hashes a string using the bcrypt algorithm
-func (*Service) Hash(data string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(data), bcrypt.DefaultCost)
+func (service *Service) Hash(data string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(data), service.cost())
 	if err != nil {
 		return "", err
 	}
@@ -17,6 +39,18 @@ func (*Service) Hash(data string) (string, error) {
 }
 
 // CompareHashAndData compares a hash to clear data and returns an error if the comparison fails.
-func (*Service) CompareHashAndData(hash string, data string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(data))
+// On success, it also returns whether the hash uses a lower cost than the current one and should be replaced
+// by a new hash of the data, so that the stored hashes are upgraded when the cost is raised.
+func (service *Service) CompareHashAndData(hash string, data string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(data))
+	if err != nil {
+		return false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, err
+	}
+
+	return cost < service.cost(), nil
 }
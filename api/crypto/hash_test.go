@@ -2,6 +2,9 @@ package crypto
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestService_Hash(t *testing.T) {
@@ -44,10 +47,50 @@ func TestService_Hash(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			err := s.CompareHashAndData(tt.args.hash, tt.args.data)
+			_, err := s.CompareHashAndData(tt.args.hash, tt.args.data)
 			if (err != nil) == tt.expect {
 				t.Errorf("Service.CompareHashAndData() = %v", err)
 			}
 		})
 	}
 }
+
+func TestService_SetHashCost(t *testing.T) {
+	is := assert.New(t)
+
+	s := &Service{}
+
+	hash, err := s.Hash("Passw0rd!")
+	is.NoError(err)
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	is.NoError(err)
+	is.Equal(bcrypt.DefaultCost, cost)
+
+	s.SetHashCost(bcrypt.MinCost)
+
+	newHash, err := s.Hash("Passw0rd!")
+	is.NoError(err)
+
+	cost, err = bcrypt.Cost([]byte(newHash))
+	is.NoError(err)
+	is.Equal(bcrypt.MinCost, cost)
+
+	needsRehash, err := s.CompareHashAndData(hash, "Passw0rd!")
+	is.NoError(err, "the hashes created with another cost still verify")
+	is.False(needsRehash, "a hash with a higher cost is kept")
+
+	needsRehash, err = s.CompareHashAndData(newHash, "Passw0rd!")
+	is.NoError(err)
+	is.False(needsRehash)
+
+	s.SetHashCost(bcrypt.MinCost + 1)
+
+	needsRehash, err = s.CompareHashAndData(newHash, "Passw0rd!")
+	is.NoError(err)
+	is.True(needsRehash, "a hash with a lower cost is upgraded")
+
+	needsRehash, err = s.CompareHashAndData(newHash, "wrong")
+	is.Error(err)
+	is.False(needsRehash)
+}
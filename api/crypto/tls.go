@@ -3,9 +3,34 @@ package crypto
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"os"
 )
 
+// DefaultTLSMinVersion is the minimum TLS protocol version of the configurations created by CreateTLSConfiguration
+const DefaultTLSMinVersion = "1.2"
+
+// tlsMinVersions are the TLS protocol versions that can be required as minimum, the older ones are deprecated
+var tlsMinVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSMinVersion returns the TLS protocol version of a minimum version such as "1.3", the default version when it
+// is empty. TLS 1.0 and 1.1 are rejected
+func ParseTLSMinVersion(version string) (uint16, error) {
+	if version == "" {
+		version = DefaultTLSMinVersion
+	}
+
+	tlsVersion, ok := tlsMinVersions[version]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q, must be 1.2 or 1.3", version)
+	}
+
+	return tlsVersion, nil
+}
+
 // CreateTLSConfiguration creates a basic tls.Config with recommended TLS settings
 func CreateTLSConfiguration() *tls.Config {
 	return &tls.Config{
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTLSMinVersion(t *testing.T) {
+	is := assert.New(t)
+
+	version, err := ParseTLSMinVersion("")
+	is.NoError(err)
+	is.Equal(uint16(tls.VersionTLS12), version, "TLS 1.2 is the default")
+	is.Equal(CreateTLSConfiguration().MinVersion, version, "the default matches the default configuration")
+
+	version, err = ParseTLSMinVersion("1.3")
+	is.NoError(err)
+	is.Equal(uint16(tls.VersionTLS13), version)
+
+	for _, unsupported := range []string{"1.0", "1.1", "1.4", "TLS1.2", " 1.2"} {
+		_, err = ParseTLSMinVersion(unsupported)
+		is.Error(err, unsupported)
+	}
+}
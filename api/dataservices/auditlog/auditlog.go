@@ -0,0 +1,52 @@
+package auditlog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "audit_log"
+)
+
+// Service represents a service for managing audit log data.
+type Service struct {
+	dataservices.BaseDataService[portainer.AuditLogEntry, portainer.AuditLogEntryID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.AuditLogEntry, portainer.AuditLogEntryID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		BaseDataServiceTx: dataservices.BaseDataServiceTx[portainer.AuditLogEntry, portainer.AuditLogEntryID]{
+			Bucket:     BucketName,
+			Connection: service.Connection,
+			Tx:         tx,
+		},
+	}
+}
+
+// Create assigns an ID to a new audit log entry and saves it.
+func (service *Service) Create(entry *portainer.AuditLogEntry) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			entry.ID = portainer.AuditLogEntryID(id)
+			return int(entry.ID), entry
+		},
+	)
+}
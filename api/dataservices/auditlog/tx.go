@@ -0,0 +1,21 @@
+package auditlog
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+type ServiceTx struct {
+	dataservices.BaseDataServiceTx[portainer.AuditLogEntry, portainer.AuditLogEntryID]
+}
+
+// Create assigns an ID to a new audit log entry and saves it.
+func (service ServiceTx) Create(entry *portainer.AuditLogEntry) error {
+	return service.Tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			entry.ID = portainer.AuditLogEntryID(id)
+			return int(entry.ID), entry
+		},
+	)
+}
@@ -11,6 +11,7 @@ import (
 type (
 	DataStoreTx interface {
 		IsErrObjectNotFound(err error) bool
+		AuditLog() AuditLogService
 		CustomTemplate() CustomTemplateService
 		EdgeGroup() EdgeGroupService
 		EdgeJob() EdgeJobService
@@ -52,6 +53,11 @@ type (
 		DataStoreTx
 	}
 
+	// AuditLogService represents a service to manage the audit log
+	AuditLogService interface {
+		BaseCRUD[portainer.AuditLogEntry, portainer.AuditLogEntryID]
+	}
+
 	// CustomTemplateService represents a service to manage custom templates
 	CustomTemplateService interface {
 		BaseCRUD[portainer.CustomTemplate, portainer.CustomTemplateID]
@@ -133,6 +139,8 @@ type (
 		GenerateTokenForKubeconfig(data *portainer.TokenData) (string, error)
 		ParseAndVerifyToken(token string) (*portainer.TokenData, error)
 		SetUserSessionDuration(userSessionDuration time.Duration)
+		SetTokenIssueFloor(floor int64)
+		SetSessionRevocationFloor(floor int64)
 	}
 
 	// RegistryService represents a service for managing registry data
@@ -162,6 +170,7 @@ type (
 	SettingsService interface {
 		Settings() (*portainer.Settings, error)
 		UpdateSettings(settings *portainer.Settings) error
+		UpdateStatus(settings *portainer.Settings) error
 		BucketName() string
 	}
 
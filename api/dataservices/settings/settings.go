@@ -50,7 +50,15 @@ func (service *Service) Settings() (*portainer.Settings, error) {
 	return &settings, nil
 }
 
-// UpdateSettings persists a Settings object.
+// UpdateSettings persists a Settings object and increments its revision, so that every write changes the ETag of the settings
 func (service *Service) UpdateSettings(settings *portainer.Settings) error {
+	settings.Revision++
+
+	return service.connection.UpdateObject(BucketName, []byte(settingsKey), settings)
+}
+
+// UpdateStatus persists a Settings object without incrementing its revision. It is used for the fields maintained by
+// Portainer itself, so that they do not invalidate the ETag read by the administrators
+func (service *Service) UpdateStatus(settings *portainer.Settings) error {
 	return service.connection.UpdateObject(BucketName, []byte(settingsKey), settings)
 }
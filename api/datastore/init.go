@@ -55,6 +55,8 @@ func (store *Store) checkOrCreateDefaultSettings() error {
 			EdgeAgentCheckinInterval: portainer.DefaultEdgeAgentCheckinIntervalInSeconds,
 			TemplatesURL:             portainer.DefaultTemplatesURL,
 			HelmRepositoryURL:        portainer.DefaultHelmRepositoryURL,
+			HelmRepositoryURLs:       []string{portainer.DefaultHelmRepositoryURL},
+			HelmRepositoryType:       portainer.HelmRepositoryTypeClassic,
 			UserSessionTimeout:       portainer.DefaultUserSessionTimeout,
 			KubeconfigExpiry:         portainer.DefaultKubeconfigExpiry,
 			KubectlShellImage:        portainer.DefaultKubectlShellImage,
@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/portainer/api/database/models"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/dataservices/apikeyrepository"
+	"github.com/portainer/portainer/api/dataservices/auditlog"
 	"github.com/portainer/portainer/api/dataservices/customtemplate"
 	"github.com/portainer/portainer/api/dataservices/dockerhub"
 	"github.com/portainer/portainer/api/dataservices/edgegroup"
@@ -45,6 +46,7 @@ type Store struct {
 	connection portainer.Connection
 
 	fileService               portainer.FileService
+	AuditLogService           *auditlog.Service
 	CustomTemplateService     *customtemplate.Service
 	DockerHubService          *dockerhub.Service
 	EdgeGroupService          *edgegroup.Service
@@ -93,6 +95,12 @@ func (store *Store) initServices() error {
 	}
 	store.DockerHubService = dockerhubService
 
+	auditLogService, err := auditlog.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AuditLogService = auditLogService
+
 	endpointRelationService, err := endpointrelation.NewService(store.connection)
 	if err != nil {
 		return err
@@ -281,6 +289,11 @@ func (store *Store) FDOProfile() dataservices.FDOProfileService {
 	return store.FDOProfilesService
 }
 
+// AuditLog gives access to the AuditLog data management layer
+func (store *Store) AuditLog() dataservices.AuditLogService {
+	return store.AuditLogService
+}
+
 // HelmUserRepository access the helm user repository settings
 func (store *Store) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return store.HelmUserRepositoryService
@@ -361,6 +374,7 @@ func (store *Store) Webhook() dataservices.WebhookService {
 }
 
 type storeExport struct {
+	AuditLog           []portainer.AuditLogEntry      `json:"audit_log,omitempty"`
 	CustomTemplate     []portainer.CustomTemplate     `json:"customtemplates,omitempty"`
 	EdgeGroup          []portainer.EdgeGroup          `json:"edgegroups,omitempty"`
 	EdgeJob            []portainer.EdgeJob            `json:"edgejobs,omitempty"`
@@ -392,6 +406,14 @@ func (store *Store) Export(filename string) (err error) {
 
 	backup := storeExport{}
 
+	if a, err := store.AuditLog().ReadAll(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			log.Error().Err(err).Msg("exporting Audit Log")
+		}
+	} else {
+		backup.AuditLog = a
+	}
+
 	if c, err := store.CustomTemplate().ReadAll(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			log.Error().Err(err).Msg("exporting Custom Templates")
@@ -610,6 +632,10 @@ func (store *Store) Import(filename string) (err error) {
 
 	store.Version().UpdateVersion(&backup.Version)
 
+	for _, v := range backup.AuditLog {
+		store.AuditLog().Update(v.ID, &v)
+	}
+
 	for _, v := range backup.CustomTemplate {
 		store.CustomTemplate().Update(v.ID, &v)
 	}
@@ -14,6 +14,10 @@ func (tx *StoreTx) IsErrObjectNotFound(err error) bool {
 	return tx.store.IsErrObjectNotFound(err)
 }
 
+func (tx *StoreTx) AuditLog() dataservices.AuditLogService {
+	return tx.store.AuditLogService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) CustomTemplate() dataservices.CustomTemplateService { return nil }
 
 func (tx *StoreTx) EdgeGroup() dataservices.EdgeGroupService {
@@ -53,6 +53,16 @@ const (
 	ExtensionRegistryManagementStorePath = "extensions"
 	// CustomTemplateStorePath represents the subfolder where custom template files are stored in the file store folder.
 	CustomTemplateStorePath = "custom_templates"
+	// TemplatesStorePath represents the subfolder where the uploaded app templates file is stored in the file store folder.
+	TemplatesStorePath = "templates"
+	// TemplatesFileName represents the name of the uploaded app templates file.
+	TemplatesFileName = "templates.json"
+	// LogoStorePath represents the subfolder where the uploaded logo is stored in the file store folder.
+	LogoStorePath = "logo"
+	// LogoFileName represents the name of the uploaded logo file.
+	LogoFileName = "logo"
+	// SettingsProfilesStorePath represents the subfolder where the named settings profiles are stored in the file store folder.
+	SettingsProfilesStorePath = "settings_profiles"
 	// TempPath represent the subfolder where temporary files are saved
 	TempPath = "tmp"
 	// SSLCertPath represents the default ssl certificates path
@@ -982,6 +992,76 @@ func (service *Service) StoreFDOProfileFileFromBytes(fdoProfileIdentifier string
 	return service.wrapFileStore(filePath), nil
 }
 
+// StoreTemplatesFile stores the app templates file that is served instead of the templates URL.
+// It returns the path to the file.
+func (service *Service) StoreTemplatesFile(data []byte) (string, error) {
+	err := service.createDirectoryInStore(TemplatesStorePath)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := JoinPaths(TemplatesStorePath, TemplatesFileName)
+	err = service.createFileInStore(filePath, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return service.wrapFileStore(filePath), nil
+}
+
+// GetTemplatesFile returns the content of the uploaded app templates file.
+func (service *Service) GetTemplatesFile() ([]byte, error) {
+	return service.GetFileContent(service.wrapFileStore(TemplatesStorePath), TemplatesFileName)
+}
+
+// StoreLogoFile stores the logo served instead of the logo URL, it replaces the previously uploaded logo.
+// It returns the path to the file.
+func (service *Service) StoreLogoFile(data []byte) (string, error) {
+	err := service.createDirectoryInStore(LogoStorePath)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := JoinPaths(LogoStorePath, LogoFileName)
+	err = service.createFileInStore(filePath, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return service.wrapFileStore(filePath), nil
+}
+
+// GetLogoFile returns the content of the uploaded logo file.
+func (service *Service) GetLogoFile() ([]byte, error) {
+	return service.GetFileContent(service.wrapFileStore(LogoStorePath), LogoFileName)
+}
+
+// RemoveLogoFile removes the uploaded logo file, it does nothing when no logo was uploaded.
+func (service *Service) RemoveLogoFile() error {
+	err := os.Remove(service.wrapFileStore(JoinPaths(LogoStorePath, LogoFileName)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// StoreSettingsProfileFile stores the named settings profile, it replaces the previous profile with the same name.
+func (service *Service) StoreSettingsProfileFile(name string, data []byte) error {
+	err := service.createDirectoryInStore(SettingsProfilesStorePath)
+	if err != nil {
+		return err
+	}
+
+	return service.createFileInStore(JoinPaths(SettingsProfilesStorePath, name+".json"), bytes.NewReader(data))
+}
+
+// GetSettingsProfileFile returns the content of the named settings profile, the error wraps os.ErrNotExist when
+// there is no profile with this name.
+func (service *Service) GetSettingsProfileFile(name string) ([]byte, error) {
+	return os.ReadFile(service.wrapFileStore(JoinPaths(SettingsProfilesStorePath, name+".json")))
+}
+
 func CreateFile(path string, r io.Reader) error {
 	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
@@ -79,7 +79,8 @@ func Get(url string, timeout int) ([]byte, error) {
 	}
 
 	client := &http.Client{
-		Timeout: time.Second * time.Duration(timeout),
+		Timeout:   time.Second * time.Duration(timeout),
+		Transport: &http.Transport{Proxy: OutboundProxy},
 	}
 
 	response, err := client.Get(url)
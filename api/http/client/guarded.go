@@ -0,0 +1,92 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// NewGuardedHTTPClient returns an HTTP client that is meant to be used when the target URL
+// is provided by a user. Connections to loopback, link-local (e.g. cloud metadata services),
+// multicast and unspecified addresses are refused, and the whole exchange is bounded by timeout.
+// When the request goes through a proxy, the target host is resolved and checked before the proxy is used.
+func NewGuardedHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: guardControl,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               guardedProxy,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
+
+// guardedProxy returns the outbound proxy of the request once its target host is checked, the proxy resolves the
+// host itself so guardControl only sees the address of the proxy
+func guardedProxy(req *http.Request) (*url.URL, error) {
+	proxyURL, err := OutboundProxy(req)
+	if err != nil || proxyURL == nil {
+		return proxyURL, err
+	}
+
+	host := req.URL.Hostname()
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(req.Context(), host)
+		if err != nil {
+			return nil, err
+		}
+
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if IsGuardedIP(ip) {
+			return nil, fmt.Errorf("connections to %s are not allowed", ip)
+		}
+	}
+
+	return proxyURL, nil
+}
+
+// guardControl is called after the address has been resolved and before the connection is
+// established, which prevents DNS rebinding from bypassing the check
+func guardControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unable to parse address %q", host)
+	}
+
+	if IsGuardedIP(ip) {
+		return fmt.Errorf("connections to %s are not allowed", ip)
+	}
+
+	return nil
+}
+
+// IsGuardedIP returns true when the IP must not be reached from user provided URLs
+func IsGuardedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
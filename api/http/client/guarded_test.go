@@ -0,0 +1,55 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGuardedIP(t *testing.T) {
+	is := assert.New(t)
+
+	for _, ip := range []string{"127.0.0.1", "::1", "169.254.169.254", "fe80::1", "224.0.0.1", "0.0.0.0", "::"} {
+		is.True(IsGuardedIP(net.ParseIP(ip)), ip)
+	}
+
+	for _, ip := range []string{"93.184.216.34", "10.0.0.1", "192.168.1.10", "2606:4700::1111"} {
+		is.False(IsGuardedIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestNewGuardedHTTPClient(t *testing.T) {
+	is := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	t.Setenv("HTTP_PROXY", "")
+
+	_, err := NewGuardedHTTPClient(time.Second).Get(srv.URL)
+	is.ErrorContains(err, "connections to 127.0.0.1 are not allowed")
+}
+
+func TestNewGuardedHTTPClient_proxy(t *testing.T) {
+	is := assert.New(t)
+	defer SetOutboundProxy("")
+
+	is.NoError(SetOutboundProxy("http://proxy.local:3128"))
+
+	transport := NewGuardedHTTPClient(time.Second).Transport.(*http.Transport)
+
+	for _, target := range []string{"http://127.0.0.1/", "http://169.254.169.254/latest/meta-data", "http://[::1]:9000/"} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		_, err := transport.Proxy(req)
+		is.Error(err, "the target is checked before going through the proxy: %s", target)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://93.184.216.34/logo.png", nil)
+	proxyURL, err := transport.Proxy(req)
+	is.NoError(err)
+	is.Equal("http://proxy.local:3128", proxyURL.String())
+}
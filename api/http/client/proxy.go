@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+var outboundProxy atomic.Pointer[url.URL]
+
+// ValidateProxyURL returns an error when the URL cannot be used as an outbound proxy
+func ValidateProxyURL(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+		return errors.New("the proxy URL scheme must be one of http, https or socks5")
+	}
+
+	if u.Host == "" {
+		return errors.New("the proxy URL must contain a host")
+	}
+
+	return nil
+}
+
+// SetOutboundProxy configures the proxy used for the outbound requests, an empty URL falls back to the proxy
+// defined by the environment
+func SetOutboundProxy(proxyURL string) error {
+	if proxyURL == "" {
+		outboundProxy.Store(nil)
+
+		return nil
+	}
+
+	err := ValidateProxyURL(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	u, _ := url.Parse(proxyURL)
+	outboundProxy.Store(u)
+
+	return nil
+}
+
+// OutboundProxy returns the proxy to use for an outbound request, it is meant to be used as the Proxy of an http.Transport
+func OutboundProxy(req *http.Request) (*url.URL, error) {
+	if u := outboundProxy.Load(); u != nil {
+		return u, nil
+	}
+
+	return http.ProxyFromEnvironment(req)
+}
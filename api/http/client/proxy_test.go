@@ -0,0 +1,32 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetOutboundProxy(t *testing.T) {
+	is := assert.New(t)
+	defer SetOutboundProxy("")
+
+	is.Error(SetOutboundProxy("ftp://proxy.local:21"))
+	is.Error(SetOutboundProxy("http://"))
+
+	err := SetOutboundProxy("http://proxy.local:3128")
+	is.NoError(err)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://portainer.io", nil)
+	proxyURL, err := OutboundProxy(req)
+	is.NoError(err)
+	is.Equal("http://proxy.local:3128", proxyURL.String())
+
+	err = SetOutboundProxy("")
+	is.NoError(err)
+
+	t.Setenv("HTTPS_PROXY", "")
+	proxyURL, err = OutboundProxy(req)
+	is.NoError(err)
+	is.Nil(proxyURL)
+}
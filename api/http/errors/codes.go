@@ -0,0 +1,59 @@
+package errors
+
+// Machine-readable codes returned in the code field of the error responses. The values are part of the API and must
+// not change once released.
+const (
+	// Settings
+	CodeInvalidAuthMethod             = "INVALID_AUTH_METHOD"
+	CodeAuthMethodChangeCooldown      = "AUTH_METHOD_CHANGE_COOLDOWN"
+	CodeAuthSettingsIncomplete        = "AUTH_SETTINGS_INCOMPLETE"
+	CodeLogoURLInvalid                = "LOGO_URL_INVALID"
+	CodeTemplatesURLInvalid           = "TEMPLATES_URL_INVALID"
+	CodeTemplatesFileMissing          = "TEMPLATES_FILE_MISSING"
+	CodeHelmURLInvalid                = "HELM_URL_INVALID"
+	CodeDurationInvalid               = "DURATION_INVALID"
+	CodeUserSessionTimeoutInvalid     = "USER_SESSION_TIMEOUT_INVALID"
+	CodePasswordPolicyInvalid         = "PASSWORD_POLICY_INVALID"
+	CodeBlackListedLabelsOpInvalid    = "BLACK_LISTED_LABELS_OP_INVALID"
+	CodeSnapshotIntervalInvalid       = "SNAPSHOT_INTERVAL_INVALID"
+	CodeSnapshotWorkerCountInvalid    = "SNAPSHOT_WORKER_COUNT_INVALID"
+	CodePKCEMethodInvalid             = "PKCE_METHOD_INVALID"
+	CodePKCERequiresAuthorizationCode = "PKCE_REQUIRES_AUTHORIZATION_CODE"
+	CodeOAuthRedirectURIInvalid       = "OAUTH_REDIRECT_URI_INVALID"
+	CodeEdgeURLInvalid                = "EDGE_URL_INVALID"
+	CodeEdgeCheckinIntervalInvalid    = "EDGE_CHECKIN_INTERVAL_INVALID"
+	CodeLDAPDistinguishedNameInvalid  = "LDAP_DN_INVALID"
+	CodeLDAPCAExpiryInvalid           = "LDAP_CA_EXPIRY_INVALID"
+	CodeLDAPPoolInvalid               = "LDAP_POOL_INVALID"
+	CodeLDAPTLSVersionInvalid         = "LDAP_TLS_VERSION_INVALID"
+	CodeUIFeatureUnknown              = "UI_FEATURE_UNKNOWN"
+	CodeWebhookURLInvalid             = "WEBHOOK_URL_INVALID"
+	CodeProxyURLInvalid               = "PROXY_URL_INVALID"
+	CodeLimitInvalid                  = "LIMIT_INVALID"
+	CodeKubectlShellImageInvalid      = "KUBECTL_SHELL_IMAGE_INVALID"
+	CodeSettingsRevisionMismatch      = "SETTINGS_REVISION_MISMATCH"
+	CodeLoginLockoutInvalid           = "LOGIN_LOCKOUT_INVALID"
+	CodeSnapshotQuietHoursInvalid     = "SNAPSHOT_QUIET_HOURS_INVALID"
+	CodeSettingsValidationTimeout     = "SETTINGS_VALIDATION_TIMEOUT"
+	CodeTwoFactorNotEnrolled          = "TWO_FACTOR_NOT_ENROLLED"
+
+	// Users
+	CodeUsernameInvalid          = "USERNAME_INVALID"
+	CodeRoleInvalid              = "ROLE_INVALID"
+	CodeUserAlreadyExists        = "USER_ALREADY_EXISTS"
+	CodeAdminAlreadyInitialized  = "ADMIN_ALREADY_INITIALIZED"
+	CodeCannotRemoveSelf         = "CANNOT_REMOVE_SELF"
+	CodeLastAdmin                = "LAST_ADMIN"
+	CodeReauthenticationRequired = "REAUTHENTICATION_REQUIRED"
+	CodePasswordTooWeak          = "PASSWORD_TOO_WEAK"
+	CodePasswordTooLong          = "PASSWORD_TOO_LONG"
+	CodePasswordMismatch         = "PASSWORD_MISMATCH"
+	CodePasswordReuse            = "PASSWORD_REUSE"
+	CodePasswordChangeLocked     = "PASSWORD_CHANGE_LOCKED"
+	CodeInternalPasswordDisabled = "INTERNAL_PASSWORD_DISABLED"
+	CodeTwoFactorRequired        = "TWO_FACTOR_REQUIRED"
+	CodeTwoFactorInvalid         = "TWO_FACTOR_INVALID"
+
+	// Authentication
+	CodeLoginLocked = "LOGIN_LOCKED"
+)
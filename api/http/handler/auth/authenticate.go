@@ -1,11 +1,16 @@
 package auth
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -16,6 +21,8 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+var errLoginLocked = errors.New("The account is locked after too many failed logins")
+
 type authenticatePayload struct {
 	// Username
 	Username string `example:"admin" validate:"required"`
@@ -44,6 +51,9 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 // @summary Authenticate
 // @description **Access policy**: public
 // @description Use this environment(endpoint) to authenticate against Portainer using a username and password.
+// @description When EnableLocalAdminFallback is set, administrators with a local password can log in with it whatever the authentication method,
+// @description the LDAP authentication is used when the local password does not match.
+// @description When MaxLoginAttempts is set, the account is locked for LoginLockoutDuration after that many consecutive failed logins.
 // @tags auth
 // @accept json
 // @produce json
@@ -51,6 +61,7 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 // @success 200 {object} authenticateResponse "Success"
 // @failure 400 "Invalid request"
 // @failure 422 "Invalid Credentials"
+// @failure 429 "Too many failed logins, the Retry-After header holds the remaining lockout in seconds"
 // @failure 500 "Server error"
 // @router /auth [post]
 func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -66,11 +77,34 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 	}
 
 	user, err := handler.DataStore.User().UserByUsername(payload.Username)
-	if err != nil {
-		if !handler.DataStore.IsErrObjectNotFound(err) {
-			return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
-		}
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
+	}
+
+	if !loginLockoutApplies(settings, user) {
+		return handler.authenticateUser(rw, user, payload, settings)
+	}
+
+	known := user != nil
+	now := time.Now()
+	if retryAfter := handler.loginLimiter.retryAfter(payload.Username, known, now); retryAfter > 0 {
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+		return &httperror.HandlerError{StatusCode: http.StatusTooManyRequests, Message: "Too many failed logins. Please try again later", Err: errLoginLocked, Code: httperrors.CodeLoginLocked}
+	}
+
+	handlerErr := handler.authenticateUser(rw, user, payload, settings)
+	if handlerErr == nil {
+		handler.loginLimiter.reset(payload.Username, known)
+	} else if isInvalidCredentials(handlerErr) {
+		handler.loginLimiter.fail(payload.Username, known, settings.MaxLoginAttempts, loginLockout(settings), now)
+	}
 
+	return handlerErr
+}
+
+func (handler *Handler) authenticateUser(rw http.ResponseWriter, user *portainer.User, payload authenticatePayload, settings *portainer.Settings) *httperror.HandlerError {
+	if user == nil {
 		if settings.AuthenticationMethod == portainer.AuthenticationInternal ||
 			settings.AuthenticationMethod == portainer.AuthenticationOAuth ||
 			(settings.AuthenticationMethod == portainer.AuthenticationLDAP && !settings.LDAPSettings.AutoCreateUsers) {
@@ -78,8 +112,14 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 		}
 	}
 
-	if user != nil && isUserInitialAdmin(user) || settings.AuthenticationMethod == portainer.AuthenticationInternal {
-		return handler.authenticateInternal(rw, user, payload.Password)
+	if user != nil && security.CanManageInternalPassword(user, settings) || settings.AuthenticationMethod == portainer.AuthenticationInternal {
+		handlerErr := handler.authenticateInternal(rw, user, payload.Password, settings.InternalAuthSettings.PasswordExpiryDays)
+
+		// the local password of the administrators is only a fallback, the authentication method is still used when
+		// it does not match
+		if handlerErr == nil || !isLocalAdminFallbackOnly(user, settings) || !isInvalidCredentials(handlerErr) {
+			return handlerErr
+		}
 	}
 
 	if settings.AuthenticationMethod == portainer.AuthenticationOAuth {
@@ -93,19 +133,47 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 	return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Login method is not supported", Err: httperrors.ErrUnauthorized}
 }
 
-func isUserInitialAdmin(user *portainer.User) bool {
-	return int(user.ID) == 1
+// isLocalAdminFallbackOnly returns true when the user can log in with a local password only because of the local
+// administrator fallback
+func isLocalAdminFallbackOnly(user *portainer.User, settings *portainer.Settings) bool {
+	return settings.AuthenticationMethod != portainer.AuthenticationInternal && !security.IsInitialAdmin(user) && security.IsLocalAdminFallback(user, settings)
 }
 
-func (handler *Handler) authenticateInternal(w http.ResponseWriter, user *portainer.User, password string) *httperror.HandlerError {
-	err := handler.CryptoService.CompareHashAndData(user.Password, password)
+// isInvalidCredentials returns true when the login failed because of the credentials, the failures of the server
+// are not counted against the user
+func isInvalidCredentials(handlerErr *httperror.HandlerError) bool {
+	return handlerErr.StatusCode == http.StatusUnprocessableEntity || handlerErr.StatusCode == http.StatusForbidden
+}
+
+func (handler *Handler) authenticateInternal(w http.ResponseWriter, user *portainer.User, password string, passwordExpiryDays int) *httperror.HandlerError {
+	needsRehash, err := handler.CryptoService.CompareHashAndData(user.Password, password)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid credentials", Err: httperrors.ErrUnauthorized}
 	}
 
+	update := loginUpdate{comparedHash: user.Password}
+
+	// the hash is upgraded to the current cost while the clear password is at hand, it is persisted with the login time
+	if needsRehash {
+		if hash, err := handler.CryptoService.Hash(password); err != nil {
+			log.Warn().Err(err).Int("user_id", int(user.ID)).Msg("unable to hash the password with the current cost")
+		} else {
+			update.rehash = hash
+		}
+	}
+
 	forceChangePassword := !handler.passwordStrengthChecker.Check(password)
 
-	return handler.writeToken(w, user, forceChangePassword)
+	// the flag is persisted with the last login time and cleared when the password is changed
+	if security.IsPasswordExpired(user, passwordExpiryDays, time.Now()) {
+		update.passwordExpired = true
+	}
+
+	if update.passwordExpired || user.PasswordExpired || user.ForcePasswordChange {
+		forceChangePassword = true
+	}
+
+	return handler.writeToken(w, user, forceChangePassword, update)
 }
 
 func (handler *Handler) authenticateLDAP(w http.ResponseWriter, user *portainer.User, username, password string, ldapSettings *portainer.LDAPSettings) *httperror.HandlerError {
@@ -132,10 +200,49 @@ func (handler *Handler) authenticateLDAP(w http.ResponseWriter, user *portainer.
 		log.Warn().Err(err).Msg("unable to automatically sync user teams with ldap")
 	}
 
-	return handler.writeToken(w, user, false)
+	return handler.writeToken(w, user, false, loginUpdate{})
 }
 
-func (handler *Handler) writeToken(w http.ResponseWriter, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+// loginUpdate holds the changes of the user persisted with its login time
+type loginUpdate struct {
+	// hash the password was compared with, the changes of the password are dropped when it changed since
+	comparedHash string
+	// hash of the password with the current cost, empty when the hash does not need to be upgraded
+	rehash string
+	// whether the password expired
+	passwordExpired bool
+}
+
+// writeToken persists the login of the user and writes its token. The user is read again in the transaction of the
+// update, so that a change made while the credentials were checked, e.g. a password reset, a role change or a
+// revocation of the sessions, is not overwritten by the user read at the start of the login
+func (handler *Handler) writeToken(w http.ResponseWriter, user *portainer.User, forceChangePassword bool, update loginUpdate) *httperror.HandlerError {
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		current, err := tx.User().Read(user.ID)
+		if err != nil {
+			return err
+		}
+
+		current.LastLoginAt = time.Now().Unix()
+
+		if update.comparedHash != "" && current.Password == update.comparedHash {
+			if update.rehash != "" {
+				current.Password = update.rehash
+			}
+
+			if update.passwordExpired {
+				current.PasswordExpired = true
+			}
+		}
+
+		user = current
+
+		return tx.User().Update(current.ID, current)
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("user_id", int(user.ID)).Msg("unable to persist the last login time of the user")
+	}
+
 	tokenData := composeTokenData(user, forceChangePassword)
 
 	return handler.persistAndWriteToken(w, tokenData)
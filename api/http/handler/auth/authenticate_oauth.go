@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/oauth"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 
@@ -13,9 +14,15 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+var errRedirectURINotAllowed = errors.New("the redirect URI is not one of the allowed OAuth redirect URIs")
+
 type oauthPayload struct {
 	// OAuth code returned from OAuth Provided
 	Code string
+	// State returned by /auth/oauth/pkce, required when PKCE is enabled
+	State string `example:"Jx1Ua3XoUrKbIyR2UsXMcOQ2fXcaN8mVo4TfB1Rq5rM"`
+	// Redirect URI sent to the authorization server, the configured redirect URI is used when empty
+	RedirectURI string `example:"https://portainer.mydomain.tld"`
 }
 
 func (payload *oauthPayload) Validate(r *http.Request) error {
@@ -26,7 +33,7 @@ func (payload *oauthPayload) Validate(r *http.Request) error {
 	return nil
 }
 
-func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuthSettings) (string, error) {
+func (handler *Handler) authenticateOAuth(code, codeVerifier string, settings *portainer.OAuthSettings) (string, error) {
 	if code == "" {
 		return "", errors.New("Invalid OAuth authorization code")
 	}
@@ -35,7 +42,7 @@ func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuth
 		return "", errors.New("Invalid OAuth configuration")
 	}
 
-	username, err := handler.OAuthService.Authenticate(code, settings)
+	username, err := handler.OAuthService.Authenticate(code, codeVerifier, settings)
 	if err != nil {
 		return "", err
 	}
@@ -45,6 +52,9 @@ func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuth
 
 // @id ValidateOAuth
 // @summary Authenticate with OAuth
+// @description The redirect URI sent to the authorization server must be one of the allowed redirect URIs of the OAuth settings,
+// @description or the configured redirect URI when none is allowed.
+// @description When PKCE is enabled, the state returned by /auth/oauth/pkce must be provided, it can only be used once.
 // @description **Access policy**: public
 // @tags auth
 // @accept json
@@ -71,7 +81,27 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 		return httperror.Forbidden("OAuth authentication is not enabled", errors.New("OAuth authentication is not enabled"))
 	}
 
-	username, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
+	// the code is exchanged with the redirect URI it was issued for, an attacker must not be able to have it sent
+	// to another URI
+	oauthSettings := settings.OAuthSettings
+	if payload.RedirectURI != "" {
+		if !oauth.IsAllowedRedirectURI(&oauthSettings, payload.RedirectURI) {
+			return httperror.BadRequest("The OAuth redirect URI is not allowed", errRedirectURINotAllowed)
+		}
+
+		oauthSettings.RedirectURI = payload.RedirectURI
+	}
+
+	var codeVerifier string
+	if settings.OAuthSettings.UsePKCE {
+		var ok bool
+		codeVerifier, ok = handler.takePKCECodeVerifier(payload.State)
+		if !ok {
+			return httperror.BadRequest("Invalid or expired OAuth state", errInvalidOAuthState)
+		}
+	}
+
+	username, err := handler.authenticateOAuth(payload.Code, codeVerifier, &oauthSettings)
 	if err != nil {
 		log.Debug().Err(err).Msg("OAuth authentication error")
 
@@ -113,5 +143,5 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 
 	}
 
-	return handler.writeToken(w, user, false)
+	return handler.writeToken(w, user, false, loginUpdate{})
 }
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/oauth"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// pkceCodeVerifierTTL is how long the user has to log in with the OAuth provider after requesting a code challenge
+const pkceCodeVerifierTTL = 10 * time.Minute
+
+var (
+	errPKCEDisabled      = errors.New("PKCE is not enabled for OAuth authentication")
+	errInvalidOAuthState = errors.New("the OAuth state is unknown, expired or was already used")
+)
+
+type oauthPKCEResponse struct {
+	// State to send to the authorization server and to /auth/oauth/validate
+	State string `example:"Jx1Ua3XoUrKbIyR2UsXMcOQ2fXcaN8mVo4TfB1Rq5rM"`
+	// Code challenge to send to the authorization server
+	CodeChallenge string `example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbFCyAHXMKNc"`
+	// Code challenge method to send to the authorization server
+	CodeChallengeMethod string `example:"S256"`
+}
+
+// @id OAuthPKCE
+// @summary Start an OAuth login protected with PKCE
+// @description Generate the PKCE code challenge of an OAuth login, the code verifier is kept by Portainer for 10 minutes.
+// @description The state and the code challenge must be sent to the authorization server, and the state to /auth/oauth/validate.
+// @description **Access policy**: public
+// @tags auth
+// @produce json
+// @success 200 {object} oauthPKCEResponse "Success"
+// @failure 403 "OAuth authentication with PKCE is not enabled"
+// @failure 500 "Server error"
+// @router /auth/oauth/pkce [post]
+func (handler *Handler) oauthPKCE(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if settings.AuthenticationMethod != portainer.AuthenticationOAuth || !settings.OAuthSettings.UsePKCE {
+		return httperror.Forbidden("OAuth authentication with PKCE is not enabled", errPKCEDisabled)
+	}
+
+	method := settings.OAuthSettings.PKCEChallengeMethod
+	if method == "" {
+		method = portainer.OAuthPKCEMethodS256
+	}
+
+	state, err := oauth.NewPKCECodeVerifier()
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the OAuth state", err)
+	}
+
+	codeVerifier, err := oauth.NewPKCECodeVerifier()
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the PKCE code verifier", err)
+	}
+
+	codeChallenge, err := oauth.PKCECodeChallenge(codeVerifier, method)
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the PKCE code challenge", err)
+	}
+
+	handler.pkceCodeVerifiers.SetDefault(state, codeVerifier)
+
+	return response.JSON(w, oauthPKCEResponse{State: state, CodeChallenge: codeChallenge, CodeChallengeMethod: method})
+}
+
+// takePKCECodeVerifier returns the code verifier of the state and forgets it, so that a state cannot be replayed
+func (handler *Handler) takePKCECodeVerifier(state string) (string, bool) {
+	if state == "" {
+		return "", false
+	}
+
+	handler.pkceMu.Lock()
+	defer handler.pkceMu.Unlock()
+
+	codeVerifier, ok := handler.pkceCodeVerifiers.Get(state)
+	if !ok {
+		return "", false
+	}
+
+	handler.pkceCodeVerifiers.Delete(state)
+
+	return codeVerifier.(string), true
+}
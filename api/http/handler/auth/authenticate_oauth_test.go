@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type oauthServiceStub struct {
+	portainer.OAuthService
+	// redirect URIs the codes were exchanged with
+	redirectURIs []string
+}
+
+func (service *oauthServiceStub) Authenticate(code, codeVerifier string, configuration *portainer.OAuthSettings) (string, error) {
+	service.redirectURIs = append(service.redirectURIs, configuration.RedirectURI)
+
+	return "bob", nil
+}
+
+func Test_validateOAuth_redirectURI(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationOAuth
+	settings.OAuthSettings.RedirectURI = "https://portainer.mydomain.tld"
+	settings.OAuthSettings.AllowedRedirectURIs = []string{"https://portainer.mydomain.tld", "https://portainer.example.tld"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	oauthService := &oauthServiceStub{}
+	h := &Handler{DataStore: store, OAuthService: oauthService}
+
+	validate := func(redirectURI string) int {
+		payload, err := json.Marshal(oauthPayload{Code: "code", RedirectURI: redirectURI})
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		if handlerErr := h.validateOAuth(rr, httptest.NewRequest(http.MethodPost, "/auth/oauth/validate", bytes.NewBuffer(payload))); handlerErr != nil {
+			return handlerErr.StatusCode
+		}
+
+		return rr.Code
+	}
+
+	// the user is not provisioned, the exchange of the code is enough to tell that the redirect URI was accepted
+	is.Equal(http.StatusForbidden, validate(""))
+	is.Equal(http.StatusForbidden, validate("https://portainer.example.tld"))
+	is.Equal([]string{"https://portainer.mydomain.tld", "https://portainer.example.tld"}, oauthService.redirectURIs)
+
+	is.Equal(http.StatusBadRequest, validate("https://evil.tld"))
+	is.Equal(http.StatusBadRequest, validate("https://portainer.example.tld.evil.tld"))
+	is.Len(oauthService.redirectURIs, 2, "the code is never exchanged with a redirect URI that is not allowed")
+}
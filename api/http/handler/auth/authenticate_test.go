@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeToken_staleUser(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "bob", Role: portainer.StandardUserRole, Password: "old-hash"}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+
+	h := &Handler{DataStore: store, JWTService: jwtService}
+
+	// the user read at the start of the login, before an administrator resets the password and revokes the sessions
+	stale := *user
+
+	reset, err := store.User().Read(user.ID)
+	is.NoError(err)
+	reset.Password = "reset-hash"
+	reset.TokenIssueAt = 42
+	reset.Role = portainer.AdministratorRole
+	is.NoError(store.User().Update(reset.ID, reset))
+
+	handlerErr := h.writeToken(httptest.NewRecorder(), &stale, false, loginUpdate{comparedHash: "old-hash", rehash: "rehashed", passwordExpired: true})
+	is.Nil(handlerErr)
+
+	persisted, err := store.User().Read(user.ID)
+	is.NoError(err)
+	is.Equal("reset-hash", persisted.Password, "the rehash of the previous password does not undo the reset")
+	is.False(persisted.PasswordExpired)
+	is.Equal(int64(42), persisted.TokenIssueAt, "the revocation of the sessions is kept")
+	is.Equal(portainer.AdministratorRole, persisted.Role)
+	is.NotZero(persisted.LastLoginAt)
+
+	handlerErr = h.writeToken(httptest.NewRecorder(), persisted, false, loginUpdate{comparedHash: "reset-hash", rehash: "rehashed"})
+	is.Nil(handlerErr)
+
+	persisted, err = store.User().Read(user.ID)
+	is.NoError(err)
+	is.Equal("rehashed", persisted.Password)
+}
+
+type ldapServiceStub struct {
+	portainer.LDAPService
+	password string
+	logins   int
+}
+
+func (service *ldapServiceStub) AuthenticateUser(username, password string, settings *portainer.LDAPSettings) error {
+	service.logins++
+	if password != service.password {
+		return errors.New("invalid credentials")
+	}
+
+	return nil
+}
+
+func Test_authenticateUser_localAdminFallback(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationLDAP
+	settings.EnableLocalAdminFallback = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("local-password")
+	is.NoError(err)
+
+	is.NoError(store.User().Create(&portainer.User{Username: "initial-admin", Role: portainer.AdministratorRole}))
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole, Password: hash}
+	is.NoError(store.User().Create(admin))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+
+	ldapService := &ldapServiceStub{password: "ldap-password"}
+	h := &Handler{
+		DataStore:               store,
+		CryptoService:           cryptoService,
+		JWTService:              jwtService,
+		LDAPService:             ldapService,
+		passwordStrengthChecker: security.NewPasswordStrengthChecker(store.SettingsService),
+	}
+
+	login := func(password string) *httperror.HandlerError {
+		return h.authenticateUser(httptest.NewRecorder(), admin, authenticatePayload{Username: admin.Username, Password: password}, settings)
+	}
+
+	is.Nil(login("local-password"))
+	is.Zero(ldapService.logins, "the local password is checked first")
+
+	is.Nil(login("ldap-password"), "the LDAP password is still accepted when the local one does not match")
+	is.Equal(1, ldapService.logins)
+
+	handlerErr := login("wrong")
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusForbidden, handlerErr.StatusCode)
+}
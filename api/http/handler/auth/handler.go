@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"sync"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
@@ -11,6 +12,7 @@ import (
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
 )
 
 // Handler is the HTTP handler used to handle authentication operations.
@@ -24,6 +26,10 @@ type Handler struct {
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
 	passwordStrengthChecker     security.PasswordStrengthChecker
+	// pkceCodeVerifiers holds the PKCE code verifiers of the pending OAuth logins by state
+	pkceCodeVerifiers *cache.Cache
+	pkceMu            sync.Mutex
+	loginLimiter      *loginLimiter
 }
 
 // NewHandler creates a handler to manage authentication operations.
@@ -31,14 +37,20 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	h := &Handler{
 		Router:                  mux.NewRouter(),
 		passwordStrengthChecker: passwordStrengthChecker,
+		pkceCodeVerifiers:       cache.New(pkceCodeVerifierTTL, pkceCodeVerifierTTL),
+		loginLimiter:            newLoginLimiter(loginLimiterSize),
 	}
 
+	h.Handle("/auth/oauth/pkce",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.oauthPKCE)))).Methods(http.MethodPost)
 	h.Handle("/auth/oauth/validate",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.validateOAuth)))).Methods(http.MethodPost)
 	h.Handle("/auth",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.authenticate)))).Methods(http.MethodPost)
 	h.Handle("/auth/logout",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.logout))).Methods(http.MethodPost)
+	h.Handle("/auth/revoke-all",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.revokeAll))).Methods(http.MethodPost)
 
 	return h
 }
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const loginLimiterSize = 1024
+
+type loginAttempts struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+// loginLimiter counts the consecutive failed logins of each username, in memory. The usernames are counted
+// whether the user exists or not, so that the lockout does not reveal the existing accounts. The failures of the
+// unknown usernames are kept apart in a bounded cache, so that a flood of random usernames cannot evict the
+// lockout of an existing account
+type loginLimiter struct {
+	mu    sync.Mutex
+	users map[string]loginAttempts
+	// unknown type [string]loginAttempts
+	unknown *lru.Cache
+}
+
+func newLoginLimiter(size int) *loginLimiter {
+	unknown, _ := lru.New(size)
+
+	return &loginLimiter{users: make(map[string]loginAttempts), unknown: unknown}
+}
+
+func (limiter *loginLimiter) get(key string, known bool) (loginAttempts, bool) {
+	if known {
+		attempts, ok := limiter.users[key]
+
+		return attempts, ok
+	}
+
+	val, ok := limiter.unknown.Get(key)
+	if !ok {
+		return loginAttempts{}, false
+	}
+
+	return val.(loginAttempts), true
+}
+
+// retryAfter returns the remaining lockout of the username, 0 when it is not locked out. known tells whether
+// the username belongs to an existing user
+func (limiter *loginLimiter) retryAfter(username string, known bool, now time.Time) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	attempts, ok := limiter.get(strings.ToLower(username), known)
+	if ok && now.Before(attempts.lockedUntil) {
+		return attempts.lockedUntil.Sub(now)
+	}
+
+	return 0
+}
+
+// fail records a failed login, the username is locked out for the lockout duration once the threshold is reached
+func (limiter *loginLimiter) fail(username string, known bool, threshold int, lockout time.Duration, now time.Time) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	key := strings.ToLower(username)
+
+	attempts, _ := limiter.get(key, known)
+
+	attempts.failures++
+	if attempts.failures >= threshold {
+		attempts = loginAttempts{lockedUntil: now.Add(lockout)}
+	}
+
+	if known {
+		limiter.users[key] = attempts
+	} else {
+		limiter.unknown.Add(key, attempts)
+	}
+}
+
+func (limiter *loginLimiter) reset(username string, known bool) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	key := strings.ToLower(username)
+	if known {
+		delete(limiter.users, key)
+	} else {
+		limiter.unknown.Remove(key)
+	}
+}
+
+// loginLockout returns the lockout duration configured in the settings
+func loginLockout(settings *portainer.Settings) time.Duration {
+	lockout, err := time.ParseDuration(settings.LoginLockoutDuration)
+	if err != nil || lockout <= 0 {
+		return portainer.DefaultLoginLockoutDuration
+	}
+
+	return lockout
+}
+
+// loginLockoutApplies returns true when the failed logins of the user are counted
+func loginLockoutApplies(settings *portainer.Settings, user *portainer.User) bool {
+	if settings.MaxLoginAttempts <= 0 {
+		return false
+	}
+
+	return !settings.LoginLockoutExemptAdmins || user == nil || user.Role != portainer.AdministratorRole
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loginLimiter(t *testing.T) {
+	is := assert.New(t)
+
+	limiter := newLoginLimiter(loginLimiterSize)
+	now := time.Now()
+
+	limiter.fail("bob", true, 3, time.Minute, now)
+	limiter.fail("Bob", true, 3, time.Minute, now)
+	is.Zero(limiter.retryAfter("bob", true, now), "the threshold is not reached")
+
+	limiter.fail("BOB", true, 3, time.Minute, now)
+	is.Equal(time.Minute, limiter.retryAfter("bob", true, now), "the usernames are case insensitive")
+	is.Zero(limiter.retryAfter("alice", true, now), "other users are not locked out")
+	is.Zero(limiter.retryAfter("bob", true, now.Add(time.Minute)), "the lockout expires")
+
+	limiter.fail("alice", true, 3, time.Minute, now)
+	limiter.fail("alice", true, 3, time.Minute, now)
+	limiter.reset("alice", true)
+	limiter.fail("alice", true, 3, time.Minute, now)
+	is.Zero(limiter.retryAfter("alice", true, now), "a successful login resets the counter")
+
+	for i := 0; i < 3; i++ {
+		limiter.fail("ghost", false, 3, time.Minute, now)
+	}
+	is.Equal(time.Minute, limiter.retryAfter("ghost", false, now), "the unknown usernames are locked out too")
+}
+
+func Test_loginLimiter_unknownUsernamesDoNotEvict(t *testing.T) {
+	is := assert.New(t)
+
+	limiter := newLoginLimiter(4)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		limiter.fail("bob", true, 3, time.Minute, now)
+	}
+
+	for i := 0; i < 100; i++ {
+		limiter.fail(fmt.Sprintf("random-%d", i), false, 3, time.Minute, now)
+	}
+
+	is.Equal(time.Minute, limiter.retryAfter("bob", true, now), "the lockout of an existing user survives a flood of unknown usernames")
+}
+
+func Test_loginLockoutApplies(t *testing.T) {
+	is := assert.New(t)
+
+	admin := &portainer.User{Role: portainer.AdministratorRole}
+	standard := &portainer.User{Role: portainer.StandardUserRole}
+
+	settings := &portainer.Settings{}
+	is.False(loginLockoutApplies(settings, standard), "the lockout is disabled")
+
+	settings.MaxLoginAttempts = 5
+	is.True(loginLockoutApplies(settings, admin))
+	is.True(loginLockoutApplies(settings, nil), "the unknown users are counted")
+
+	settings.LoginLockoutExemptAdmins = true
+	is.False(loginLockoutApplies(settings, admin))
+	is.True(loginLockoutApplies(settings, standard))
+
+	is.Equal(portainer.DefaultLoginLockoutDuration, loginLockout(settings))
+	settings.LoginLockoutDuration = "1h"
+	is.Equal(time.Hour, loginLockout(settings))
+}
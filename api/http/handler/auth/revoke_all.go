@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type revokeAllResponse struct {
+	// Unix timestamp before which every token is rejected
+	RevokedBefore int64 `json:"revokedBefore" example:"1587399600"`
+	// Number of users who logged in within the user session timeout, an estimate of the sessions revoked
+	EstimatedSessions int `json:"estimatedSessions" example:"12"`
+}
+
+// @id AuthRevokeAll
+// @summary Revoke every session
+// @description Reject every token issued until now, whatever its user, including the session used for the request.
+// @description The tokens of the kubeconfig files are rejected as well, the API keys are not revoked.
+// @description The revocation is persisted in the settings so that it survives restarts.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} revokeAllResponse "Success"
+// @failure 500 "Server error"
+// @router /auth/revoke-all [post]
+func (handler *Handler) revokeAll(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	now := time.Now()
+
+	var sessionTimeout time.Duration
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err := tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		sessionTimeout, _ = time.ParseDuration(settings.UserSessionTimeout)
+		settings.SessionRevocationFloor = now.Unix()
+
+		return tx.Settings().UpdateSettings(settings)
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the settings changes inside the database", err)
+	}
+
+	// the floor is applied once persisted, so that a failure does not revoke the sessions until the next restart only
+	handler.JWTService.SetSessionRevocationFloor(now.Unix())
+
+	users, err := handler.DataStore.User().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve users from the database", err)
+	}
+
+	// the tokens are not tracked, the users who logged in recently enough to hold a valid token are counted instead
+	estimatedSessions := 0
+	for _, user := range users {
+		if user.LastLoginAt >= now.Add(-sessionTimeout).Unix() {
+			estimatedSessions++
+		}
+	}
+
+	return response.JSON(w, revokeAllResponse{RevokedBefore: now.Unix(), EstimatedSessions: estimatedSessions})
+}
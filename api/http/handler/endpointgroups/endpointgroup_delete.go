@@ -81,6 +81,8 @@ func (handler *Handler) deleteEndpointGroup(tx dataservices.DataStoreTx, endpoin
 				return httperror.InternalServerError("Unable to update environment", err)
 			}
 
+			handler.reconcileRegistrySecrets(tx, &endpoint, endpointGroupID)
+
 			err = handler.updateEndpointRelations(tx, &endpoint, nil)
 			if err != nil {
 				return httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
@@ -88,6 +90,23 @@ func (handler *Handler) deleteEndpointGroup(tx dataservices.DataStoreTx, endpoin
 		}
 	}
 
+	registries, err := tx.Registry().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve registries from the database", err)
+	}
+
+	for idx := range registries {
+		registry := &registries[idx]
+		if _, ok := registry.GroupRegistryAccesses[endpointGroupID]; ok {
+			delete(registry.GroupRegistryAccesses, endpointGroupID)
+
+			err = tx.Registry().Update(registry.ID, registry)
+			if err != nil {
+				return httperror.InternalServerError("Unable to persist registry changes inside the database", err)
+			}
+		}
+	}
+
 	for _, tagID := range endpointGroup.TagIDs {
 		if featureflags.IsEnabled(portainer.FeatureNoTx) {
 			err = tx.Tag().UpdateTagFunc(tagID, func(tag *portainer.Tag) {
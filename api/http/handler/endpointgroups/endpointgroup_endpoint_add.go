@@ -72,6 +72,7 @@ func (handler *Handler) addEndpoint(tx dataservices.DataStoreTx, endpointGroupID
 		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
+	previousGroupID := endpoint.GroupID
 	endpoint.GroupID = endpointGroup.ID
 
 	err = tx.Endpoint().UpdateEndpoint(endpoint.ID, endpoint)
@@ -79,6 +80,8 @@ func (handler *Handler) addEndpoint(tx dataservices.DataStoreTx, endpointGroupID
 		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
 	}
 
+	handler.reconcileRegistrySecrets(tx, endpoint, previousGroupID)
+
 	err = handler.updateEndpointRelations(tx, endpoint, endpointGroup)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
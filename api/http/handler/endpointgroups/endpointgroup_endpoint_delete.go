@@ -71,6 +71,7 @@ func (handler *Handler) removeEndpoint(tx dataservices.DataStoreTx, endpointGrou
 		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
+	previousGroupID := endpoint.GroupID
 	endpoint.GroupID = portainer.EndpointGroupID(1)
 
 	err = tx.Endpoint().UpdateEndpoint(endpoint.ID, endpoint)
@@ -78,6 +79,8 @@ func (handler *Handler) removeEndpoint(tx dataservices.DataStoreTx, endpointGrou
 		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
 	}
 
+	handler.reconcileRegistrySecrets(tx, endpoint, previousGroupID)
+
 	err = handler.updateEndpointRelations(tx, endpoint, nil)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
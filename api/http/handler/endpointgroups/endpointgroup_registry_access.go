@@ -0,0 +1,233 @@
+package endpointgroups
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+var errEndpointUnreachable = errors.New("the environment is unreachable")
+
+type endpointGroupRegistryAccessPayload struct {
+	// Namespaces with access to the registry in each Kubernetes environment of the group, empty to remove the access
+	Namespaces []string `example:"default"`
+}
+
+func (payload *endpointGroupRegistryAccessPayload) Validate(r *http.Request) error {
+	return registryutils.ValidateNamespaces(payload.Namespaces)
+}
+
+type endpointGroupRegistryAccessResponse struct {
+	// Environments whose registry secrets could not be reconciled, e.g. because they are unreachable
+	UnreconciledEndpoints []portainer.EndpointID `json:"UnreconciledEndpoints"`
+}
+
+// @id EndpointGroupRegistryAccess
+// @summary Update the registry access of an environment(endpoint) group
+// @description Grant the access to a registry to namespaces of every Kubernetes environment of the group.
+// @description The namespaces are merged with the namespaces granted to each environment, unless the environment excludes the access of its group.
+// @description The registry secrets of the reachable environments are reconciled, the unreconciled environments are listed in the response.
+// @description The change is recorded in the registry access history and can be undone in the next 15 minutes.
+// @description **Access policy**: administrator
+// @tags endpoint_groups
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "EndpointGroup identifier"
+// @param registryId path int true "Registry identifier"
+// @param body body endpointGroupRegistryAccessPayload true "Registry access"
+// @success 200 {object} endpointGroupRegistryAccessResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "EndpointGroup or registry not found"
+// @failure 500 "Server error"
+// @router /endpoint_groups/{id}/registries/{registryId} [put]
+func (handler *Handler) endpointGroupRegistryAccess(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointGroupID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment group identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	var payload endpointGroupRegistryAccessPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	var unreconciled []portainer.EndpointID
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		unreconciled, err = handler.updateGroupRegistryAccess(handler.DataStore, securityContext.UserID, portainer.EndpointGroupID(endpointGroupID), portainer.RegistryID(registryID), payload)
+	} else {
+		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			unreconciled, err = handler.updateGroupRegistryAccess(tx, securityContext.UserID, portainer.EndpointGroupID(endpointGroupID), portainer.RegistryID(registryID), payload)
+			return err
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, endpointGroupRegistryAccessResponse{UnreconciledEndpoints: unreconciled})
+}
+
+func (handler *Handler) updateGroupRegistryAccess(tx dataservices.DataStoreTx, userID portainer.UserID, endpointGroupID portainer.EndpointGroupID, registryID portainer.RegistryID, payload endpointGroupRegistryAccessPayload) ([]portainer.EndpointID, error) {
+	registry, err := readGroupRegistry(tx, endpointGroupID, registryID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if _, maxNamespaces := registryutils.AccessLimits(settings); len(payload.Namespaces) > maxNamespaces {
+		return nil, httperror.BadRequest("Invalid request payload", fmt.Errorf("the access exceeds the limit of %d namespaces", maxNamespaces))
+	}
+
+	return handler.applyGroupRegistryAccess(tx, registry, endpointGroupID, payload.Namespaces, portainer.RegistryAccessChange{
+		EndpointGroupID: endpointGroupID,
+		UserID:          userID,
+		Timestamp:       time.Now().Unix(),
+	})
+}
+
+// readGroupRegistry returns the registry once the environment group is known to exist
+func readGroupRegistry(tx dataservices.DataStoreTx, endpointGroupID portainer.EndpointGroupID, registryID portainer.RegistryID) (*portainer.Registry, error) {
+	_, err := tx.EndpointGroup().Read(endpointGroupID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment group with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+	}
+
+	registry, err := tx.Registry().Read(registryID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	return registry, nil
+}
+
+// applyGroupRegistryAccess grants the registry to the namespaces of the environments of the group, records the change
+// in the access history and reconciles the registry secrets of the Kubernetes environments of the group
+func (handler *Handler) applyGroupRegistryAccess(tx dataservices.DataStoreTx, registry *portainer.Registry, endpointGroupID portainer.EndpointGroupID, namespaces []string, change portainer.RegistryAccessChange) ([]portainer.EndpointID, error) {
+	endpoints, err := tx.Endpoint().Endpoints()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve environments from the database", err)
+	}
+
+	previousNamespaces := map[portainer.EndpointID][]string{}
+	for _, endpoint := range endpoints {
+		if endpoint.GroupID == endpointGroupID {
+			previousNamespaces[endpoint.ID] = registryutils.EffectiveAccess(registry, endpoint.ID, endpointGroupID).Namespaces
+		}
+	}
+
+	change.Before = portainer.RegistryAccessPolicies{Namespaces: registry.GroupRegistryAccesses[endpointGroupID].Namespaces}
+	change.After = portainer.RegistryAccessPolicies{Namespaces: namespaces}
+
+	if len(namespaces) == 0 {
+		delete(registry.GroupRegistryAccesses, endpointGroupID)
+	} else {
+		if registry.GroupRegistryAccesses == nil {
+			registry.GroupRegistryAccesses = portainer.GroupRegistryAccesses{}
+		}
+
+		registry.GroupRegistryAccesses[endpointGroupID] = portainer.GroupRegistryAccess{Namespaces: namespaces}
+	}
+
+	registryutils.RecordAccessChange(registry, change)
+
+	err = tx.Registry().Update(registry.ID, registry)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to persist registry changes inside the database", err)
+	}
+
+	// a failure of one environment does not prevent the others from being reconciled, the access is already saved
+	unreconciled := []portainer.EndpointID{}
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if endpoint.GroupID != endpointGroupID || !endpointutils.IsKubernetesEndpoint(endpoint) {
+			continue
+		}
+
+		namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpointGroupID).Namespaces
+		if slices.Equal(previousNamespaces[endpoint.ID], namespaces) {
+			continue
+		}
+
+		cli, err := handler.kubeClient(endpoint)
+		if err == nil {
+			err = registryutils.UpdateKubeAccess(cli, registry, previousNamespaces[endpoint.ID], namespaces)
+		}
+
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Int("registry_id", int(registry.ID)).Msg("unable to reconcile the registry secrets")
+
+			unreconciled = append(unreconciled, endpoint.ID)
+		}
+	}
+
+	return unreconciled, nil
+}
+
+// reconcileRegistrySecrets updates the registry secrets of a Kubernetes environment moved out of the group
+// previousGroupID, the failures are only logged since the environment is moved anyway
+func (handler *Handler) reconcileRegistrySecrets(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, previousGroupID portainer.EndpointGroupID) {
+	if !endpointutils.IsKubernetesEndpoint(endpoint) || endpoint.GroupID == previousGroupID {
+		return
+	}
+
+	registries, err := tx.Registry().ReadAll()
+	if err == nil {
+		err = registryutils.ReconcileGroupMove(registries, endpoint, previousGroupID, func() (portainer.KubeClient, error) {
+			return handler.kubeClient(endpoint)
+		})
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to reconcile the registry secrets of the environment moved to another group")
+	}
+}
+
+// kubeClient returns a client of the environment, the registry secrets of an unreachable environment cannot be updated
+func (handler *Handler) kubeClient(endpoint *portainer.Endpoint) (portainer.KubeClient, error) {
+	if endpoint.Status == portainer.EndpointStatusDown {
+		return nil, errEndpointUnreachable
+	}
+
+	return handler.K8sClientFactory.GetKubeClient(endpoint)
+}
@@ -0,0 +1,94 @@
+package endpointgroups
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+var errNoRegistryAccessChange = errors.New("no registry access change to undo")
+
+// @id EndpointGroupRegistryAccessUndo
+// @summary Undo the latest registry access change of an environment(endpoint) group
+// @description Restore the namespaces granted to the group as they were before the latest change, made in the last 15 minutes.
+// @description The registry secrets of the reachable Kubernetes environments of the group are reconciled, the unreconciled environments are listed in the response.
+// @description An undo cannot be undone, and the access cannot be restored once the 15 minutes have passed.
+// @description **Access policy**: administrator
+// @tags endpoint_groups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EndpointGroup identifier"
+// @param registryId path int true "Registry identifier"
+// @success 200 {object} endpointGroupRegistryAccessResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "EndpointGroup, registry or change to undo not found"
+// @failure 500 "Server error"
+// @router /endpoint_groups/{id}/registries/{registryId}/undo [post]
+func (handler *Handler) endpointGroupRegistryAccessUndo(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointGroupID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment group identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	var unreconciled []portainer.EndpointID
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		unreconciled, err = handler.undoGroupRegistryAccess(handler.DataStore, securityContext.UserID, portainer.EndpointGroupID(endpointGroupID), portainer.RegistryID(registryID))
+	} else {
+		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			unreconciled, err = handler.undoGroupRegistryAccess(tx, securityContext.UserID, portainer.EndpointGroupID(endpointGroupID), portainer.RegistryID(registryID))
+			return err
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, endpointGroupRegistryAccessResponse{UnreconciledEndpoints: unreconciled})
+}
+
+func (handler *Handler) undoGroupRegistryAccess(tx dataservices.DataStoreTx, userID portainer.UserID, endpointGroupID portainer.EndpointGroupID, registryID portainer.RegistryID) ([]portainer.EndpointID, error) {
+	registry, err := readGroupRegistry(tx, endpointGroupID, registryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	change, ok := registryutils.UndoableGroupChange(registry, endpointGroupID, now, registryutils.AccessUndoWindow)
+	if !ok {
+		return nil, httperror.NotFound("The registry access of the environment group was not changed in the last 15 minutes or the change was already undone", errNoRegistryAccessChange)
+	}
+
+	return handler.applyGroupRegistryAccess(tx, registry, endpointGroupID, change.Before.Namespaces, portainer.RegistryAccessChange{
+		EndpointGroupID: endpointGroupID,
+		UserID:          userID,
+		Timestamp:       now.Unix(),
+		Undo:            true,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/kubernetes/cli"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -16,6 +17,7 @@ type Handler struct {
 	*mux.Router
 	AuthorizationService *authorization.Service
 	DataStore            dataservices.DataStore
+	K8sClientFactory     *cli.ClientFactory
 }
 
 // NewHandler creates a handler to manage environment(endpoint) group operations.
@@ -37,5 +39,9 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupAddEndpoint))).Methods(http.MethodPut)
 	h.Handle("/endpoint_groups/{id}/endpoints/{endpointId}",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupDeleteEndpoint))).Methods(http.MethodDelete)
+	h.Handle("/endpoint_groups/{id}/registries/{registryId}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupRegistryAccess))).Methods(http.MethodPut)
+	h.Handle("/endpoint_groups/{id}/registries/{registryId}/undo",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupRegistryAccessUndo))).Methods(http.MethodPost)
 	return h
 }
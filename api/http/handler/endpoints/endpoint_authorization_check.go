@@ -0,0 +1,124 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+type endpointAuthorizationCheckResponse struct {
+	// Whether the current user is authorized to execute the operation on the environment
+	Authorized bool `json:"authorized" example:"true"`
+	// Operation that was checked, empty when only the environment access was checked. The operations are authorized
+	// whenever the environment is accessible
+	Operation portainer.Authorization `json:"operation,omitempty" example:"DockerContainerList"`
+	// Factor that decided the access: role, userAccessPolicy, teamAccessPolicy, groupUserAccessPolicy or groupTeamAccessPolicy
+	Factor security.EndpointAccessFactor `json:"factor,omitempty" example:"teamAccessPolicy"`
+	// Role referenced by the deciding access policy
+	RoleID portainer.RoleID `json:"roleId,omitempty" example:"1"`
+	// Team referenced by the deciding access policy
+	TeamID portainer.TeamID `json:"teamId,omitempty" example:"1"`
+	// Human readable explanation of the decision
+	Reason string `json:"reason" example:"access granted by the access policy of team 1"`
+}
+
+// @id EndpointAuthorizationCheck
+// @summary Check the authorization of the current user on an environment
+// @description Check whether the current user can access the environment and, optionally, execute a specific operation on it.
+// @description The response describes the factor (role, user or team access policy) that decided the access.
+// @description The decision is the one enforced on the requests to the environment, every operation is authorized when the environment is accessible.
+// @description **Access policy**: authenticated
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param operation query string false "Operation to check, e.g. DockerContainerList"
+// @success 200 {object} endpointAuthorizationCheckResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/authorizations/check [get]
+func (handler *Handler) endpointAuthorizationCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	operation, _ := request.RetrieveQueryParameter(r, "operation", true)
+
+	var resp *endpointAuthorizationCheckResponse
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		resp, err = handler.checkEndpointAuthorization(handler.DataStore, r, portainer.EndpointID(endpointID), portainer.Authorization(operation))
+	} else {
+		err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+			resp, err = handler.checkEndpointAuthorization(tx, r, portainer.EndpointID(endpointID), portainer.Authorization(operation))
+			return err
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, resp)
+}
+
+func (handler *Handler) checkEndpointAuthorization(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID, operation portainer.Authorization) (*endpointAuthorizationCheckResponse, error) {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	endpoint, err := tx.Endpoint().Endpoint(endpointID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	// the decision is the one enforced by the request bouncer, the roles of the access policies do not restrict
+	// the operations in this edition
+	decision, err := security.EndpointAccess(tx, endpoint, tokenData)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the access of the user to the environment", err)
+	}
+
+	resp := &endpointAuthorizationCheckResponse{
+		Authorized: decision.Authorized,
+		Operation:  operation,
+		Factor:     decision.Factor,
+		TeamID:     decision.TeamID,
+	}
+
+	if decision.Policy != nil {
+		resp.RoleID = decision.Policy.RoleID
+	}
+
+	switch {
+	case !decision.Authorized:
+		resp.Reason = "no access policy of the user, of its teams or of the environment group grants access to the environment"
+	case decision.Factor == security.EndpointAccessByRole:
+		resp.Reason = "access granted to administrators"
+	case decision.TeamID != 0:
+		resp.Reason = fmt.Sprintf("access granted by the %s of team %d", decision.Factor, decision.TeamID)
+	default:
+		resp.Reason = fmt.Sprintf("access granted by the %s of the user", decision.Factor)
+	}
+
+	return resp, nil
+}
@@ -7,8 +7,8 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
-	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	"github.com/portainer/portainer/pkg/featureflags"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -43,10 +43,6 @@ func (handler *Handler) endpointDelete(w http.ResponseWriter, r *http.Request) *
 		return httperror.BadRequest("Invalid boolean query parameter", err)
 	}
 
-	if handler.demoService.IsDemoEnvironment(portainer.EndpointID(endpointID)) {
-		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
-	}
-
 	if featureflags.IsEnabled(portainer.FeatureNoTx) {
 		err = handler.deleteEndpoint(handler.DataStore, portainer.EndpointID(endpointID), deleteCluster)
 	} else {
@@ -166,6 +162,8 @@ func (handler *Handler) deleteEndpoint(tx dataservices.DataStoreTx, endpointID p
 
 	for idx := range registries {
 		registry := &registries[idx]
+		handler.deleteRegistrySecrets(endpoint, registry, registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces)
+
 		if _, ok := registry.RegistryAccesses[endpoint.ID]; ok {
 			delete(registry.RegistryAccesses, endpoint.ID)
 			err = tx.Registry().Update(registry.ID, registry)
@@ -208,6 +206,29 @@ func (handler *Handler) deleteEndpoint(tx dataservices.DataStoreTx, endpointID p
 	return nil
 }
 
+// deleteRegistrySecrets removes the secrets created in the namespaces of a Kubernetes environment to pull from the
+// registry. The secrets of an unreachable environment cannot be removed, they are left behind with the cluster
+func (handler *Handler) deleteRegistrySecrets(endpoint *portainer.Endpoint, registry *portainer.Registry, namespaces []string) {
+	if !endpointutils.IsKubernetesEndpoint(endpoint) || len(namespaces) == 0 {
+		return
+	}
+
+	if endpoint.Status == portainer.EndpointStatusDown {
+		log.Warn().Int("endpoint_id", int(endpoint.ID)).Int("registry_id", int(registry.ID)).Msg("the environment is unreachable, unable to remove the registry secrets")
+
+		return
+	}
+
+	cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+	if err == nil {
+		err = registryutils.UpdateKubeAccess(cli, registry, namespaces, nil)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Int("registry_id", int(registry.ID)).Msg("unable to remove the registry secrets")
+	}
+}
+
 func removeElement(slice []portainer.EndpointID, elem portainer.EndpointID) []portainer.EndpointID {
 	for i, id := range slice {
 		if id == elem {
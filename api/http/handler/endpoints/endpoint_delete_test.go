@@ -84,3 +84,57 @@ func TestEndpointDeleteEdgeGroupsConcurrently(t *testing.T) {
 		t.Fatal("the edge group is not consistent")
 	}
 }
+
+func TestEndpointDeleteUnreachableKubernetesRegistryAccess(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+	handler.ProxyManager = proxy.NewManager(nil, nil, nil, nil, nil, nil, nil)
+
+	err := store.Endpoint().Create(&portainer.Endpoint{
+		ID:     1,
+		Name:   "kube",
+		Type:   portainer.AgentOnKubernetesEnvironment,
+		Status: portainer.EndpointStatusDown,
+	})
+	if err != nil {
+		t.Fatal("could not create endpoint:", err)
+	}
+
+	err = store.Registry().Create(&portainer.Registry{
+		ID: 1,
+		RegistryAccesses: portainer.RegistryAccesses{
+			1: {Namespaces: []string{"default"}},
+			2: {Namespaces: []string{"default"}},
+		},
+	})
+	if err != nil {
+		t.Fatal("could not create registry:", err)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, "/endpoints/1", nil)
+	if err != nil {
+		t.Fatal("could not create request:", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+
+	registry, err := store.Registry().Read(1)
+	if err != nil {
+		t.Fatal("could not retrieve the registry:", err)
+	}
+
+	if _, ok := registry.RegistryAccesses[1]; ok {
+		t.Fatal("the registry access of the deleted environment was kept")
+	}
+
+	if _, ok := registry.RegistryAccesses[2]; !ok {
+		t.Fatal("the registry access of another environment was removed")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	"github.com/portainer/portainer/pkg/featureflags"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -117,7 +118,7 @@ func (handler *Handler) filterKubernetesEndpointRegistries(r *http.Request, regi
 			return nil, httperror.Forbidden("User is not authorized to use namespace", errors.New("user is not authorized to use namespace"))
 		}
 
-		return filterRegistriesByNamespaces(registries, endpoint.ID, []string{namespaceParam}), nil
+		return filterRegistriesByNamespaces(registries, endpoint, []string{namespaceParam}), nil
 	}
 
 	if isAdmin {
@@ -154,11 +155,11 @@ func (handler *Handler) isNamespaceAuthorized(endpoint *portainer.Endpoint, name
 	return security.AuthorizedAccess(userId, memberships, namespacePolicy.UserAccessPolicies, namespacePolicy.TeamAccessPolicies), nil
 }
 
-func filterRegistriesByNamespaces(registries []portainer.Registry, endpointId portainer.EndpointID, namespaces []string) []portainer.Registry {
+func filterRegistriesByNamespaces(registries []portainer.Registry, endpoint *portainer.Endpoint, namespaces []string) []portainer.Registry {
 	filteredRegistries := []portainer.Registry{}
 
 	for _, registry := range registries {
-		if registryAccessPoliciesContainsNamespace(registry.RegistryAccesses[endpointId], namespaces) {
+		if registryAccessPoliciesContainsNamespace(registryutils.EffectiveAccess(&registry, endpoint.ID, endpoint.GroupID), namespaces) {
 			filteredRegistries = append(filteredRegistries, registry)
 		}
 	}
@@ -167,11 +168,9 @@ func filterRegistriesByNamespaces(registries []portainer.Registry, endpointId po
 }
 
 func registryAccessPoliciesContainsNamespace(registryAccess portainer.RegistryAccessPolicies, namespaces []string) bool {
-	for _, authorizedNamespace := range registryAccess.Namespaces {
-		for _, namespace := range namespaces {
-			if namespace == authorizedNamespace {
-				return true
-			}
+	for _, namespace := range namespaces {
+		if registryutils.HasNamespaceAccess(registryAccess.Namespaces, namespace) {
+			return true
 		}
 	}
 	return false
@@ -191,7 +190,7 @@ func (handler *Handler) filterKubernetesRegistriesByUserRole(r *http.Request, re
 		return nil, httperror.InternalServerError("unable to retrieve user namespaces", err)
 	}
 
-	return filterRegistriesByNamespaces(registries, endpoint.ID, userNamespaces), nil
+	return filterRegistriesByNamespaces(registries, endpoint, userNamespaces), nil
 }
 
 func (handler *Handler) userNamespaces(endpoint *portainer.Endpoint, user *portainer.User) ([]string, error) {
@@ -229,6 +228,7 @@ func (handler *Handler) userNamespaces(endpoint *portainer.Endpoint, user *porta
 func hideRegistryFields(registry *portainer.Registry, hideAccesses bool) {
 	registry.Password = ""
 	registry.ManagementConfiguration = nil
+	registry.AccessHistory = nil
 	if hideAccesses {
 		registry.RegistryAccesses = nil
 	}
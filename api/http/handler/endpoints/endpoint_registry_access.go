@@ -2,29 +2,71 @@ package endpoints
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	"github.com/portainer/portainer/pkg/featureflags"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// idempotencyKeyHeader is the header holding the key that identifies a registry access update across retries
+	idempotencyKeyHeader = "X-Idempotency-Key"
+	// registryAccessIdempotencyTTL is how long a completed registry access update is remembered
+	registryAccessIdempotencyTTL = 10 * time.Minute
 )
 
 type registryAccessPayload struct {
 	UserAccessPolicies portainer.UserAccessPolicies
 	TeamAccessPolicies portainer.TeamAccessPolicies
-	Namespaces         []string
+	// Access policies keyed by username, ignored when UserAccessPolicies is set
+	UserAccessPoliciesByName map[string]portainer.AccessPolicy
+	// Access policies keyed by team name, ignored when TeamAccessPolicies is set
+	TeamAccessPoliciesByName map[string]portainer.AccessPolicy
+	Namespaces               []string
+	// Ignore the namespaces granted to the group of the environment, only on Kubernetes environments
+	ExcludeGroupAccess bool `example:"false"`
+	// Recreate the registry secrets of every namespace instead of only the namespaces that gained the access,
+	// e.g. when a namespace was deleted and recreated with the same name
+	ForceRegistrySecretRefresh bool `example:"false"`
 }
 
 func (payload *registryAccessPayload) Validate(r *http.Request) error {
-	return nil
+	for name := range payload.UserAccessPoliciesByName {
+		if name == "" {
+			return errors.New("invalid username in the user access policies")
+		}
+	}
+
+	for name := range payload.TeamAccessPoliciesByName {
+		if name == "" {
+			return errors.New("invalid team name in the team access policies")
+		}
+	}
+
+	return registryutils.ValidateNamespaces(payload.Namespaces)
 }
 
 // @id endpointRegistryAccess
 // @summary update registry access for environment
+// @description Only administrators can update the registry access, unless the delegation to team leaders is enabled in the settings.
+// @description In that case, the leaders of a team that has access to the environment can update it as well.
+// @description Team leaders can only change the access policies of the teams they lead and of the members of those teams.
+// @description On Kubernetes environments, the namespaces must exist in the environment and ForceRegistrySecretRefresh recreates the registry secrets of every namespace of the access.
+// @description The namespaces granted to the group of the environment are merged with the namespaces of the environment, unless ExcludeGroupAccess is set.
+// @description The user and team access policies can be keyed by name instead of identifier, the policies keyed by identifier take precedence when both are provided.
+// @description A request replaying the idempotency key of an update completed in the last 10 minutes succeeds without applying the update again.
+// @description With verbose, the namespaces added, removed and unchanged by the update are returned, they are empty on non Kubernetes environments.
 // @description **Access policy**: authenticated
 // @tags endpoints
 // @security ApiKeyAuth
@@ -33,12 +75,15 @@ func (payload *registryAccessPayload) Validate(r *http.Request) error {
 // @produce json
 // @param id path int true "Environment(Endpoint) identifier"
 // @param registryId path int true "Registry identifier"
+// @param X-Idempotency-Key header string false "Key identifying the update across retries"
+// @param verbose query bool false "Return the namespaces reconciled by the update"
 // @param body body registryAccessPayload true "details"
+// @success 200 {object} registryAccessResponse "Success, when verbose is set"
 // @success 204 "Success"
-// @failure 400 "Invalid request"
+// @failure 400 "Invalid request, e.g. unknown namespaces, users or teams"
 // @failure 403 "Permission denied"
 // @failure 404 "Endpoint not found"
-// @failure 500 "Server error"
+// @failure 500 {object} registryAccessFailure "Server error, the registry secrets handled before a Kubernetes failure are listed"
 // @router /endpoints/{id}/registries/{registryId} [put]
 func (handler *Handler) endpointRegistryAccess(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
 	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
@@ -51,59 +96,134 @@ func (handler *Handler) endpointRegistryAccess(w http.ResponseWriter, r *http.Re
 		return httperror.BadRequest("Invalid registry identifier route variable", err)
 	}
 
+	verbose, _ := request.RetrieveBooleanQueryParameter(r, "verbose", true)
+
+	idempotencyKey, err := registryAccessIdempotencyKey(r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if cached, completed := handler.registryAccessKeys.Get(idempotencyKey); completed {
+		return writeRegistryAccessResponse(w, cached.(*registryAccessResponse), verbose)
+	}
+
+	var resp *registryAccessResponse
 	if featureflags.IsEnabled(portainer.FeatureNoTx) {
-		err = handler.updateRegistryAccess(handler.DataStore, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+		resp, err = handler.updateRegistryAccess(handler.DataStore, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
 	} else {
 		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
-			return handler.updateRegistryAccess(tx, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+			resp, err = handler.updateRegistryAccess(tx, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+			return err
 		})
 	}
 
 	if err != nil {
 		var httpErr *httperror.HandlerError
 		if errors.As(err, &httpErr) {
+			var kubeErr *registryutils.KubeAccessError
+			if errors.As(httpErr.Err, &kubeErr) {
+				return writeRegistryAccessFailure(w, httpErr, kubeErr)
+			}
+
 			return httpErr
 		}
 
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
-	return response.Empty(w)
+	if idempotencyKey != "" {
+		handler.registryAccessKeys.SetDefault(idempotencyKey, resp)
+	}
+
+	return writeRegistryAccessResponse(w, resp, verbose)
 }
 
-func (handler *Handler) updateRegistryAccess(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID, registryID portainer.RegistryID) error {
-	endpoint, err := tx.Endpoint().Endpoint(endpointID)
-	if tx.IsErrObjectNotFound(err) {
-		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
-	} else if err != nil {
-		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+type registryAccessResponse struct {
+	// Namespaces reconciled by the update, only set on Kubernetes environments
+	Namespaces *registryutils.KubeAccessSummary `json:"namespaces,omitempty"`
+}
+
+func writeRegistryAccessResponse(w http.ResponseWriter, resp *registryAccessResponse, verbose bool) *httperror.HandlerError {
+	if !verbose {
+		return response.Empty(w)
 	}
 
-	securityContext, err := security.RetrieveRestrictedRequestContext(r)
-	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	return response.JSON(w, resp)
+}
+
+type registryAccessFailure struct {
+	Message string `json:"message" example:"Unable to update kube access policies"`
+	Details string `json:"details"`
+	// What was done in each namespace before the failure, the secrets created by the update are rolled back
+	Namespaces []registryutils.NamespaceSecretResult `json:"namespaces"`
+}
+
+// writeRegistryAccessFailure reports the registry secrets handled before the failure, so that the namespaces
+// can be reconciled manually when the rollback fails as well
+func writeRegistryAccessFailure(w http.ResponseWriter, httpErr *httperror.HandlerError, kubeErr *registryutils.KubeAccessError) *httperror.HandlerError {
+	log.Error().Err(kubeErr).Msg("unable to reconcile the registry secrets")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.StatusCode)
+
+	return response.JSON(w, registryAccessFailure{Message: httpErr.Message, Details: kubeErr.Error(), Namespaces: kubeErr.Results})
+}
+
+// registryAccessIdempotencyKey returns the idempotency key of the request scoped to the environment, the registry
+// and the user, so that unrelated updates sharing a key do not collide. It is empty when the header is not set
+func registryAccessIdempotencyKey(r *http.Request, endpointID portainer.EndpointID, registryID portainer.RegistryID) (string, error) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		return "", nil
 	}
 
-	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
-		return httperror.Forbidden("Permission denied to access environment", err)
+		return "", err
 	}
 
-	if !securityContext.IsAdmin {
-		return httperror.Forbidden("User is not authorized", err)
+	return fmt.Sprintf("%d:%d:%d:%s", endpointID, registryID, tokenData.ID, key), nil
+}
+
+func (handler *Handler) updateRegistryAccess(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID, registryID portainer.RegistryID) (*registryAccessResponse, error) {
+	endpoint, err := tx.Endpoint().Endpoint(endpointID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := handler.authorizeRegistryAccessUpdate(tx, r, endpoint)
+	if err != nil {
+		return nil, err
 	}
 
 	registry, err := tx.Registry().Read(registryID)
 	if tx.IsErrObjectNotFound(err) {
-		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
 	} else if err != nil {
-		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
 	var payload registryAccessPayload
 	err = request.DecodeAndValidateJSONPayload(r, &payload)
 	if err != nil {
-		return httperror.BadRequest("Invalid request payload", err)
+		return nil, httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = payload.resolveAccessPolicyNames(tx)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	err = payload.validateLimits(newRegistryAccessLimits(settings))
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid request payload", err)
 	}
 
 	if registry.RegistryAccesses == nil {
@@ -115,72 +235,187 @@ func (handler *Handler) updateRegistryAccess(tx dataservices.DataStoreTx, r *htt
 	}
 
 	registryAccess := registry.RegistryAccesses[endpoint.ID]
+	previousAccess := registryAccess
+
+	resp := &registryAccessResponse{}
 
 	if endpoint.Type == portainer.KubernetesLocalEnvironment || endpoint.Type == portainer.AgentOnKubernetesEnvironment || endpoint.Type == portainer.EdgeAgentOnKubernetesEnvironment {
-		err := handler.updateKubeAccess(endpoint, registry, registryAccess.Namespaces, payload.Namespaces)
+		cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
 		if err != nil {
-			return httperror.InternalServerError("Unable to update kube access policies", err)
+			return nil, httperror.InternalServerError("Unable to create Kubernetes client", err)
 		}
 
+		err = registryutils.ValidateKubeNamespaces(cli, payload.Namespaces)
+		if errors.Is(err, registryutils.ErrUnknownNamespaces) {
+			return nil, httperror.BadRequest("Invalid request payload", err)
+		} else if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the namespaces of the environment", err)
+		}
+
+		// the secrets follow the effective access, the namespaces of the group are kept unless the environment excludes them
+		previousNamespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
 		registryAccess.Namespaces = payload.Namespaces
+		registryAccess.ExcludeGroupAccess = payload.ExcludeGroupAccess
+		registry.RegistryAccesses[endpoint.ID] = registryAccess
+
+		namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
+		summary, err := registryutils.ReconcileKubeAccess(cli, registry, previousNamespaces, namespaces, payload.ForceRegistrySecretRefresh)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to update kube access policies", err)
+		}
+
+		resp.Namespaces = &summary
 	} else {
 		registryAccess.UserAccessPolicies = payload.UserAccessPolicies
 		registryAccess.TeamAccessPolicies = payload.TeamAccessPolicies
+
+		err = authorizeDelegatedPolicies(tx, securityContext, previousAccess, registryAccess)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	registry.RegistryAccesses[portainer.EndpointID(endpointID)] = registryAccess
 
-	return tx.Registry().Update(registry.ID, registry)
-}
+	registryutils.RecordAccessChange(registry, portainer.RegistryAccessChange{
+		EndpointID: endpoint.ID,
+		UserID:     securityContext.UserID,
+		Timestamp:  time.Now().Unix(),
+		Before:     previousAccess,
+		After:      registryAccess,
+	})
 
-func (handler *Handler) updateKubeAccess(endpoint *portainer.Endpoint, registry *portainer.Registry, oldNamespaces, newNamespaces []string) error {
-	oldNamespacesSet := toSet(oldNamespaces)
-	newNamespacesSet := toSet(newNamespaces)
+	err = tx.Registry().Update(registry.ID, registry)
+	if err != nil {
+		return nil, err
+	}
 
-	namespacesToRemove := setDifference(oldNamespacesSet, newNamespacesSet)
-	namespacesToAdd := setDifference(newNamespacesSet, oldNamespacesSet)
+	return resp, nil
+}
 
-	cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+// authorizeRegistryAccessUpdate returns the context of a user allowed to update the registry access of the
+// environment, an administrator or a team leader when the delegation is enabled
+func (handler *Handler) authorizeRegistryAccessUpdate(tx dataservices.DataStoreTx, r *http.Request, endpoint *portainer.Endpoint) (*security.RestrictedRequestContext, error) {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
 	if err != nil {
-		return err
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
 	}
 
-	for namespace := range namespacesToRemove {
-		err := cli.DeleteRegistrySecret(registry, namespace)
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if !securityContext.IsAdmin {
+		owned, err := teamLeaderOwnsEndpoint(tx, securityContext, endpoint)
 		if err != nil {
-			return err
+			return nil, httperror.InternalServerError("Unable to verify the ownership of the environment", err)
+		}
+
+		if !owned {
+			return nil, httperror.Forbidden("User is not authorized", httperrors.ErrUnauthorized)
 		}
 	}
 
-	for namespace := range namespacesToAdd {
-		err := cli.CreateRegistrySecret(registry, namespace)
-		if err != nil {
-			return err
+	return securityContext, nil
+}
+
+// teamLeaderOwnsEndpoint returns true when the delegation of the registry accesses to team leaders is enabled
+// and the user leads a team that has access to the environment, directly or through its group
+func teamLeaderOwnsEndpoint(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, endpoint *portainer.Endpoint) (bool, error) {
+	if !securityContext.IsTeamLeader {
+		return false, nil
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return false, err
+	}
+
+	if !settings.TeamLeadersManageRegistryAccess {
+		return false, nil
+	}
+
+	group, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, membership := range securityContext.UserMemberships {
+		if membership.Role != portainer.TeamLeader {
+			continue
+		}
+
+		if _, ok := endpoint.TeamAccessPolicies[membership.TeamID]; ok {
+			return true, nil
+		}
+
+		if _, ok := group.TeamAccessPolicies[membership.TeamID]; ok {
+			return true, nil
 		}
 	}
 
-	return nil
+	return false, nil
 }
 
-type stringSet map[string]bool
+// authorizeDelegatedPolicies returns an error when a team leader changes the access policy of a team they do not
+// lead or of a user who is not a member of a team they lead, administrators can change every access policy
+func authorizeDelegatedPolicies(tx dataservices.DataStoreTx, securityContext *security.RestrictedRequestContext, before, after portainer.RegistryAccessPolicies) error {
+	if securityContext.IsAdmin {
+		return nil
+	}
 
-func toSet(list []string) stringSet {
-	set := stringSet{}
-	for _, el := range list {
-		set[el] = true
+	ledTeams := map[portainer.TeamID]bool{}
+	members := map[portainer.UserID]bool{}
+	for _, membership := range securityContext.UserMemberships {
+		if membership.Role != portainer.TeamLeader {
+			continue
+		}
+
+		ledTeams[membership.TeamID] = true
+
+		teamMemberships, err := tx.TeamMembership().TeamMembershipsByTeamID(membership.TeamID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the members of the team", err)
+		}
+
+		for _, teamMembership := range teamMemberships {
+			members[teamMembership.UserID] = true
+		}
+	}
+
+	for _, teamID := range changedAccessPolicies(before.TeamAccessPolicies, after.TeamAccessPolicies) {
+		if !ledTeams[teamID] {
+			return httperror.Forbidden(fmt.Sprintf("Permission denied to change the access policy of the team %d", teamID), httperrors.ErrUnauthorized)
+		}
+	}
+
+	for _, userID := range changedAccessPolicies(before.UserAccessPolicies, after.UserAccessPolicies) {
+		if !members[userID] {
+			return httperror.Forbidden(fmt.Sprintf("Permission denied to change the access policy of the user %d", userID), httperrors.ErrUnauthorized)
+		}
 	}
-	return set
+
+	return nil
 }
 
-// setDifference returns the set difference tagsA - tagsB
-func setDifference(setA stringSet, setB stringSet) stringSet {
-	set := stringSet{}
+// changedAccessPolicies returns the keys of the access policies added, removed or modified between before and after
+func changedAccessPolicies[K comparable](before, after map[K]portainer.AccessPolicy) []K {
+	var changed []K
+
+	for key, policy := range before {
+		if afterPolicy, ok := after[key]; !ok || afterPolicy != policy {
+			changed = append(changed, key)
+		}
+	}
 
-	for el := range setA {
-		if !setB[el] {
-			set[el] = true
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changed = append(changed, key)
 		}
 	}
 
-	return set
+	return changed
 }
@@ -0,0 +1,130 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id endpointRegistryAccessDelete
+// @summary Remove the registry access of an environment
+// @description Remove every access policy and namespace of the registry on the environment.
+// @description On Kubernetes environments, the registry secrets of the removed namespaces are deleted, the namespaces granted to the group of the environment keep their secret.
+// @description The removal can be undone like any other registry access change.
+// @description Only administrators can remove the registry access, unless the delegation to team leaders is enabled in the settings.
+// @description **Access policy**: authenticated
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Environment(Endpoint) identifier"
+// @param registryId path int true "Registry identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment or registry not found"
+// @failure 500 {object} registryAccessFailure "Server error, the registry secrets handled before a Kubernetes failure are listed"
+// @router /endpoints/{id}/registries/{registryId} [delete]
+func (handler *Handler) endpointRegistryAccessDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		err = handler.deleteRegistryAccess(handler.DataStore, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+	} else {
+		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			return handler.deleteRegistryAccess(tx, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			var kubeErr *registryutils.KubeAccessError
+			if errors.As(httpErr.Err, &kubeErr) {
+				return writeRegistryAccessFailure(w, httpErr, kubeErr)
+			}
+
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.Empty(w)
+}
+
+func (handler *Handler) deleteRegistryAccess(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID, registryID portainer.RegistryID) error {
+	endpoint, err := tx.Endpoint().Endpoint(endpointID)
+	if tx.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := handler.authorizeRegistryAccessUpdate(tx, r, endpoint)
+	if err != nil {
+		return err
+	}
+
+	registry, err := tx.Registry().Read(registryID)
+	if tx.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	currentAccess, ok := registry.RegistryAccesses[endpoint.ID]
+	if !ok {
+		return nil
+	}
+
+	err = authorizeDelegatedPolicies(tx, securityContext, currentAccess, portainer.RegistryAccessPolicies{})
+	if err != nil {
+		return err
+	}
+
+	if endpointutils.IsKubernetesEndpoint(endpoint) {
+		cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+		if err != nil {
+			return httperror.InternalServerError("Unable to create Kubernetes client", err)
+		}
+
+		// the secrets follow the effective access, the namespaces of the group are granted to the environment again
+		previousNamespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+		delete(registry.RegistryAccesses, endpoint.ID)
+		namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
+		err = registryutils.UpdateKubeAccess(cli, registry, previousNamespaces, namespaces)
+		if err != nil {
+			return httperror.InternalServerError("Unable to delete the registry secrets", err)
+		}
+	}
+
+	delete(registry.RegistryAccesses, endpoint.ID)
+
+	registryutils.RecordAccessChange(registry, portainer.RegistryAccessChange{
+		EndpointID: endpoint.ID,
+		UserID:     securityContext.UserID,
+		Timestamp:  time.Now().Unix(),
+		Before:     currentAccess,
+		After:      portainer.RegistryAccessPolicies{},
+	})
+
+	return tx.Registry().Update(registry.ID, registry)
+}
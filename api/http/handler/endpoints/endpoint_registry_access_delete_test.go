@@ -0,0 +1,61 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_endpointRegistryAccessDelete(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{
+		ID:               1,
+		Name:             "registry",
+		RegistryAccesses: portainer.RegistryAccesses{1: {TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}}},
+	}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	serve := func(method, url string, ctx *security.RestrictedRequestContext) int {
+		req := httptest.NewRequest(method, url, nil)
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: ctx.UserID, Role: portainer.StandardUserRole}))
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, ctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	admin := &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}
+	user := &security.RestrictedRequestContext{UserID: 2}
+
+	is.Equal(http.StatusForbidden, serve(http.MethodDelete, "/endpoints/1/registries/1", user))
+
+	is.Equal(http.StatusNoContent, serve(http.MethodDelete, "/endpoints/1/registries/1", admin))
+
+	registry, err := store.Registry().Read(1)
+	is.NoError(err)
+	is.NotContains(registry.RegistryAccesses, portainer.EndpointID(1))
+
+	is.Equal(http.StatusNoContent, serve(http.MethodPost, "/endpoints/1/registries/1/undo", admin), "the removal can be undone")
+
+	registry, err = store.Registry().Read(1)
+	is.NoError(err)
+	is.Contains(registry.RegistryAccesses[1].TeamAccessPolicies, portainer.TeamID(1))
+
+	is.Equal(http.StatusNotFound, serve(http.MethodDelete, "/endpoints/1/registries/2", admin))
+}
@@ -0,0 +1,77 @@
+package endpoints
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type registryAccessInspectResponse struct {
+	// Effective access of the environment, its namespaces merged with the namespaces of its group
+	portainer.RegistryAccessPolicies
+	// Namespaces granted to the environment itself
+	EndpointNamespaces []string `json:"EndpointNamespaces" example:"default"`
+	// Namespaces granted to the group of the environment, ignored when ExcludeGroupAccess is set
+	GroupNamespaces []string `json:"GroupNamespaces" example:"production"`
+}
+
+// @id EndpointRegistryAccessInspect
+// @summary Inspect the effective registry access of an environment
+// @description Retrieve the access of an environment to a registry, with the namespaces granted to its group merged with its own.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param registryId path int true "Registry identifier"
+// @success 200 {object} registryAccessInspectResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment or registry not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/registries/{registryId}/access [get]
+func (handler *Handler) endpointRegistryAccessInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	registry, err := handler.DataStore.Registry().Read(portainer.RegistryID(registryID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	endpointNamespaces := registry.RegistryAccesses[endpoint.ID].Namespaces
+	if endpointNamespaces == nil {
+		endpointNamespaces = []string{}
+	}
+
+	groupNamespaces := registry.GroupRegistryAccesses[endpoint.GroupID].Namespaces
+	if groupNamespaces == nil {
+		groupNamespaces = []string{}
+	}
+
+	return response.JSON(w, registryAccessInspectResponse{
+		RegistryAccessPolicies: registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID),
+		EndpointNamespaces:     endpointNamespaces,
+		GroupNamespaces:        groupNamespaces,
+	})
+}
@@ -0,0 +1,44 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_endpointRegistryAccessInspect(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	group := &portainer.EndpointGroup{Name: "production"}
+	is.NoError(store.EndpointGroup().Create(group))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "cluster", GroupID: group.ID, Type: portainer.KubernetesLocalEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{
+		Name:                  "registry",
+		RegistryAccesses:      portainer.RegistryAccesses{1: {Namespaces: []string{"default"}}},
+		GroupRegistryAccesses: portainer.GroupRegistryAccesses{group.ID: {Namespaces: []string{"prod"}}},
+	}))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/endpoints/1/registries/1/access", nil), map[string]string{"id": "1", "registryId": "1"})
+	rr := httptest.NewRecorder()
+	is.Nil(h.endpointRegistryAccessInspect(rr, req))
+
+	var access registryAccessInspectResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&access))
+	is.Equal([]string{"default", "prod"}, access.Namespaces)
+	is.Equal([]string{"default"}, access.EndpointNamespaces)
+	is.Equal([]string{"prod"}, access.GroupNamespaces)
+}
@@ -0,0 +1,81 @@
+package endpoints
+
+import (
+	"fmt"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// resolveAccessPolicyNames converts the access policies keyed by user and team names into the policies keyed by
+// identifier. The policies keyed by identifier are authoritative, the names are ignored when both are provided
+func (payload *registryAccessPayload) resolveAccessPolicyNames(tx dataservices.DataStoreTx) error {
+	if payload.UserAccessPolicies == nil && payload.UserAccessPoliciesByName != nil {
+		users, err := tx.User().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		policies, err := resolveNames(payload.UserAccessPoliciesByName, "user", users, func(user portainer.User) (string, portainer.UserID) {
+			return user.Username, user.ID
+		})
+		if err != nil {
+			return err
+		}
+
+		payload.UserAccessPolicies = portainer.UserAccessPolicies(policies)
+	}
+
+	if payload.TeamAccessPolicies == nil && payload.TeamAccessPoliciesByName != nil {
+		teams, err := tx.Team().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		policies, err := resolveNames(payload.TeamAccessPoliciesByName, "team", teams, func(team portainer.Team) (string, portainer.TeamID) {
+			return team.Name, team.ID
+		})
+		if err != nil {
+			return err
+		}
+
+		payload.TeamAccessPolicies = portainer.TeamAccessPolicies(policies)
+	}
+
+	payload.UserAccessPoliciesByName = nil
+	payload.TeamAccessPoliciesByName = nil
+
+	return nil
+}
+
+// resolveNames maps the names to the identifiers of the elements, the names are case insensitive like the user and
+// team lookups, so a name matching several elements is rejected instead of picking one of them
+func resolveNames[T any, ID comparable](byName map[string]portainer.AccessPolicy, kind string, elements []T, identify func(T) (string, ID)) (map[ID]portainer.AccessPolicy, error) {
+	ids := make(map[string][]ID, len(elements))
+	for _, element := range elements {
+		name, id := identify(element)
+		ids[strings.ToLower(name)] = append(ids[strings.ToLower(name)], id)
+	}
+
+	policies := make(map[ID]portainer.AccessPolicy, len(byName))
+	for name, policy := range byName {
+		matches := ids[strings.ToLower(name)]
+
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("unknown %s: %s", kind, name)
+		case 1:
+		default:
+			return nil, fmt.Errorf("ambiguous %s name %s matches %d %ss", kind, name, len(matches), kind)
+		}
+
+		if _, ok := policies[matches[0]]; ok {
+			return nil, fmt.Errorf("ambiguous %s name %s, the %s is listed several times", kind, name, kind)
+		}
+
+		policies[matches[0]] = policy
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,302 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_teamLeaderOwnsEndpoint(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	group := &portainer.EndpointGroup{Name: "group", TeamAccessPolicies: portainer.TeamAccessPolicies{2: {}}}
+	err := store.EndpointGroup().Create(group)
+	is.NoError(err)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "env", GroupID: group.ID, TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}}
+
+	leaderOf := func(teamID portainer.TeamID) *security.RestrictedRequestContext {
+		return &security.RestrictedRequestContext{
+			IsTeamLeader:    true,
+			UserMemberships: []portainer.TeamMembership{{TeamID: teamID, Role: portainer.TeamLeader}},
+		}
+	}
+
+	owned, err := teamLeaderOwnsEndpoint(store, leaderOf(1), endpoint)
+	is.NoError(err)
+	is.False(owned, "delegation is disabled by default")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.TeamLeadersManageRegistryAccess = true
+	err = store.Settings().UpdateSettings(settings)
+	is.NoError(err)
+
+	owned, err = teamLeaderOwnsEndpoint(store, leaderOf(1), endpoint)
+	is.NoError(err)
+	is.True(owned, "team has access to the environment")
+
+	owned, err = teamLeaderOwnsEndpoint(store, leaderOf(2), endpoint)
+	is.NoError(err)
+	is.True(owned, "team has access to the environment group")
+
+	owned, err = teamLeaderOwnsEndpoint(store, leaderOf(3), endpoint)
+	is.NoError(err)
+	is.False(owned, "team has no access to the environment")
+
+	member := &security.RestrictedRequestContext{UserMemberships: []portainer.TeamMembership{{TeamID: 1, Role: portainer.TeamMember}}}
+	owned, err = teamLeaderOwnsEndpoint(store, member, endpoint)
+	is.NoError(err)
+	is.False(owned, "team members cannot manage the registry access")
+}
+
+func Test_endpointRegistryAccess_idempotencyKey(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{ID: 1, Name: "registry"}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	updateAccess := func(userID portainer.UserID, key string, teamID portainer.TeamID) int {
+		payload, err := json.Marshal(registryAccessPayload{TeamAccessPolicies: portainer.TeamAccessPolicies{teamID: {}}})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, "/endpoints/1/registries/1", bytes.NewBuffer(payload))
+		req.Header.Set(idempotencyKeyHeader, key)
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: userID, Role: portainer.AdministratorRole}))
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true, UserID: userID}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	teamPolicies := func() portainer.TeamAccessPolicies {
+		registry, err := store.Registry().Read(1)
+		is.NoError(err)
+
+		return registry.RegistryAccesses[1].TeamAccessPolicies
+	}
+
+	is.Equal(http.StatusNoContent, updateAccess(1, "key", 1))
+	is.Contains(teamPolicies(), portainer.TeamID(1))
+
+	is.Equal(http.StatusNoContent, updateAccess(1, "key", 2))
+	is.NotContains(teamPolicies(), portainer.TeamID(2), "the replayed update is not applied again")
+
+	is.Equal(http.StatusNoContent, updateAccess(2, "key", 2))
+	is.Contains(teamPolicies(), portainer.TeamID(2), "the keys of different users do not collide")
+}
+
+func Test_endpointRegistryAccess_verbose(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{ID: 1, Name: "registry"}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	req := httptest.NewRequest(http.MethodPut, "/endpoints/1/registries/1?verbose=true", bytes.NewBufferString(`{}`))
+	req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+	req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusOK, rr.Code)
+	is.JSONEq(`{}`, rr.Body.String(), "no namespaces are reconciled outside of Kubernetes")
+}
+
+func Test_writeRegistryAccessFailure(t *testing.T) {
+	is := assert.New(t)
+
+	kubeErr := &registryutils.KubeAccessError{
+		Err: errors.New("creation failed"),
+		Results: []registryutils.NamespaceSecretResult{
+			{Namespace: "dev", Status: registryutils.NamespaceSecretRolledBack},
+			{Namespace: "prod", Status: registryutils.NamespaceSecretFailed, Error: "creation failed"},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	handlerErr := writeRegistryAccessFailure(rr, httperror.InternalServerError("Unable to update kube access policies", kubeErr), kubeErr)
+	is.Nil(handlerErr)
+	is.Equal(http.StatusInternalServerError, rr.Code)
+
+	var failure registryAccessFailure
+	is.NoError(json.NewDecoder(rr.Body).Decode(&failure))
+	is.Equal("Unable to update kube access policies", failure.Message)
+	is.Equal(kubeErr.Results, failure.Namespaces)
+}
+
+func Test_endpointRegistryAccessesList(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{
+		ID:       1,
+		Name:     "granted",
+		Password: "secret",
+		RegistryAccesses: portainer.RegistryAccesses{
+			1: {TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}, Namespaces: []string{"dev"}},
+		},
+	}))
+	is.NoError(store.Registry().Create(&portainer.Registry{
+		ID:               2,
+		Name:             "other environment",
+		RegistryAccesses: portainer.RegistryAccesses{2: {}},
+	}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	listAccesses := func(securityContext *security.RestrictedRequestContext) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/endpoints/1/registries/accesses", nil)
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, securityContext))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := listAccesses(&security.RestrictedRequestContext{IsAdmin: true, UserID: 1})
+	is.Equal(http.StatusOK, rr.Code)
+	is.NotContains(rr.Body.String(), "secret", "the credentials are hidden")
+
+	var accesses []endpointRegistryAccess
+	is.NoError(json.NewDecoder(rr.Body).Decode(&accesses))
+	is.Len(accesses, 1)
+	is.Equal(portainer.RegistryID(1), accesses[0].RegistryID)
+	is.Equal([]string{"dev"}, accesses[0].Namespaces)
+	is.Contains(accesses[0].TeamAccessPolicies, portainer.TeamID(1))
+
+	rr = listAccesses(&security.RestrictedRequestContext{UserID: 2})
+	is.Equal(http.StatusForbidden, rr.Code, "standard users cannot audit the accesses")
+}
+
+func Test_registryAccessPayload_resolveAccessPolicyNames(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.User().Create(&portainer.User{ID: 1, Username: "alice"}))
+	is.NoError(store.Team().Create(&portainer.Team{ID: 1, Name: "dev"}))
+	is.NoError(store.Team().Create(&portainer.Team{ID: 2, Name: "ops"}))
+
+	payload := registryAccessPayload{
+		UserAccessPoliciesByName: map[string]portainer.AccessPolicy{"Alice": {}},
+		TeamAccessPoliciesByName: map[string]portainer.AccessPolicy{"dev": {}},
+	}
+	is.NoError(payload.resolveAccessPolicyNames(store))
+	is.Equal(portainer.UserAccessPolicies{1: {}}, payload.UserAccessPolicies)
+	is.Equal(portainer.TeamAccessPolicies{1: {}}, payload.TeamAccessPolicies)
+
+	payload = registryAccessPayload{
+		TeamAccessPolicies:       portainer.TeamAccessPolicies{2: {}},
+		TeamAccessPoliciesByName: map[string]portainer.AccessPolicy{"dev": {}},
+	}
+	is.NoError(payload.resolveAccessPolicyNames(store))
+	is.Equal(portainer.TeamAccessPolicies{2: {}}, payload.TeamAccessPolicies, "the identifiers take precedence over the names")
+
+	payload = registryAccessPayload{TeamAccessPoliciesByName: map[string]portainer.AccessPolicy{"qa": {}}}
+	is.ErrorContains(payload.resolveAccessPolicyNames(store), "unknown team: qa")
+
+	payload = registryAccessPayload{TeamAccessPoliciesByName: map[string]portainer.AccessPolicy{"dev": {}, "DEV": {}}}
+	is.ErrorContains(payload.resolveAccessPolicyNames(store), "ambiguous team name")
+
+	is.NoError(store.Team().Create(&portainer.Team{ID: 3, Name: "Ops"}))
+	payload = registryAccessPayload{TeamAccessPoliciesByName: map[string]portainer.AccessPolicy{"ops": {}}}
+	is.ErrorContains(payload.resolveAccessPolicyNames(store), "ambiguous team name ops matches 2 teams")
+}
+
+func Test_registryAccessPayload_Validate_namespaces(t *testing.T) {
+	is := assert.New(t)
+
+	payload := registryAccessPayload{Namespaces: []string{"default", "dev"}}
+	is.NoError(payload.Validate(nil))
+
+	payload = registryAccessPayload{Namespaces: []string{"dev", "default", "dev"}}
+	is.EqualError(payload.Validate(nil), "duplicate namespace: dev")
+
+	payload = registryAccessPayload{Namespaces: []string{"dev", "Dev"}}
+	is.NoError(payload.Validate(nil), "the namespaces are case sensitive")
+
+	payload = registryAccessPayload{Namespaces: []string{"default", ""}}
+	is.EqualError(payload.Validate(nil), "invalid empty namespace")
+}
+
+func Test_endpointRegistryAccess_teamLeaderPolicies(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.TeamLeadersManageRegistryAccess = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment, GroupID: 1, TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}}))
+	is.NoError(store.Registry().Create(&portainer.Registry{ID: 1, Name: "registry"}))
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: 2, TeamID: 1, Role: portainer.TeamLeader}))
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: 3, TeamID: 1, Role: portainer.TeamMember}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	updateAccess := func(payload registryAccessPayload) int {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, "/endpoints/1/registries/1", bytes.NewBuffer(body))
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 2, Role: portainer.StandardUserRole}))
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{
+			UserID:          2,
+			IsTeamLeader:    true,
+			UserMemberships: []portainer.TeamMembership{{UserID: 2, TeamID: 1, Role: portainer.TeamLeader}},
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusForbidden, updateAccess(registryAccessPayload{TeamAccessPolicies: portainer.TeamAccessPolicies{2: {}}}), "the team is not led by the user")
+	is.Equal(http.StatusForbidden, updateAccess(registryAccessPayload{UserAccessPolicies: portainer.UserAccessPolicies{4: {}}}), "the user is not a member of a led team")
+
+	is.Equal(http.StatusNoContent, updateAccess(registryAccessPayload{
+		TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}},
+		UserAccessPolicies: portainer.UserAccessPolicies{3: {}},
+	}))
+
+	registry, err := store.Registry().Read(1)
+	is.NoError(err)
+	is.Equal(portainer.TeamAccessPolicies{1: {}}, registry.RegistryAccesses[1].TeamAccessPolicies)
+	is.Equal(portainer.UserAccessPolicies{3: {}}, registry.RegistryAccesses[1].UserAccessPolicies)
+}
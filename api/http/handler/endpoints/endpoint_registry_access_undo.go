@@ -0,0 +1,163 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+var errNoRegistryAccessChange = errors.New("no registry access change to undo")
+
+// @id endpointRegistryAccessUndo
+// @summary Undo the latest registry access change of an environment
+// @description Restore the registry access of the environment as it was before its latest change, made in the last 15 minutes.
+// @description On Kubernetes environments, the registry secrets of the restored namespaces are recreated.
+// @description An undo cannot be undone, and the access cannot be restored once the 15 minutes have passed.
+// @description Only administrators can undo a registry access change, unless the delegation to team leaders is enabled in the settings.
+// @description **Access policy**: authenticated
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Environment(Endpoint) identifier"
+// @param registryId path int true "Registry identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment, registry or change to undo not found"
+// @failure 500 {object} registryAccessFailure "Server error, the registry secrets handled before a Kubernetes failure are listed"
+// @router /endpoints/{id}/registries/{registryId}/undo [post]
+func (handler *Handler) endpointRegistryAccessUndo(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		err = handler.undoRegistryAccess(handler.DataStore, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+	} else {
+		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			return handler.undoRegistryAccess(tx, r, portainer.EndpointID(endpointID), portainer.RegistryID(registryID))
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			var kubeErr *registryutils.KubeAccessError
+			if errors.As(httpErr.Err, &kubeErr) {
+				return writeRegistryAccessFailure(w, httpErr, kubeErr)
+			}
+
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.Empty(w)
+}
+
+func (handler *Handler) undoRegistryAccess(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID, registryID portainer.RegistryID) error {
+	endpoint, err := tx.Endpoint().Endpoint(endpointID)
+	if tx.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := handler.authorizeRegistryAccessUpdate(tx, r, endpoint)
+	if err != nil {
+		return err
+	}
+
+	registry, err := tx.Registry().Read(registryID)
+	if tx.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	now := time.Now()
+
+	change, ok := registryutils.UndoableChange(registry, endpoint.ID, now, registryutils.AccessUndoWindow)
+	if !ok {
+		return httperror.NotFound("The registry access of the environment was not changed in the last 15 minutes or the change was already undone", errNoRegistryAccessChange)
+	}
+
+	if registry.RegistryAccesses == nil {
+		registry.RegistryAccesses = portainer.RegistryAccesses{}
+	}
+
+	currentAccess := registry.RegistryAccesses[endpoint.ID]
+
+	err = authorizeDelegatedPolicies(tx, securityContext, currentAccess, change.Before)
+	if err != nil {
+		return err
+	}
+
+	if endpointutils.IsKubernetesEndpoint(endpoint) {
+		cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+		if err != nil {
+			return httperror.InternalServerError("Unable to create Kubernetes client", err)
+		}
+
+		previousNamespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+		registry.RegistryAccesses[endpoint.ID] = change.Before
+		namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
+		// the namespaces of the restored access may have been deleted since, there is nothing left to grant them
+		deleted, err := registryutils.UnknownNamespaces(cli, namespaces)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the namespaces of the environment", err)
+		}
+		namespaces = withoutNamespaces(namespaces, deleted)
+
+		err = registryutils.UpdateKubeAccess(cli, registry, previousNamespaces, namespaces)
+		if err != nil {
+			return httperror.InternalServerError("Unable to restore the registry secrets", err)
+		}
+	}
+
+	registry.RegistryAccesses[endpoint.ID] = change.Before
+
+	registryutils.RecordAccessChange(registry, portainer.RegistryAccessChange{
+		EndpointID: endpoint.ID,
+		UserID:     securityContext.UserID,
+		Timestamp:  now.Unix(),
+		Before:     currentAccess,
+		After:      change.Before,
+		Undo:       true,
+	})
+
+	return tx.Registry().Update(registry.ID, registry)
+}
+
+func withoutNamespaces(namespaces, excluded []string) []string {
+	if len(excluded) == 0 {
+		return namespaces
+	}
+
+	kept := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		if !slices.Contains(excluded, namespace) {
+			kept = append(kept, namespace)
+		}
+	}
+
+	return kept
+}
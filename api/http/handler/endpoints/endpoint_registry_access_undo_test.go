@@ -0,0 +1,66 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_endpointRegistryAccessUndo(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env", Type: portainer.DockerEnvironment}))
+	is.NoError(store.Registry().Create(&portainer.Registry{
+		ID:               1,
+		Name:             "registry",
+		RegistryAccesses: portainer.RegistryAccesses{1: {TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}}},
+	}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	serve := func(method, url string, payload any) int {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	teamPolicies := func() portainer.TeamAccessPolicies {
+		registry, err := store.Registry().Read(1)
+		is.NoError(err)
+
+		return registry.RegistryAccesses[1].TeamAccessPolicies
+	}
+
+	is.Equal(http.StatusNotFound, serve(http.MethodPost, "/endpoints/1/registries/1/undo", nil), "the access was never changed")
+
+	is.Equal(http.StatusNoContent, serve(http.MethodPut, "/endpoints/1/registries/1", registryAccessPayload{TeamAccessPolicies: portainer.TeamAccessPolicies{2: {}}}))
+	is.NotContains(teamPolicies(), portainer.TeamID(1))
+
+	is.Equal(http.StatusNoContent, serve(http.MethodPost, "/endpoints/1/registries/1/undo", nil))
+	is.Contains(teamPolicies(), portainer.TeamID(1), "the previous access is restored")
+	is.NotContains(teamPolicies(), portainer.TeamID(2))
+
+	is.Equal(http.StatusNotFound, serve(http.MethodPost, "/endpoints/1/registries/1/undo", nil), "an undo cannot be undone")
+	is.Contains(teamPolicies(), portainer.TeamID(1))
+}
@@ -0,0 +1,84 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type registryAccessLimits struct {
+	// Maximum number of user and team access policies
+	Policies int `json:"policies" example:"1000"`
+	// Maximum number of namespaces
+	Namespaces int `json:"namespaces" example:"500"`
+}
+
+func newRegistryAccessLimits(settings *portainer.Settings) registryAccessLimits {
+	policies, namespaces := registryutils.AccessLimits(settings)
+
+	return registryAccessLimits{Policies: policies, Namespaces: namespaces}
+}
+
+func (payload *registryAccessPayload) validateLimits(limits registryAccessLimits) error {
+	policies := len(payload.UserAccessPolicies) + len(payload.TeamAccessPolicies)
+	if policies > limits.Policies {
+		return fmt.Errorf("the payload contains %d user and team access policies, the limit is %d access policies and %d namespaces", policies, limits.Policies, limits.Namespaces)
+	}
+
+	if len(payload.Namespaces) > limits.Namespaces {
+		return fmt.Errorf("the payload contains %d namespaces, the limit is %d access policies and %d namespaces", len(payload.Namespaces), limits.Policies, limits.Namespaces)
+	}
+
+	return nil
+}
+
+type registryAccessValidateResponse struct {
+	Limits registryAccessLimits `json:"limits"`
+}
+
+// @id endpointRegistryAccessValidate
+// @summary Validate a registry access payload
+// @description Check that a registry access payload does not exceed the number of access policies and namespaces
+// @description allowed in a single update. The limits are returned on success and included in the error otherwise.
+// @description **Access policy**: authenticated
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body registryAccessPayload true "details"
+// @success 200 {object} registryAccessValidateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /endpoints/registries/validate [post]
+func (handler *Handler) endpointRegistryAccessValidate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registryAccessPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = payload.resolveAccessPolicyNames(handler.DataStore)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	limits := newRegistryAccessLimits(settings)
+
+	err = payload.validateLimits(limits)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	return response.JSON(w, registryAccessValidateResponse{Limits: limits})
+}
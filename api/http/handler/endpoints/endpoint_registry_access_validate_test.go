@@ -0,0 +1,39 @@
+package endpoints
+
+import (
+	"fmt"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/registryutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registryAccessPayload_validateLimits(t *testing.T) {
+	is := assert.New(t)
+
+	limits := newRegistryAccessLimits(&portainer.Settings{})
+	is.Equal(registryutils.DefaultMaxAccessPolicies, limits.Policies)
+	is.Equal(registryutils.DefaultMaxAccessNamespaces, limits.Namespaces)
+
+	limits = newRegistryAccessLimits(&portainer.Settings{MaxRegistryAccessPolicies: 2, MaxRegistryAccessNamespaces: 2})
+
+	payload := registryAccessPayload{
+		UserAccessPolicies: portainer.UserAccessPolicies{1: {}},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}},
+		Namespaces:         []string{"default", "kube-system"},
+	}
+	is.NoError(payload.validateLimits(limits))
+
+	payload.TeamAccessPolicies[2] = portainer.AccessPolicy{}
+	err := payload.validateLimits(limits)
+	is.ErrorContains(err, "the limit is 2 access policies and 2 namespaces")
+
+	delete(payload.TeamAccessPolicies, 2)
+	for i := 0; i < 3; i++ {
+		payload.Namespaces = append(payload.Namespaces, fmt.Sprintf("namespace-%d", i))
+	}
+	err = payload.validateLimits(limits)
+	is.ErrorContains(err, "the payload contains 5 namespaces")
+}
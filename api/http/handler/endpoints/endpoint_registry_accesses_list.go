@@ -0,0 +1,122 @@
+package endpoints
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/pkg/featureflags"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+type endpointRegistryAccess struct {
+	RegistryID portainer.RegistryID   `json:"RegistryId" example:"1"`
+	Name       string                 `example:"my-registry"`
+	URL        string                 `example:"registry.mydomain.tld:2375"`
+	Type       portainer.RegistryType `example:"3"`
+	portainer.RegistryAccessPolicies
+}
+
+// @id endpointRegistryAccessesList
+// @summary List the registry accesses of an environment
+// @description List the registries the environment can pull from, with their access policies and namespaces.
+// @description The credentials of the registries are not returned.
+// @description Only administrators can list the registry accesses, unless the delegation to team leaders is enabled in the settings.
+// @description **Access policy**: authenticated
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {array} endpointRegistryAccess "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Endpoint not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/registries/accesses [get]
+func (handler *Handler) endpointRegistryAccessesList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	var accesses []endpointRegistryAccess
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		accesses, err = handler.listRegistryAccesses(handler.DataStore, r, portainer.EndpointID(endpointID))
+	} else {
+		err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+			accesses, err = handler.listRegistryAccesses(tx, r, portainer.EndpointID(endpointID))
+			return err
+		})
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	return response.JSON(w, accesses)
+}
+
+func (handler *Handler) listRegistryAccesses(tx dataservices.DataStoreTx, r *http.Request, endpointID portainer.EndpointID) ([]endpointRegistryAccess, error) {
+	endpoint, err := tx.Endpoint().Endpoint(endpointID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	if !securityContext.IsAdmin {
+		owned, err := teamLeaderOwnsEndpoint(tx, securityContext, endpoint)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to verify the ownership of the environment", err)
+		}
+
+		if !owned {
+			return nil, httperror.Forbidden("User is not authorized", httperrors.ErrUnauthorized)
+		}
+	}
+
+	registries, err := tx.Registry().ReadAll()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve registries from the database", err)
+	}
+
+	accesses := []endpointRegistryAccess{}
+	for _, registry := range registries {
+		access, ok := registry.RegistryAccesses[endpoint.ID]
+		if !ok {
+			continue
+		}
+
+		accesses = append(accesses, endpointRegistryAccess{
+			RegistryID:             registry.ID,
+			Name:                   registry.Name,
+			URL:                    registry.URL,
+			Type:                   registry.Type,
+			RegistryAccessPolicies: access,
+		})
+	}
+
+	return accesses, nil
+}
@@ -0,0 +1,111 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const redactedDockerConfig = "[REDACTED]"
+
+type registrySecretInspectResponse struct {
+	models.K8sRegistrySecret
+	// Always redacted, the credentials stored in the secret are never returned
+	DockerConfigJSON string `json:"DockerConfigJSON" example:"[REDACTED]"`
+	// Whether the secret references the registry it was created for
+	MatchesRegistry bool `json:"MatchesRegistry" example:"true"`
+}
+
+// @id EndpointRegistrySecretInspect
+// @summary Inspect the registry secret of a namespace
+// @description Retrieve the metadata of the Kubernetes secret created by Portainer to pull images from a registry in a namespace.
+// @description The docker config stored in the secret is redacted.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param registryId path int true "Registry identifier"
+// @param namespace query string true "Namespace of the secret"
+// @success 200 {object} registrySecretInspectResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment, registry or secret not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/registries/{registryId}/secret [get]
+func (handler *Handler) endpointRegistrySecretInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	namespace, err := request.RetrieveQueryParameter(r, "namespace", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace query parameter", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return httperror.BadRequest("Registry secrets are only available on Kubernetes environments", errors.New("environment is not a Kubernetes environment"))
+	}
+
+	registry, err := handler.DataStore.Registry().Read(portainer.RegistryID(registryID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create Kubernetes client", err)
+	}
+
+	secret, err := cli.GetRegistrySecret(registry, namespace)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the registry secret", err)
+	}
+
+	if secret == nil {
+		return httperror.NotFound("Unable to find the registry secret in the namespace", errors.New("registry secret not found"))
+	}
+
+	return response.JSON(w, registrySecretInspectResponse{
+		K8sRegistrySecret: *secret,
+		DockerConfigJSON:  redactedDockerConfig,
+		MatchesRegistry:   registrySecretMatches(secret, registry),
+	})
+}
+
+// registrySecretMatches returns true when the secret was created for the registry and references its URL
+func registrySecretMatches(secret *models.K8sRegistrySecret, registry *portainer.Registry) bool {
+	if secret.RegistryID != strconv.Itoa(int(registry.ID)) {
+		return false
+	}
+
+	for _, url := range secret.RegistryURLs {
+		if url == registry.URL {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package endpoints
 
 import (
+	"errors"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -9,9 +10,13 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
 )
 
 type endpointUpdatePayload struct {
@@ -121,6 +126,7 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 	}
 
 	updateRelations := false
+	previousGroupID := endpoint.GroupID
 
 	if payload.GroupID != nil {
 		groupID := portainer.EndpointGroupID(*payload.GroupID)
@@ -272,6 +278,10 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
 	}
 
+	if endpoint.GroupID != previousGroupID {
+		handler.reconcileGroupRegistrySecrets(endpoint, previousGroupID)
+	}
+
 	if updateRelations {
 		err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 			return handler.updateEdgeRelations(tx, endpoint)
@@ -308,3 +318,26 @@ func shouldReloadTLSConfiguration(endpoint *portainer.Endpoint, payload *endpoin
 	}
 	return false
 }
+
+// reconcileGroupRegistrySecrets updates the registry secrets of a Kubernetes environment moved out of the group
+// previousGroupID, the failures are only logged since the environment is moved anyway
+func (handler *Handler) reconcileGroupRegistrySecrets(endpoint *portainer.Endpoint, previousGroupID portainer.EndpointGroupID) {
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return
+	}
+
+	registries, err := handler.DataStore.Registry().ReadAll()
+	if err == nil {
+		err = registryutils.ReconcileGroupMove(registries, endpoint, previousGroupID, func() (portainer.KubeClient, error) {
+			if endpoint.Status == portainer.EndpointStatusDown {
+				return nil, errors.New("the environment is unreachable")
+			}
+
+			return handler.K8sClientFactory.GetKubeClient(endpoint)
+		})
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to reconcile the registry secrets of the environment moved to another group")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
 )
 
 func hideFields(endpoint *portainer.Endpoint) {
@@ -37,14 +38,17 @@ type Handler struct {
 	AuthorizationService *authorization.Service
 	BindAddress          string
 	BindAddressHTTPS     string
+	// registryAccessKeys holds the idempotency keys of the completed registry access updates
+	registryAccessKeys *cache.Cache
 }
 
 // NewHandler creates a handler to manage environment(endpoint) operations.
 func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Handler {
 	h := &Handler{
-		Router:         mux.NewRouter(),
-		requestBouncer: bouncer,
-		demoService:    demoService,
+		Router:             mux.NewRouter(),
+		requestBouncer:     bouncer,
+		demoService:        demoService,
+		registryAccessKeys: cache.New(registryAccessIdempotencyTTL, registryAccessIdempotencyTTL),
 	}
 
 	h.Handle("/endpoints",
@@ -61,6 +65,9 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.agentVersions))).Methods(http.MethodGet)
 	h.Handle("/endpoints/relations", bouncer.RestrictedAccess(httperror.LoggerHandler(h.updateRelations))).Methods(http.MethodPut)
 
+	h.Handle("/endpoints/registries/validate",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccessValidate))).Methods(http.MethodPost)
+
 	h.Handle("/endpoints/{id}",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointInspect))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}",
@@ -71,10 +78,22 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointDockerhubStatus))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/snapshot",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointSnapshot))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/authorizations/check",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointAuthorizationCheck))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/registries",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistriesList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/registries/accesses",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccessesList))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/registries/{registryId}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccess))).Methods(http.MethodPut)
+	h.Handle("/endpoints/{id}/registries/{registryId}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccessDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/{id}/registries/{registryId}/access",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointRegistryAccessInspect))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/registries/{registryId}/undo",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistryAccessUndo))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/registries/{registryId}/secret",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointRegistrySecretInspect))).Methods(http.MethodGet)
 
 	h.Handle("/endpoints/global-key", bouncer.PublicAccess(httperror.LoggerHandler(h.endpointCreateGlobalKey))).Methods(http.MethodPost)
 
@@ -4,10 +4,14 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/portainer/portainer/api/http/middlewares"
 	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
 )
 
 // @id getKubernetesNamespaces
@@ -157,9 +161,31 @@ func (handler *Handler) createKubernetesNamespace(w http.ResponseWriter, r *http
 			err,
 		)
 	}
+
+	// the namespace is already created, a failure to propagate the registries is only logged
+	err = handler.createWildcardRegistrySecrets(r, payload.Name)
+	if err != nil {
+		log.Warn().Err(err).Str("namespace", payload.Name).Msg("unable to create the registry secrets of the namespace")
+	}
+
 	return nil
 }
 
+// createWildcardRegistrySecrets propagates the registries granted to every namespace of the environment to a new namespace
+func (handler *Handler) createWildcardRegistrySecrets(r *http.Request, namespace string) error {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return err
+	}
+
+	cli, err := handler.KubernetesClientFactory.GetKubeClient(endpoint)
+	if err != nil {
+		return err
+	}
+
+	return registryutils.CreateWildcardSecrets(cli, handler.DataStore, endpoint, namespace)
+}
+
 // @id deleteKubernetesNamespace
 // @summary Delete kubernetes namespace
 // @description Delete a kubernetes namespace within the given environment
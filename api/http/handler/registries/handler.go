@@ -17,6 +17,7 @@ import (
 func hideFields(registry *portainer.Registry, hideAccesses bool) {
 	registry.Password = ""
 	registry.ManagementConfiguration = nil
+	registry.AccessHistory = nil
 	if hideAccesses {
 		registry.RegistryAccesses = nil
 	}
@@ -59,6 +60,8 @@ func (handler *Handler) initRouter(bouncer accessGuard) {
 	adminRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryUpdate)).Methods(http.MethodPut)
 	adminRouter.Handle("/registries/{id}/configure", httperror.LoggerHandler(handler.registryConfigure)).Methods(http.MethodPost)
 	adminRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/registries/access/history", httperror.LoggerHandler(handler.registryAccessHistory)).Methods(http.MethodGet)
+	adminRouter.Handle("/registries/{registryId}/access", httperror.LoggerHandler(handler.registryAccessUpdate)).Methods(http.MethodPut)
 
 	authenticatedRouter.Handle("/registries/{id}", httperror.LoggerHandler(handler.registryInspect)).Methods(http.MethodGet)
 	authenticatedRouter.PathPrefix("/registries/proxies/gitlab").Handler(httperror.LoggerHandler(handler.proxyRequestsToGitlabAPIWithoutRegistry))
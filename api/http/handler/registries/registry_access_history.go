@@ -0,0 +1,165 @@
+package registries
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type registryAccessHistoryEntry struct {
+	RegistryID   portainer.RegistryID `json:"registryId" example:"1"`
+	RegistryName string               `json:"registryName" example:"my-registry"`
+	// Identifier of the environment, zero when the access granted to an environment group changed
+	EndpointID portainer.EndpointID `json:"endpointId" example:"1"`
+	// Name of the environment, empty when it was removed
+	EndpointName string `json:"endpointName" example:"my-environment"`
+	// Identifier of the environment group, only set when the access granted to the group changed
+	EndpointGroupID portainer.EndpointGroupID `json:"endpointGroupId,omitempty" example:"1"`
+	// Name of the environment group, empty when it was removed
+	EndpointGroupName string           `json:"endpointGroupName,omitempty" example:"my-group"`
+	UserID            portainer.UserID `json:"userId" example:"1"`
+	// Name of the user who made the change, empty when the user was removed
+	Username string `json:"username" example:"admin"`
+	// Unix timestamp of the change
+	Timestamp        int64                            `json:"timestamp" example:"1587399600"`
+	BeforeNamespaces []string                         `json:"beforeNamespaces"`
+	AfterNamespaces  []string                         `json:"afterNamespaces"`
+	Before           portainer.RegistryAccessPolicies `json:"before"`
+	After            portainer.RegistryAccessPolicies `json:"after"`
+}
+
+// @id RegistryAccessHistory
+// @summary List the changes of the registry accesses
+// @description List who changed the access policies and namespaces of a registry for an environment or an environment group, and when.
+// @description At least one of the registry, environment or environment group filters is required. The most recent changes come first.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param registryId query int false "Only return the changes of this registry"
+// @param endpointId query int false "Only return the changes for this environment(endpoint)"
+// @param endpointGroupId query int false "Only return the changes for this environment(endpoint) group"
+// @success 200 {array} registryAccessHistoryEntry "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry not found"
+// @failure 500 "Server error"
+// @router /registries/access/history [get]
+func (handler *Handler) registryAccessHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	registryID, _ := request.RetrieveNumericQueryParameter(r, "registryId", true)
+	endpointID, _ := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	endpointGroupID, _ := request.RetrieveNumericQueryParameter(r, "endpointGroupId", true)
+
+	if registryID == 0 && endpointID == 0 && endpointGroupID == 0 {
+		return httperror.BadRequest("Invalid query parameters", errors.New("registryId, endpointId or endpointGroupId is required"))
+	}
+
+	var registries []portainer.Registry
+	if registryID != 0 {
+		registry, err := handler.DataStore.Registry().Read(portainer.RegistryID(registryID))
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+		}
+
+		registries = []portainer.Registry{*registry}
+	} else {
+		var err error
+		registries, err = handler.DataStore.Registry().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve registries from the database", err)
+		}
+	}
+
+	endpointNames := map[portainer.EndpointID]string{}
+	endpointGroupNames := map[portainer.EndpointGroupID]string{}
+	usernames := map[portainer.UserID]string{}
+
+	entries := []registryAccessHistoryEntry{}
+	for _, registry := range registries {
+		for _, change := range registry.AccessHistory {
+			if endpointID != 0 && change.EndpointID != portainer.EndpointID(endpointID) {
+				continue
+			}
+
+			if endpointGroupID != 0 && change.EndpointGroupID != portainer.EndpointGroupID(endpointGroupID) {
+				continue
+			}
+
+			endpointGroupName, ok := endpointGroupNames[change.EndpointGroupID]
+			if !ok && change.EndpointGroupID != 0 {
+				endpointGroup, err := handler.DataStore.EndpointGroup().Read(change.EndpointGroupID)
+				if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+					return httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+				}
+
+				if endpointGroup != nil {
+					endpointGroupName = endpointGroup.Name
+				}
+				endpointGroupNames[change.EndpointGroupID] = endpointGroupName
+			}
+
+			endpointName, ok := endpointNames[change.EndpointID]
+			if !ok && change.EndpointID != 0 {
+				endpoint, err := handler.DataStore.Endpoint().Endpoint(change.EndpointID)
+				if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+					return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+				}
+
+				if endpoint != nil {
+					endpointName = endpoint.Name
+				}
+				endpointNames[change.EndpointID] = endpointName
+			}
+
+			username, ok := usernames[change.UserID]
+			if !ok {
+				user, err := handler.DataStore.User().Read(change.UserID)
+				if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+					return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+				}
+
+				if user != nil {
+					username = user.Username
+				}
+				usernames[change.UserID] = username
+			}
+
+			entries = append(entries, registryAccessHistoryEntry{
+				RegistryID:        registry.ID,
+				RegistryName:      registry.Name,
+				EndpointID:        change.EndpointID,
+				EndpointName:      endpointName,
+				EndpointGroupID:   change.EndpointGroupID,
+				EndpointGroupName: endpointGroupName,
+				UserID:            change.UserID,
+				Username:          username,
+				Timestamp:         change.Timestamp,
+				BeforeNamespaces:  emptyIfNil(change.Before.Namespaces),
+				AfterNamespaces:   emptyIfNil(change.After.Namespaces),
+				Before:            change.Before,
+				After:             change.After,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp > entries[j].Timestamp
+	})
+
+	return response.JSON(w, entries)
+}
+
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
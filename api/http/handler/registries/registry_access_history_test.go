@@ -0,0 +1,87 @@
+package registries
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registryAccessHistory(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(user)
+	is.NoError(err)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "k8s"}
+	err = store.Endpoint().Create(endpoint)
+	is.NoError(err)
+
+	endpointGroup := &portainer.EndpointGroup{ID: 2, Name: "production"}
+	err = store.EndpointGroup().Create(endpointGroup)
+	is.NoError(err)
+
+	registry := &portainer.Registry{
+		ID:   1,
+		Name: "registry",
+		AccessHistory: []portainer.RegistryAccessChange{
+			{EndpointID: endpoint.ID, UserID: user.ID, Timestamp: 100, After: portainer.RegistryAccessPolicies{Namespaces: []string{"default"}}},
+			{EndpointID: endpoint.ID, UserID: user.ID, Timestamp: 200, Before: portainer.RegistryAccessPolicies{Namespaces: []string{"default"}}},
+			{EndpointID: 2, UserID: 42, Timestamp: 300},
+			{EndpointGroupID: endpointGroup.ID, UserID: user.ID, Timestamp: 50, After: portainer.RegistryAccessPolicies{Namespaces: []string{"prod"}}},
+		},
+	}
+	err = store.Registry().Create(registry)
+	is.NoError(err)
+
+	h := &Handler{DataStore: store}
+
+	history := func(query string) (int, []registryAccessHistoryEntry) {
+		rr := httptest.NewRecorder()
+		handlerErr := h.registryAccessHistory(rr, httptest.NewRequest(http.MethodGet, "/registries/access/history?"+query, nil))
+		if handlerErr != nil {
+			return handlerErr.StatusCode, nil
+		}
+
+		var entries []registryAccessHistoryEntry
+		is.NoError(json.NewDecoder(rr.Body).Decode(&entries))
+
+		return rr.Code, entries
+	}
+
+	status, _ := history("")
+	is.Equal(http.StatusBadRequest, status)
+
+	status, entries := history("endpointId=1")
+	is.Equal(http.StatusOK, status)
+	is.Len(entries, 2)
+	is.Equal(int64(200), entries[0].Timestamp, "the most recent change comes first")
+	is.Equal([]string{"default"}, entries[0].BeforeNamespaces)
+	is.Equal([]string{}, entries[0].AfterNamespaces)
+	is.Equal("admin", entries[0].Username)
+	is.Equal("k8s", entries[0].EndpointName)
+	is.Equal("registry", entries[0].RegistryName)
+
+	status, entries = history("registryId=1")
+	is.Equal(http.StatusOK, status)
+	is.Len(entries, 4)
+	is.Empty(entries[0].Username, "the user was removed")
+	is.Empty(entries[0].EndpointName, "the environment was removed")
+
+	status, entries = history("endpointGroupId=2")
+	is.Equal(http.StatusOK, status)
+	is.Len(entries, 1, "the changes of the environments are not listed with the changes of the group")
+	is.Equal("production", entries[0].EndpointGroupName)
+	is.Equal([]string{"prod"}, entries[0].AfterNamespaces)
+
+	status, _ = history("registryId=2")
+	is.Equal(http.StatusNotFound, status)
+}
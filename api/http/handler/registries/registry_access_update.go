@@ -0,0 +1,297 @@
+package registries
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type registryAccessUpdateEntry struct {
+	// Environment(Endpoint) identifier
+	EndpointID portainer.EndpointID `example:"1"`
+	// Access policies of the users, ignored for Kubernetes environments
+	UserAccessPolicies portainer.UserAccessPolicies
+	// Access policies of the teams, ignored for Kubernetes environments
+	TeamAccessPolicies portainer.TeamAccessPolicies
+	// Namespaces that can use the registry, only for Kubernetes environments. "*" grants the access to every namespace
+	Namespaces []string
+	// Ignore the namespaces granted to the group of the environment, only for Kubernetes environments.
+	// The current value is kept when it is not set
+	ExcludeGroupAccess *bool `example:"false"`
+}
+
+type registryAccessUpdatePayload struct {
+	// Registry access of each environment(endpoint) to update
+	Accesses []registryAccessUpdateEntry
+}
+
+func (payload *registryAccessUpdatePayload) Validate(r *http.Request) error {
+	if len(payload.Accesses) == 0 {
+		return errors.New("Invalid accesses. Must contain at least one environment")
+	}
+
+	endpoints := map[portainer.EndpointID]bool{}
+	for _, access := range payload.Accesses {
+		if access.EndpointID == 0 {
+			return errors.New("Invalid environment identifier. Must be set for every access")
+		}
+
+		if endpoints[access.EndpointID] {
+			return fmt.Errorf("Invalid accesses. The environment %d is listed more than once", access.EndpointID)
+		}
+
+		endpoints[access.EndpointID] = true
+
+		err := registryutils.ValidateNamespaces(access.Namespaces)
+		if err != nil {
+			return fmt.Errorf("Invalid namespaces of the environment %d: %w", access.EndpointID, err)
+		}
+	}
+
+	return nil
+}
+
+// appliedKubeAccess is a reconciliation of the registry secrets of an environment, kept to revert it when the update fails
+type appliedKubeAccess struct {
+	registry      *portainer.Registry
+	endpoint      *portainer.Endpoint
+	oldNamespaces []string
+	newNamespaces []string
+}
+
+type registryAccessRevertFailure struct {
+	EndpointID portainer.EndpointID `json:"endpointId" example:"1"`
+	Error      string               `json:"error"`
+	// What was done in each namespace of the environment before the revert failed
+	Namespaces []registryutils.NamespaceSecretResult `json:"namespaces,omitempty"`
+}
+
+type registryAccessUpdateFailure struct {
+	Message string `json:"message" example:"Unable to update the registry secrets of the environment 1"`
+	Details string `json:"details"`
+	// What was done in each namespace of the environment that failed, the secrets created by the update are rolled back
+	Namespaces []registryutils.NamespaceSecretResult `json:"namespaces,omitempty"`
+	// Environments already updated whose registry secrets could not be reverted, they must be reconciled manually
+	NotReverted []registryAccessRevertFailure `json:"notReverted"`
+}
+
+// @id RegistryAccessUpdate
+// @summary Update the registry access of several environments
+// @description Update the registry access of several environments(endpoints) at once. The update is atomic: when an environment
+// @description cannot be updated, none is, and the registry secrets already reconciled on Kubernetes environments are reverted.
+// @description The environments whose registry secrets could not be reverted are listed in the response.
+// @description On Kubernetes environments, the namespaces must exist in the environment.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param registryId path int true "Registry identifier"
+// @param body body registryAccessUpdatePayload true "Accesses"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Registry or environment not found"
+// @failure 500 {object} registryAccessUpdateFailure "Server error, the environments whose registry secrets could not be reverted are listed"
+// @router /registries/{registryId}/access [put]
+func (handler *Handler) registryAccessUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "registryId")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	var payload registryAccessUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	var applied []appliedKubeAccess
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		applied, err = handler.updateRegistryAccesses(tx, r, portainer.RegistryID(registryID), payload)
+		return err
+	})
+	if err != nil {
+		notReverted := handler.revertKubeAccesses(applied)
+
+		var httpErr *httperror.HandlerError
+		if !errors.As(err, &httpErr) {
+			httpErr = httperror.InternalServerError("Unexpected error", err)
+		}
+
+		var kubeErr *registryutils.KubeAccessError
+		if errors.As(httpErr.Err, &kubeErr) || len(notReverted) > 0 {
+			return writeRegistryAccessUpdateFailure(w, httpErr, kubeErr, notReverted)
+		}
+
+		return httpErr
+	}
+
+	return response.Empty(w)
+}
+
+// updateRegistryAccesses returns the registry secrets reconciled before a failure along with the error, the
+// environment that failed is not listed since its own reconciliation is already rolled back
+func (handler *Handler) updateRegistryAccesses(tx dataservices.DataStoreTx, r *http.Request, registryID portainer.RegistryID, payload registryAccessUpdatePayload) ([]appliedKubeAccess, error) {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	registry, err := tx.Registry().Read(registryID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	maxPolicies, maxNamespaces := registryutils.AccessLimits(settings)
+	for _, access := range payload.Accesses {
+		if len(access.UserAccessPolicies)+len(access.TeamAccessPolicies) > maxPolicies || len(access.Namespaces) > maxNamespaces {
+			return nil, httperror.BadRequest("Invalid request payload", fmt.Errorf("the access of the environment %d exceeds the limit of %d access policies and %d namespaces", access.EndpointID, maxPolicies, maxNamespaces))
+		}
+	}
+
+	if registry.RegistryAccesses == nil {
+		registry.RegistryAccesses = portainer.RegistryAccesses{}
+	}
+
+	var applied []appliedKubeAccess
+
+	for _, access := range payload.Accesses {
+		endpoint, err := tx.Endpoint().Endpoint(access.EndpointID)
+		if tx.IsErrObjectNotFound(err) {
+			return applied, httperror.NotFound(fmt.Sprintf("Unable to find the environment %d inside the database", access.EndpointID), err)
+		} else if err != nil {
+			return applied, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+		}
+
+		err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+		if err != nil {
+			return applied, httperror.Forbidden(fmt.Sprintf("Permission denied to access the environment %d", endpoint.ID), err)
+		}
+
+		registryAccess := registry.RegistryAccesses[endpoint.ID]
+		previousAccess := registryAccess
+
+		if endpointutils.IsKubernetesEndpoint(endpoint) {
+			cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
+			if err != nil {
+				return applied, httperror.InternalServerError("Unable to create Kubernetes client", err)
+			}
+
+			err = registryutils.ValidateKubeNamespaces(cli, access.Namespaces)
+			if errors.Is(err, registryutils.ErrUnknownNamespaces) {
+				return applied, httperror.BadRequest(fmt.Sprintf("Invalid namespaces of the environment %d", endpoint.ID), err)
+			} else if err != nil {
+				return applied, httperror.InternalServerError(fmt.Sprintf("Unable to retrieve the namespaces of the environment %d", endpoint.ID), err)
+			}
+
+			// the secrets follow the effective access, merged with the namespaces of the group of the environment
+			previousNamespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
+			registryAccess.Namespaces = access.Namespaces
+			if access.ExcludeGroupAccess != nil {
+				registryAccess.ExcludeGroupAccess = *access.ExcludeGroupAccess
+			}
+			registry.RegistryAccesses[endpoint.ID] = registryAccess
+
+			namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+
+			// a failed reconciliation rolls back its own secrets, only the successful ones are reverted
+			err = registryutils.UpdateKubeAccess(cli, registry, previousNamespaces, namespaces)
+			if err != nil {
+				return applied, httperror.InternalServerError(fmt.Sprintf("Unable to update the registry secrets of the environment %d", endpoint.ID), err)
+			}
+
+			applied = append(applied, appliedKubeAccess{registry: registry, endpoint: endpoint, oldNamespaces: previousNamespaces, newNamespaces: namespaces})
+		} else {
+			registryAccess.UserAccessPolicies = access.UserAccessPolicies
+			registryAccess.TeamAccessPolicies = access.TeamAccessPolicies
+		}
+
+		registry.RegistryAccesses[endpoint.ID] = registryAccess
+
+		registryutils.RecordAccessChange(registry, portainer.RegistryAccessChange{
+			EndpointID: endpoint.ID,
+			UserID:     securityContext.UserID,
+			Timestamp:  time.Now().Unix(),
+			Before:     previousAccess,
+			After:      registryAccess,
+		})
+	}
+
+	err = tx.Registry().Update(registry.ID, registry)
+	if err != nil {
+		return applied, httperror.InternalServerError("Unable to persist registry changes inside the database", err)
+	}
+
+	return applied, nil
+}
+
+// revertKubeAccesses restores the registry secrets of the environments in reverse order and returns the
+// environments that could not be reverted
+func (handler *Handler) revertKubeAccesses(applied []appliedKubeAccess) []registryAccessRevertFailure {
+	var notReverted []registryAccessRevertFailure
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		access := applied[i]
+
+		cli, err := handler.K8sClientFactory.GetKubeClient(access.endpoint)
+		if err == nil {
+			err = registryutils.UpdateKubeAccess(cli, access.registry, access.newNamespaces, access.oldNamespaces)
+		}
+
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(access.endpoint.ID)).Msg("unable to revert the registry secrets")
+
+			failure := registryAccessRevertFailure{EndpointID: access.endpoint.ID, Error: err.Error()}
+
+			var kubeErr *registryutils.KubeAccessError
+			if errors.As(err, &kubeErr) {
+				failure.Namespaces = kubeErr.Results
+			}
+
+			notReverted = append(notReverted, failure)
+		}
+	}
+
+	return notReverted
+}
+
+// writeRegistryAccessUpdateFailure reports the registry secrets handled before the failure and the environments that
+// could not be reverted, so that their namespaces can be reconciled manually
+func writeRegistryAccessUpdateFailure(w http.ResponseWriter, httpErr *httperror.HandlerError, kubeErr *registryutils.KubeAccessError, notReverted []registryAccessRevertFailure) *httperror.HandlerError {
+	log.Error().Err(httpErr.Err).Msg("unable to update the registry access")
+
+	failure := registryAccessUpdateFailure{Message: httpErr.Message, Details: httpErr.Err.Error(), NotReverted: notReverted}
+	if kubeErr != nil {
+		failure.Namespaces = kubeErr.Results
+	}
+
+	if failure.NotReverted == nil {
+		failure.NotReverted = []registryAccessRevertFailure{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.StatusCode)
+
+	return response.JSON(w, failure)
+}
@@ -0,0 +1,112 @@
+package registries
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type bouncerStub struct {
+	security.BouncerService
+}
+
+func (bouncer *bouncerStub) AuthorizedEndpointOperation(r *http.Request, endpoint *portainer.Endpoint) error {
+	return nil
+}
+
+func Test_registryAccessUpdate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	for _, endpoint := range []*portainer.Endpoint{{ID: 1, Name: "docker-1"}, {ID: 2, Name: "docker-2"}} {
+		is.NoError(store.Endpoint().Create(endpoint))
+	}
+
+	registry := &portainer.Registry{ID: 1, Name: "registry"}
+	is.NoError(store.Registry().Create(registry))
+
+	h := &Handler{DataStore: store, requestBouncer: &bouncerStub{}}
+
+	update := func(accesses []registryAccessUpdateEntry) int {
+		body, err := json.Marshal(registryAccessUpdatePayload{Accesses: accesses})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, "/registries/1/access", bytes.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"registryId": "1"})
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}))
+
+		rr := httptest.NewRecorder()
+		handlerErr := h.registryAccessUpdate(rr, req)
+		if handlerErr != nil {
+			return handlerErr.StatusCode
+		}
+
+		return rr.Code
+	}
+
+	policies := portainer.UserAccessPolicies{1: {}}
+
+	status := update([]registryAccessUpdateEntry{{EndpointID: 1, UserAccessPolicies: policies}, {EndpointID: 3, UserAccessPolicies: policies}})
+	is.Equal(http.StatusNotFound, status)
+
+	registry, err := store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Empty(registry.RegistryAccesses, "the update is rolled back when an environment fails")
+
+	status = update([]registryAccessUpdateEntry{{EndpointID: 1, UserAccessPolicies: policies}, {EndpointID: 2, UserAccessPolicies: policies}})
+	is.Equal(http.StatusNoContent, status)
+
+	registry, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Equal(policies, registry.RegistryAccesses[1].UserAccessPolicies)
+	is.Equal(policies, registry.RegistryAccesses[2].UserAccessPolicies)
+	is.Len(registry.AccessHistory, 2)
+
+	is.Equal(http.StatusBadRequest, update([]registryAccessUpdateEntry{{EndpointID: 1}, {EndpointID: 1}}), "environments cannot be listed twice")
+
+	is.Equal(http.StatusBadRequest, update([]registryAccessUpdateEntry{{EndpointID: 1, Namespaces: []string{""}}}), "the namespaces cannot be empty")
+	is.Equal(http.StatusBadRequest, update([]registryAccessUpdateEntry{{EndpointID: 1, Namespaces: []string{"dev", "dev"}}}), "the namespaces cannot be listed twice")
+
+	registry.RegistryAccesses[1] = portainer.RegistryAccessPolicies{ExcludeGroupAccess: true}
+	is.NoError(store.Registry().Update(registry.ID, registry))
+
+	is.Equal(http.StatusNoContent, update([]registryAccessUpdateEntry{{EndpointID: 1, UserAccessPolicies: policies}}))
+
+	registry, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.True(registry.RegistryAccesses[1].ExcludeGroupAccess, "the exclusion of the group access is kept when it is not set")
+}
+
+func Test_writeRegistryAccessUpdateFailure(t *testing.T) {
+	is := assert.New(t)
+
+	kubeErr := &registryutils.KubeAccessError{
+		Err:     errors.New("creation failed"),
+		Results: []registryutils.NamespaceSecretResult{{Namespace: "prod", Status: registryutils.NamespaceSecretFailed, Error: "creation failed"}},
+	}
+	notReverted := []registryAccessRevertFailure{{EndpointID: 1, Error: "unable to reach the environment"}}
+
+	rr := httptest.NewRecorder()
+	handlerErr := writeRegistryAccessUpdateFailure(rr, httperror.InternalServerError("Unable to update the registry secrets of the environment 2", kubeErr), kubeErr, notReverted)
+	is.Nil(handlerErr)
+	is.Equal(http.StatusInternalServerError, rr.Code)
+
+	var failure registryAccessUpdateFailure
+	is.NoError(json.NewDecoder(rr.Body).Decode(&failure))
+	is.Equal("Unable to update the registry secrets of the environment 2", failure.Message)
+	is.Equal(kubeErr.Results, failure.Namespaces)
+	is.Equal(notReverted, failure.NotReverted, "the environments that could not be reverted are reported")
+}
@@ -8,6 +8,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -43,6 +44,9 @@ type registryCreatePayload struct {
 	Quay portainer.QuayRegistryData
 	// ECR specific details, required when type = 7
 	Ecr portainer.EcrData
+	// Template of the name of the Kubernetes secrets of the registry, {registryId} (required) and {namespace} are substituted.
+	// The secrets are named registry-<registry ID> when it is empty
+	SecretNameTemplate string `example:"regcred-{registryId}"`
 }
 
 func (payload *registryCreatePayload) Validate(_ *http.Request) error {
@@ -74,7 +78,7 @@ func (payload *registryCreatePayload) Validate(_ *http.Request) error {
 		return fmt.Errorf("BaseURL is required for registry type %d (ProGet)", portainer.ProGetRegistry)
 	}
 
-	return nil
+	return registryutils.ValidateSecretNameTemplate(payload.SecretNameTemplate)
 }
 
 // @id RegistryCreate
@@ -107,17 +111,18 @@ func (handler *Handler) registryCreate(w http.ResponseWriter, r *http.Request) *
 	}
 
 	registry := &portainer.Registry{
-		Type:             portainer.RegistryType(payload.Type),
-		Name:             payload.Name,
-		URL:              payload.URL,
-		BaseURL:          payload.BaseURL,
-		Authentication:   payload.Authentication,
-		Username:         payload.Username,
-		Password:         payload.Password,
-		Gitlab:           payload.Gitlab,
-		Quay:             payload.Quay,
-		RegistryAccesses: portainer.RegistryAccesses{},
-		Ecr:              payload.Ecr,
+		Type:               portainer.RegistryType(payload.Type),
+		Name:               payload.Name,
+		URL:                payload.URL,
+		BaseURL:            payload.BaseURL,
+		Authentication:     payload.Authentication,
+		Username:           payload.Username,
+		Password:           payload.Password,
+		Gitlab:             payload.Gitlab,
+		Quay:               payload.Quay,
+		RegistryAccesses:   portainer.RegistryAccesses{},
+		Ecr:                payload.Ecr,
+		SecretNameTemplate: payload.SecretNameTemplate,
 	}
 
 	registry.ManagementConfiguration = syncConfig(registry)
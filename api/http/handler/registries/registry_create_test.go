@@ -42,4 +42,18 @@ func Test_registryCreatePayload_Validate(t *testing.T) {
 		err := payload.Validate(nil)
 		assert.NoError(t, err)
 	})
+	t.Run("Can't create a registry with a secret name template that renders an invalid name", func(t *testing.T) {
+		payload := basePayload
+		payload.Type = portainer.CustomRegistry
+		payload.SecretNameTemplate = "Registry_{registryId}"
+		err := payload.Validate(nil)
+		assert.Error(t, err)
+	})
+	t.Run("Can create a registry with a secret name template", func(t *testing.T) {
+		payload := basePayload
+		payload.Type = portainer.CustomRegistry
+		payload.SecretNameTemplate = "regcred-{registryId}-{namespace}"
+		err := payload.Validate(nil)
+		assert.NoError(t, err)
+	})
 }
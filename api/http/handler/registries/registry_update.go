@@ -8,6 +8,7 @@ import (
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -32,15 +33,38 @@ type registryUpdatePayload struct {
 	RegistryAccesses *portainer.RegistryAccesses `json:",omitempty"`
 	// ECR data
 	Ecr *portainer.EcrData `json:",omitempty"`
+	// Template of the name of the Kubernetes secrets of the registry, {registryId} (required) and {namespace} are substituted.
+	// An empty template restores the default registry-<registry ID> name
+	SecretNameTemplate *string `json:",omitempty" example:"regcred-{registryId}"`
 }
 
 func (payload *registryUpdatePayload) Validate(r *http.Request) error {
+	if payload.SecretNameTemplate != nil {
+		return registryutils.ValidateSecretNameTemplate(*payload.SecretNameTemplate)
+	}
+
 	return nil
 }
 
+type refreshedRegistrySecret struct {
+	EndpointID portainer.EndpointID `example:"1"`
+	Namespace  string               `example:"default"`
+	Secret     string               `example:"registry-1"`
+}
+
+type registryUpdateResponse struct {
+	*portainer.Registry
+	// Kubernetes secrets recreated because the credentials of the registry changed
+	RefreshedSecrets []refreshedRegistrySecret `json:",omitempty"`
+}
+
 // @id RegistryUpdate
 // @summary Update a registry
 // @description Update a registry
+// @description When the credentials change, the Kubernetes secrets of the namespaces that can access the registry are recreated,
+// @description unless DisableRegistrySecretRefresh is enabled in the settings. The refreshed secrets are listed in the response.
+// @description Changing the secret name template renames the secrets of the namespaces that can access the registry, even when
+// @description DisableRegistrySecretRefresh is enabled.
 // @description **Access policy**: restricted
 // @tags registries
 // @security ApiKeyAuth
@@ -49,7 +73,7 @@ func (payload *registryUpdatePayload) Validate(r *http.Request) error {
 // @produce json
 // @param id path int true "Registry identifier"
 // @param body body registryUpdatePayload true "Registry details"
-// @success 200 {object} portainer.Registry "Success"
+// @success 200 {object} registryUpdateResponse "Success"
 // @failure 400 "Invalid request"
 // @failure 404 "Registry not found"
 // @failure 409 "Another registry with the same URL already exists"
@@ -101,11 +125,15 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 		}
 	}
 
+	// the secrets are looked up by name, the previous name is needed to remove them when the template changes
+	previousRegistry := *registry
+
 	if registry.Type == portainer.ProGetRegistry && payload.BaseURL != nil {
 		registry.BaseURL = *payload.BaseURL
 	}
 
 	shouldUpdateSecrets := false
+	secretNameChanged := false
 
 	if payload.Authentication != nil {
 		shouldUpdateSecrets = shouldUpdateSecrets || (registry.Authentication != *payload.Authentication)
@@ -141,6 +169,12 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 
 	registry.ManagementConfiguration = syncConfig(registry)
 
+	if payload.SecretNameTemplate != nil {
+		secretNameChanged = registry.SecretNameTemplate != *payload.SecretNameTemplate
+		shouldUpdateSecrets = shouldUpdateSecrets || secretNameChanged
+		registry.SecretNameTemplate = *payload.SecretNameTemplate
+	}
+
 	if payload.URL != nil {
 		shouldUpdateSecrets = shouldUpdateSecrets || (*payload.URL != registry.URL)
 
@@ -153,21 +187,37 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 		}
 	}
 
+	var refreshedSecrets []refreshedRegistrySecret
+
 	if shouldUpdateSecrets {
 		registry.AccessToken = ""
 		registry.AccessTokenExpiry = 0
 
-		for endpointID, endpointAccess := range registry.RegistryAccesses {
-			endpoint, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+		settings, err := handler.DataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+		}
+
+		// the secrets are still renamed when the refresh is disabled, the pods would otherwise reference a missing secret
+		if !settings.DisableRegistrySecretRefresh || secretNameChanged {
+			endpoints, err := handler.DataStore.Endpoint().Endpoints()
 			if err != nil {
-				return httperror.InternalServerError("Unable to update access to registry", err)
+				return httperror.InternalServerError("Unable to retrieve the environments from the database", err)
 			}
 
-			if endpointutils.IsKubernetesEndpoint(endpoint) {
-				err = handler.updateEndpointRegistryAccess(endpoint, registry, endpointAccess)
+			for _, access := range kubeRegistryAccesses(endpoints, registry) {
+				namespaces, err := handler.updateEndpointRegistryAccess(access.endpoint, &previousRegistry, registry, access.namespaces)
 				if err != nil {
 					return httperror.InternalServerError("Unable to update access to registry", err)
 				}
+
+				for _, namespace := range namespaces {
+					refreshedSecrets = append(refreshedSecrets, refreshedRegistrySecret{
+						EndpointID: access.endpoint.ID,
+						Namespace:  namespace,
+						Secret:     registryutils.SecretName(registry, namespace),
+					})
+				}
 			}
 		}
 	}
@@ -181,7 +231,7 @@ func (handler *Handler) registryUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist registry changes inside the database", err)
 	}
 
-	return response.JSON(w, registry)
+	return response.JSON(w, registryUpdateResponse{Registry: registry, RefreshedSecrets: refreshedSecrets})
 }
 
 func syncConfig(registry *portainer.Registry) *portainer.RegistryManagementConfiguration {
@@ -199,24 +249,64 @@ func syncConfig(registry *portainer.Registry) *portainer.RegistryManagementConfi
 	return config
 }
 
-func (handler *Handler) updateEndpointRegistryAccess(endpoint *portainer.Endpoint, registry *portainer.Registry, endpointAccess portainer.RegistryAccessPolicies) error {
+type kubeRegistryAccess struct {
+	endpoint   *portainer.Endpoint
+	namespaces []string
+}
+
+// kubeRegistryAccesses returns the Kubernetes environments with access to the registry and their namespaces, the
+// namespaces granted to the group of an environment are included
+func kubeRegistryAccesses(endpoints []portainer.Endpoint, registry *portainer.Registry) []kubeRegistryAccess {
+	var accesses []kubeRegistryAccess
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if !endpointutils.IsKubernetesEndpoint(endpoint) {
+			continue
+		}
+
+		namespaces := registryutils.EffectiveAccess(registry, endpoint.ID, endpoint.GroupID).Namespaces
+		if len(namespaces) == 0 {
+			continue
+		}
+
+		accesses = append(accesses, kubeRegistryAccess{endpoint: endpoint, namespaces: namespaces})
+	}
+
+	return accesses
+}
+
+// updateEndpointRegistryAccess recreates the registry secrets of an environment and returns the namespaces they were recreated in.
+// The secrets named after the previous state of the registry are removed
+func (handler *Handler) updateEndpointRegistryAccess(endpoint *portainer.Endpoint, previousRegistry, registry *portainer.Registry, accessNamespaces []string) ([]string, error) {
 
 	cli, err := handler.K8sClientFactory.GetKubeClient(endpoint)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	namespaces, err := registryutils.ExpandNamespaces(cli, accessNamespaces)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, namespace := range endpointAccess.Namespaces {
-		err := cli.DeleteRegistrySecret(registry, namespace)
+	for _, namespace := range namespaces {
+		if err := registryutils.ValidateSecretName(registry, namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, namespace := range namespaces {
+		err := cli.DeleteRegistrySecret(previousRegistry, namespace)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		err = cli.CreateRegistrySecret(registry, namespace)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return namespaces, nil
 }
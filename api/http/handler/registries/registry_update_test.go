@@ -0,0 +1,140 @@
+package registries
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registryUpdate_secretRefreshDisabled(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.DisableRegistrySecretRefresh = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "k8s", Type: portainer.KubernetesLocalEnvironment}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	registry := &portainer.Registry{
+		ID:             1,
+		Name:           "registry",
+		URL:            "registry.mydomain.tld",
+		Authentication: true,
+		Username:       "user",
+		Password:       "old",
+		RegistryAccesses: portainer.RegistryAccesses{
+			endpoint.ID: {Namespaces: []string{"default"}},
+		},
+	}
+	is.NoError(store.Registry().Create(registry))
+
+	// the Kubernetes client factory is left unset, the refresh would fail if it was attempted
+	h := &Handler{DataStore: store}
+
+	body, err := json.Marshal(map[string]any{"Authentication": true, "Password": "new"})
+	is.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, "/registries/1", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true}))
+
+	rr := httptest.NewRecorder()
+	handlerErr := h.registryUpdate(rr, req)
+	is.Nil(handlerErr)
+
+	var resp registryUpdateResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Empty(resp.RefreshedSecrets)
+
+	registry, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Equal("new", registry.Password)
+}
+
+func Test_registryUpdate_secretRefreshDisabled_renamesSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.DisableRegistrySecretRefresh = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "k8s", Type: portainer.KubernetesLocalEnvironment}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	registry := &portainer.Registry{
+		ID:   1,
+		Name: "registry",
+		URL:  "registry.mydomain.tld",
+		RegistryAccesses: portainer.RegistryAccesses{
+			endpoint.ID: {Namespaces: []string{"default"}},
+		},
+	}
+	is.NoError(store.Registry().Create(registry))
+
+	// the tests do not run in a cluster, the client of the local environment cannot be created
+	factory, err := cli.NewClientFactory(nil, nil, store, "", "", "")
+	is.NoError(err)
+
+	h := &Handler{DataStore: store, K8sClientFactory: factory}
+
+	body, err := json.Marshal(map[string]any{"SecretNameTemplate": "regcred-{registryId}"})
+	is.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, "/registries/1", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true}))
+
+	handlerErr := h.registryUpdate(httptest.NewRecorder(), req)
+	is.NotNil(handlerErr, "the secrets are renamed even when the refresh is disabled")
+	is.Equal(http.StatusInternalServerError, handlerErr.StatusCode)
+
+	registry, err = store.Registry().Read(registry.ID)
+	is.NoError(err)
+	is.Empty(registry.SecretNameTemplate, "the template is not saved when the secrets cannot be renamed")
+}
+
+func Test_kubeRegistryAccesses(t *testing.T) {
+	is := assert.New(t)
+
+	endpoints := []portainer.Endpoint{
+		{ID: 1, Name: "k8s-own", Type: portainer.KubernetesLocalEnvironment, GroupID: 1},
+		{ID: 2, Name: "k8s-group", Type: portainer.AgentOnKubernetesEnvironment, GroupID: 2},
+		{ID: 3, Name: "k8s-excluded", Type: portainer.KubernetesLocalEnvironment, GroupID: 2},
+		{ID: 4, Name: "docker", Type: portainer.DockerEnvironment, GroupID: 2},
+		{ID: 5, Name: "k8s-none", Type: portainer.KubernetesLocalEnvironment, GroupID: 1},
+	}
+
+	registry := &portainer.Registry{
+		RegistryAccesses: portainer.RegistryAccesses{
+			1: {Namespaces: []string{"default"}},
+			3: {ExcludeGroupAccess: true},
+		},
+		GroupRegistryAccesses: portainer.GroupRegistryAccesses{
+			2: {Namespaces: []string{"team"}},
+		},
+	}
+
+	accesses := kubeRegistryAccesses(endpoints, registry)
+	is.Len(accesses, 2)
+	is.Equal(portainer.EndpointID(1), accesses[0].endpoint.ID)
+	is.Equal([]string{"default"}, accesses[0].namespaces)
+	is.Equal(portainer.EndpointID(2), accesses[1].endpoint.ID, "the environments with only the access of their group are refreshed")
+	is.Equal([]string{"team"}, accesses[1].namespaces)
+}
@@ -0,0 +1,198 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxSettingsEventSubscribers is the maximum number of clients streaming the settings events at the same time
+	maxSettingsEventSubscribers = 10
+	// settingsEventBufferSize is the number of events kept for a slow subscriber before new events are dropped
+	settingsEventBufferSize = 16
+
+	redactedValue = "[REDACTED]"
+)
+
+var errTooManySubscribers = errors.New("too many subscribers to the settings events")
+
+// redactedSettingsFields lists the settings fields, using their JSON path, whose values are never sent in events
+var redactedSettingsFields = map[string]bool{
+	"AgentSecret":                 true,
+	"JWTSigningKey":               true,
+	"LDAPCAExpiryWebhookURL":      true,
+	"LDAPSettings.Password":       true,
+	"OAuthSettings.ClientSecret":  true,
+	"OAuthSettings.KubeSecretKey": true,
+	"OutboundProxyURL":            true,
+	"SettingsChangeWebhookURL":    true,
+}
+
+type settingsFieldChange struct {
+	// JSON path of the field
+	Field string `json:"field" example:"LDAPSettings.URL"`
+	// Previous value, redacted for secrets
+	Old any `json:"old"`
+	// New value, redacted for secrets
+	New any `json:"new"`
+}
+
+type settingsChangeEvent struct {
+	// Unix timestamp of the change
+	Timestamp int64                 `json:"timestamp" example:"1587399600"`
+	Changes   []settingsFieldChange `json:"changes"`
+}
+
+// settingsEventBroker fans out the settings change events to a bounded number of subscribers
+type settingsEventBroker struct {
+	mu             sync.Mutex
+	subscribers    map[chan settingsChangeEvent]struct{}
+	maxSubscribers int
+}
+
+func newSettingsEventBroker(maxSubscribers int) *settingsEventBroker {
+	return &settingsEventBroker{
+		subscribers:    make(map[chan settingsChangeEvent]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+func (broker *settingsEventBroker) subscribe() (chan settingsChangeEvent, error) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if len(broker.subscribers) >= broker.maxSubscribers {
+		return nil, errTooManySubscribers
+	}
+
+	ch := make(chan settingsChangeEvent, settingsEventBufferSize)
+	broker.subscribers[ch] = struct{}{}
+
+	return ch, nil
+}
+
+func (broker *settingsEventBroker) unsubscribe(ch chan settingsChangeEvent) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	delete(broker.subscribers, ch)
+}
+
+// publish never blocks, the event is dropped for the subscribers that are not keeping up
+func (broker *settingsEventBroker) publish(event settingsChangeEvent) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for ch := range broker.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// newSettingsChangeEvent returns the redacted differences between two versions of the settings
+func newSettingsChangeEvent(previous, current *portainer.Settings) (settingsChangeEvent, error) {
+	event := settingsChangeEvent{Timestamp: time.Now().Unix(), Changes: []settingsFieldChange{}}
+
+	previousFields, err := settingsToMap(previous)
+	if err != nil {
+		return event, err
+	}
+
+	currentFields, err := settingsToMap(current)
+	if err != nil {
+		return event, err
+	}
+
+	// the revision changes with every update, it is not a change of the settings themselves
+	delete(previousFields, "Revision")
+	delete(currentFields, "Revision")
+
+	event.Changes = diffSettingsFields("", previousFields, currentFields, event.Changes)
+	sort.Slice(event.Changes, func(i, j int) bool {
+		return event.Changes[i].Field < event.Changes[j].Field
+	})
+
+	return event, nil
+}
+
+func settingsToMap(settings *portainer.Settings) (map[string]any, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	err = json.Unmarshal(data, &fields)
+
+	return fields, err
+}
+
+func diffSettingsFields(prefix string, previous, current map[string]any, changes []settingsFieldChange) []settingsFieldChange {
+	keys := map[string]struct{}{}
+	for key := range previous {
+		keys[key] = struct{}{}
+	}
+	for key := range current {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		oldValue, newValue := previous[key], current[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		oldMap, oldIsMap := oldValue.(map[string]any)
+		newMap, newIsMap := newValue.(map[string]any)
+		if oldIsMap && newIsMap {
+			changes = diffSettingsFields(path, oldMap, newMap, changes)
+			continue
+		}
+
+		if redactedSettingsFields[path] {
+			oldValue, newValue = redactedValue, redactedValue
+		}
+
+		changes = append(changes, settingsFieldChange{Field: path, Old: oldValue, New: newValue})
+	}
+
+	return changes
+}
+
+// changedFields returns the JSON paths of the changed fields
+func (event settingsChangeEvent) changedFields() []string {
+	fields := make([]string, 0, len(event.Changes))
+	for _, change := range event.Changes {
+		fields = append(fields, change.Field)
+	}
+
+	return fields
+}
+
+func (handler *Handler) publishSettingsChange(previous, current *portainer.Settings) {
+	event, err := newSettingsChangeEvent(previous, current)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to compute the settings changes")
+
+		return
+	}
+
+	if len(event.Changes) > 0 {
+		handler.events.publish(event)
+	}
+}
@@ -0,0 +1,51 @@
+package settings
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newSettingsChangeEvent(t *testing.T) {
+	is := assert.New(t)
+
+	previous := &portainer.Settings{LogoURL: "https://example.com/logo.png"}
+	previous.LDAPSettings.Password = "old-password"
+
+	current := *previous
+	current.LogoURL = ""
+	current.LDAPSettings.Password = "new-password"
+
+	event, err := newSettingsChangeEvent(previous, &current)
+	is.NoError(err)
+	is.Equal([]settingsFieldChange{
+		{Field: "LDAPSettings.Password", Old: redactedValue, New: redactedValue},
+		{Field: "LogoURL", Old: "https://example.com/logo.png", New: ""},
+	}, event.Changes)
+
+	event, err = newSettingsChangeEvent(previous, previous)
+	is.NoError(err)
+	is.Empty(event.Changes)
+}
+
+func Test_settingsEventBroker(t *testing.T) {
+	is := assert.New(t)
+
+	broker := newSettingsEventBroker(1)
+
+	ch, err := broker.subscribe()
+	is.NoError(err)
+
+	_, err = broker.subscribe()
+	is.ErrorIs(err, errTooManySubscribers)
+
+	broker.publish(settingsChangeEvent{Timestamp: 1})
+	is.Equal(int64(1), (<-ch).Timestamp)
+
+	broker.unsubscribe(ch)
+
+	_, err = broker.subscribe()
+	is.NoError(err, "a slot is released when a subscriber leaves")
+}
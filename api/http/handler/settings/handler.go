@@ -2,31 +2,53 @@ package settings
 
 import (
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
 )
 
 func hideFields(settings *portainer.Settings) {
 	settings.LDAPSettings.Password = ""
 	settings.OAuthSettings.ClientSecret = ""
 	settings.OAuthSettings.KubeSecretKey = nil
+	settings.JWTSigningKey = nil
 }
 
 // Handler is the HTTP handler used to handle settings operations.
 type Handler struct {
 	*mux.Router
+	CryptoService   portainer.CryptoService
 	DataStore       dataservices.DataStore
 	FileService     portainer.FileService
 	JWTService      dataservices.JWTService
 	LDAPService     portainer.LDAPService
+	OAuthService    portainer.OAuthService
 	SnapshotService portainer.SnapshotService
 	demoService     *demo.Service
+	events          *settingsEventBroker
+	// helmRepositories holds the Helm repository URLs validated recently
+	helmRepositories *cache.Cache
+	// helmRepositoryClient returns the client probing the Helm repositories
+	helmRepositoryClient func(timeout time.Duration) *http.Client
+	// webhookClient delivers the settings change notifications
+	webhookClient *http.Client
+
+	// EdgeEnforceHTTPS requires EdgePortainerURL to use https
+	EdgeEnforceHTTPS bool
+	// MaxUserSessionTimeout is the longest user session timeout that can be configured
+	MaxUserSessionTimeout time.Duration
+	// ValidationTimeout bounds each network call made to validate the settings, e.g. to the Helm repositories
+	ValidationTimeout time.Duration
+	// Overrides lists the settings replaced at startup by environment variables and CLI flags
+	Overrides []portainer.SettingsOverride
 }
 
 // NewHandler creates a handler to manage settings operations.
@@ -34,11 +56,53 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 	h := &Handler{
 		Router:      mux.NewRouter(),
 		demoService: demoService,
+		events:      newSettingsEventBroker(maxSettingsEventSubscribers),
+
+		MaxUserSessionTimeout: portainer.DefaultMaxUserSessionTimeout,
+		ValidationTimeout:     DefaultValidationTimeout,
+
+		helmRepositories:     cache.New(helmRepositoryValidationTTL, helmRepositoryValidationTTL),
+		helmRepositoryClient: client.NewGuardedHTTPClient,
+		webhookClient:        client.NewGuardedHTTPClient(settingsWebhookTimeout),
 	}
 	h.Handle("/settings",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsInspect))).Methods(http.MethodGet)
 	h.Handle("/settings",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsUpdate))).Methods(http.MethodPut)
+	h.Handle("/settings/durations/normalize",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsDurationsNormalize))).Methods(http.MethodPost)
+	h.Handle("/settings/edge/validate",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsEdgeValidate))).Methods(http.MethodPost)
+	h.Handle("/settings/effective",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsEffective))).Methods(http.MethodGet)
+	h.Handle("/settings/export",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsExport))).Methods(http.MethodGet)
+	h.Handle("/settings/import",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsImport))).Methods(http.MethodPost)
+	h.Handle("/settings/events",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsEvents))).Methods(http.MethodGet)
+	h.Handle("/settings/profiles/{name}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsProfileSave))).Methods(http.MethodPut)
+	h.Handle("/settings/profiles/{name}/preview",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsProfilePreview))).Methods(http.MethodGet)
+	h.Handle("/settings/profiles/{name}/apply",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsProfileApply))).Methods(http.MethodPost)
+	h.Handle("/settings/overrides",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsOverrides))).Methods(http.MethodGet)
+	h.Handle("/settings/logo",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsLogoUpload))).Methods(http.MethodPost)
+	h.Handle("/settings/logo",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsLogoDelete))).Methods(http.MethodDelete)
+	h.Handle("/settings/logo",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.settingsLogoInspect))).Methods(http.MethodGet)
+	h.Handle("/settings/ldap/check",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsLDAPCheck))).Methods(http.MethodPost)
+	h.Handle("/settings/oauth/check",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsOAuthCheck))).Methods(http.MethodPost)
+	h.Handle("/settings/oauth/rotate-secret",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsOAuthSecretRotate))).Methods(http.MethodPost)
+	h.Handle("/settings/security/assessment",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsSecurityAssessment))).Methods(http.MethodGet)
 	h.Handle("/settings/public",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.settingsPublic))).Methods(http.MethodGet)
 
@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// validateLogoURLImage issues a HEAD request against the logo URL and checks that
+// the returned content type is an image
+func validateLogoURLImage(ctx context.Context, client *http.Client, logoURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, logoURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "invalid logo URL")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to reach the logo URL")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the logo URL responded with status %d", resp.StatusCode)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return errors.Wrap(err, "unable to parse the content type of the logo URL")
+	}
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return fmt.Errorf("the logo URL serves %q instead of an image", mediaType)
+	}
+
+	return nil
+}
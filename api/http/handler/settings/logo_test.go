@@ -0,0 +1,33 @@
+package settings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateLogoURLImage(t *testing.T) {
+	is := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+		case "/logo.svg":
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		case "/index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	is.NoError(validateLogoURLImage(context.Background(), srv.Client(), srv.URL+"/logo.png"))
+	is.NoError(validateLogoURLImage(context.Background(), srv.Client(), srv.URL+"/logo.svg"))
+	is.Error(validateLogoURLImage(context.Background(), srv.Client(), srv.URL+"/index.html"))
+	is.Error(validateLogoURLImage(context.Background(), srv.Client(), srv.URL+"/missing.png"))
+}
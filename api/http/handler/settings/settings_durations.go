@@ -0,0 +1,122 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// settingsDuration references a duration field of the settings update payload
+type settingsDuration struct {
+	name  string
+	value *string
+	// message returned by Validate when the value cannot be parsed
+	invalidMessage string
+	// whether an empty value is accepted, usually to disable the feature
+	optional bool
+}
+
+// durations returns every duration field of the payload, new duration settings must be added here
+func (payload *settingsUpdatePayload) durations() []settingsDuration {
+	return []settingsDuration{
+		{name: "SnapshotInterval", value: payload.SnapshotInterval, invalidMessage: "Invalid snapshot interval"},
+		{name: "UserSessionTimeout", value: payload.UserSessionTimeout, invalidMessage: "Invalid user session timeout"},
+		{name: "KubeconfigExpiry", value: payload.KubeconfigExpiry, invalidMessage: "Invalid Kubeconfig Expiry"},
+		{name: "AuthenticationMethodChangeCooldown", value: payload.AuthenticationMethodChangeCooldown, invalidMessage: "Invalid authentication method change cooldown", optional: true},
+		{name: "PasswordChangeReauthenticationWindow", value: payload.PasswordChangeReauthenticationWindow, invalidMessage: "Invalid password change reauthentication window", optional: true},
+		{name: "LoginLockoutDuration", value: payload.LoginLockoutDuration, invalidMessage: "Invalid login lockout duration", optional: true},
+	}
+}
+
+// normalizeDuration returns the canonical form of a duration, e.g. "90s" becomes "1m30s"
+func normalizeDuration(value string) (string, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return "", err
+	}
+
+	return duration.String(), nil
+}
+
+type settingsDurationResult struct {
+	// Name of the settings field
+	Name string `json:"name" example:"UserSessionTimeout"`
+	// Value as provided in the payload
+	Value string `json:"value" example:"90m"`
+	// Canonical form of the value, empty when the value is invalid
+	Normalized string `json:"normalized,omitempty" example:"1h30m0s"`
+	// Whether the value is a valid duration
+	Valid bool `json:"valid" example:"true"`
+	// Parsing error, empty when the value is valid
+	Error string `json:"error,omitempty"`
+}
+
+type settingsDurationsNormalizeResponse struct {
+	// Whether every provided duration is valid
+	Valid bool `json:"valid" example:"true"`
+	// Result for each duration field provided in the payload
+	Durations []settingsDurationResult `json:"durations"`
+}
+
+// @id SettingsDurationsNormalize
+// @summary Validate and normalize the duration settings
+// @description Validate every duration field of a settings payload and return their canonical form.
+// @description Fields that are not duration settings are ignored and nothing is persisted.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body settingsUpdatePayload true "Settings"
+// @success 200 {object} settingsDurationsNormalizeResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /settings/durations/normalize [post]
+func (handler *Handler) settingsDurationsNormalize(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	// the payload is not validated, reporting the invalid durations is the purpose of this endpoint
+	var payload settingsUpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", errors.WithMessage(err, "unable to decode the settings"))
+	}
+
+	return response.JSON(w, normalizeDurations(payload.durations()))
+}
+
+func normalizeDurations(durations []settingsDuration) settingsDurationsNormalizeResponse {
+	resp := settingsDurationsNormalizeResponse{
+		Valid:     true,
+		Durations: []settingsDurationResult{},
+	}
+
+	for _, d := range durations {
+		if d.value == nil {
+			continue
+		}
+
+		result := settingsDurationResult{Name: d.name, Value: *d.value, Valid: true}
+		if d.optional && *d.value == "" {
+			resp.Durations = append(resp.Durations, result)
+			continue
+		}
+
+		normalized, err := normalizeDuration(*d.value)
+		if err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+			resp.Valid = false
+		} else {
+			result.Normalized = normalized
+		}
+
+		resp.Durations = append(resp.Durations, result)
+	}
+
+	return resp
+}
@@ -0,0 +1,38 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_normalizeDurations(t *testing.T) {
+	is := assert.New(t)
+
+	snapshotInterval := "90s"
+	sessionTimeout := "8h"
+	kubeconfigExpiry := "one day"
+
+	payload := settingsUpdatePayload{
+		SnapshotInterval:   &snapshotInterval,
+		UserSessionTimeout: &sessionTimeout,
+	}
+
+	resp := normalizeDurations(payload.durations())
+	is.True(resp.Valid)
+	is.Len(resp.Durations, 2)
+	is.Equal("1m30s", resp.Durations[0].Normalized)
+	is.Equal("8h0m0s", resp.Durations[1].Normalized)
+
+	payload.KubeconfigExpiry = &kubeconfigExpiry
+
+	resp = normalizeDurations(payload.durations())
+	is.False(resp.Valid)
+	is.Len(resp.Durations, 3)
+	is.Equal("KubeconfigExpiry", resp.Durations[2].Name)
+	is.False(resp.Durations[2].Valid)
+	is.Empty(resp.Durations[2].Normalized)
+	is.NotEmpty(resp.Durations[2].Error)
+
+	is.EqualError(payload.Validate(nil), "Invalid Kubeconfig Expiry")
+}
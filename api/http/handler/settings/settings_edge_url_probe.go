@@ -0,0 +1,41 @@
+package settings
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// probeEdgePortainerURL checks that the Portainer API answers at the URL the edge agents use.
+// The certificate is not verified, the probe is about reachability and the agents can be configured to trust it
+func probeEdgePortainerURL(ctx context.Context, httpClient *http.Client, portainerURL string) error {
+	if !strings.Contains(portainerURL, "://") {
+		portainerURL = "https://" + portainerURL
+	}
+
+	if transport, ok := httpClient.Transport.(*http.Transport); ok {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(portainerURL, "/")+"/api/status", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the status endpoint returned %s", resp.Status)
+	}
+
+	return nil
+}
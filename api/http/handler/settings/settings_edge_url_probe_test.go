@@ -0,0 +1,28 @@
+package settings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_probeEdgePortainerURL(t *testing.T) {
+	is := assert.New(t)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/status" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	is.NoError(probeEdgePortainerURL(context.Background(), &http.Client{Transport: &http.Transport{}}, srv.URL+"/"), "the self-signed certificate is accepted")
+	is.NoError(probeEdgePortainerURL(context.Background(), &http.Client{Transport: &http.Transport{}}, srv.Listener.Addr().String()), "https is used when the scheme is omitted")
+	is.Error(probeEdgePortainerURL(context.Background(), &http.Client{Transport: &http.Transport{}}, srv.URL+"/portainer"))
+
+	srv.Close()
+	is.Error(probeEdgePortainerURL(context.Background(), &http.Client{Transport: &http.Transport{}}, srv.URL))
+}
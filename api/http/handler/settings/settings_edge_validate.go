@@ -0,0 +1,175 @@
+package settings
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// MinEdgeAgentCheckinInterval is the lowest accepted edge agent check-in interval (in seconds)
+	MinEdgeAgentCheckinInterval = 1
+	// MaxEdgeAgentCheckinInterval is the highest accepted edge agent check-in interval (in seconds)
+	MaxEdgeAgentCheckinInterval = 3600
+	// MaxEdgeCheckinsPerSecond is the check-in rate of the edge agents above which the tunnel server may be overloaded
+	MaxEdgeCheckinsPerSecond = 100
+)
+
+type edgeSettingsValidatePayload struct {
+	// TrustOnFirstConnect makes Portainer accepting edge agent connection by default
+	TrustOnFirstConnect *bool `example:"false"`
+	// EnforceEdgeID makes Portainer store the Edge ID instead of accepting anyone
+	EnforceEdgeID *bool `example:"false"`
+	// EdgePortainerURL is the URL that is exposed to edge agents
+	EdgePortainerURL *string `json:"EdgePortainerURL"`
+	// The default check in interval for edge agent (in seconds)
+	EdgeAgentCheckinInterval *int `example:"5"`
+}
+
+func (payload *edgeSettingsValidatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+type edgeSettingsValidateResponse struct {
+	// Whether the edge configuration can be applied
+	Valid bool `json:"valid" example:"true"`
+	// Errors that prevent the edge configuration from being applied
+	Errors []string `json:"errors"`
+	// Risky but valid combinations
+	Warnings []string `json:"warnings"`
+}
+
+// @id SettingsEdgeValidate
+// @summary Validate an edge configuration
+// @description Validate the edge settings as a whole before applying them. Fields that are not provided are taken from the current settings.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body edgeSettingsValidatePayload true "Edge settings"
+// @success 200 {object} edgeSettingsValidateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /settings/edge/validate [post]
+func (handler *Handler) settingsEdgeValidate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload edgeSettingsValidatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if payload.TrustOnFirstConnect != nil {
+		settings.TrustOnFirstConnect = *payload.TrustOnFirstConnect
+	}
+
+	if payload.EnforceEdgeID != nil {
+		settings.EnforceEdgeID = *payload.EnforceEdgeID
+	}
+
+	if payload.EdgePortainerURL != nil {
+		settings.EdgePortainerURL = *payload.EdgePortainerURL
+	}
+
+	if payload.EdgeAgentCheckinInterval != nil {
+		settings.EdgeAgentCheckinInterval = *payload.EdgeAgentCheckinInterval
+	}
+
+	return response.JSON(w, validateEdgeSettings(settings))
+}
+
+func validateEdgeSettings(settings *portainer.Settings) *edgeSettingsValidateResponse {
+	resp := &edgeSettingsValidateResponse{
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+
+	host := ""
+	if settings.EdgePortainerURL != "" {
+		var err error
+		host, err = edge.ParseHostForEdge(settings.EdgePortainerURL)
+		if err != nil {
+			resp.Errors = append(resp.Errors, fmt.Sprintf("Invalid EdgePortainerURL: %s", err))
+		}
+	}
+
+	if settings.EdgeAgentCheckinInterval < MinEdgeAgentCheckinInterval || settings.EdgeAgentCheckinInterval > MaxEdgeAgentCheckinInterval {
+		resp.Errors = append(resp.Errors, fmt.Sprintf("EdgeAgentCheckinInterval must be between %d and %d seconds", MinEdgeAgentCheckinInterval, MaxEdgeAgentCheckinInterval))
+	}
+
+	if settings.TrustOnFirstConnect {
+		if !settings.EnforceEdgeID {
+			resp.Warnings = append(resp.Warnings, "TrustOnFirstConnect without EnforceEdgeID lets any agent that knows an edge key connect, and the edge ID of an environment can be taken over")
+		}
+
+		if host != "" && isPublicHost(host) {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("TrustOnFirstConnect is enabled while EdgePortainerURL (%s) looks publicly reachable: any agent reaching it will be trusted automatically", host))
+		}
+	}
+
+	resp.Valid = len(resp.Errors) == 0
+
+	return resp
+}
+
+// edgeCheckinLoadWarning warns when the edge agents using the default check-in interval would check in more often
+// than the tunnel server can comfortably handle. The async agents and the environments with their own interval are
+// not counted
+func (handler *Handler) edgeCheckinLoadWarning(checkinInterval int) (string, error) {
+	if checkinInterval <= 0 {
+		return "", nil
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return "", err
+	}
+
+	agents := 0
+	for i := range endpoints {
+		if endpointutils.IsEdgeEndpoint(&endpoints[i]) && !endpoints[i].Edge.AsyncMode && endpoints[i].EdgeCheckinInterval == 0 {
+			agents++
+		}
+	}
+
+	if agents/checkinInterval <= MaxEdgeCheckinsPerSecond {
+		return "", nil
+	}
+
+	return fmt.Sprintf("With %d edge agents checking in every %d seconds, the tunnel server receives about %d check-ins per second and may be overloaded. Consider an interval of at least %d seconds",
+		agents, checkinInterval, agents/checkinInterval, (agents+MaxEdgeCheckinsPerSecond-1)/MaxEdgeCheckinsPerSecond), nil
+}
+
+// isPublicHost makes a best effort guess, without any network call, on whether a host is reachable from the internet
+func isPublicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.Contains(host, ".") {
+		return false
+	}
+
+	for _, suffix := range []string{".local", ".lan", ".internal", ".localdomain", ".home.arpa", ".corp"} {
+		if strings.HasSuffix(host, suffix) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateEdgeSettings(t *testing.T) {
+	is := assert.New(t)
+
+	resp := validateEdgeSettings(&portainer.Settings{
+		EdgePortainerURL:         "https://portainer.internal:9443",
+		EdgeAgentCheckinInterval: 5,
+		EnforceEdgeID:            true,
+	})
+	is.True(resp.Valid)
+	is.Empty(resp.Warnings)
+
+	resp = validateEdgeSettings(&portainer.Settings{
+		EdgePortainerURL:         "https://localhost:9443",
+		EdgeAgentCheckinInterval: 0,
+	})
+	is.False(resp.Valid)
+	is.Len(resp.Errors, 2)
+
+	resp = validateEdgeSettings(&portainer.Settings{
+		EdgePortainerURL:         "https://portainer.example.com",
+		EdgeAgentCheckinInterval: 5,
+		TrustOnFirstConnect:      true,
+	})
+	is.True(resp.Valid)
+	is.Len(resp.Warnings, 2)
+
+	resp = validateEdgeSettings(&portainer.Settings{
+		EdgePortainerURL:         "https://10.0.0.5:9443",
+		EdgeAgentCheckinInterval: 5,
+		TrustOnFirstConnect:      true,
+		EnforceEdgeID:            true,
+	})
+	is.True(resp.Valid)
+	is.Empty(resp.Warnings)
+}
+
+func Test_edgeCheckinLoadWarning(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	for i := 1; i <= 2*MaxEdgeCheckinsPerSecond+1; i++ {
+		is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: portainer.EndpointID(i), Type: portainer.EdgeAgentOnDockerEnvironment}))
+	}
+
+	// neither the async agents nor the environments with their own interval use the default interval
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1000, Type: portainer.EdgeAgentOnDockerEnvironment, Edge: portainer.EnvironmentEdgeSettings{AsyncMode: true}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1001, Type: portainer.EdgeAgentOnKubernetesEnvironment, EdgeCheckinInterval: 60}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1002, Type: portainer.DockerEnvironment}))
+
+	h := &Handler{DataStore: store}
+
+	warning, err := h.edgeCheckinLoadWarning(1)
+	is.NoError(err)
+	is.Contains(warning, "201 edge agents")
+	is.Contains(warning, "at least 3 seconds")
+
+	warning, err = h.edgeCheckinLoadWarning(3)
+	is.NoError(err)
+	is.Empty(warning)
+}
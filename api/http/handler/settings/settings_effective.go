@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	ldapservice "github.com/portainer/portainer/api/ldap"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type settingsEffectiveResponse struct {
+	*portainer.Settings
+	// JSON paths of the settings whose stored value is empty and replaced by a default
+	Defaulted []string `json:"Defaulted" example:"SnapshotInterval"`
+	// Unix timestamp of the next scheduled snapshot, after the quiet hours when it falls in them
+	NextSnapshotTime int64 `json:"NextSnapshotTime,omitempty" example:"1587399600"`
+}
+
+// @id SettingsEffective
+// @summary Retrieve the effective settings
+// @description Retrieve the settings with the defaults applied at runtime, the values the server uses.
+// @description A stored value always takes precedence, a default only replaces an empty one. The settings overridden
+// @description at startup by an environment variable or a CLI flag are stored, their stored value is the effective one.
+// @description The defaulted settings are:
+// @description - HelmRepositoryURL, to the Bitnami charts repository
+// @description - HelmRepositoryType, to the type of the Helm repository URL
+// @description - SnapshotInterval, to 5m
+// @description - SnapshotWorkerCount, to 1 when lower
+// @description - TemplatesURL, to the official templates
+// @description - UserSessionTimeout, to 8h
+// @description - LoginLockoutDuration, to 15m when the lockout is enabled
+// @description - LDAPSettings.PoolIdleTimeout, to 5m when the pool is enabled
+// @description - LDAPSettings.TLSMinVersion, to 1.2 when TLS or StartTLS is enabled
+// @description - SnapshotQuietHours.Timezone, to UTC when the quiet hours are enabled
+// @description The response also tells when the next scheduled snapshot runs, once the snapshots are started.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} settingsEffectiveResponse "Success"
+// @failure 500 "Server error"
+// @router /settings/effective [get]
+func (handler *Handler) settingsEffective(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	defaulted := applySettingsDefaults(settings)
+
+	var nextSnapshotTime int64
+	if next := handler.SnapshotService.NextSnapshotTime(); !next.IsZero() {
+		nextSnapshotTime = next.Unix()
+	}
+
+	hideFields(settings)
+	return response.JSON(w, settingsEffectiveResponse{Settings: settings, Defaulted: defaulted, NextSnapshotTime: nextSnapshotTime})
+}
+
+// applySettingsDefaults replaces the empty settings by the defaults used at runtime and returns their JSON paths
+func applySettingsDefaults(settings *portainer.Settings) []string {
+	defaulted := []string{}
+
+	setDefault := func(field string, value *string, defaultValue string) {
+		if *value == "" {
+			*value = defaultValue
+			defaulted = append(defaulted, field)
+		}
+	}
+
+	setDefault("HelmRepositoryURL", &settings.HelmRepositoryURL, portainer.DefaultHelmRepositoryURL)
+
+	if settings.HelmRepositoryType == "" {
+		settings.HelmRepositoryType = helmRepositoryType(settings.HelmRepositoryURL)
+		defaulted = append(defaulted, "HelmRepositoryType")
+	}
+
+	setDefault("SnapshotInterval", &settings.SnapshotInterval, portainer.DefaultSnapshotInterval)
+
+	// the environments are snapshotted one at a time when the count is lower than 1
+	if settings.SnapshotWorkerCount < 1 {
+		settings.SnapshotWorkerCount = 1
+		defaulted = append(defaulted, "SnapshotWorkerCount")
+	}
+
+	setDefault("TemplatesURL", &settings.TemplatesURL, portainer.DefaultTemplatesURL)
+	setDefault("UserSessionTimeout", &settings.UserSessionTimeout, portainer.DefaultUserSessionTimeout)
+
+	if settings.MaxLoginAttempts > 0 {
+		setDefault("LoginLockoutDuration", &settings.LoginLockoutDuration, portainer.DefaultLoginLockoutDuration.String())
+	}
+
+	if settings.LDAPSettings.PoolSize > 0 {
+		setDefault("LDAPSettings.PoolIdleTimeout", &settings.LDAPSettings.PoolIdleTimeout, ldapservice.DefaultPoolIdleTimeout.String())
+	}
+
+	if settings.LDAPSettings.TLSConfig.TLS || settings.LDAPSettings.StartTLS {
+		setDefault("LDAPSettings.TLSMinVersion", &settings.LDAPSettings.TLSMinVersion, crypto.DefaultTLSMinVersion)
+	}
+
+	if settings.SnapshotQuietHours.Start != "" {
+		setDefault("SnapshotQuietHours.Timezone", &settings.SnapshotQuietHours.Timezone, "UTC")
+	}
+
+	return defaulted
+}
@@ -0,0 +1,71 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nextSnapshotServiceStub struct {
+	portainer.SnapshotService
+	next time.Time
+}
+
+func (service *nextSnapshotServiceStub) NextSnapshotTime() time.Time {
+	return service.next
+}
+
+func Test_settingsEffective(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.HelmRepositoryURL = ""
+	settings.HelmRepositoryType = ""
+	settings.TemplatesURL = ""
+	settings.SnapshotInterval = "10m"
+	settings.MaxLoginAttempts = 5
+	settings.LoginLockoutDuration = ""
+	settings.LDAPSettings.Password = "ldap-password"
+	settings.LDAPSettings.StartTLS = true
+	settings.SnapshotQuietHours = portainer.SnapshotQuietHours{Start: "22:00", End: "06:00"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.SnapshotService = &nextSnapshotServiceStub{next: time.Unix(1587399600, 0)}
+
+	rr := httptest.NewRecorder()
+	is.Nil(h.settingsEffective(rr, httptest.NewRequest(http.MethodGet, "/settings/effective", nil)))
+
+	var effective settingsEffectiveResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&effective))
+	is.Equal(portainer.DefaultHelmRepositoryURL, effective.HelmRepositoryURL)
+	is.Equal(portainer.HelmRepositoryTypeClassic, effective.HelmRepositoryType)
+	is.Equal(portainer.DefaultTemplatesURL, effective.TemplatesURL)
+	is.Equal("10m", effective.SnapshotInterval, "a stored value takes precedence over the default")
+	is.Equal("15m0s", effective.LoginLockoutDuration)
+	is.Empty(effective.LDAPSettings.PoolIdleTimeout, "the pool is disabled")
+	is.Empty(effective.LDAPSettings.Password)
+	is.Equal("1.2", effective.LDAPSettings.TLSMinVersion)
+	is.Equal("UTC", effective.SnapshotQuietHours.Timezone)
+	is.Equal(int64(1587399600), effective.NextSnapshotTime)
+	is.Contains(effective.Defaulted, "HelmRepositoryURL")
+	is.Contains(effective.Defaulted, "LoginLockoutDuration")
+	is.NotContains(effective.Defaulted, "SnapshotInterval")
+
+	stored, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Empty(stored.TemplatesURL, "the defaults are not stored")
+}
@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+const settingsEventsKeepAliveInterval = 30 * time.Second
+
+// @id SettingsEvents
+// @summary Stream the settings changes
+// @description Stream the changes applied to the settings as server-sent events. Secret values are redacted.
+// @description The number of concurrent subscribers is limited.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce text/event-stream
+// @success 200 {object} settingsChangeEvent "Stream of settings events"
+// @failure 429 "Too many subscribers"
+// @failure 500 "Server error"
+// @router /settings/events [get]
+func (handler *Handler) settingsEvents(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return httperror.InternalServerError("Streaming is not supported", errors.New("the response writer does not support flushing"))
+	}
+
+	events, err := handler.events.subscribe()
+	if err != nil {
+		return httperror.NewError(http.StatusTooManyRequests, "Unable to subscribe to the settings events", err)
+	}
+	defer handler.events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(settingsEventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
+
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: settings\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// settingsExportVersion is the version of the format of the exported settings
+const settingsExportVersion = 1
+
+var errSettingsImportSecrets = errors.New("the imported settings contain secrets")
+
+// renamedSettingsFields maps the JSON names of the settings to the names of the update payload when they differ
+var renamedSettingsFields = map[string]string{
+	"EdgePortainerUrl": "EdgePortainerURL",
+}
+
+type settingsExportDocument struct {
+	// Version of the format of the document
+	Version int `json:"version" example:"1"`
+	// Unix timestamp of the export
+	ExportedAt int64 `json:"exportedAt" example:"1587399600"`
+	// Settings that can be updated, the secrets are omitted
+	Settings map[string]any `json:"settings"`
+}
+
+type settingsImportPayload settingsExportDocument
+
+func (payload *settingsImportPayload) Validate(r *http.Request) error {
+	if payload.Version != settingsExportVersion {
+		return fmt.Errorf("Invalid version. Must be %d", settingsExportVersion)
+	}
+
+	if payload.Settings == nil {
+		return errors.New("Invalid settings. Must not be empty")
+	}
+
+	return nil
+}
+
+// @id SettingsExport
+// @summary Export the settings
+// @description Export the settings that can be updated as a document to import in another instance.
+// @description The secrets are omitted, the imported instance keeps its own.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} settingsExportDocument "Success"
+// @failure 500 "Server error"
+// @router /settings/export [get]
+func (handler *Handler) settingsExport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	fields, err := settingsToMap(settings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to export the settings", err)
+	}
+
+	for name, payloadName := range renamedSettingsFields {
+		if value, ok := fields[name]; ok {
+			fields[payloadName] = value
+			delete(fields, name)
+		}
+	}
+
+	updatable := settingsUpdatePayloadFields()
+	for key := range fields {
+		if !slices.Contains(updatable, key) {
+			delete(fields, key)
+		}
+	}
+
+	for path := range redactedSettingsFields {
+		deleteSettingsField(fields, path)
+	}
+
+	// the worker count is 0 until it is set, an update only accepts a count of at least 1
+	if settings.SnapshotWorkerCount == 0 {
+		delete(fields, "SnapshotWorkerCount")
+	}
+
+	return response.JSON(w, settingsExportDocument{Version: settingsExportVersion, ExportedAt: time.Now().Unix(), Settings: fields})
+}
+
+// @id SettingsImport
+// @summary Import the settings
+// @description Apply an exported settings document, with the same validation as an update of the settings.
+// @description The document is rejected when it contains secrets, unless allowSecrets is set.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body settingsImportPayload true "Exported settings"
+// @param allowSecrets query boolean false "Import the secrets of the document"
+// @param dryRun query boolean false "Validate the document and return the changes without saving them"
+// @success 200 {object} settingsUpdateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 412 "The settings were updated since they were read"
+// @failure 504 "A logo URL or Helm repository to validate did not answer in time"
+// @failure 500 "Server error"
+// @router /settings/import [post]
+func (handler *Handler) settingsImport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	allowSecrets, _ := request.RetrieveBooleanQueryParameter(r, "allowSecrets", true)
+	dryRun, _ := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+
+	var document settingsImportPayload
+	err := request.DecodeAndValidateJSONPayload(r, &document)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if secrets := secretSettingsFields(document.Settings); len(secrets) > 0 && !allowSecrets {
+		return httperror.BadRequest("The settings contain secrets: "+strings.Join(secrets, ", ")+". Remove them or allow their import", errSettingsImportSecrets)
+	}
+
+	payload, handlerErr := handler.settingsDocumentPayload(r, document.Settings, dryRun)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	return handler.applySettingsUpdate(w, payload, false, false)
+}
+
+// settingsDocumentPayload returns the validated update payload of the settings of an exported document
+func (handler *Handler) settingsDocumentPayload(r *http.Request, fields map[string]any, dryRun bool) (settingsUpdatePayload, *httperror.HandlerError) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return settingsUpdatePayload{}, httperror.BadRequest("Invalid request payload", err)
+	}
+
+	payload := settingsUpdatePayload{edgeEnforceHTTPS: handler.EdgeEnforceHTTPS, maxUserSessionTimeout: handler.MaxUserSessionTimeout, dryRun: dryRun, ctx: r.Context()}
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return settingsUpdatePayload{}, httperror.BadRequest("Invalid settings", err)
+	}
+
+	err = payload.Validate(r)
+	if err != nil {
+		return settingsUpdatePayload{}, httperror.BadRequest("Invalid settings", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return settingsUpdatePayload{}, httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+	payload.userID = tokenData.ID
+	payload.ifMatch = r.Header.Get("If-Match")
+
+	return payload, nil
+}
+
+// settingsUpdatePayloadFields returns the JSON names of the settings that can be updated
+func settingsUpdatePayloadFields() []string {
+	var fields []string
+
+	payloadType := reflect.TypeOf(settingsUpdatePayload{})
+	for i := 0; i < payloadType.NumField(); i++ {
+		field := payloadType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, name)
+	}
+
+	return fields
+}
+
+// secretSettingsFields returns the JSON paths of the secrets set in the settings
+func secretSettingsFields(fields map[string]any) []string {
+	var secrets []string
+	for path := range redactedSettingsFields {
+		if value, ok := lookupSettingsField(fields, path); ok && value != nil && value != "" {
+			secrets = append(secrets, path)
+		}
+	}
+
+	slices.Sort(secrets)
+
+	return secrets
+}
+
+func lookupSettingsField(fields map[string]any, path string) (any, bool) {
+	parent, key, ok := strings.Cut(path, ".")
+	if !ok {
+		value, ok := fields[path]
+		return value, ok
+	}
+
+	nested, ok := fields[parent].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return lookupSettingsField(nested, key)
+}
+
+func deleteSettingsField(fields map[string]any, path string) {
+	parent, key, ok := strings.Cut(path, ".")
+	if !ok {
+		delete(fields, path)
+		return
+	}
+
+	if nested, ok := fields[parent].(map[string]any); ok {
+		deleteSettingsField(nested, key)
+	}
+}
@@ -0,0 +1,98 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_settingsExportImport(t *testing.T) {
+	is := assert.New(t)
+
+	_, source := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := source.Settings().Settings()
+	is.NoError(err)
+	settings.TemplatesURL = "https://templates.example.com/templates.json"
+	settings.DisabledFeatures = []portainer.UIFeature{portainer.UIFeatureHelm}
+	settings.LDAPSettings.Password = "ldap-password"
+	settings.OAuthSettings.ClientSecret = "oauth-secret"
+	settings.SettingsChangeWebhookURL = "https://hooks.example.com/token"
+	settings.LDAPCAExpiryWebhookURL = "https://hooks.example.com/ldap-token"
+	settings.EdgePortainerURL = "https://portainer.example.com"
+	is.NoError(source.Settings().UpdateSettings(settings))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = source
+
+	rr := httptest.NewRecorder()
+	is.Nil(h.settingsExport(rr, httptest.NewRequest(http.MethodGet, "/settings/export", nil)))
+
+	var document settingsExportDocument
+	is.NoError(json.NewDecoder(rr.Body).Decode(&document))
+	is.Equal(settingsExportVersion, document.Version)
+	is.Equal("https://templates.example.com/templates.json", document.Settings["TemplatesURL"])
+	is.Empty(secretSettingsFields(document.Settings), "the secrets are omitted")
+	is.NotContains(document.Settings, "LDAPCAExpiryWebhookURL")
+	is.Equal("https://portainer.example.com", document.Settings["EdgePortainerURL"], "the fields are exported with their payload name")
+	is.NotContains(document.Settings, "Revision", "only the settings that can be updated are exported")
+	is.NotContains(document.Settings, "JWTSigningKey")
+
+	_, target := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h = NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = target
+	h.FileService = fileService
+	h.JWTService = &sessionDurationJWTServiceStub{sessionDuration: 8 * time.Hour}
+
+	importSettings := func(document settingsExportDocument, query string) *httperror.HandlerError {
+		body, err := json.Marshal(document)
+		is.NoError(err)
+
+		return h.settingsImport(httptest.NewRecorder(), newSettingsUpdateRequest("/settings/import"+query, body))
+	}
+
+	is.Nil(importSettings(document, ""))
+
+	settings, err = target.Settings().Settings()
+	is.NoError(err)
+	is.Equal("https://templates.example.com/templates.json", settings.TemplatesURL)
+	is.Equal([]portainer.UIFeature{portainer.UIFeatureHelm}, settings.DisabledFeatures)
+	is.Equal("https://portainer.example.com", settings.EdgePortainerURL)
+
+	document.Settings["OAuthSettings"].(map[string]any)["ClientSecret"] = "imported-secret"
+	handlerErr := importSettings(document, "")
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode, "the secrets are not imported in cleartext by default")
+	is.ErrorIs(handlerErr.Err, errSettingsImportSecrets)
+
+	is.Nil(importSettings(document, "?allowSecrets=true"))
+
+	settings, err = target.Settings().Settings()
+	is.NoError(err)
+	is.Equal("imported-secret", settings.OAuthSettings.ClientSecret)
+
+	document.Settings["SnapshotWorkerCount"] = 0
+	handlerErr = importSettings(document, "?allowSecrets=true")
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode, "the settings are validated like an update")
+
+	document.Version = settingsExportVersion + 1
+	handlerErr = importSettings(document, "")
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode)
+}
@@ -3,19 +3,32 @@ package settings
 import (
 	"net/http"
 
+	portainer "github.com/portainer/portainer/api"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
+type settingsInspectResponse struct {
+	*portainer.Settings
+	// CA certificate used to verify the LDAP server, only when TLS or StartTLS is enabled without skipping the verification
+	LDAPCACertificate *ldapCACertificate `json:"LDAPCACertificate,omitempty"`
+	// Utilization of the pool of LDAP connections, only when the pool is enabled
+	LDAPPool *portainer.LDAPPoolStats `json:"LDAPPool,omitempty"`
+}
+
 // @id SettingsInspect
 // @summary Retrieve Portainer settings
 // @description Retrieve Portainer settings.
+// @description The response describes the CA certificate used to verify the LDAP server when TLS or StartTLS is enabled.
+// @description It also describes the utilization of the pool of LDAP connections when the pool is enabled.
+// @description The settings are returned as YAML when the Accept header asks for application/yaml, with the same fields as the JSON.
 // @description **Access policy**: administrator
 // @tags settings
 // @security ApiKeyAuth
 // @security jwt
-// @produce json
-// @success 200 {object} portainer.Settings "Success"
+// @produce json,application/yaml
+// @success 200 {object} settingsInspectResponse "Success"
+// @header 200 {string} ETag "ETag of the settings, to send in the If-Match header of an update"
 // @failure 500 "Server error"
 // @router /settings [get]
 func (handler *Handler) settingsInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -24,6 +37,21 @@ func (handler *Handler) settingsInspect(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
 	}
 
+	caCertificate := handler.ldapCACertificate(settings)
+
+	var ldapPool *portainer.LDAPPoolStats
+	if settings.LDAPSettings.PoolSize > 0 {
+		stats := handler.LDAPService.PoolStats()
+		ldapPool = &stats
+	}
+
 	hideFields(settings)
-	return response.JSON(w, settings)
+	writeSettingsETag(w, settings)
+
+	inspectResponse := settingsInspectResponse{Settings: settings, LDAPCACertificate: caCertificate, LDAPPool: ldapPool}
+	if acceptsYAML(r) {
+		return writeYAML(w, inspectResponse)
+	}
+
+	return response.JSON(w, inspectResponse)
 }
@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var errNoLDAPCACertificate = errors.New("no certificate found in the CA file")
+
+// ldapCACertificate describes the CA certificate used to verify the LDAP server
+type ldapCACertificate struct {
+	// SHA-256 fingerprint of the certificate, null when the certificate cannot be read
+	Fingerprint *string `json:"Fingerprint" example:"3f2a6c1be0c0d9f1c7d1e4a7b0b1c3d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1"`
+	// Subject of the certificate
+	Subject string `json:"Subject,omitempty" example:"CN=ldap.mydomain.tld"`
+	// Unix timestamp of the expiry of the certificate
+	ExpiresAt int64 `json:"ExpiresAt,omitempty" example:"1735689600"`
+	// Why the certificate cannot be read
+	Error string `json:"Error,omitempty"`
+}
+
+// ldapCACertificate returns the CA certificate in use when the LDAP settings verify the certificate of the server,
+// the first certificate of the file when it holds a chain. A missing or invalid file is reported in Error
+func (handler *Handler) ldapCACertificate(settings *portainer.Settings) *ldapCACertificate {
+	ldapSettings := settings.LDAPSettings
+	if !(ldapSettings.TLSConfig.TLS || ldapSettings.StartTLS) ||
+		ldapSettings.TLSConfig.TLSSkipVerify ||
+		ldapSettings.TLSConfig.TLSCACertPath == "" {
+		return nil
+	}
+
+	data, err := handler.FileService.GetFileContent(ldapSettings.TLSConfig.TLSCACertPath, "")
+	if err != nil {
+		return &ldapCACertificate{Error: err.Error()}
+	}
+
+	cert, err := parseLDAPCACertificate(data)
+	if err != nil {
+		return &ldapCACertificate{Error: err.Error()}
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	return &ldapCACertificate{
+		Fingerprint: &fingerprint,
+		Subject:     cert.Subject.String(),
+		ExpiresAt:   cert.NotAfter.Unix(),
+	}
+}
+
+func parseLDAPCACertificate(data []byte) (*x509.Certificate, error) {
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	return nil, errNoLDAPCACertificate
+}
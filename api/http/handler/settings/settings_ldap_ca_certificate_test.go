@@ -0,0 +1,87 @@
+package settings
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ldapCACertificate(t *testing.T) {
+	is := assert.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	notAfter := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caCertPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caCertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	require.NoError(t, err)
+
+	h := &Handler{FileService: fileService}
+
+	newSettings := func(caCertPath string) *portainer.Settings {
+		settings := &portainer.Settings{}
+		settings.LDAPSettings.StartTLS = true
+		settings.LDAPSettings.TLSConfig.TLSCACertPath = caCertPath
+
+		return settings
+	}
+
+	t.Run("valid certificate", func(t *testing.T) {
+		sum := sha256.Sum256(der)
+
+		certificate := h.ldapCACertificate(newSettings(caCertPath))
+		is.NotNil(certificate.Fingerprint)
+		is.Equal(hex.EncodeToString(sum[:]), *certificate.Fingerprint)
+		is.Equal("CN=ldap-ca", certificate.Subject)
+		is.Equal(notAfter.Unix(), certificate.ExpiresAt)
+		is.Empty(certificate.Error)
+	})
+
+	t.Run("missing certificate", func(t *testing.T) {
+		certificate := h.ldapCACertificate(newSettings(filepath.Join(t.TempDir(), "missing.pem")))
+		is.NotEmpty(certificate.Error)
+
+		data, err := json.Marshal(certificate)
+		is.NoError(err)
+		is.Contains(string(data), `"Fingerprint":null`)
+	})
+
+	t.Run("skipped verification", func(t *testing.T) {
+		settings := newSettings(caCertPath)
+		settings.LDAPSettings.TLSConfig.TLSSkipVerify = true
+
+		is.Nil(h.ldapCACertificate(settings))
+	})
+}
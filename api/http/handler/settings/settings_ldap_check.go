@@ -0,0 +1,81 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/ldap"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type settingsLDAPCheckPayload struct {
+	// LDAP settings to check, the reader DN, password and group search settings default to the stored ones when omitted
+	LDAPSettings portainer.LDAPSettings
+}
+
+func (payload *settingsLDAPCheckPayload) Validate(r *http.Request) error {
+	if payload.LDAPSettings.URL == "" {
+		return errors.New("Invalid LDAP URL. Must not be empty")
+	}
+
+	return nil
+}
+
+type settingsLDAPCheckResponse struct {
+	// Number of users matched by the search settings
+	Users int `json:"users" example:"42"`
+}
+
+type settingsLDAPCheckFailure struct {
+	// Stage of the check that failed, one of connection, bind or search. TLS errors are reported at the connection stage
+	Stage   string `json:"stage" example:"bind"`
+	Message string `json:"message" example:"LDAP bind failed: LDAP Result Code 49 \"Invalid Credentials\""`
+}
+
+// @id SettingsLDAPCheck
+// @summary Check LDAP settings without saving them
+// @description Connect and bind to the LDAP server with the provided settings, then run the user search.
+// @description The reader password defaults to the stored one when blank. Nothing is persisted.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body settingsLDAPCheckPayload true "LDAP settings"
+// @success 200 {object} settingsLDAPCheckResponse "Success"
+// @failure 400 {object} settingsLDAPCheckFailure "The check failed"
+// @failure 500 "Server error"
+// @router /settings/ldap/check [post]
+func (handler *Handler) settingsLDAPCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload settingsLDAPCheckPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	ldapSettings := mergeLDAPSettings(settings.LDAPSettings, payload.LDAPSettings)
+	ldapSettings.TLSConfig.TLSCACertPath = handler.ldapCACertPath(&ldapSettings)
+
+	users, err := handler.LDAPService.CheckSettings(&ldapSettings)
+	if err != nil {
+		var checkErr *ldap.CheckError
+		if !errors.As(err, &checkErr) {
+			return httperror.InternalServerError("Unable to check the LDAP settings", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		return response.JSON(w, settingsLDAPCheckFailure{Stage: checkErr.Stage, Message: checkErr.Error()})
+	}
+
+	return response.JSON(w, settingsLDAPCheckResponse{Users: users})
+}
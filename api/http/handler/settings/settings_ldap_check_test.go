@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/ldap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ldapServiceStub struct {
+	portainer.LDAPService
+	checked *portainer.LDAPSettings
+	err     error
+}
+
+func (service *ldapServiceStub) CheckSettings(settings *portainer.LDAPSettings) (int, error) {
+	service.checked = settings
+	if service.err != nil {
+		return 0, service.err
+	}
+
+	return 3, nil
+}
+
+func Test_settingsLDAPCheck(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.LDAPSettings.ReaderDN = "cn=reader"
+	settings.LDAPSettings.Password = "stored"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	ldapService := &ldapServiceStub{}
+	h := &Handler{DataStore: store, LDAPService: ldapService}
+
+	check := func() *httptest.ResponseRecorder {
+		body, err := json.Marshal(settingsLDAPCheckPayload{LDAPSettings: portainer.LDAPSettings{URL: "ldap.local:389"}})
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		handlerErr := h.settingsLDAPCheck(rr, httptest.NewRequest(http.MethodPost, "/settings/ldap/check", bytes.NewReader(body)))
+		is.Nil(handlerErr)
+
+		return rr
+	}
+
+	rr := check()
+	is.Equal(http.StatusOK, rr.Code)
+	is.Equal("stored", ldapService.checked.Password, "a blank password falls back to the stored one")
+	is.Equal("cn=reader", ldapService.checked.ReaderDN)
+
+	var resp settingsLDAPCheckResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Equal(3, resp.Users)
+
+	ldapService.err = &ldap.CheckError{Stage: ldap.CheckStageBind, Err: errors.New("invalid credentials")}
+	rr = check()
+	is.Equal(http.StatusBadRequest, rr.Code)
+
+	var failure settingsLDAPCheckFailure
+	is.NoError(json.NewDecoder(rr.Body).Decode(&failure))
+	is.Equal(ldap.CheckStageBind, failure.Stage)
+
+	stored, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Empty(stored.LDAPSettings.URL, "nothing is persisted")
+}
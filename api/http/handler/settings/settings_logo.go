@@ -0,0 +1,196 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// uploadedLogoURL is the logo URL serving the uploaded logo, relative to the URL of Portainer
+	uploadedLogoURL = "api/settings/logo"
+	// maxLogoSize is the maximum size of an uploaded logo
+	maxLogoSize = 1 << 20
+)
+
+var errUnsupportedLogoType = errors.New("the logo must be a PNG, JPEG or SVG image")
+
+// @id SettingsLogoUpload
+// @summary Upload a logo
+// @description Upload a PNG, JPEG or SVG image of at most 1MB that is served by Portainer, the logo URL is set to the uploaded logo.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @produce json
+// @param file formData file true "Logo image"
+// @success 200 {object} portainer.Settings "Success"
+// @failure 400 "Invalid request or unsupported image"
+// @failure 403 "Unavailable in demo mode"
+// @failure 500 "Server error"
+// @router /settings/logo [post]
+func (handler *Handler) settingsLogoUpload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if handler.demoService.IsDemo() {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	// the multipart overhead is allowed on top of the logo itself
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogoSize+4096)
+
+	data, _, err := request.RetrieveMultiPartFormFile(r, "file")
+	if err != nil {
+		return httperror.BadRequest("Invalid logo file. Ensure that the file is uploaded correctly and that it does not exceed 1MB", err)
+	}
+
+	if len(data) > maxLogoSize {
+		return httperror.BadRequest("Invalid logo file", fmt.Errorf("the logo cannot exceed %d bytes", maxLogoSize))
+	}
+
+	if _, err := logoContentType(data); err != nil {
+		return httperror.BadRequest("Invalid logo file", err)
+	}
+
+	_, err = handler.FileService.StoreLogoFile(data)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the logo on disk", err)
+	}
+
+	settings, err := handler.setLogoURL(uploadedLogoURL)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the settings changes inside the database", err)
+	}
+
+	hideFields(settings)
+	return response.JSON(w, settings)
+}
+
+// @id SettingsLogoDelete
+// @summary Delete the uploaded logo
+// @description Remove the uploaded logo and reset the logo URL so that the default logo is displayed.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @success 204 "Success"
+// @failure 403 "Unavailable in demo mode"
+// @failure 500 "Server error"
+// @router /settings/logo [delete]
+func (handler *Handler) settingsLogoDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if handler.demoService.IsDemo() {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	err := handler.FileService.RemoveLogoFile()
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the logo from disk", err)
+	}
+
+	_, err = handler.setLogoURL("")
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the settings changes inside the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id SettingsLogoInspect
+// @summary Retrieve the uploaded logo
+// @description **Access policy**: public
+// @tags settings
+// @produce image/png,image/jpeg,image/svg+xml
+// @success 200 "Success"
+// @failure 404 "No logo was uploaded"
+// @router /settings/logo [get]
+func (handler *Handler) settingsLogoInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	data, err := handler.FileService.GetLogoFile()
+	if err != nil {
+		return httperror.NotFound("No logo was uploaded", err)
+	}
+
+	contentType, err := logoContentType(data)
+	if err != nil {
+		return httperror.InternalServerError("Invalid logo file", err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// an SVG logo opened directly must not run scripts in the origin of Portainer
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	_, err = w.Write(data)
+	if err != nil {
+		return httperror.InternalServerError("Unable to write the logo", err)
+	}
+
+	return nil
+}
+
+// setLogoURL persists the logo URL and returns the updated settings
+func (handler *Handler) setLogoURL(logoURL string) (*portainer.Settings, error) {
+	var settings *portainer.Settings
+
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		settings, err = tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		settings.LogoURL = logoURL
+
+		return tx.Settings().UpdateSettings(settings)
+	})
+
+	return settings, err
+}
+
+// logoContentType returns the content type of a PNG, JPEG or SVG logo
+func logoContentType(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/png", "image/jpeg":
+		return contentType, nil
+	}
+
+	if isSVG(data) {
+		return "image/svg+xml", nil
+	}
+
+	return "", errUnsupportedLogoType
+}
+
+// isSVG returns true when the root element of the document is an svg element
+func isSVG(data []byte) bool {
+	data = bytes.TrimSpace(data)
+
+	for {
+		switch {
+		case bytes.HasPrefix(data, []byte("<?")):
+			data = skipUntil(data, "?>")
+		case bytes.HasPrefix(data, []byte("<!--")):
+			data = skipUntil(data, "-->")
+		case bytes.HasPrefix(data, []byte("<!")):
+			data = skipUntil(data, ">")
+		default:
+			return bytes.HasPrefix(data, []byte("<svg"))
+		}
+	}
+}
+
+// skipUntil returns the data after the first occurrence of the delimiter with the leading spaces trimmed,
+// it returns nil when the delimiter is not found
+func skipUntil(data []byte, delimiter string) []byte {
+	i := bytes.Index(data, []byte(delimiter))
+	if i < 0 {
+		return nil
+	}
+
+	return bytes.TrimSpace(data[i+len(delimiter):])
+}
@@ -0,0 +1,101 @@
+package settings
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newLogoUploadRequest(t *testing.T, data []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", "logo")
+	assert.NoError(t, err)
+	_, err = part.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/settings/logo", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func Test_settingsLogo(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, newLogoUploadRequest(t, []byte("<html></html>")))
+	is.Equal(http.StatusBadRequest, rr.Code, "only images are accepted")
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, newLogoUploadRequest(t, append(pngHeader, make([]byte, maxLogoSize)...)))
+	is.Equal(http.StatusBadRequest, rr.Code, "the size of the logo is limited")
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, newLogoUploadRequest(t, pngHeader))
+	is.Equal(http.StatusOK, rr.Code)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(uploadedLogoURL, settings.LogoURL)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/settings/logo", nil))
+	is.Equal(http.StatusOK, rr.Code)
+	is.Equal("image/png", rr.Header().Get("Content-Type"))
+	is.Equal(pngHeader, rr.Body.Bytes())
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/settings/logo", nil))
+	is.Equal(http.StatusNoContent, rr.Code)
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Empty(settings.LogoURL)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/settings/logo", nil))
+	is.Equal(http.StatusNotFound, rr.Code)
+}
+
+func Test_logoContentType(t *testing.T) {
+	is := assert.New(t)
+
+	for data, expected := range map[string]string{
+		string(pngHeader):  "image/png",
+		"\xff\xd8\xff\xe0": "image/jpeg",
+		`<svg xmlns="http://www.w3.org/2000/svg"></svg>`:                      "image/svg+xml",
+		"<?xml version=\"1.0\"?>\n<!-- logo -->\n<!DOCTYPE svg>\n<svg></svg>": "image/svg+xml",
+	} {
+		contentType, err := logoContentType([]byte(data))
+		is.NoError(err, data)
+		is.Equal(expected, contentType)
+	}
+
+	for _, data := range []string{"GIF89a", "<html><svg></svg></html>", "<?xml version=\"1.0\"?>"} {
+		_, err := logoContentType([]byte(data))
+		is.ErrorIs(err, errUnsupportedLogoType, data)
+	}
+}
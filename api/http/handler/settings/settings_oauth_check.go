@@ -0,0 +1,87 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/portainer/portainer/api/oauth"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type settingsOAuthCheckPayload struct {
+	// OAuth settings to check, omitted fields and a blank client secret default to the stored ones
+	OAuthSettings oauthSettingsPayload
+}
+
+func (payload *settingsOAuthCheckPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+type settingsOAuthCheckFailure struct {
+	Message string `json:"message" example:"OAuth check failed for AccessTokenURI"`
+	// URLs that failed the check
+	URLs []oauth.URLCheckFailure `json:"urls"`
+}
+
+// @id SettingsOAuthCheck
+// @summary Check OAuth settings without saving them
+// @description Check that the authorization, access token and resource URLs are reachable and answer like OAuth endpoints.
+// @description A dummy authorization code is exchanged with the client credentials, so that rejected credentials are reported.
+// @description Omitted fields and a blank client secret default to the stored ones. Nothing is persisted.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body settingsOAuthCheckPayload true "OAuth settings"
+// @success 204 "Success"
+// @failure 400 {object} settingsOAuthCheckFailure "The check failed"
+// @failure 500 "Server error"
+// @router /settings/oauth/check [post]
+func (handler *Handler) settingsOAuthCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload settingsOAuthCheckPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	oauthSettings := mergeOAuthSettings(settings.OAuthSettings, payload.OAuthSettings)
+
+	var missing []string
+	for _, url := range []struct{ field, value string }{
+		{oauth.CheckURLAuthorization, oauthSettings.AuthorizationURI},
+		{oauth.CheckURLAccessToken, oauthSettings.AccessTokenURI},
+		{oauth.CheckURLResource, oauthSettings.ResourceURI},
+	} {
+		if url.value == "" {
+			missing = append(missing, url.field)
+		}
+	}
+
+	if len(missing) > 0 {
+		return httperror.BadRequest("The OAuth settings are incomplete, missing: "+strings.Join(missing, ", "), errIncompleteAuthenticationSettings)
+	}
+
+	err = handler.OAuthService.CheckSettings(&oauthSettings)
+	if err != nil {
+		var checkErr *oauth.CheckError
+		if !errors.As(err, &checkErr) {
+			return httperror.InternalServerError("Unable to check the OAuth settings", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		return response.JSON(w, settingsOAuthCheckFailure{Message: checkErr.Error(), URLs: checkErr.Failures})
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/oauth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type oauthServiceStub struct {
+	portainer.OAuthService
+	checked *portainer.OAuthSettings
+	err     error
+}
+
+func (service *oauthServiceStub) CheckSettings(settings *portainer.OAuthSettings) error {
+	service.checked = settings
+
+	return service.err
+}
+
+func Test_settingsOAuthCheck(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.OAuthSettings.ClientSecret = "stored"
+	settings.OAuthSettings.ResourceURI = "https://oauth.mydomain.tld/user"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	oauthService := &oauthServiceStub{}
+	h := &Handler{DataStore: store, OAuthService: oauthService}
+
+	check := func(payload oauthSettingsPayload) *httptest.ResponseRecorder {
+		body, err := json.Marshal(settingsOAuthCheckPayload{OAuthSettings: payload})
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		handlerErr := h.settingsOAuthCheck(rr, httptest.NewRequest(http.MethodPost, "/settings/oauth/check", bytes.NewReader(body)))
+		if handlerErr != nil {
+			rr.Code = handlerErr.StatusCode
+		}
+
+		return rr
+	}
+
+	authorizationURI, accessTokenURI, blank := "https://oauth.mydomain.tld/authorize", "https://oauth.mydomain.tld/token", ""
+
+	rr := check(oauthSettingsPayload{AuthorizationURI: &authorizationURI})
+	is.Equal(http.StatusBadRequest, rr.Code, "the access token URI is missing")
+	is.Nil(oauthService.checked)
+
+	rr = check(oauthSettingsPayload{AuthorizationURI: &authorizationURI, AccessTokenURI: &accessTokenURI, ClientSecret: &blank})
+	is.Equal(http.StatusNoContent, rr.Code)
+	is.Equal("stored", oauthService.checked.ClientSecret, "a blank client secret falls back to the stored one")
+	is.Equal("https://oauth.mydomain.tld/user", oauthService.checked.ResourceURI)
+
+	oauthService.err = &oauth.CheckError{Failures: []oauth.URLCheckFailure{{Field: oauth.CheckURLAccessToken, URL: accessTokenURI, Message: "unexpected status 404 Not Found"}}}
+	rr = check(oauthSettingsPayload{AuthorizationURI: &authorizationURI, AccessTokenURI: &accessTokenURI})
+	is.Equal(http.StatusBadRequest, rr.Code)
+
+	var failure settingsOAuthCheckFailure
+	is.NoError(json.NewDecoder(rr.Body).Decode(&failure))
+	is.Equal(oauthService.err.(*oauth.CheckError).Failures, failure.URLs)
+
+	stored, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Empty(stored.OAuthSettings.AccessTokenURI, "nothing is persisted")
+}
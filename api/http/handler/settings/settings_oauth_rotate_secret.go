@@ -0,0 +1,68 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type oauthSecretRotatePayload struct {
+	// New client secret of the OAuth application
+	ClientSecret string `json:"clientSecret" example:"my-new-client-secret" validate:"required"`
+}
+
+func (payload *oauthSecretRotatePayload) Validate(r *http.Request) error {
+	if payload.ClientSecret == "" {
+		return errors.New("Invalid client secret. Must not be empty")
+	}
+
+	return nil
+}
+
+// @id SettingsOAuthSecretRotate
+// @summary Rotate the OAuth client secret
+// @description Replace the client secret of the OAuth settings, the other settings are left untouched.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param body body oauthSecretRotatePayload true "New client secret"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /settings/oauth/rotate-secret [post]
+func (handler *Handler) settingsOAuthSecretRotate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload oauthSecretRotatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	var previousSettings, settings *portainer.Settings
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err = tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		previous := *settings
+		previousSettings = &previous
+
+		settings.OAuthSettings.ClientSecret = payload.ClientSecret
+
+		return tx.Settings().UpdateSettings(settings)
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the OAuth client secret inside the database", err)
+	}
+
+	handler.publishSettingsChange(previousSettings, settings)
+
+	return response.Empty(w)
+}
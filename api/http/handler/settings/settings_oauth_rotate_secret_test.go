@@ -0,0 +1,44 @@
+package settings
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_settingsOAuthSecretRotate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.OAuthSettings.ClientID = "client-id"
+	settings.OAuthSettings.ClientSecret = "old-secret"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+
+	rotateSecret := func(body string) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/settings/oauth/rotate-secret", bytes.NewBufferString(body)))
+
+		return rr.Code
+	}
+
+	is.Equal(http.StatusBadRequest, rotateSecret(`{"clientSecret": ""}`))
+	is.Equal(http.StatusNoContent, rotateSecret(`{"clientSecret": "new-secret", "clientID": "ignored"}`))
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal("new-secret", settings.OAuthSettings.ClientSecret)
+	is.Equal("client-id", settings.OAuthSettings.ClientID, "the other settings are left untouched")
+}
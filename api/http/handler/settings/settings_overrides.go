@@ -0,0 +1,30 @@
+package settings
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SettingsOverrides
+// @summary List the settings overridden at startup
+// @description List the settings whose stored values are replaced on each start by an environment variable or a CLI flag,
+// @description along with the source of the override and the effective value. Secrets are redacted.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.SettingsOverride "Success"
+// @failure 500 "Server error"
+// @router /settings/overrides [get]
+func (handler *Handler) settingsOverrides(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	overrides := handler.Overrides
+	if overrides == nil {
+		overrides = []portainer.SettingsOverride{}
+	}
+
+	return response.JSON(w, overrides)
+}
@@ -0,0 +1,161 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// settingsProfileNamePattern restricts the profile names to the characters that are safe in a file name
+var settingsProfileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+var errInvalidSettingsProfileName = errors.New("the profile name must contain between 1 and 64 letters, digits, hyphens or underscores")
+
+// settingsProfilePayload is a settings document in the export format, saved under a name to be applied later
+type settingsProfilePayload settingsImportPayload
+
+func (payload *settingsProfilePayload) Validate(r *http.Request) error {
+	return (*settingsImportPayload)(payload).Validate(r)
+}
+
+// @id SettingsProfileSave
+// @summary Save a settings profile
+// @description Save a settings document, in the format of the settings export, under a name to preview and apply it later.
+// @description The document is validated like an update of the settings, it replaces the profile with the same name.
+// @description The profiles cannot contain secrets, the instance keeps its own.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param name path string true "Profile name, letters, digits, hyphens and underscores"
+// @param body body settingsProfilePayload true "Settings of the profile"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /settings/profiles/{name} [put]
+func (handler *Handler) settingsProfileSave(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	name, handlerErr := settingsProfileName(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	var profile settingsProfilePayload
+	err := request.DecodeAndValidateJSONPayload(r, &profile)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if secrets := secretSettingsFields(profile.Settings); len(secrets) > 0 {
+		return httperror.BadRequest("The settings contain secrets: "+strings.Join(secrets, ", ")+". Remove them from the profile", errSettingsImportSecrets)
+	}
+
+	_, handlerErr = handler.settingsDocumentPayload(r, profile.Settings, true)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	profile.ExportedAt = time.Now().Unix()
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return httperror.InternalServerError("Unable to encode the settings profile", err)
+	}
+
+	err = handler.FileService.StoreSettingsProfileFile(name, data)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the settings profile on disk", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id SettingsProfilePreview
+// @summary Preview a settings profile
+// @description Return the settings as they would be after applying the profile, with the secrets hidden, and the changed fields.
+// @description The profile is validated like an update of the settings, nothing is saved.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param name path string true "Profile name"
+// @success 200 {object} settingsDryRunResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Profile not found"
+// @failure 504 "A logo URL or Helm repository to validate did not answer in time"
+// @failure 500 "Server error"
+// @router /settings/profiles/{name}/preview [get]
+func (handler *Handler) settingsProfilePreview(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.applySettingsProfile(w, r, true)
+}
+
+// @id SettingsProfileApply
+// @summary Apply a settings profile
+// @description Update the settings with the profile, with the same validation as an update of the settings.
+// @description Send the ETag of the settings in the If-Match header to reject the update when the settings were changed since the preview.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param name path string true "Profile name"
+// @param If-Match header string false "ETag of the settings the profile was previewed with"
+// @success 200 {object} settingsUpdateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Profile not found"
+// @failure 412 "The settings were updated since the ETag of the If-Match header was read"
+// @failure 504 "A logo URL or Helm repository to validate did not answer in time"
+// @failure 500 "Server error"
+// @router /settings/profiles/{name}/apply [post]
+func (handler *Handler) settingsProfileApply(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.applySettingsProfile(w, r, false)
+}
+
+func (handler *Handler) applySettingsProfile(w http.ResponseWriter, r *http.Request, dryRun bool) *httperror.HandlerError {
+	name, handlerErr := settingsProfileName(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	data, err := handler.FileService.GetSettingsProfileFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return httperror.NotFound("Unable to find a settings profile with the specified name", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to read the settings profile from disk", err)
+	}
+
+	var profile settingsProfilePayload
+	err = json.Unmarshal(data, &profile)
+	if err != nil {
+		return httperror.InternalServerError("Unable to decode the settings profile", err)
+	}
+
+	payload, handlerErr := handler.settingsDocumentPayload(r, profile.Settings, dryRun)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	return handler.applySettingsUpdate(w, payload, false, false)
+}
+
+func settingsProfileName(r *http.Request) (string, *httperror.HandlerError) {
+	name, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return "", httperror.BadRequest("Invalid profile name route variable", err)
+	}
+
+	if !settingsProfileNamePattern.MatchString(name) {
+		return "", httperror.BadRequest("Invalid profile name route variable", errInvalidSettingsProfileName)
+	}
+
+	return name, nil
+}
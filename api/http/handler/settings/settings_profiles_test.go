@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_settingsProfiles(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.JWTService = &sessionDurationJWTServiceStub{sessionDuration: 8 * time.Hour}
+
+	profileRequest := func(name string, body []byte) *http.Request {
+		return mux.SetURLVars(newSettingsUpdateRequest("/settings/profiles/"+name, body), map[string]string{"name": name})
+	}
+
+	save := func(name string, fields map[string]any) *httperror.HandlerError {
+		body, err := json.Marshal(settingsProfilePayload{Version: settingsExportVersion, Settings: fields})
+		is.NoError(err)
+
+		return h.settingsProfileSave(httptest.NewRecorder(), profileRequest(name, body))
+	}
+
+	is.Nil(save("production", map[string]any{"TemplatesURL": "https://templates.example.com/templates.json"}))
+
+	handlerErr := save("../production", map[string]any{"TemplatesURL": "https://templates.example.com/templates.json"})
+	is.NotNil(handlerErr)
+	is.ErrorIs(handlerErr.Err, errInvalidSettingsProfileName)
+
+	handlerErr = save("secrets", map[string]any{"OAuthSettings": map[string]any{"ClientSecret": "secret"}})
+	is.NotNil(handlerErr)
+	is.ErrorIs(handlerErr.Err, errSettingsImportSecrets, "the profiles do not contain secrets")
+
+	handlerErr = save("invalid", map[string]any{"SnapshotWorkerCount": 0})
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode, "the profiles are validated when they are saved")
+
+	previous, err := store.Settings().Settings()
+	is.NoError(err)
+
+	rr := httptest.NewRecorder()
+	is.Nil(h.settingsProfilePreview(rr, profileRequest("production", nil)))
+
+	var preview settingsDryRunResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&preview))
+	is.Equal("https://templates.example.com/templates.json", preview.Settings.TemplatesURL)
+	is.Nil(preview.Settings.JWTSigningKey, "the secrets are hidden")
+	is.Contains(preview.Changes, settingsFieldChange{Field: "TemplatesURL", Old: previous.TemplatesURL, New: "https://templates.example.com/templates.json"})
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(previous.TemplatesURL, settings.TemplatesURL, "the preview does not save the profile")
+
+	is.Nil(h.settingsProfileApply(httptest.NewRecorder(), profileRequest("production", nil)))
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal("https://templates.example.com/templates.json", settings.TemplatesURL)
+
+	handlerErr = h.settingsProfilePreview(httptest.NewRecorder(), profileRequest("staging", nil))
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusNotFound, handlerErr.StatusCode)
+}
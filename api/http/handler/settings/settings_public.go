@@ -27,12 +27,16 @@ type publicSettingsResponse struct {
 	OAuthLoginURI string `json:"OAuthLoginURI" example:"https://gitlab.com/oauth"`
 	// The URL used for oauth logout
 	OAuthLogoutURI string `json:"OAuthLogoutURI" example:"https://gitlab.com/oauth/logout"`
+	// Whether the oauth login must start with /auth/oauth/pkce
+	OAuthUsePKCE bool `json:"OAuthUsePKCE" example:"false"`
 	// Whether telemetry is enabled
 	EnableTelemetry bool `json:"EnableTelemetry" example:"true"`
 	// The expiry of a Kubeconfig
 	KubeconfigExpiry string `example:"24h" default:"0"`
 	// Whether team sync is enabled
 	TeamSync bool `json:"TeamSync" example:"true"`
+	// Features hidden from the UI
+	DisabledFeatures []portainer.UIFeature `json:"DisabledFeatures" example:"appTemplates,kubectlShell"`
 
 	// Whether FDO is enabled
 	IsFDOEnabled bool
@@ -79,6 +83,7 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 		RequiredPasswordLength:    appSettings.InternalAuthSettings.RequiredPasswordLength,
 		EnableEdgeComputeFeatures: appSettings.EnableEdgeComputeFeatures,
 		ShowKomposeBuildOption:    appSettings.ShowKomposeBuildOption,
+		DisabledFeatures:          appSettings.DisabledFeatures,
 		EnableTelemetry:           appSettings.EnableTelemetry,
 		KubeconfigExpiry:          appSettings.KubeconfigExpiry,
 		Features:                  featureflags.FeatureFlags(),
@@ -96,6 +101,7 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 	//if OAuth authentication is on, compose the related fields from application settings
 	if publicSettings.AuthenticationMethod == portainer.AuthenticationOAuth {
 		publicSettings.OAuthLogoutURI = appSettings.OAuthSettings.LogoutURI
+		publicSettings.OAuthUsePKCE = appSettings.OAuthSettings.UsePKCE
 		publicSettings.OAuthLoginURI = fmt.Sprintf("%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s",
 			appSettings.OAuthSettings.AuthorizationURI,
 			appSettings.OAuthSettings.ClientID,
@@ -0,0 +1,36 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var errSettingsRevisionMismatch = errors.New("the settings were updated since they were read")
+
+// settingsETag returns the entity tag of the settings, derived from their revision
+func settingsETag(settings *portainer.Settings) string {
+	return strconv.Quote(strconv.Itoa(settings.Revision))
+}
+
+// matchesSettingsETag returns true when the If-Match header value lists the entity tag of the settings or is a wildcard.
+// Weak tags are compared like strong ones since the revision changes with any update
+func matchesSettingsETag(ifMatch string, settings *portainer.Settings) bool {
+	etag := settingsETag(settings)
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeSettingsETag(w http.ResponseWriter, settings *portainer.Settings) {
+	w.Header().Set("ETag", settingsETag(settings))
+}
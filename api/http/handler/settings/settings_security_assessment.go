@@ -0,0 +1,207 @@
+package settings
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type securityFindingSeverity string
+
+const (
+	securitySeverityHigh   securityFindingSeverity = "high"
+	securitySeverityMedium securityFindingSeverity = "medium"
+	securitySeverityLow    securityFindingSeverity = "low"
+)
+
+// securitySeverityWeights is the weight of a finding in the score, depending on its severity
+var securitySeverityWeights = map[securityFindingSeverity]int{
+	securitySeverityHigh:   3,
+	securitySeverityMedium: 2,
+	securitySeverityLow:    1,
+}
+
+const (
+	recommendedPasswordLength     = 12
+	recommendedUserSessionTimeout = 8 * time.Hour
+	recommendedKubeconfigExpiry   = 24 * time.Hour
+)
+
+type securityFinding struct {
+	// Identifier of the best practice
+	ID       string                  `json:"id" example:"passwordLength"`
+	Severity securityFindingSeverity `json:"severity" example:"high"`
+	// Whether the current settings follow the best practice
+	Passed bool `json:"passed" example:"false"`
+	// JSON path of the relevant setting, empty when the best practice cannot be configured
+	Setting        string `json:"setting" example:"InternalAuthSettings.RequiredPasswordLength"`
+	CurrentValue   any    `json:"currentValue"`
+	SuggestedValue any    `json:"suggestedValue"`
+	Recommendation string `json:"recommendation" example:"Require passwords of at least 12 characters"`
+}
+
+type securityAssessmentResponse struct {
+	// Percentage of the weighted best practices followed by the current settings
+	Score    int               `json:"score" example:"80"`
+	Findings []securityFinding `json:"findings"`
+}
+
+// @id SettingsSecurityAssessment
+// @summary Assess the security of the settings
+// @description Evaluate the current settings against security best practices and return a score along with a recommendation for each practice.
+// @description Nothing is changed.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} securityAssessmentResponse "Success"
+// @failure 500 "Server error"
+// @router /settings/security/assessment [get]
+func (handler *Handler) settingsSecurityAssessment(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	return response.JSON(w, assessSecurity(settings))
+}
+
+func assessSecurity(settings *portainer.Settings) securityAssessmentResponse {
+	findings := []securityFinding{
+		{
+			ID:             "mfa",
+			Severity:       securitySeverityHigh,
+			Passed:         settings.RequireTwoFactorForAdmins,
+			Setting:        "RequireTwoFactorForAdmins",
+			CurrentValue:   settings.RequireTwoFactorForAdmins,
+			SuggestedValue: true,
+			Recommendation: "Require a second factor from the administrators to change their password, or delegate the authentication to an OAuth provider that enforces it",
+		},
+		{
+			ID:             "passwordLength",
+			Severity:       securitySeverityHigh,
+			Passed:         settings.InternalAuthSettings.RequiredPasswordLength >= recommendedPasswordLength,
+			Setting:        "InternalAuthSettings.RequiredPasswordLength",
+			CurrentValue:   settings.InternalAuthSettings.RequiredPasswordLength,
+			SuggestedValue: recommendedPasswordLength,
+			Recommendation: "Require passwords of at least 12 characters",
+		},
+		{
+			ID:             "temporaryPasswordReuse",
+			Severity:       securitySeverityLow,
+			Passed:         settings.InternalAuthSettings.RejectTemporaryPasswordReuse,
+			Setting:        "InternalAuthSettings.RejectTemporaryPasswordReuse",
+			CurrentValue:   settings.InternalAuthSettings.RejectTemporaryPasswordReuse,
+			SuggestedValue: true,
+			Recommendation: "Force users to replace the passwords set by an administrator",
+		},
+		{
+			ID:             "kubeconfigExpiry",
+			Severity:       securitySeverityMedium,
+			Passed:         durationWithin(settings.KubeconfigExpiry, recommendedKubeconfigExpiry),
+			Setting:        "KubeconfigExpiry",
+			CurrentValue:   settings.KubeconfigExpiry,
+			SuggestedValue: "24h",
+			Recommendation: "Make the generated kubeconfig files expire within a day",
+		},
+		{
+			ID:             "userSessionTimeout",
+			Severity:       securitySeverityMedium,
+			Passed:         durationWithin(settings.UserSessionTimeout, recommendedUserSessionTimeout),
+			Setting:        "UserSessionTimeout",
+			CurrentValue:   settings.UserSessionTimeout,
+			SuggestedValue: portainer.DefaultUserSessionTimeout,
+			Recommendation: "Keep the user sessions shorter than a working day",
+		},
+		{
+			ID:             "passwordChangeReauthentication",
+			Severity:       securitySeverityLow,
+			Passed:         settings.PasswordChangeReauthenticationWindow != "",
+			Setting:        "PasswordChangeReauthenticationWindow",
+			CurrentValue:   settings.PasswordChangeReauthenticationWindow,
+			SuggestedValue: "15m",
+			Recommendation: "Require a recent authentication to change a password",
+		},
+		{
+			ID:             "apiKeysPerUser",
+			Severity:       securitySeverityLow,
+			Passed:         settings.MaxAPIKeysPerUser > 0,
+			Setting:        "MaxAPIKeysPerUser",
+			CurrentValue:   settings.MaxAPIKeysPerUser,
+			SuggestedValue: 5,
+			Recommendation: "Limit the number of API keys a user can own",
+		},
+		{
+			ID:             "telemetry",
+			Severity:       securitySeverityLow,
+			Passed:         !settings.EnableTelemetry,
+			Setting:        "EnableTelemetry",
+			CurrentValue:   settings.EnableTelemetry,
+			SuggestedValue: false,
+			Recommendation: "Disable telemetry in restricted environments",
+		},
+		{
+			ID:             "edgeTrustOnFirstConnect",
+			Severity:       securitySeverityMedium,
+			Passed:         !settings.TrustOnFirstConnect,
+			Setting:        "TrustOnFirstConnect",
+			CurrentValue:   settings.TrustOnFirstConnect,
+			SuggestedValue: false,
+			Recommendation: "Review the edge agents before trusting them",
+		},
+	}
+
+	if settings.AuthenticationMethod == portainer.AuthenticationLDAP {
+		ldapTLS := settings.LDAPSettings.TLSConfig.TLS || settings.LDAPSettings.StartTLS
+
+		findings = append(findings,
+			securityFinding{
+				ID:             "ldapTLS",
+				Severity:       securitySeverityHigh,
+				Passed:         ldapTLS,
+				Setting:        "LDAPSettings.StartTLS",
+				CurrentValue:   ldapTLS,
+				SuggestedValue: true,
+				Recommendation: "Connect to the LDAP server with TLS or StartTLS",
+			},
+			securityFinding{
+				ID:             "ldapTLSVerification",
+				Severity:       securitySeverityHigh,
+				Passed:         !settings.LDAPSettings.TLSConfig.TLSSkipVerify,
+				Setting:        "LDAPSettings.TLSConfig.TLSSkipVerify",
+				CurrentValue:   settings.LDAPSettings.TLSConfig.TLSSkipVerify,
+				SuggestedValue: false,
+				Recommendation: "Verify the certificate of the LDAP server",
+			},
+		)
+	}
+
+	total, passed := 0, 0
+	for _, finding := range findings {
+		weight := securitySeverityWeights[finding.Severity]
+
+		total += weight
+		if finding.Passed {
+			passed += weight
+		}
+	}
+
+	return securityAssessmentResponse{
+		Score:    passed * 100 / total,
+		Findings: findings,
+	}
+}
+
+// durationWithin returns true when the duration is set and does not exceed the maximum, "0" means no expiry
+func durationWithin(value string, max time.Duration) bool {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return false
+	}
+
+	return duration > 0 && duration <= max
+}
@@ -0,0 +1,50 @@
+package settings
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_assessSecurity(t *testing.T) {
+	is := assert.New(t)
+
+	findingsByID := func(report securityAssessmentResponse) map[string]securityFinding {
+		findings := map[string]securityFinding{}
+		for _, finding := range report.Findings {
+			findings[finding.ID] = finding
+		}
+
+		return findings
+	}
+
+	settings := &portainer.Settings{
+		AuthenticationMethod: portainer.AuthenticationInternal,
+		InternalAuthSettings: portainer.InternalAuthSettings{RequiredPasswordLength: 8},
+		KubeconfigExpiry:     portainer.DefaultKubeconfigExpiry,
+		UserSessionTimeout:   portainer.DefaultUserSessionTimeout,
+	}
+
+	report := assessSecurity(settings)
+	findings := findingsByID(report)
+	is.False(findings["passwordLength"].Passed)
+	is.Equal(12, findings["passwordLength"].SuggestedValue)
+	is.False(findings["kubeconfigExpiry"].Passed, "kubeconfig files never expire")
+	is.True(findings["userSessionTimeout"].Passed)
+	is.False(findings["mfa"].Passed)
+	is.NotContains(findings, "ldapTLS", "LDAP findings only apply to LDAP authentication")
+
+	settings.InternalAuthSettings.RequiredPasswordLength = 12
+	settings.KubeconfigExpiry = "8h"
+	settings.RequireTwoFactorForAdmins = true
+	is.True(findingsByID(assessSecurity(settings))["mfa"].Passed)
+	is.Greater(assessSecurity(settings).Score, report.Score)
+
+	settings.AuthenticationMethod = portainer.AuthenticationLDAP
+	settings.LDAPSettings.TLSConfig.TLSSkipVerify = true
+	findings = findingsByID(assessSecurity(settings))
+	is.False(findings["ldapTLS"].Passed)
+	is.False(findings["ldapTLSVerification"].Passed)
+}
@@ -1,14 +1,28 @@
 package settings
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/client"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/snapshot"
+	ldapservice "github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/oauth"
 	"github.com/portainer/portainer/pkg/featureflags"
 	"github.com/portainer/portainer/pkg/libhelm"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -16,19 +30,240 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/go-ldap/ldap/v3"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
+// oauthSettingsPayload is a partial update of the OAuth settings, omitted fields keep their current value
+type oauthSettingsPayload struct {
+	ClientID *string `example:"my-client-id"`
+	// Client secret, an empty value keeps the current secret
+	ClientSecret         *string           `example:"my-client-secret"`
+	AccessTokenURI       *string           `example:"https://oauth.mydomain.tld/token"`
+	AuthorizationURI     *string           `example:"https://oauth.mydomain.tld/authorize"`
+	ResourceURI          *string           `example:"https://oauth.mydomain.tld/user"`
+	RedirectURI          *string           `example:"https://portainer.mydomain.tld"`
+	UserIdentifier       *string           `example:"email"`
+	Scopes               *string           `example:"openid profile"`
+	OAuthAutoCreateUsers *bool             `example:"false"`
+	DefaultTeamID        *portainer.TeamID `example:"0"`
+	SSO                  *bool             `example:"false"`
+	LogoutURI            *string           `example:"https://oauth.mydomain.tld/logout"`
+	KubeSecretKey        []byte
+	// Whether the OAuth login is protected with PKCE, the login then starts with /auth/oauth/pkce
+	UsePKCE *bool `example:"false"`
+	// PKCE code challenge method, S256 or plain. An empty value keeps the current method
+	PKCEChallengeMethod *string `example:"S256"`
+	// Absolute http or https URLs accepted as redirect URI of the OAuth login, an empty list only accepts RedirectURI
+	AllowedRedirectURIs []string `example:"https://portainer.mydomain.tld"`
+}
+
+// internalAuthSettingsPayload is a partial update of the internal authentication settings, omitted fields keep their
+// current value
+type internalAuthSettingsPayload struct {
+	RequiredPasswordLength *int `example:"12"`
+	// Whether a user must replace a password set by an administrator with a different one
+	RejectTemporaryPasswordReuse *bool `example:"true"`
+	// Number of previous passwords, including the current one, that cannot be reused. 0 disables the check
+	PasswordHistoryDepth *int `example:"5"`
+	// Number of consecutive failed password changes after which a user is locked out. 0 disables the lockout
+	PasswordChangeMaxFailedAttempts *int `example:"5"`
+	// Duration of the password change lockout, 15 minutes when empty
+	PasswordChangeLockoutDuration *string `example:"15m"`
+	// Number of days after which a password expires and must be changed. 0 disables the expiry
+	PasswordExpiryDays *int `example:"90"`
+	// Length from which a password is considered a passphrase and the character classes rule is waived. 0 disables the passphrase mode
+	PassphraseMinLength *int `example:"16"`
+	// bcrypt cost of the password hashes, from 4 to 31. 0 uses the default cost of 10
+	PasswordHashCost *int `example:"12"`
+}
+
+// operations applying BlackListedLabels to the current list
+const (
+	blackListedLabelsOpReplace = "replace"
+	blackListedLabelsOpAppend  = "append"
+	blackListedLabelsOpRemove  = "remove"
+)
+
+const (
+	// MinSnapshotInterval is the shortest accepted interval between two environment snapshots
+	MinSnapshotInterval = time.Minute
+	// MaxSnapshotInterval is the longest accepted interval between two environment snapshots
+	MaxSnapshotInterval = 24 * time.Hour
+	// MaxSnapshotWorkerCount is the highest number of environments snapshotted in parallel, higher values are clamped
+	// so that the snapshots do not overwhelm the Docker and Kubernetes APIs
+	MaxSnapshotWorkerCount = 32
+
+	// helmRepositoryValidationTTL is how long a Helm repository URL is not validated again after a successful validation
+	helmRepositoryValidationTTL = 10 * time.Minute
+
+	// KubectlShellImageAllowlistEnvVar is the environment variable holding the comma separated prefixes
+	// the kubectl shell image must start with, any image is accepted when it is not set
+	KubectlShellImageAllowlistEnvVar = "KUBECTL_SHELL_IMAGE_ALLOWLIST"
+
+	// HelmRepositorySchemesEnvVar is the environment variable holding the comma separated URL schemes accepted
+	// for the Helm repositories, e.g. "https,oci,http" to allow plaintext repositories in an air-gapped deployment
+	HelmRepositorySchemesEnvVar = "HELM_REPOSITORY_SCHEMES"
+	// DefaultHelmRepositorySchemes are the URL schemes accepted for the Helm repositories when the environment
+	// variable is not set, the charts are not pulled over plaintext
+	DefaultHelmRepositorySchemes = "https,oci"
+)
+
+// kubectlShellImageAllowlist is the list of prefixes the kubectl shell image must start with, empty to accept any image
+var kubectlShellImageAllowlist = parseImageAllowlist(os.Getenv(KubectlShellImageAllowlistEnvVar))
+
+// helmRepositorySchemes is the list of URL schemes accepted for the Helm repositories
+var helmRepositorySchemes = parseHelmRepositorySchemes(os.Getenv(HelmRepositorySchemesEnvVar))
+
+func parseHelmRepositorySchemes(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		value = DefaultHelmRepositorySchemes
+	}
+
+	var schemes []string
+	for _, scheme := range strings.Split(value, ",") {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return schemes
+}
+
+// isHelmRepositoryURL returns true when the URL is a valid classic or OCI Helm repository URL
+func isHelmRepositoryURL(repositoryURL string) bool {
+	if libhelm.IsOCIRepositoryURL(repositoryURL) {
+		_, err := libhelm.ParseOCIRepositoryURL(repositoryURL)
+
+		return err == nil
+	}
+
+	return govalidator.IsURL(repositoryURL)
+}
+
+// helmRepositoryType returns the type of the Helm repository, empty when there is no repository
+func helmRepositoryType(repositoryURL string) portainer.HelmRepositoryType {
+	switch {
+	case repositoryURL == "":
+		return ""
+	case libhelm.IsOCIRepositoryURL(repositoryURL):
+		return portainer.HelmRepositoryTypeOCI
+	}
+
+	return portainer.HelmRepositoryTypeClassic
+}
+
+// validateHelmRepositoryScheme checks that the Helm repository URL uses one of the accepted schemes
+func validateHelmRepositoryScheme(repositoryURL string, schemes []string) error {
+	parsedURL, err := url.Parse(repositoryURL)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid Helm repository URL %q", repositoryURL)
+	}
+
+	if !slices.Contains(schemes, strings.ToLower(parsedURL.Scheme)) {
+		return fmt.Errorf("Invalid Helm repository URL %q. The scheme must be one of: %s", repositoryURL, strings.Join(schemes, ", "))
+	}
+
+	return nil
+}
+
+func parseImageAllowlist(value string) []string {
+	var allowlist []string
+	for _, prefix := range strings.Split(value, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			allowlist = append(allowlist, prefix)
+		}
+	}
+
+	return allowlist
+}
+
+// validateKubectlShellImage checks that the image is a valid reference, with a tag or a digest when one is specified,
+// and that it starts with one of the allowed prefixes. The prefixes are matched against the image as written
+// and against its fully qualified name, so that "docker.io/portainer/" allows "portainer/kubectl-shell"
+func validateKubectlShellImage(image string, allowlist []string) error {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return errors.Wrap(err, "Invalid kubectl shell image")
+	}
+
+	if len(allowlist) == 0 {
+		return nil
+	}
+
+	for _, prefix := range allowlist {
+		if strings.HasPrefix(image, prefix) || strings.HasPrefix(named.String(), prefix) {
+			return nil
+		}
+	}
+
+	return errors.New("Invalid kubectl shell image. The image is not allowed")
+}
+
+// validateLDAPDistinguishedNames checks the syntax of the distinguished names of the LDAP settings, so that a malformed
+// DN is reported when the settings are saved instead of failing every login. Empty values are not checked, the reader
+// DN is kept when omitted and the required fields are checked with the authentication method
+func validateLDAPDistinguishedNames(ldapSettings *portainer.LDAPSettings) error {
+	validate := func(field, dn string) error {
+		if dn == "" {
+			return nil
+		}
+
+		if _, err := ldap.ParseDN(dn); err != nil {
+			return errors.Wrapf(err, "Invalid %s. Must be a valid distinguished name", field)
+		}
+
+		return nil
+	}
+
+	if err := validate("LDAPSettings.ReaderDN", ldapSettings.ReaderDN); err != nil {
+		return err
+	}
+
+	for i, searchSettings := range ldapSettings.SearchSettings {
+		if err := validate(fmt.Sprintf("LDAPSettings.SearchSettings[%d].BaseDN", i), searchSettings.BaseDN); err != nil {
+			return err
+		}
+	}
+
+	for i, groupSearchSettings := range ldapSettings.GroupSearchSettings {
+		if err := validate(fmt.Sprintf("LDAPSettings.GroupSearchSettings[%d].GroupBaseDN", i), groupSearchSettings.GroupBaseDN); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func joinUIFeatures(features []portainer.UIFeature) string {
+	names := make([]string, 0, len(features))
+	for _, feature := range features {
+		names = append(names, string(feature))
+	}
+
+	return strings.Join(names, ", ")
+}
+
 type settingsUpdatePayload struct {
 	// URL to a logo that will be displayed on the login page as well as on top of the sidebar. Will use default Portainer logo when value is empty string
 	LogoURL *string `example:"https://mycompany.mydomain.tld/logo.png"`
+	// Whether the logo URL must be checked to serve an image when it is updated. Should stay disabled on air-gapped setups
+	EnforceLogoURLImage *bool `example:"false"`
 	// A list of label name & value that will be used to hide containers when querying containers
 	BlackListedLabels []portainer.Pair
+	// How BlackListedLabels is applied: replace (default) the current list, append the missing pairs or remove the listed pairs
+	BlackListedLabelsOp *string `example:"replace" enums:"replace,append,remove"`
 	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, or 3 for oauth
 	AuthenticationMethod *int `example:"1"`
-	InternalAuthSettings *portainer.InternalAuthSettings
-	LDAPSettings         *portainer.LDAPSettings
-	OAuthSettings        *portainer.OAuthSettings
+	// Minimum duration between two changes of the authentication method, empty to disable the cooldown
+	AuthenticationMethodChangeCooldown *string `example:"1h"`
+	// Allows the authentication method to be changed during the cooldown
+	OverrideAuthenticationMethodCooldown bool `example:"false"`
+	InternalAuthSettings                 *internalAuthSettingsPayload
+	LDAPSettings                         *portainer.LDAPSettings
+	OAuthSettings                        *oauthSettingsPayload
 	// The interval in which environment(endpoint) snapshots are created
 	SnapshotInterval *string `example:"5m"`
 	// URL to the templates that will be displayed in the UI when navigating to App Templates
@@ -41,12 +276,18 @@ type settingsUpdatePayload struct {
 	EnableEdgeComputeFeatures *bool `example:"true"`
 	// The duration of a user session
 	UserSessionTimeout *string `example:"5m"`
+	// Whether a snapshot of the environments is scheduled once the update is saved, usually along with a lower SnapshotInterval
+	TriggerSnapshotNow bool `example:"false"`
+	// Whether lowering the user session timeout also closes the sessions started earlier that exceed it
+	EnforceUserSessionTimeout bool `example:"false"`
 	// The expiry of a Kubeconfig
 	KubeconfigExpiry *string `example:"24h" default:"0"`
 	// Whether telemetry is enabled
 	EnableTelemetry *bool `example:"false"`
 	// Helm repository URL
 	HelmRepositoryURL *string `example:"https://charts.bitnami.com/bitnami"`
+	// Helm repository URLs, replaces HelmRepositoryURL when both are set
+	HelmRepositoryURLs []string `example:"https://charts.bitnami.com/bitnami"`
 	// Kubectl Shell Image
 	KubectlShellImage *string `example:"portainer/kubectl-shell:latest"`
 	// TrustOnFirstConnect makes Portainer accepting edge agent connection by default
@@ -55,43 +296,318 @@ type settingsUpdatePayload struct {
 	EnforceEdgeID *bool `example:"false"`
 	// EdgePortainerURL is the URL that is exposed to edge agents
 	EdgePortainerURL *string `json:"EdgePortainerURL"`
+	// The maximum number of API keys a user can own, 0 means unlimited
+	MaxAPIKeysPerUser *int `example:"0"`
+	// Whether team leaders can manage the registry accesses of the environments their teams have access to
+	TeamLeadersManageRegistryAccess *bool `example:"false"`
+	// Number of days before the expiry of the LDAP CA certificate from which a warning is raised, 0 to disable the check
+	LDAPCAExpiryWarningDays *int `example:"30"`
+	// URL notified when a warning about the expiry of the LDAP CA certificate is raised
+	LDAPCAExpiryWebhookURL *string `example:"https://hooks.mycompany.tld/ldap"`
+	// The maximum number of user and team access policies in a registry access update, 0 means the default limit
+	MaxRegistryAccessPolicies *int `example:"0"`
+	// The maximum number of namespaces in a registry access update, 0 means the default limit
+	MaxRegistryAccessNamespaces *int `example:"0"`
+	// URL of the proxy used for the outbound requests, the proxy defined by the environment is used when empty
+	OutboundProxyURL *string `example:"http://proxy.mycompany.tld:3128"`
+	// Whether the last administrator can change their own role away from administrator
+	AllowLastAdminSelfDemotion *bool `example:"false"`
+	// Whether the app templates are served from the uploaded templates file instead of TemplatesURL
+	UseLocalTemplatesFile *bool `example:"false"`
+	// Maximum age of the session used to change a password, empty to disable the check
+	PasswordChangeReauthenticationWindow *string `example:"15m"`
+	// Whether the Kubernetes registry secrets are left untouched when the credentials of a registry change, they are still renamed when the secret name template changes
+	DisableRegistrySecretRefresh *bool `example:"false"`
+	// Whether administrators with a local password can still log in with it when the authentication method is LDAP or OAuth
+	EnableLocalAdminFallback *bool `example:"false"`
+	// Number of environments snapshotted in parallel, values above the maximum are clamped
+	SnapshotWorkerCount *int `example:"4"`
+	// URL notified with the changed fields after each settings update, empty to disable the notifications
+	SettingsChangeWebhookURL *string `example:"https://hooks.mycompany.tld/settings"`
+	// Features hidden from the UI, one of appTemplates, customTemplates, helm, kubectlShell, containerConsole or stackGit.
+	// An empty list enables every feature
+	DisabledFeatures []portainer.UIFeature `example:"appTemplates,kubectlShell"`
+	// Number of consecutive failed logins after which the account is locked, 0 to disable the lockout
+	MaxLoginAttempts *int `example:"5"`
+	// Duration of the login lockout, 15 minutes when empty
+	LoginLockoutDuration *string `example:"15m"`
+	// Whether the administrators are never locked out
+	LoginLockoutExemptAdmins *bool `example:"false"`
+	// Daily window during which the scheduled snapshots are skipped, empty Start and End to disable it
+	SnapshotQuietHours *portainer.SnapshotQuietHours
+	// Whether the administrators must provide a TOTP code of their second factor to change their password. It cannot be
+	// enabled while an administrator with a local password has not enrolled a second factor
+	RequireTwoFactorForAdmins *bool `example:"false"`
+
+	// set by the handler, the If-Match header of the request
+	ifMatch string
+	// set by the handler, context of the request, the validation calls are cancelled when the client goes away
+	ctx context.Context
+	// set by the handler, whether EdgePortainerURL must use https
+	edgeEnforceHTTPS bool
+	// set by the handler, longest accepted user session timeout
+	maxUserSessionTimeout time.Duration
+	// set by the handler, identifier of the user updating the settings
+	userID portainer.UserID
+	// set by the handler, whether the update is only previewed
+	dryRun bool
+	// set by the handler, whether the Helm repositories are validated even when they were validated recently
+	revalidateHelm bool
+}
+
+// errSettingsDryRun rolls back the transaction of a dry-run update
+var errSettingsDryRun = errors.New("settings dry-run")
+
+var errTwoFactorNotEnrolled = httperror.WithCode(httperrors.CodeTwoFactorNotEnrolled, errors.New("administrators have not enrolled a second factor"))
+var errIncompleteAuthenticationSettings = httperror.WithCode(httperrors.CodeAuthSettingsIncomplete, errors.New("the settings of the authentication method are incomplete"))
+var errPKCEWithoutAuthorizationCodeFlow = httperror.WithCode(httperrors.CodePKCERequiresAuthorizationCode, errors.New("PKCE requires the authorization code flow"))
+
+type settingsDryRunResponse struct {
+	// Settings as they would be after the update
+	Settings *portainer.Settings `json:"settings"`
+	// Fields changed by the update, secrets are redacted
+	Changes []settingsFieldChange `json:"changes"`
+}
+
+// changedFieldsHeader is the response header listing the JSON paths of the settings changed by an update
+const changedFieldsHeader = "X-Changed-Fields"
+
+type settingsUpdateVerboseResponse struct {
+	Settings *portainer.Settings `json:"settings"`
+	// JSON paths of the fields changed by the update
+	ChangedFields []string `json:"changedFields" example:"LDAPSettings.URL"`
+	// Problems found with the saved settings that did not prevent the update
+	Warnings []string `json:"warnings,omitempty"`
+	// Whether a snapshot of the environments was scheduled, it runs in the background
+	SnapshotScheduled bool `json:"snapshotScheduled,omitempty"`
+	// CA certificate used to verify the LDAP server, only when TLS or StartTLS is enabled without skipping the verification
+	LDAPCACertificate *ldapCACertificate `json:"ldapCACertificate,omitempty"`
+}
+
+type settingsUpdateResponse struct {
+	*portainer.Settings
+	// Problems found with the saved settings that did not prevent the update
+	Warnings []string `json:"Warnings,omitempty"`
+	// Whether a snapshot of the environments was scheduled, it runs in the background
+	SnapshotScheduled bool `json:"SnapshotScheduled,omitempty"`
+	// CA certificate used to verify the LDAP server, only when TLS or StartTLS is enabled without skipping the verification
+	LDAPCACertificate *ldapCACertificate `json:"LDAPCACertificate,omitempty"`
 }
 
 func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 	if payload.AuthenticationMethod != nil && *payload.AuthenticationMethod != 1 && *payload.AuthenticationMethod != 2 && *payload.AuthenticationMethod != 3 {
-		return errors.New("Invalid authentication method value. Value must be one of: 1 (internal), 2 (LDAP/AD) or 3 (OAuth)")
+		return httperror.WithCode(httperrors.CodeInvalidAuthMethod, errors.New("Invalid authentication method value. Value must be one of: 1 (internal), 2 (LDAP/AD) or 3 (OAuth)"))
 	}
 
-	if payload.LogoURL != nil && *payload.LogoURL != "" && !govalidator.IsURL(*payload.LogoURL) {
-		return errors.New("Invalid logo URL. Must correspond to a valid URL format")
+	if payload.LogoURL != nil && *payload.LogoURL != "" && *payload.LogoURL != uploadedLogoURL && !govalidator.IsURL(*payload.LogoURL) {
+		return httperror.WithCode(httperrors.CodeLogoURLInvalid, errors.New("Invalid logo URL. Must correspond to a valid URL format"))
 	}
 
 	if payload.TemplatesURL != nil && *payload.TemplatesURL != "" && !govalidator.IsURL(*payload.TemplatesURL) {
-		return errors.New("Invalid external templates URL. Must correspond to a valid URL format")
+		return httperror.WithCode(httperrors.CodeTemplatesURLInvalid, errors.New("Invalid external templates URL. Must correspond to a valid URL format"))
+	}
+
+	if payload.HelmRepositoryURL != nil && *payload.HelmRepositoryURL != "" && !isHelmRepositoryURL(*payload.HelmRepositoryURL) {
+		return httperror.WithCode(httperrors.CodeHelmURLInvalid, errors.New("Invalid Helm repository URL. Must correspond to a valid URL format"))
 	}
 
-	if payload.HelmRepositoryURL != nil && *payload.HelmRepositoryURL != "" && !govalidator.IsURL(*payload.HelmRepositoryURL) {
-		return errors.New("Invalid Helm repository URL. Must correspond to a valid URL format")
+	for _, url := range payload.HelmRepositoryURLs {
+		if !isHelmRepositoryURL(url) {
+			return httperror.WithCode(httperrors.CodeHelmURLInvalid, fmt.Errorf("Invalid Helm repository URL %q. Must correspond to a valid URL format", url))
+		}
+
+		if err := validateHelmRepositoryScheme(url, helmRepositorySchemes); err != nil {
+			return httperror.WithCode(httperrors.CodeHelmURLInvalid, err)
+		}
+	}
+
+	if payload.HelmRepositoryURL != nil && *payload.HelmRepositoryURL != "" {
+		if err := validateHelmRepositoryScheme(*payload.HelmRepositoryURL, helmRepositorySchemes); err != nil {
+			return httperror.WithCode(httperrors.CodeHelmURLInvalid, err)
+		}
+	}
+
+	for _, d := range payload.durations() {
+		if d.value == nil || (d.optional && *d.value == "") {
+			continue
+		}
+
+		if _, err := normalizeDuration(*d.value); err != nil {
+			return httperror.WithCode(httperrors.CodeDurationInvalid, errors.New(d.invalidMessage))
+		}
 	}
 
 	if payload.UserSessionTimeout != nil {
-		_, err := time.ParseDuration(*payload.UserSessionTimeout)
-		if err != nil {
-			return errors.New("Invalid user session timeout")
+		maxUserSessionTimeout := payload.maxUserSessionTimeout
+		if maxUserSessionTimeout == 0 {
+			maxUserSessionTimeout = portainer.DefaultMaxUserSessionTimeout
+		}
+
+		userSessionTimeout, _ := time.ParseDuration(*payload.UserSessionTimeout)
+		if userSessionTimeout <= 0 || userSessionTimeout > maxUserSessionTimeout {
+			return httperror.WithCode(httperrors.CodeUserSessionTimeoutInvalid, fmt.Errorf("Invalid user session timeout. Must be greater than 0 and at most %s", maxUserSessionTimeout))
 		}
 	}
 
-	if payload.KubeconfigExpiry != nil {
-		_, err := time.ParseDuration(*payload.KubeconfigExpiry)
-		if err != nil {
-			return errors.New("Invalid Kubeconfig Expiry")
+	if internalAuth := payload.InternalAuthSettings; internalAuth != nil {
+		if depth := internalAuth.PasswordHistoryDepth; depth != nil && (*depth < 0 || *depth > portainer.MaxPasswordHistoryDepth) {
+			return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, fmt.Errorf("Invalid password history depth. Must be between 0 and %d", portainer.MaxPasswordHistoryDepth))
+		}
+
+		if attempts := internalAuth.PasswordChangeMaxFailedAttempts; attempts != nil && *attempts < 0 {
+			return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, errors.New("Invalid maximum number of failed password changes. Must be a positive number or 0 to disable the lockout"))
+		}
+
+		if lockout := internalAuth.PasswordChangeLockoutDuration; lockout != nil && *lockout != "" {
+			if _, err := normalizeDuration(*lockout); err != nil {
+				return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, errors.New("Invalid password change lockout duration"))
+			}
+		}
+
+		if days := internalAuth.PasswordExpiryDays; days != nil && *days < 0 {
+			return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, errors.New("Invalid password expiry. Must be a positive number of days or 0 to disable the expiry"))
+		}
+
+		if passphraseMinLength := internalAuth.PassphraseMinLength; passphraseMinLength != nil && (*passphraseMinLength < 0 ||
+			(*passphraseMinLength != 0 && internalAuth.RequiredPasswordLength != nil && *passphraseMinLength < *internalAuth.RequiredPasswordLength)) {
+			return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, errors.New("Invalid passphrase minimum length. Must be 0 to disable the passphrase mode or at least the required password length"))
+		}
+
+		if cost := internalAuth.PasswordHashCost; cost != nil && *cost != 0 && (*cost < portainer.MinPasswordHashCost || *cost > portainer.MaxPasswordHashCost) {
+			return httperror.WithCode(httperrors.CodePasswordPolicyInvalid, fmt.Errorf("Invalid password hash cost. Must be 0 to use the default cost or between %d and %d", portainer.MinPasswordHashCost, portainer.MaxPasswordHashCost))
+		}
+	}
+
+	if payload.BlackListedLabelsOp != nil {
+		switch *payload.BlackListedLabelsOp {
+		case blackListedLabelsOpReplace, blackListedLabelsOpAppend, blackListedLabelsOpRemove:
+		default:
+			return httperror.WithCode(httperrors.CodeBlackListedLabelsOpInvalid, errors.New("Invalid black listed labels operation. Must be one of: replace, append or remove"))
+		}
+	}
+
+	if payload.SnapshotInterval != nil {
+		interval, _ := time.ParseDuration(*payload.SnapshotInterval)
+		if interval < MinSnapshotInterval || interval > MaxSnapshotInterval {
+			return httperror.WithCode(httperrors.CodeSnapshotIntervalInvalid, fmt.Errorf("Invalid snapshot interval. Must be between %s and %s", MinSnapshotInterval, MaxSnapshotInterval))
 		}
 	}
 
+	if payload.OAuthSettings != nil && payload.OAuthSettings.PKCEChallengeMethod != nil {
+		switch *payload.OAuthSettings.PKCEChallengeMethod {
+		case "", portainer.OAuthPKCEMethodS256, portainer.OAuthPKCEMethodPlain:
+		default:
+			return httperror.WithCode(httperrors.CodePKCEMethodInvalid, errors.New("Invalid PKCE challenge method. Must be one of: S256 or plain"))
+		}
+	}
+
+	if payload.OAuthSettings != nil {
+		for _, redirectURI := range payload.OAuthSettings.AllowedRedirectURIs {
+			if err := oauth.ValidateRedirectURI(redirectURI); err != nil {
+				return httperror.WithCode(httperrors.CodeOAuthRedirectURIInvalid, fmt.Errorf("Invalid OAuth redirect URI %q: %w", redirectURI, err))
+			}
+		}
+	}
+
+	if payload.SnapshotWorkerCount != nil && *payload.SnapshotWorkerCount < 1 {
+		return httperror.WithCode(httperrors.CodeSnapshotWorkerCountInvalid, errors.New("Invalid snapshot worker count. Must be at least 1"))
+	}
+
+	if payload.EdgeAgentCheckinInterval != nil && (*payload.EdgeAgentCheckinInterval < MinEdgeAgentCheckinInterval || *payload.EdgeAgentCheckinInterval > MaxEdgeAgentCheckinInterval) {
+		return httperror.WithCode(httperrors.CodeEdgeCheckinIntervalInvalid, fmt.Errorf("Invalid edge agent check-in interval. Must be between %d and %d seconds", MinEdgeAgentCheckinInterval, MaxEdgeAgentCheckinInterval))
+	}
+
 	if payload.EdgePortainerURL != nil && *payload.EdgePortainerURL != "" {
 		_, err := edge.ParseHostForEdge(*payload.EdgePortainerURL)
 		if err != nil {
-			return err
+			return httperror.WithCode(httperrors.CodeEdgeURLInvalid, err)
+		}
+
+		if payload.edgeEnforceHTTPS && !strings.HasPrefix(strings.ToLower(*payload.EdgePortainerURL), "https://") {
+			return httperror.WithCode(httperrors.CodeEdgeURLInvalid, errors.New("Invalid edge Portainer URL. Must use https"))
+		}
+	}
+
+	if payload.LDAPSettings != nil {
+		err := validateLDAPDistinguishedNames(payload.LDAPSettings)
+		if err != nil {
+			return httperror.WithCode(httperrors.CodeLDAPDistinguishedNameInvalid, err)
+		}
+	}
+
+	if payload.LDAPSettings != nil {
+		if payload.LDAPSettings.PoolSize < 0 || payload.LDAPSettings.PoolSize > ldapservice.MaxPoolSize {
+			return httperror.WithCode(httperrors.CodeLDAPPoolInvalid, fmt.Errorf("Invalid LDAP pool size. Must be between 0 and %d", ldapservice.MaxPoolSize))
+		}
+
+		if idleTimeout := payload.LDAPSettings.PoolIdleTimeout; idleTimeout != "" {
+			if timeout, err := time.ParseDuration(idleTimeout); err != nil || timeout <= 0 {
+				return httperror.WithCode(httperrors.CodeLDAPPoolInvalid, errors.New("Invalid LDAP pool idle timeout. Must be a positive duration"))
+			}
+		}
+
+		if _, err := crypto.ParseTLSMinVersion(payload.LDAPSettings.TLSMinVersion); err != nil {
+			return httperror.WithCode(httperrors.CodeLDAPTLSVersionInvalid, errors.Wrap(err, "Invalid LDAP minimum TLS version"))
+		}
+	}
+
+	if payload.LDAPCAExpiryWarningDays != nil && *payload.LDAPCAExpiryWarningDays < 0 {
+		return httperror.WithCode(httperrors.CodeLDAPCAExpiryInvalid, errors.New("Invalid LDAP CA expiry warning days. Must be a positive number or 0 to disable the check"))
+	}
+
+	if payload.LDAPCAExpiryWebhookURL != nil && *payload.LDAPCAExpiryWebhookURL != "" && !govalidator.IsURL(*payload.LDAPCAExpiryWebhookURL) {
+		return httperror.WithCode(httperrors.CodeWebhookURLInvalid, errors.New("Invalid LDAP CA expiry webhook URL. Must correspond to a valid URL format"))
+	}
+
+	for _, feature := range payload.DisabledFeatures {
+		if !slices.Contains(portainer.UIFeatures, feature) {
+			return httperror.WithCode(httperrors.CodeUIFeatureUnknown, fmt.Errorf("Invalid disabled feature %q. Must be one of: %s", feature, joinUIFeatures(portainer.UIFeatures)))
+		}
+	}
+
+	if payload.SettingsChangeWebhookURL != nil && *payload.SettingsChangeWebhookURL != "" && !govalidator.IsURL(*payload.SettingsChangeWebhookURL) {
+		return httperror.WithCode(httperrors.CodeWebhookURLInvalid, errors.New("Invalid settings change webhook URL. Must correspond to a valid URL format"))
+	}
+
+	if payload.OutboundProxyURL != nil && *payload.OutboundProxyURL != "" {
+		err := client.ValidateProxyURL(*payload.OutboundProxyURL)
+		if err != nil {
+			return httperror.WithCode(httperrors.CodeProxyURLInvalid, errors.Wrap(err, "Invalid outbound proxy URL"))
+		}
+	}
+
+	if payload.MaxRegistryAccessPolicies != nil && *payload.MaxRegistryAccessPolicies < 0 {
+		return httperror.WithCode(httperrors.CodeLimitInvalid, errors.New("Invalid maximum number of registry access policies. Must be a positive number or 0 to use the default limit"))
+	}
+
+	if payload.MaxRegistryAccessNamespaces != nil && *payload.MaxRegistryAccessNamespaces < 0 {
+		return httperror.WithCode(httperrors.CodeLimitInvalid, errors.New("Invalid maximum number of registry access namespaces. Must be a positive number or 0 to use the default limit"))
+	}
+
+	if payload.MaxLoginAttempts != nil && *payload.MaxLoginAttempts < 0 {
+		return httperror.WithCode(httperrors.CodeLoginLockoutInvalid, errors.New("Invalid maximum number of login attempts. Must be a positive number or 0 to disable the lockout"))
+	}
+
+	if payload.LoginLockoutDuration != nil && *payload.LoginLockoutDuration != "" {
+		if lockout, _ := time.ParseDuration(*payload.LoginLockoutDuration); lockout <= 0 {
+			return httperror.WithCode(httperrors.CodeLoginLockoutInvalid, errors.New("Invalid login lockout duration. Must be a positive duration"))
+		}
+	}
+
+	if payload.SnapshotQuietHours != nil {
+		if _, err := snapshot.ParseQuietHours(*payload.SnapshotQuietHours); err != nil {
+			return httperror.WithCode(httperrors.CodeSnapshotQuietHoursInvalid, errors.Wrap(err, "Invalid snapshot quiet hours"))
+		}
+	}
+
+	if payload.MaxAPIKeysPerUser != nil && *payload.MaxAPIKeysPerUser < 0 {
+		return httperror.WithCode(httperrors.CodeLimitInvalid, errors.New("Invalid maximum number of API keys per user. Must be a positive number or 0 for unlimited"))
+	}
+
+	if payload.KubectlShellImage != nil {
+		err := validateKubectlShellImage(*payload.KubectlShellImage, kubectlShellImageAllowlist)
+		if err != nil {
+			return httperror.WithCode(httperrors.CodeKubectlShellImageInvalid, err)
 		}
 	}
 
@@ -101,30 +617,73 @@ func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 // @id SettingsUpdate
 // @summary Update Portainer settings
 // @description Update Portainer settings.
+// @description Send the ETag of the settings in the If-Match header to reject the update when the settings were changed in the meantime.
+// @description The payload can be sent as YAML with the application/yaml Content-Type, with the same fields as the JSON. It is validated
+// @description the same way, the response is always JSON.
 // @description **Access policy**: administrator
 // @tags settings
 // @security ApiKeyAuth
 // @security jwt
-// @accept json
+// @accept json,application/yaml
 // @produce json
+// @param dryRun query bool false "Preview the update without applying it, the would-be settings and the changed fields are returned (settingsDryRunResponse)"
+// @param revalidateHelm query bool false "Validate the Helm repositories even when they were validated in the last 10 minutes"
+// @param verbose query bool false "Wrap the settings with the list of the changed fields (settingsUpdateVerboseResponse)"
+// @param checkEdgeURL query bool false "Check that the Portainer API is reachable at the edge Portainer URL, a warning is returned when it is not"
+// @param If-Match header string false "ETag of the settings the update is based on, the update is rejected when the settings changed since"
 // @param body body settingsUpdatePayload true "New settings"
-// @success 200 {object} portainer.Settings "Success"
+// @success 200 {object} settingsUpdateResponse "Success"
+// @header 200 {string} X-Changed-Fields "Comma separated JSON paths of the changed fields"
+// @header 200 {string} ETag "ETag of the updated settings"
 // @failure 400 "Invalid request"
+// @failure 412 "The settings were updated since the ETag of the If-Match header was read"
+// @failure 504 "A logo URL or Helm repository to validate did not answer in time"
 // @failure 500 "Server error"
 // @router /settings [put]
 func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
-	var payload settingsUpdatePayload
-	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	dryRun, _ := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+	revalidateHelm, _ := request.RetrieveBooleanQueryParameter(r, "revalidateHelm", true)
+	checkEdgeURL, _ := request.RetrieveBooleanQueryParameter(r, "checkEdgeURL", true)
+	verbose, _ := request.RetrieveBooleanQueryParameter(r, "verbose", true)
+
+	payload := settingsUpdatePayload{edgeEnforceHTTPS: handler.EdgeEnforceHTTPS, maxUserSessionTimeout: handler.MaxUserSessionTimeout, dryRun: dryRun, revalidateHelm: revalidateHelm}
+	err := decodeAndValidatePayload(r, &payload)
 	if err != nil {
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
-	var settings *portainer.Settings
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+	payload.userID = tokenData.ID
+	payload.ifMatch = r.Header.Get("If-Match")
+	payload.ctx = r.Context()
+
+	return handler.applySettingsUpdate(w, payload, checkEdgeURL, verbose)
+}
+
+// applySettingsUpdate saves the validated payload and applies the changed settings to the services
+func (handler *Handler) applySettingsUpdate(w http.ResponseWriter, payload settingsUpdatePayload, checkEdgeURL, verbose bool) *httperror.HandlerError {
+	if payload.dryRun {
+		previousSettings, err := handler.DataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+		}
+
+		return handler.settingsUpdateDryRun(w, previousSettings, payload)
+	}
+
+	// the previous settings are read in the same transaction as the update, so that the changes
+	// of a concurrent update are not reported as changes of this one
+	var previousSettings, settings *portainer.Settings
+	var err error
+	// the non transactional path is deprecated, Test_updateSettings_noTx keeps both paths in line until its removal
 	if featureflags.IsEnabled(portainer.FeatureNoTx) {
-		settings, err = handler.updateSettings(handler.DataStore, payload)
+		previousSettings, settings, err = handler.updateSettingsWithPrevious(handler.DataStore, payload)
 	} else {
 		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
-			settings, err = handler.updateSettings(tx, payload)
+			previousSettings, settings, err = handler.updateSettingsWithPrevious(tx, payload)
 			return err
 		})
 	}
@@ -138,8 +697,150 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	// the JWT service is only updated once the settings are persisted, a failed update must not change the sessions
+	if payload.UserSessionTimeout != nil {
+		userSessionDuration, _ := time.ParseDuration(settings.UserSessionTimeout)
+		handler.JWTService.SetUserSessionDuration(userSessionDuration)
+		handler.JWTService.SetTokenIssueFloor(settings.TokenIssueFloor)
+	}
+
+	if settings.OutboundProxyURL != previousSettings.OutboundProxyURL {
+		err := client.SetOutboundProxy(settings.OutboundProxyURL)
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to apply the outbound proxy")
+		}
+	}
+
+	if settings.InternalAuthSettings.PasswordHashCost != previousSettings.InternalAuthSettings.PasswordHashCost {
+		handler.CryptoService.SetHashCost(settings.InternalAuthSettings.PasswordHashCost)
+	}
+
+	// the pooled connections were opened and bound with the previous LDAP settings
+	if !reflect.DeepEqual(settings.LDAPSettings, previousSettings.LDAPSettings) {
+		handler.LDAPService.ResetPool()
+	}
+
+	// the scheduler is only changed once the interval is persisted, the interval was validated with the payload
+	if settings.SnapshotInterval != previousSettings.SnapshotInterval {
+		err := handler.SnapshotService.SetSnapshotInterval(settings.SnapshotInterval)
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to apply the snapshot interval")
+		}
+	}
+
+	if settings.SnapshotQuietHours != previousSettings.SnapshotQuietHours {
+		err := handler.SnapshotService.SetSnapshotQuietHours(settings.SnapshotQuietHours)
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to apply the snapshot quiet hours")
+		}
+	}
+
+	if settings.SnapshotWorkerCount != previousSettings.SnapshotWorkerCount {
+		handler.SnapshotService.SetSnapshotWorkerCount(settings.SnapshotWorkerCount)
+	}
+
+	// the snapshot runs in the background of the snapshot service, the response only tells that it was scheduled
+	if payload.TriggerSnapshotNow {
+		handler.SnapshotService.SnapshotNow()
+	}
+
+	var changedFields []string
+	event, err := newSettingsChangeEvent(previousSettings, settings)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to compute the settings changes")
+	} else {
+		changedFields = event.changedFields()
+
+		if len(event.Changes) > 0 {
+			handler.events.publish(event)
+
+			// the delivery is retried in the background, a webhook failure never fails the update
+			if settings.SettingsChangeWebhookURL != "" {
+				go handler.notifySettingsChange(settings.SettingsChangeWebhookURL, event)
+			}
+		}
+	}
+
+	var warnings []string
+	if payload.SnapshotWorkerCount != nil && *payload.SnapshotWorkerCount > MaxSnapshotWorkerCount {
+		warnings = append(warnings, fmt.Sprintf("The snapshot worker count was lowered to %d to avoid overwhelming the environments", MaxSnapshotWorkerCount))
+	}
+
+	if payload.EdgeAgentCheckinInterval != nil {
+		warning, err := handler.edgeCheckinLoadWarning(settings.EdgeAgentCheckinInterval)
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to estimate the load of the edge agent check-ins")
+		} else if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// the probe runs once the settings are saved so that a slow edge URL does not hold the transaction
+	if checkEdgeURL && payload.EdgePortainerURL != nil && *payload.EdgePortainerURL != "" {
+		ctx, cancel := handler.validationContext(payload.ctx)
+		err := probeEdgePortainerURL(ctx, client.NewGuardedHTTPClient(handler.validationTimeout()), *payload.EdgePortainerURL)
+		cancel()
+		if isValidationTimeout(err) {
+			warnings = append(warnings, fmt.Sprintf("Edge agents may not be able to reach Portainer at %s: no answer within %s", *payload.EdgePortainerURL, handler.validationTimeout()))
+		} else if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Edge agents may not be able to reach Portainer at %s: %s", *payload.EdgePortainerURL, err))
+		}
+	}
+
+	caCertificate := handler.ldapCACertificate(settings)
+
 	hideFields(settings)
-	return response.JSON(w, settings)
+
+	w.Header().Set(changedFieldsHeader, strings.Join(changedFields, ","))
+	writeSettingsETag(w, settings)
+
+	if verbose {
+		return response.JSON(w, settingsUpdateVerboseResponse{Settings: settings, ChangedFields: changedFields, Warnings: warnings, SnapshotScheduled: payload.TriggerSnapshotNow, LDAPCACertificate: caCertificate})
+	}
+
+	return response.JSON(w, settingsUpdateResponse{Settings: settings, Warnings: warnings, SnapshotScheduled: payload.TriggerSnapshotNow, LDAPCACertificate: caCertificate})
+}
+
+func (handler *Handler) updateSettingsWithPrevious(tx dataservices.DataStoreTx, payload settingsUpdatePayload) (previous, current *portainer.Settings, err error) {
+	previous, err = tx.Settings().Settings()
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	current, err = handler.updateSettings(tx, payload)
+
+	return previous, current, err
+}
+
+// settingsUpdateDryRun runs the update in a transaction that is always rolled back
+func (handler *Handler) settingsUpdateDryRun(w http.ResponseWriter, previousSettings *portainer.Settings, payload settingsUpdatePayload) *httperror.HandlerError {
+	var settings *portainer.Settings
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		var err error
+		settings, err = handler.updateSettings(tx, payload)
+		if err != nil {
+			return err
+		}
+
+		return errSettingsDryRun
+	})
+
+	if err != nil && !errors.Is(err, errSettingsDryRun) {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	event, err := newSettingsChangeEvent(previousSettings, settings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compare the settings", err)
+	}
+
+	hideFields(settings)
+	return response.JSON(w, settingsDryRunResponse{Settings: settings, Changes: event.Changes})
 }
 
 func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload settingsUpdatePayload) (*portainer.Settings, error) {
@@ -148,16 +849,62 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
 	}
 
+	// the revision is read in the transaction of the update, so that a concurrent update cannot slip in between
+	if payload.ifMatch != "" && !matchesSettingsETag(payload.ifMatch, settings) {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusPreconditionFailed, Message: "The settings were updated since they were read. Reload them and retry", Err: errSettingsRevisionMismatch, Code: httperrors.CodeSettingsRevisionMismatch}
+	}
+
 	if handler.demoService.IsDemo() {
 		payload.EnableTelemetry = nil
 		payload.LogoURL = nil
 	}
 
-	if payload.AuthenticationMethod != nil {
+	if payload.AuthenticationMethod != nil && portainer.AuthenticationMethod(*payload.AuthenticationMethod) != settings.AuthenticationMethod {
+		now := time.Now()
+
+		if !payload.OverrideAuthenticationMethodCooldown {
+			err := checkAuthenticationMethodCooldown(settings, now)
+			if err != nil {
+				return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The authentication method was changed too recently", Err: err, Code: httperrors.CodeAuthMethodChangeCooldown}
+			}
+		}
+
+		// the entry is written in the transaction of the update so that the change cannot be saved without it
+		err = tx.AuditLog().Create(&portainer.AuditLogEntry{
+			Action:    portainer.AuditLogAuthenticationMethodChange,
+			UserID:    payload.userID,
+			Timestamp: now.Unix(),
+			Before:    authenticationMethodName(settings.AuthenticationMethod),
+			After:     authenticationMethodName(portainer.AuthenticationMethod(*payload.AuthenticationMethod)),
+		})
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to record the authentication method change inside the audit log", err)
+		}
+
 		settings.AuthenticationMethod = portainer.AuthenticationMethod(*payload.AuthenticationMethod)
+		settings.AuthenticationMethodChangedAt = now.Unix()
+	}
+
+	if payload.AuthenticationMethodChangeCooldown != nil {
+		settings.AuthenticationMethodChangeCooldown = *payload.AuthenticationMethodChangeCooldown
+	}
+
+	if payload.EnforceLogoURLImage != nil {
+		settings.EnforceLogoURLImage = *payload.EnforceLogoURLImage
 	}
 
 	if payload.LogoURL != nil {
+		if settings.EnforceLogoURLImage && *payload.LogoURL != "" && *payload.LogoURL != uploadedLogoURL && *payload.LogoURL != settings.LogoURL {
+			ctx, cancel := handler.validationContext(payload.ctx)
+			err := validateLogoURLImage(ctx, client.NewGuardedHTTPClient(handler.validationTimeout()), *payload.LogoURL)
+			cancel()
+			if isValidationTimeout(err) {
+				return nil, handler.validationTimeoutError("logo URL", err)
+			} else if err != nil {
+				return nil, httperror.BadRequest("Invalid logo URL. Must point to an image", err).WithCode(httperrors.CodeLogoURLInvalid)
+			}
+		}
+
 		settings.LogoURL = *payload.LogoURL
 	}
 
@@ -165,66 +912,89 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.TemplatesURL = *payload.TemplatesURL
 	}
 
+	if payload.UseLocalTemplatesFile != nil {
+		if *payload.UseLocalTemplatesFile && !settings.UseLocalTemplatesFile {
+			_, err := handler.FileService.GetTemplatesFile()
+			if err != nil {
+				return nil, httperror.BadRequest("No templates file was uploaded. Upload a templates file before enabling it", err).WithCode(httperrors.CodeTemplatesFileMissing)
+			}
+		}
+
+		settings.UseLocalTemplatesFile = *payload.UseLocalTemplatesFile
+	}
+
 	if payload.ShowKomposeBuildOption != nil {
 		settings.ShowKomposeBuildOption = *payload.ShowKomposeBuildOption
 	}
 
-	if payload.HelmRepositoryURL != nil {
-		if *payload.HelmRepositoryURL != "" {
+	if payload.HelmRepositoryURLs != nil || payload.HelmRepositoryURL != nil {
+		urls := payload.HelmRepositoryURLs
+		if urls == nil {
+			urls = replaceFirstHelmRepositoryURL(currentHelmRepositoryURLs(settings), *payload.HelmRepositoryURL)
+		}
+
+		urls = normalizeHelmRepositoryURLs(urls)
 
-			newHelmRepo := strings.TrimSuffix(strings.ToLower(*payload.HelmRepositoryURL), "/")
+		current := currentHelmRepositoryURLs(settings)
+		for _, url := range urls {
+			if slices.Contains(current, url) || url == portainer.DefaultHelmRepositoryURL {
+				continue
+			}
 
-			if newHelmRepo != settings.HelmRepositoryURL && newHelmRepo != portainer.DefaultHelmRepositoryURL {
-				err := libhelm.ValidateHelmRepositoryURL(*payload.HelmRepositoryURL, nil)
-				if err != nil {
-					return nil, httperror.BadRequest("Invalid Helm repository URL. Must correspond to a valid URL format", err)
-				}
+			err := handler.validateHelmRepositoryURL(payload.ctx, url, payload.revalidateHelm)
+			if isValidationTimeout(err) {
+				return nil, handler.validationTimeoutError("Helm repository "+url, err)
+			} else if err != nil {
+				return nil, httperror.BadRequest("Invalid Helm repository URL. Must correspond to a valid URL format", err).WithCode(httperrors.CodeHelmURLInvalid)
 			}
+		}
 
-			settings.HelmRepositoryURL = newHelmRepo
-		} else {
-			settings.HelmRepositoryURL = ""
+		settings.HelmRepositoryURLs = urls
+		settings.HelmRepositoryURL = ""
+		if len(urls) > 0 {
+			settings.HelmRepositoryURL = urls[0]
 		}
+		settings.HelmRepositoryType = helmRepositoryType(settings.HelmRepositoryURL)
 	}
 
 	if payload.BlackListedLabels != nil {
-		settings.BlackListedLabels = payload.BlackListedLabels
-	}
+		op := blackListedLabelsOpReplace
+		if payload.BlackListedLabelsOp != nil {
+			op = *payload.BlackListedLabelsOp
+		}
 
-	if payload.InternalAuthSettings != nil {
-		settings.InternalAuthSettings.RequiredPasswordLength = payload.InternalAuthSettings.RequiredPasswordLength
+		settings.BlackListedLabels = applyBlackListedLabels(settings.BlackListedLabels, payload.BlackListedLabels, op)
 	}
 
-	if payload.LDAPSettings != nil {
-		ldapReaderDN := settings.LDAPSettings.ReaderDN
-		ldapPassword := settings.LDAPSettings.Password
-
-		if payload.LDAPSettings.ReaderDN != "" {
-			ldapReaderDN = payload.LDAPSettings.ReaderDN
-		}
+	if payload.InternalAuthSettings != nil {
+		settings.InternalAuthSettings = mergeInternalAuthSettings(settings.InternalAuthSettings, *payload.InternalAuthSettings)
 
-		if payload.LDAPSettings.Password != "" {
-			ldapPassword = payload.LDAPSettings.Password
+		// the passphrase length is checked once merged, either length can be left out of the update
+		if passphraseMinLength := settings.InternalAuthSettings.PassphraseMinLength; passphraseMinLength != 0 && passphraseMinLength < settings.InternalAuthSettings.RequiredPasswordLength {
+			return nil, httperror.BadRequest("Invalid request payload", errors.New("Invalid passphrase minimum length. Must be 0 to disable the passphrase mode or at least the required password length")).WithCode(httperrors.CodePasswordPolicyInvalid)
 		}
+	}
 
-		settings.LDAPSettings = *payload.LDAPSettings
-		settings.LDAPSettings.ReaderDN = ldapReaderDN
-		settings.LDAPSettings.Password = ldapPassword
+	if payload.LDAPSettings != nil {
+		settings.LDAPSettings = mergeLDAPSettings(settings.LDAPSettings, *payload.LDAPSettings)
 	}
 
 	if payload.OAuthSettings != nil {
-		clientSecret := payload.OAuthSettings.ClientSecret
-		if clientSecret == "" {
-			clientSecret = settings.OAuthSettings.ClientSecret
+		settings.OAuthSettings = mergeOAuthSettings(settings.OAuthSettings, *payload.OAuthSettings)
+
+		// the code verifier is only exchanged with the authorization code, PKCE cannot protect another flow
+		oauthSettings := settings.OAuthSettings
+		if oauthSettings.UsePKCE && (oauthSettings.AuthorizationURI == "" || oauthSettings.AccessTokenURI == "") {
+			return nil, httperror.BadRequest("PKCE requires the authorization code flow, the authorization and access token URIs must be set", errPKCEWithoutAuthorizationCodeFlow)
 		}
+	}
 
-		kubeSecret := payload.OAuthSettings.KubeSecretKey
-		if kubeSecret == nil {
-			kubeSecret = settings.OAuthSettings.KubeSecretKey
+	// the settings of the external authentication are checked once merged, so that the fields already stored count
+	if payload.AuthenticationMethod != nil {
+		if missing := missingAuthenticationSettings(settings); len(missing) > 0 {
+			message := fmt.Sprintf("The %s authentication settings are incomplete, missing: %s", authenticationMethodName(settings.AuthenticationMethod), strings.Join(missing, ", "))
+			return nil, httperror.BadRequest(message, errIncompleteAuthenticationSettings)
 		}
-		settings.OAuthSettings = *payload.OAuthSettings
-		settings.OAuthSettings.ClientSecret = clientSecret
-		settings.OAuthSettings.KubeSecretKey = kubeSecret
 	}
 
 	if payload.EnableEdgeComputeFeatures != nil {
@@ -243,11 +1013,8 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.EdgePortainerURL = *payload.EdgePortainerURL
 	}
 
-	if payload.SnapshotInterval != nil && *payload.SnapshotInterval != settings.SnapshotInterval {
-		err := handler.updateSnapshotInterval(settings, *payload.SnapshotInterval)
-		if err != nil {
-			return nil, httperror.InternalServerError("Unable to update snapshot interval", err)
-		}
+	if payload.SnapshotInterval != nil {
+		settings.SnapshotInterval = *payload.SnapshotInterval
 	}
 
 	if payload.EdgeAgentCheckinInterval != nil {
@@ -259,26 +1026,123 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 	}
 
 	if payload.UserSessionTimeout != nil {
-		settings.UserSessionTimeout = *payload.UserSessionTimeout
-
+		previousUserSessionDuration, _ := time.ParseDuration(settings.UserSessionTimeout)
 		userSessionDuration, _ := time.ParseDuration(*payload.UserSessionTimeout)
 
-		handler.JWTService.SetUserSessionDuration(userSessionDuration)
+		settings.UserSessionTimeout = *payload.UserSessionTimeout
+		if payload.EnforceUserSessionTimeout && userSessionDuration < previousUserSessionDuration {
+			settings.TokenIssueFloor = time.Now().Unix()
+		}
 	}
 
 	if payload.EnableTelemetry != nil {
 		settings.EnableTelemetry = *payload.EnableTelemetry
 	}
 
-	err = handler.updateTLS(settings)
-	if err != nil {
-		return nil, err
+	if payload.dryRun {
+		settings.LDAPSettings.TLSConfig.TLSCACertPath = handler.ldapCACertPath(&settings.LDAPSettings)
+	} else {
+		err = handler.updateTLS(settings)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if payload.KubectlShellImage != nil {
 		settings.KubectlShellImage = *payload.KubectlShellImage
 	}
 
+	if payload.MaxAPIKeysPerUser != nil {
+		settings.MaxAPIKeysPerUser = *payload.MaxAPIKeysPerUser
+	}
+
+	if payload.TeamLeadersManageRegistryAccess != nil {
+		settings.TeamLeadersManageRegistryAccess = *payload.TeamLeadersManageRegistryAccess
+	}
+
+	if payload.LDAPCAExpiryWarningDays != nil {
+		settings.LDAPCAExpiryWarningDays = *payload.LDAPCAExpiryWarningDays
+	}
+
+	if payload.LDAPCAExpiryWebhookURL != nil {
+		settings.LDAPCAExpiryWebhookURL = *payload.LDAPCAExpiryWebhookURL
+	}
+
+	if payload.MaxRegistryAccessPolicies != nil {
+		settings.MaxRegistryAccessPolicies = *payload.MaxRegistryAccessPolicies
+	}
+
+	if payload.MaxRegistryAccessNamespaces != nil {
+		settings.MaxRegistryAccessNamespaces = *payload.MaxRegistryAccessNamespaces
+	}
+
+	if payload.PasswordChangeReauthenticationWindow != nil {
+		settings.PasswordChangeReauthenticationWindow = *payload.PasswordChangeReauthenticationWindow
+	}
+
+	if payload.AllowLastAdminSelfDemotion != nil {
+		settings.AllowLastAdminSelfDemotion = *payload.AllowLastAdminSelfDemotion
+	}
+
+	if payload.DisableRegistrySecretRefresh != nil {
+		settings.DisableRegistrySecretRefresh = *payload.DisableRegistrySecretRefresh
+	}
+
+	if payload.EnableLocalAdminFallback != nil {
+		settings.EnableLocalAdminFallback = *payload.EnableLocalAdminFallback
+	}
+
+	if payload.SnapshotWorkerCount != nil {
+		settings.SnapshotWorkerCount = min(*payload.SnapshotWorkerCount, MaxSnapshotWorkerCount)
+	}
+
+	if payload.SettingsChangeWebhookURL != nil {
+		settings.SettingsChangeWebhookURL = *payload.SettingsChangeWebhookURL
+	}
+
+	if payload.MaxLoginAttempts != nil {
+		settings.MaxLoginAttempts = *payload.MaxLoginAttempts
+	}
+
+	if payload.LoginLockoutDuration != nil {
+		settings.LoginLockoutDuration = *payload.LoginLockoutDuration
+	}
+
+	if payload.LoginLockoutExemptAdmins != nil {
+		settings.LoginLockoutExemptAdmins = *payload.LoginLockoutExemptAdmins
+	}
+
+	if payload.SnapshotQuietHours != nil {
+		settings.SnapshotQuietHours = *payload.SnapshotQuietHours
+	}
+
+	if payload.RequireTwoFactorForAdmins != nil {
+		if *payload.RequireTwoFactorForAdmins && !settings.RequireTwoFactorForAdmins {
+			unenrolled, err := unenrolledAdministrators(tx)
+			if err != nil {
+				return nil, httperror.InternalServerError("Unable to retrieve the administrators from the database", err)
+			}
+
+			// the administrators without a second factor could no longer change their password
+			if unenrolled > 0 {
+				return nil, httperror.BadRequest(fmt.Sprintf("%d administrators have not enrolled a second factor, it cannot be required yet", unenrolled), errTwoFactorNotEnrolled)
+			}
+		}
+
+		settings.RequireTwoFactorForAdmins = *payload.RequireTwoFactorForAdmins
+	}
+
+	if payload.DisabledFeatures != nil {
+		disabledFeatures := slices.Clone(payload.DisabledFeatures)
+		slices.Sort(disabledFeatures)
+		settings.DisabledFeatures = slices.Compact(disabledFeatures)
+	}
+
+	if payload.OutboundProxyURL != nil && *payload.OutboundProxyURL != settings.OutboundProxyURL {
+		// the proxy of the outbound requests is only replaced once the settings are persisted
+		settings.OutboundProxyURL = *payload.OutboundProxyURL
+	}
+
 	err = tx.Settings().UpdateSettings(settings)
 	if err != nil {
 		return nil, httperror.InternalServerError("Unable to persist settings changes inside the database", err)
@@ -287,21 +1151,176 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 	return settings, nil
 }
 
-func (handler *Handler) updateSnapshotInterval(settings *portainer.Settings, snapshotInterval string) error {
-	settings.SnapshotInterval = snapshotInterval
+// mergeLDAPSettings replaces the LDAP settings while keeping the reader credentials and the group search settings
+// when they are omitted from the update. An empty list of group search settings removes them.
+func mergeLDAPSettings(current, update portainer.LDAPSettings) portainer.LDAPSettings {
+	merged := update
+
+	if update.ReaderDN == "" {
+		merged.ReaderDN = current.ReaderDN
+	}
+
+	if update.Password == "" {
+		merged.Password = current.Password
+	}
+
+	if update.GroupSearchSettings == nil {
+		merged.GroupSearchSettings = current.GroupSearchSettings
+	}
+
+	return merged
+}
+
+// applyBlackListedLabels returns the black listed labels after applying the operation, appended pairs are
+// de-duplicated on name and value and removing a missing pair is a no-op
+func applyBlackListedLabels(current, labels []portainer.Pair, op string) []portainer.Pair {
+	switch op {
+	case blackListedLabelsOpAppend:
+		result := append([]portainer.Pair{}, current...)
+		for _, label := range labels {
+			if !slices.Contains(result, label) {
+				result = append(result, label)
+			}
+		}
+
+		return result
+	case blackListedLabelsOpRemove:
+		result := []portainer.Pair{}
+		for _, label := range current {
+			if !slices.Contains(labels, label) {
+				result = append(result, label)
+			}
+		}
+
+		return result
+	}
 
-	return handler.SnapshotService.SetSnapshotInterval(snapshotInterval)
+	return labels
 }
 
-func (handler *Handler) updateTLS(settings *portainer.Settings) error {
-	if (settings.LDAPSettings.TLSConfig.TLS || settings.LDAPSettings.StartTLS) && !settings.LDAPSettings.TLSConfig.TLSSkipVerify {
+// mergeInternalAuthSettings applies the fields provided in the update to the current internal authentication settings
+func mergeInternalAuthSettings(current portainer.InternalAuthSettings, update internalAuthSettingsPayload) portainer.InternalAuthSettings {
+	merged := current
+
+	setInt := func(field *int, value *int) {
+		if value != nil {
+			*field = *value
+		}
+	}
+
+	setInt(&merged.RequiredPasswordLength, update.RequiredPasswordLength)
+	setInt(&merged.PasswordHistoryDepth, update.PasswordHistoryDepth)
+	setInt(&merged.PasswordChangeMaxFailedAttempts, update.PasswordChangeMaxFailedAttempts)
+	setInt(&merged.PasswordExpiryDays, update.PasswordExpiryDays)
+	setInt(&merged.PassphraseMinLength, update.PassphraseMinLength)
+	setInt(&merged.PasswordHashCost, update.PasswordHashCost)
+
+	if update.RejectTemporaryPasswordReuse != nil {
+		merged.RejectTemporaryPasswordReuse = *update.RejectTemporaryPasswordReuse
+	}
+
+	if update.PasswordChangeLockoutDuration != nil {
+		merged.PasswordChangeLockoutDuration = *update.PasswordChangeLockoutDuration
+	}
+
+	return merged
+}
+
+// mergeOAuthSettings applies the fields provided in the update to the current OAuth settings
+func mergeOAuthSettings(current portainer.OAuthSettings, update oauthSettingsPayload) portainer.OAuthSettings {
+	merged := current
+
+	setString := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+
+	setString(&merged.ClientID, update.ClientID)
+	setString(&merged.AccessTokenURI, update.AccessTokenURI)
+	setString(&merged.AuthorizationURI, update.AuthorizationURI)
+	setString(&merged.ResourceURI, update.ResourceURI)
+	setString(&merged.RedirectURI, update.RedirectURI)
+	setString(&merged.UserIdentifier, update.UserIdentifier)
+	setString(&merged.Scopes, update.Scopes)
+	setString(&merged.LogoutURI, update.LogoutURI)
+
+	if update.ClientSecret != nil && *update.ClientSecret != "" {
+		merged.ClientSecret = *update.ClientSecret
+	}
+
+	if update.UsePKCE != nil {
+		merged.UsePKCE = *update.UsePKCE
+	}
+
+	if update.PKCEChallengeMethod != nil && *update.PKCEChallengeMethod != "" {
+		merged.PKCEChallengeMethod = *update.PKCEChallengeMethod
+	}
+
+	if merged.UsePKCE && merged.PKCEChallengeMethod == "" {
+		merged.PKCEChallengeMethod = portainer.OAuthPKCEMethodS256
+	}
+
+	if update.OAuthAutoCreateUsers != nil {
+		merged.OAuthAutoCreateUsers = *update.OAuthAutoCreateUsers
+	}
+
+	if update.DefaultTeamID != nil {
+		merged.DefaultTeamID = *update.DefaultTeamID
+	}
+
+	if update.SSO != nil {
+		merged.SSO = *update.SSO
+	}
+
+	if update.KubeSecretKey != nil {
+		merged.KubeSecretKey = update.KubeSecretKey
+	}
+
+	if update.AllowedRedirectURIs != nil {
+		merged.AllowedRedirectURIs = slices.Clone(update.AllowedRedirectURIs)
+	}
+
+	return merged
+}
+
+// checkAuthenticationMethodCooldown returns an error when the authentication method was changed within the configured cooldown
+func checkAuthenticationMethodCooldown(settings *portainer.Settings, now time.Time) error {
+	if settings.AuthenticationMethodChangeCooldown == "" || settings.AuthenticationMethodChangedAt == 0 {
+		return nil
+	}
+
+	cooldown, err := time.ParseDuration(settings.AuthenticationMethodChangeCooldown)
+	if err != nil || cooldown <= 0 {
+		return nil
+	}
+
+	nextChange := time.Unix(settings.AuthenticationMethodChangedAt, 0).Add(cooldown)
+	if now.Before(nextChange) {
+		return fmt.Errorf("the authentication method cannot be changed before %s", nextChange.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// ldapCACertPath returns the path of the uploaded LDAP CA certificate, or an empty path when the LDAP settings
+// do not verify the certificate of the server
+func (handler *Handler) ldapCACertPath(ldapSettings *portainer.LDAPSettings) string {
+	if (ldapSettings.TLSConfig.TLS || ldapSettings.StartTLS) && !ldapSettings.TLSConfig.TLSSkipVerify {
 		caCertPath, _ := handler.FileService.GetPathForTLSFile(filesystem.LDAPStorePath, portainer.TLSFileCA)
-		settings.LDAPSettings.TLSConfig.TLSCACertPath = caCertPath
 
+		return caCertPath
+	}
+
+	return ""
+}
+
+func (handler *Handler) updateTLS(settings *portainer.Settings) error {
+	settings.LDAPSettings.TLSConfig.TLSCACertPath = handler.ldapCACertPath(&settings.LDAPSettings)
+	if settings.LDAPSettings.TLSConfig.TLSCACertPath != "" {
 		return nil
 	}
 
-	settings.LDAPSettings.TLSConfig.TLSCACertPath = ""
 	err := handler.FileService.DeleteTLSFiles(filesystem.LDAPStorePath)
 	if err != nil {
 		return httperror.InternalServerError("Unable to remove TLS files from disk", err)
@@ -309,3 +1328,142 @@ func (handler *Handler) updateTLS(settings *portainer.Settings) error {
 
 	return nil
 }
+
+// missingAuthenticationSettings returns the JSON paths of the fields required by the LDAP or OAuth authentication
+// that are not set, so that enabling it does not lock every user out
+func missingAuthenticationSettings(settings *portainer.Settings) []string {
+	var missing []string
+
+	switch settings.AuthenticationMethod {
+	case portainer.AuthenticationLDAP:
+		if settings.LDAPSettings.URL == "" {
+			missing = append(missing, "LDAPSettings.URL")
+		}
+
+		if !settings.LDAPSettings.AnonymousMode && settings.LDAPSettings.ReaderDN == "" {
+			missing = append(missing, "LDAPSettings.ReaderDN")
+		}
+	case portainer.AuthenticationOAuth:
+		for _, field := range []struct{ name, value string }{
+			{"OAuthSettings.AuthorizationURI", settings.OAuthSettings.AuthorizationURI},
+			{"OAuthSettings.AccessTokenURI", settings.OAuthSettings.AccessTokenURI},
+			{"OAuthSettings.ResourceURI", settings.OAuthSettings.ResourceURI},
+			{"OAuthSettings.ClientID", settings.OAuthSettings.ClientID},
+		} {
+			if field.value == "" {
+				missing = append(missing, field.name)
+			}
+		}
+	}
+
+	return missing
+}
+
+func authenticationMethodName(method portainer.AuthenticationMethod) string {
+	switch method {
+	case portainer.AuthenticationInternal:
+		return "internal"
+	case portainer.AuthenticationLDAP:
+		return "ldap"
+	case portainer.AuthenticationOAuth:
+		return "oauth"
+	}
+
+	return strconv.Itoa(int(method))
+}
+
+// validateHelmRepositoryURL validates the Helm repository unless it was validated successfully in the last minutes,
+// failures are not cached so that a repository is validated again once fixed
+func (handler *Handler) validateHelmRepositoryURL(ctx context.Context, url string, revalidate bool) error {
+	if _, validated := handler.helmRepositories.Get(url); validated && !revalidate {
+		return nil
+	}
+
+	ctx, cancel := handler.validationContext(ctx)
+	defer cancel()
+
+	err := libhelm.ValidateHelmRepositoryURLWithContext(ctx, url, handler.helmRepositoryClient(handler.validationTimeout()))
+	if err != nil {
+		return err
+	}
+
+	handler.helmRepositories.SetDefault(url, struct{}{})
+
+	return nil
+}
+
+// currentHelmRepositoryURLs returns the stored Helm repository URLs, settings saved before the support
+// of several repositories only hold the singular URL
+func currentHelmRepositoryURLs(settings *portainer.Settings) []string {
+	if len(settings.HelmRepositoryURLs) == 0 && settings.HelmRepositoryURL != "" {
+		return []string{settings.HelmRepositoryURL}
+	}
+
+	return settings.HelmRepositoryURLs
+}
+
+// replaceFirstHelmRepositoryURL applies an update of the singular Helm repository URL to the list,
+// an empty URL removes the first repository
+func replaceFirstHelmRepositoryURL(urls []string, url string) []string {
+	urls = slices.Clone(urls)
+
+	switch {
+	case url == "" && len(urls) > 0:
+		return urls[1:]
+	case url == "":
+		return urls
+	case len(urls) == 0:
+		return []string{url}
+	}
+
+	urls[0] = url
+	return urls
+}
+
+// normalizeHelmRepositoryURLs lowercases the URLs, trims their trailing slash and removes the duplicates.
+// Only the scheme and the host of the OCI URLs are lowercased, the path is the name of the registry repository
+func normalizeHelmRepositoryURLs(urls []string) []string {
+	normalized := make([]string, 0, len(urls))
+	for _, url := range urls {
+		url = strings.TrimSuffix(normalizeHelmRepositoryURLCase(url), "/")
+		if url != "" && !slices.Contains(normalized, url) {
+			normalized = append(normalized, url)
+		}
+	}
+
+	return normalized
+}
+
+func normalizeHelmRepositoryURLCase(repositoryURL string) string {
+	if !libhelm.IsOCIRepositoryURL(repositoryURL) {
+		return strings.ToLower(repositoryURL)
+	}
+
+	u, err := libhelm.ParseOCIRepositoryURL(repositoryURL)
+	if err != nil {
+		return repositoryURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	return u.String()
+}
+
+// unenrolledAdministrators returns the number of administrators with a local password that have not enrolled a
+// second factor, the administrators authenticated by an external provider have no local password to change
+func unenrolledAdministrators(tx dataservices.DataStoreTx) (int, error) {
+	admins, err := tx.User().UsersByRole(portainer.AdministratorRole)
+	if err != nil {
+		return 0, err
+	}
+
+	unenrolled := 0
+	for _, admin := range admins {
+		if admin.Password != "" && admin.TOTPSecret == "" {
+			unenrolled++
+		}
+	}
+
+	return unenrolled, nil
+}
@@ -0,0 +1,1227 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/client"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	ldapservice "github.com/portainer/portainer/api/ldap"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_checkAuthenticationMethodCooldown(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+
+	settings := &portainer.Settings{AuthenticationMethodChangedAt: now.Add(-30 * time.Minute).Unix()}
+	is.NoError(checkAuthenticationMethodCooldown(settings, now), "cooldown is disabled by default")
+
+	settings.AuthenticationMethodChangeCooldown = "1h"
+	is.Error(checkAuthenticationMethodCooldown(settings, now))
+	is.NoError(checkAuthenticationMethodCooldown(settings, now.Add(31*time.Minute)))
+
+	settings.AuthenticationMethodChangedAt = 0
+	is.NoError(checkAuthenticationMethodCooldown(settings, now), "method was never changed")
+}
+
+func Test_settingsUpdatePayload_edgeEnforceHTTPS(t *testing.T) {
+	is := assert.New(t)
+
+	edgeURL := "http://portainer.example.com:9443"
+	payload := settingsUpdatePayload{EdgePortainerURL: &edgeURL}
+	is.NoError(payload.Validate(nil), "http is accepted by default")
+
+	payload.edgeEnforceHTTPS = true
+	is.Error(payload.Validate(nil))
+
+	edgeURL = "https://portainer.example.com:9443"
+	is.NoError(payload.Validate(nil))
+}
+
+func Test_mergeLDAPSettings(t *testing.T) {
+	is := assert.New(t)
+
+	current := portainer.LDAPSettings{
+		ReaderDN:            "cn=reader",
+		Password:            "secret",
+		URL:                 "ldap.local:389",
+		GroupSearchSettings: []portainer.LDAPGroupSearchSettings{{GroupBaseDN: "ou=groups", GroupAttribute: "member"}},
+	}
+
+	merged := mergeLDAPSettings(current, portainer.LDAPSettings{URL: "ldap.local:636"})
+	is.Equal("ldap.local:636", merged.URL)
+	is.Equal("cn=reader", merged.ReaderDN)
+	is.Equal("secret", merged.Password)
+	is.Equal(current.GroupSearchSettings, merged.GroupSearchSettings, "omitted group search settings are preserved")
+
+	update := []portainer.LDAPGroupSearchSettings{{GroupBaseDN: "ou=teams"}}
+	merged = mergeLDAPSettings(current, portainer.LDAPSettings{GroupSearchSettings: update})
+	is.Equal(update, merged.GroupSearchSettings)
+
+	merged = mergeLDAPSettings(current, portainer.LDAPSettings{GroupSearchSettings: []portainer.LDAPGroupSearchSettings{}})
+	is.Empty(merged.GroupSearchSettings, "an empty list removes the group search settings")
+}
+
+func Test_settingsUpdatePayload_snapshotInterval(t *testing.T) {
+	is := assert.New(t)
+
+	for _, interval := range []string{"1s", "59s", "25h"} {
+		payload := settingsUpdatePayload{SnapshotInterval: &interval}
+		is.Error(payload.Validate(nil), interval)
+	}
+
+	for _, interval := range []string{"1m", "5m", "24h"} {
+		payload := settingsUpdatePayload{SnapshotInterval: &interval}
+		is.NoError(payload.Validate(nil), interval)
+	}
+}
+
+func Test_settingsUpdatePayload_passphraseMinLength(t *testing.T) {
+	is := assert.New(t)
+
+	required := 12
+
+	for _, length := range []int{-1, 8} {
+		length := length
+		payload := settingsUpdatePayload{InternalAuthSettings: &internalAuthSettingsPayload{RequiredPasswordLength: &required, PassphraseMinLength: &length}}
+		is.Error(payload.Validate(nil), length)
+	}
+
+	for _, length := range []int{0, 12, 16} {
+		length := length
+		payload := settingsUpdatePayload{InternalAuthSettings: &internalAuthSettingsPayload{RequiredPasswordLength: &required, PassphraseMinLength: &length}}
+		is.NoError(payload.Validate(nil), length)
+	}
+}
+
+func Test_settingsUpdatePayload_passwordHashCost(t *testing.T) {
+	is := assert.New(t)
+
+	for _, cost := range []int{-1, 3, 32} {
+		cost := cost
+		payload := settingsUpdatePayload{InternalAuthSettings: &internalAuthSettingsPayload{PasswordHashCost: &cost}}
+		is.Error(payload.Validate(nil), cost)
+	}
+
+	for _, cost := range []int{0, 4, 12, 31} {
+		cost := cost
+		payload := settingsUpdatePayload{InternalAuthSettings: &internalAuthSettingsPayload{PasswordHashCost: &cost}}
+		is.NoError(payload.Validate(nil), cost)
+	}
+}
+
+func Test_settingsUpdatePayload_loginLockout(t *testing.T) {
+	is := assert.New(t)
+
+	attempts := func(v int) *int { return &v }
+	duration := func(v string) *string { return &v }
+
+	is.NoError((&settingsUpdatePayload{MaxLoginAttempts: attempts(5), LoginLockoutDuration: duration("30m")}).Validate(nil))
+	is.NoError((&settingsUpdatePayload{MaxLoginAttempts: attempts(0), LoginLockoutDuration: duration("")}).Validate(nil), "the lockout can be disabled")
+	is.Error((&settingsUpdatePayload{MaxLoginAttempts: attempts(-1)}).Validate(nil))
+	is.Error((&settingsUpdatePayload{LoginLockoutDuration: duration("soon")}).Validate(nil))
+	is.Error((&settingsUpdatePayload{LoginLockoutDuration: duration("-1m")}).Validate(nil))
+}
+
+func Test_settingsUpdatePayload_ldapPool(t *testing.T) {
+	is := assert.New(t)
+
+	pool := func(size int, idleTimeout string) *settingsUpdatePayload {
+		return &settingsUpdatePayload{LDAPSettings: &portainer.LDAPSettings{PoolSize: size, PoolIdleTimeout: idleTimeout}}
+	}
+
+	is.NoError(pool(0, "").Validate(nil), "the pool can be disabled")
+	is.NoError(pool(10, "10m").Validate(nil))
+	is.NoError(pool(ldapservice.MaxPoolSize, "").Validate(nil))
+	is.Error(pool(-1, "").Validate(nil))
+	is.Error(pool(ldapservice.MaxPoolSize+1, "").Validate(nil))
+	is.Error(pool(10, "later").Validate(nil))
+	is.Error(pool(10, "0s").Validate(nil))
+}
+
+func Test_settingsUpdatePayload_ldapTLSMinVersion(t *testing.T) {
+	is := assert.New(t)
+
+	minVersion := func(version string) *settingsUpdatePayload {
+		return &settingsUpdatePayload{LDAPSettings: &portainer.LDAPSettings{TLSConfig: portainer.TLSConfiguration{TLS: true}, TLSMinVersion: version}}
+	}
+
+	is.NoError(minVersion("").Validate(nil))
+	is.NoError(minVersion("1.2").Validate(nil))
+	is.NoError(minVersion("1.3").Validate(nil))
+
+	for _, version := range []string{"1.0", "1.1", "1.4", "TLS1.3", "tls13"} {
+		err := minVersion(version).Validate(nil)
+		is.Error(err, version)
+		is.Equal(httperrors.CodeLDAPTLSVersionInvalid, httperror.ErrorCode(&httperror.HandlerError{Err: err}))
+	}
+}
+
+func Test_settingsUpdatePayload_snapshotQuietHours(t *testing.T) {
+	is := assert.New(t)
+
+	quietHours := func(start, end, timezone string) *settingsUpdatePayload {
+		return &settingsUpdatePayload{SnapshotQuietHours: &portainer.SnapshotQuietHours{Start: start, End: end, Timezone: timezone}}
+	}
+
+	is.NoError(quietHours("", "", "").Validate(nil), "the quiet hours can be disabled")
+	is.NoError(quietHours("22:00", "06:00", "America/New_York").Validate(nil))
+
+	err := quietHours("22:00", "22:00", "").Validate(nil)
+	is.Error(err)
+	is.Equal(httperrors.CodeSnapshotQuietHoursInvalid, httperror.ErrorCode(&httperror.HandlerError{Err: err}))
+}
+
+func Test_settingsUpdatePayload_allowedRedirectURIs(t *testing.T) {
+	is := assert.New(t)
+
+	redirectURIs := func(uris ...string) *settingsUpdatePayload {
+		return &settingsUpdatePayload{OAuthSettings: &oauthSettingsPayload{AllowedRedirectURIs: uris}}
+	}
+
+	is.NoError(redirectURIs().Validate(nil))
+	is.NoError(redirectURIs("https://portainer.mydomain.tld", "http://localhost:9000").Validate(nil))
+
+	err := redirectURIs("https://portainer.mydomain.tld", "/auth").Validate(nil)
+	is.Error(err)
+	is.Equal(httperrors.CodeOAuthRedirectURIInvalid, httperror.ErrorCode(&httperror.HandlerError{Err: err}))
+
+	merged := mergeOAuthSettings(portainer.OAuthSettings{AllowedRedirectURIs: []string{"https://portainer.mydomain.tld"}}, oauthSettingsPayload{})
+	is.Equal([]string{"https://portainer.mydomain.tld"}, merged.AllowedRedirectURIs, "omitted URIs are kept")
+
+	merged = mergeOAuthSettings(merged, oauthSettingsPayload{AllowedRedirectURIs: []string{}})
+	is.Empty(merged.AllowedRedirectURIs, "an empty list clears the URIs")
+}
+
+func Test_settingsUpdatePayload_edgeAgentCheckinInterval(t *testing.T) {
+	is := assert.New(t)
+
+	interval := func(v int) *settingsUpdatePayload { return &settingsUpdatePayload{EdgeAgentCheckinInterval: &v} }
+
+	is.NoError(interval(MinEdgeAgentCheckinInterval).Validate(nil))
+	is.NoError(interval(MaxEdgeAgentCheckinInterval).Validate(nil))
+	is.Error(interval(0).Validate(nil))
+	is.Error(interval(-5).Validate(nil))
+	is.Error(interval(MaxEdgeAgentCheckinInterval + 1).Validate(nil))
+}
+
+func Test_settingsUpdate_requireTwoFactorForAdmins(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.User().Create(&portainer.User{Username: "enrolled", Role: portainer.AdministratorRole, Password: "hash", TOTPSecret: "GEZDGNBVGY3TQOJQ"}))
+	is.NoError(store.User().Create(&portainer.User{Username: "user", Role: portainer.StandardUserRole, Password: "hash"}))
+	// the administrators without a local password are authenticated by the external provider
+	is.NoError(store.User().Create(&portainer.User{Username: "external", Role: portainer.AdministratorRole}))
+	unenrolled := &portainer.User{Username: "unenrolled", Role: portainer.AdministratorRole, Password: "hash"}
+	is.NoError(store.User().Create(unenrolled))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	requireTwoFactor := func() *httperror.HandlerError {
+		return h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", []byte(`{"RequireTwoFactorForAdmins": true}`)))
+	}
+
+	handlerErr := requireTwoFactor()
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode)
+	is.ErrorIs(handlerErr.Err, errTwoFactorNotEnrolled)
+	is.Contains(handlerErr.Message, "1 administrators")
+
+	unenrolled.TOTPSecret = "GEZDGNBVGY3TQOJQ"
+	is.NoError(store.User().Update(unenrolled.ID, unenrolled))
+
+	is.Nil(requireTwoFactor())
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.True(settings.RequireTwoFactorForAdmins)
+}
+
+func Test_settingsUpdatePayload_Validate_userSessionTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		max     time.Duration
+		valid   bool
+	}{
+		{timeout: "1s", valid: true},
+		{timeout: "8h", valid: true},
+		{timeout: "720h", valid: true},
+		{timeout: "720h0m1s", valid: false},
+		{timeout: "87600h", valid: false},
+		{timeout: "0s", valid: false},
+		{timeout: "0", valid: false},
+		{timeout: "-1h", valid: false},
+		{timeout: "24h", max: 24 * time.Hour, valid: true},
+		{timeout: "25h", max: 24 * time.Hour, valid: false},
+		{timeout: "1000h", max: 1000 * time.Hour, valid: true},
+	}
+
+	for _, test := range tests {
+		payload := settingsUpdatePayload{UserSessionTimeout: &test.timeout, maxUserSessionTimeout: test.max}
+
+		err := payload.Validate(nil)
+		if test.valid {
+			assert.NoError(t, err, "%s with a maximum of %s", test.timeout, test.max)
+		} else {
+			assert.Error(t, err, "%s with a maximum of %s", test.timeout, test.max)
+		}
+	}
+}
+
+type snapshotServiceStub struct {
+	portainer.SnapshotService
+	err       error
+	interval  string
+	snapshots int
+	workers   int
+}
+
+func (service *snapshotServiceStub) SetSnapshotWorkerCount(count int) {
+	service.workers = count
+}
+
+func (service *snapshotServiceStub) SnapshotNow() {
+	service.snapshots++
+}
+
+func (service *snapshotServiceStub) SetSnapshotInterval(snapshotInterval string) error {
+	if service.err != nil {
+		return service.err
+	}
+
+	service.interval = snapshotInterval
+
+	return nil
+}
+
+func Test_settingsUpdate_internalAuthSettingsPartial(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings = portainer.InternalAuthSettings{
+		RequiredPasswordLength:          12,
+		RejectTemporaryPasswordReuse:    true,
+		PasswordHistoryDepth:            5,
+		PasswordChangeMaxFailedAttempts: 3,
+		PasswordChangeLockoutDuration:   "30m",
+		PasswordExpiryDays:              90,
+		PassphraseMinLength:             20,
+		PasswordHashCost:                bcrypt.MinCost,
+	}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.CryptoService = &crypto.Service{}
+
+	// the authentication page of the UI only sends the required password length
+	rr := httptest.NewRecorder()
+	handlerErr := h.settingsUpdate(rr, newSettingsUpdateRequest("/settings", []byte(`{"InternalAuthSettings": {"RequiredPasswordLength": 14}}`)))
+	is.Nil(handlerErr)
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(portainer.InternalAuthSettings{
+		RequiredPasswordLength:          14,
+		RejectTemporaryPasswordReuse:    true,
+		PasswordHistoryDepth:            5,
+		PasswordChangeMaxFailedAttempts: 3,
+		PasswordChangeLockoutDuration:   "30m",
+		PasswordExpiryDays:              90,
+		PassphraseMinLength:             20,
+		PasswordHashCost:                bcrypt.MinCost,
+	}, settings.InternalAuthSettings, "the omitted fields keep their value")
+
+	handlerErr = h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", []byte(`{"InternalAuthSettings": {"RequiredPasswordLength": 24}}`)))
+	is.NotNil(handlerErr, "the passphrase length is checked against the merged required length")
+	is.Equal(httperrors.CodePasswordPolicyInvalid, handlerErr.Code)
+}
+
+func Test_mergeOAuthSettings(t *testing.T) {
+	is := assert.New(t)
+
+	current := portainer.OAuthSettings{
+		ClientID:      "old-id",
+		ClientSecret:  "secret",
+		Scopes:        "openid profile",
+		SSO:           true,
+		KubeSecretKey: []byte("kube"),
+	}
+
+	clientID := "new-id"
+	merged := mergeOAuthSettings(current, oauthSettingsPayload{ClientID: &clientID})
+	is.Equal("new-id", merged.ClientID)
+	is.Equal("secret", merged.ClientSecret)
+	is.Equal("openid profile", merged.Scopes, "omitted scopes are preserved")
+	is.True(merged.SSO)
+	is.Equal([]byte("kube"), merged.KubeSecretKey)
+
+	sso, scopes, emptySecret := false, "", ""
+	merged = mergeOAuthSettings(current, oauthSettingsPayload{SSO: &sso, Scopes: &scopes, ClientSecret: &emptySecret})
+	is.False(merged.SSO, "provided values are applied even when empty")
+	is.Empty(merged.Scopes)
+	is.Equal("secret", merged.ClientSecret, "an empty client secret keeps the current one")
+}
+
+func Test_mergeOAuthSettings_pkce(t *testing.T) {
+	is := assert.New(t)
+
+	usePKCE, plain, emptyMethod := true, portainer.OAuthPKCEMethodPlain, ""
+
+	merged := mergeOAuthSettings(portainer.OAuthSettings{}, oauthSettingsPayload{UsePKCE: &usePKCE})
+	is.True(merged.UsePKCE)
+	is.Equal(portainer.OAuthPKCEMethodS256, merged.PKCEChallengeMethod, "S256 is used by default")
+
+	merged = mergeOAuthSettings(merged, oauthSettingsPayload{PKCEChallengeMethod: &plain})
+	is.Equal(portainer.OAuthPKCEMethodPlain, merged.PKCEChallengeMethod)
+
+	merged = mergeOAuthSettings(merged, oauthSettingsPayload{PKCEChallengeMethod: &emptyMethod})
+	is.Equal(portainer.OAuthPKCEMethodPlain, merged.PKCEChallengeMethod, "an empty method keeps the current one")
+	is.True(merged.UsePKCE, "omitted PKCE flag is preserved")
+
+	unknownMethod := "S512"
+	payload := settingsUpdatePayload{OAuthSettings: &oauthSettingsPayload{PKCEChallengeMethod: &unknownMethod}}
+	is.Error(payload.Validate(nil))
+}
+
+func Test_settingsUpdate_pkce(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	updateOAuthSettings := func(oauthSettings map[string]any) *httperror.HandlerError {
+		body, err := json.Marshal(map[string]any{"OAuthSettings": oauthSettings})
+		is.NoError(err)
+
+		return h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	}
+
+	is.Nil(updateOAuthSettings(map[string]any{
+		"UsePKCE":          true,
+		"AuthorizationURI": "https://oauth.mydomain.tld/authorize",
+		"AccessTokenURI":   "https://oauth.mydomain.tld/token",
+	}))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.True(settings.OAuthSettings.UsePKCE)
+	is.Equal(portainer.OAuthPKCEMethodS256, settings.OAuthSettings.PKCEChallengeMethod)
+
+	handlerErr := updateOAuthSettings(map[string]any{"AccessTokenURI": ""})
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode, "PKCE requires the authorization code flow")
+	is.ErrorIs(handlerErr.Err, errPKCEWithoutAuthorizationCodeFlow)
+}
+
+func Test_settingsUpdate_dryRun(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.SnapshotInterval = "5m"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	snapshotService := &snapshotServiceStub{}
+	h := &Handler{DataStore: store, SnapshotService: snapshotService, demoService: demo.NewService()}
+
+	body, err := json.Marshal(map[string]any{"SnapshotInterval": "10m"})
+	is.NoError(err)
+
+	rr := httptest.NewRecorder()
+	handlerErr := h.settingsUpdate(rr, newSettingsUpdateRequest("/settings?dryRun=true", body))
+	is.Nil(handlerErr)
+
+	var resp settingsDryRunResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Equal("10m", resp.Settings.SnapshotInterval)
+	is.Len(resp.Changes, 1)
+	is.Equal("SnapshotInterval", resp.Changes[0].Field)
+
+	is.Empty(snapshotService.interval, "the snapshot interval is not applied")
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal("5m", settings.SnapshotInterval, "the settings are not persisted")
+}
+
+func Test_applyBlackListedLabels(t *testing.T) {
+	is := assert.New(t)
+
+	current := []portainer.Pair{{Name: "env", Value: "prod"}, {Name: "team", Value: "a"}}
+
+	is.Equal([]portainer.Pair{{Name: "tier", Value: "db"}}, applyBlackListedLabels(current, []portainer.Pair{{Name: "tier", Value: "db"}}, blackListedLabelsOpReplace))
+
+	appended := applyBlackListedLabels(current, []portainer.Pair{{Name: "env", Value: "prod"}, {Name: "env", Value: "dev"}, {Name: "env", Value: "dev"}}, blackListedLabelsOpAppend)
+	is.Equal([]portainer.Pair{{Name: "env", Value: "prod"}, {Name: "team", Value: "a"}, {Name: "env", Value: "dev"}}, appended)
+
+	removed := applyBlackListedLabels(current, []portainer.Pair{{Name: "team", Value: "a"}, {Name: "missing", Value: "x"}}, blackListedLabelsOpRemove)
+	is.Equal([]portainer.Pair{{Name: "env", Value: "prod"}}, removed)
+
+	op := "merge"
+	payload := settingsUpdatePayload{BlackListedLabelsOp: &op}
+	is.Error(payload.Validate(nil))
+}
+
+func Test_validateKubectlShellImage(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(validateKubectlShellImage(portainer.DefaultKubectlShellImage, nil), "any image is accepted without an allowlist")
+	is.NoError(validateKubectlShellImage("registry.local/kubectl-shell:1.0", nil))
+	is.Error(validateKubectlShellImage("portainer/kubectl-shell:", nil), "the tag cannot be empty")
+	is.Error(validateKubectlShellImage("portainer/kubectl-shell@sha256:abc", nil), "the digest must be valid")
+
+	allowlist := parseImageAllowlist(" docker.io/portainer/ , registry.local/")
+	is.Equal([]string{"docker.io/portainer/", "registry.local/"}, allowlist)
+
+	is.NoError(validateKubectlShellImage("portainer/kubectl-shell:latest", allowlist), "the prefix matches the fully qualified name")
+	is.NoError(validateKubectlShellImage("registry.local/kubectl-shell", allowlist))
+	is.Error(validateKubectlShellImage("attacker/kubectl-shell", allowlist))
+}
+
+func Test_validateLDAPDistinguishedNames(t *testing.T) {
+	is := assert.New(t)
+
+	ldapSettings := portainer.LDAPSettings{
+		ReaderDN:            "cn=readonly-account,dc=ldap,dc=domain,dc=tld",
+		SearchSettings:      []portainer.LDAPSearchSettings{{BaseDN: "dc=ldap,dc=domain,dc=tld"}, {}},
+		GroupSearchSettings: []portainer.LDAPGroupSearchSettings{{GroupBaseDN: "ou=groups,dc=ldap,dc=domain,dc=tld"}},
+	}
+	is.NoError(validateLDAPDistinguishedNames(&ldapSettings), "empty DNs are not checked")
+
+	ldapSettings.GroupSearchSettings = append(ldapSettings.GroupSearchSettings, portainer.LDAPGroupSearchSettings{GroupBaseDN: "ou=groups,,dc=ldap"})
+	is.ErrorContains(validateLDAPDistinguishedNames(&ldapSettings), "LDAPSettings.GroupSearchSettings[1].GroupBaseDN")
+
+	ldapSettings.SearchSettings[1].BaseDN = "ou=users,dc"
+	is.ErrorContains(validateLDAPDistinguishedNames(&ldapSettings), "LDAPSettings.SearchSettings[1].BaseDN")
+
+	ldapSettings.ReaderDN = "readonly-account"
+	payload := settingsUpdatePayload{LDAPSettings: &ldapSettings}
+	is.ErrorContains(payload.Validate(nil), "LDAPSettings.ReaderDN")
+}
+
+func Test_settingsUpdate_helmRepositoryURLs(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.HelmRepositoryURL = "https://a.example.com"
+	settings.HelmRepositoryURLs = []string{"https://a.example.com", "https://b.example.com"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	updateSettings := func(payload map[string]any) *portainer.Settings {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+		is.Nil(handlerErr)
+
+		settings, err := store.Settings().Settings()
+		is.NoError(err)
+
+		return settings
+	}
+
+	settings = updateSettings(map[string]any{
+		"HelmRepositoryURL":  "https://a.example.com",
+		"HelmRepositoryURLs": []string{"https://B.example.com/", "https://b.example.com", portainer.DefaultHelmRepositoryURL},
+	})
+	is.Equal([]string{"https://b.example.com", portainer.DefaultHelmRepositoryURL}, settings.HelmRepositoryURLs, "the list wins, normalized and deduplicated")
+	is.Equal("https://b.example.com", settings.HelmRepositoryURL)
+
+	settings = updateSettings(map[string]any{"HelmRepositoryURL": portainer.DefaultHelmRepositoryURL + "/"})
+	is.Equal([]string{portainer.DefaultHelmRepositoryURL}, settings.HelmRepositoryURLs, "the singular URL replaces the first repository")
+	is.Equal(portainer.DefaultHelmRepositoryURL, settings.HelmRepositoryURL)
+
+	settings = updateSettings(map[string]any{"HelmRepositoryURLs": []string{}})
+	is.Empty(settings.HelmRepositoryURLs)
+	is.Empty(settings.HelmRepositoryURL)
+}
+
+func Test_validateHelmRepositoryScheme(t *testing.T) {
+	is := assert.New(t)
+
+	schemes := parseHelmRepositorySchemes("")
+	is.Equal([]string{"https", "oci"}, schemes)
+
+	is.NoError(validateHelmRepositoryScheme("https://charts.example.com", schemes))
+	is.NoError(validateHelmRepositoryScheme("HTTPS://charts.example.com", schemes))
+	is.Error(validateHelmRepositoryScheme("http://charts.example.com", schemes), "plaintext repositories are rejected by default")
+	is.Error(validateHelmRepositoryScheme("file:///charts", schemes))
+
+	schemes = parseHelmRepositorySchemes(" https, HTTP ")
+	is.Equal([]string{"https", "http"}, schemes)
+	is.NoError(validateHelmRepositoryScheme("http://charts.example.com", schemes), "plaintext repositories can be allowed")
+
+	payload := settingsUpdatePayload{HelmRepositoryURLs: []string{"https://charts.example.com", "http://charts.example.com"}}
+	err := payload.Validate(nil)
+	is.Error(err)
+	is.ErrorContains(err, "The scheme must be one of: https, oci")
+}
+
+func Test_normalizeHelmRepositoryURLs(t *testing.T) {
+	is := assert.New(t)
+
+	urls := normalizeHelmRepositoryURLs([]string{
+		"HTTPS://Charts.Example.com/Stable/",
+		"https://charts.example.com/stable",
+		"OCI://Registry.Example.com/Team/Charts/",
+		"oci://registry.example.com/Team/Charts",
+	})
+	is.Equal([]string{"https://charts.example.com/stable", "oci://registry.example.com/Team/Charts"}, urls, "the path of the OCI URLs is kept")
+
+	is.Equal(portainer.HelmRepositoryTypeClassic, helmRepositoryType(urls[0]))
+	is.Equal(portainer.HelmRepositoryTypeOCI, helmRepositoryType(urls[1]))
+	is.Empty(helmRepositoryType(""))
+
+	is.True(isHelmRepositoryURL("https://charts.example.com/stable"))
+	is.True(isHelmRepositoryURL("oci://registry.example.com:5000/charts"))
+	is.False(isHelmRepositoryURL("oci:///charts"))
+
+	payload := settingsUpdatePayload{HelmRepositoryURLs: []string{"https://charts.example.com/stable", "oci://registry.example.com/charts"}}
+	is.NoError(payload.Validate(nil))
+}
+
+// newLoopbackHTTPClient returns a client reaching the test servers, the guarded client refuses the loopback addresses
+func newLoopbackHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
+
+func Test_validateHelmRepositoryURL_cache(t *testing.T) {
+	is := assert.New(t)
+
+	validations := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validations++
+	}))
+	defer srv.Close()
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+
+	is.Error(h.validateHelmRepositoryURL(context.Background(), srv.URL, false), "the loopback addresses are refused")
+	is.Zero(validations)
+
+	h.helmRepositoryClient = newLoopbackHTTPClient
+
+	is.NoError(h.validateHelmRepositoryURL(context.Background(), srv.URL, false))
+	is.NoError(h.validateHelmRepositoryURL(context.Background(), srv.URL, false))
+	is.Equal(1, validations, "the repository validated recently is not validated again")
+
+	is.NoError(h.validateHelmRepositoryURL(context.Background(), srv.URL, true))
+	is.Equal(2, validations, "the validation is forced")
+}
+
+func Test_validateHelmRepositoryURL_timeout(t *testing.T) {
+	is := assert.New(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.helmRepositoryClient = newLoopbackHTTPClient
+	h.ValidationTimeout = 50 * time.Millisecond
+
+	err := h.validateHelmRepositoryURL(context.Background(), srv.URL, false)
+	is.True(isValidationTimeout(err), "the repository does not answer within the timeout")
+
+	handlerErr := h.validationTimeoutError("Helm repository", err)
+	is.Equal(http.StatusGatewayTimeout, handlerErr.StatusCode)
+	is.Equal(httperrors.CodeSettingsValidationTimeout, handlerErr.Code)
+
+	h.ValidationTimeout = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err = h.validateHelmRepositoryURL(ctx, srv.URL, false)
+	is.ErrorIs(err, context.Canceled, "the validation stops when the request is aborted")
+	is.False(isValidationTimeout(err))
+	is.Less(time.Since(start), time.Minute)
+}
+
+func Test_settingsUpdate_changedFields(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.EnableTelemetry = false
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	body, err := json.Marshal(map[string]any{"EnableTelemetry": true, "EnforceEdgeID": settings.EnforceEdgeID})
+	is.NoError(err)
+
+	rr := httptest.NewRecorder()
+	handlerErr := h.settingsUpdate(rr, newSettingsUpdateRequest("/settings?verbose=true", body))
+	is.Nil(handlerErr)
+
+	is.Equal("EnableTelemetry", rr.Header().Get(changedFieldsHeader), "the fields set to their current value are not reported")
+
+	var resp settingsUpdateVerboseResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Equal([]string{"EnableTelemetry"}, resp.ChangedFields)
+	is.True(resp.Settings.EnableTelemetry)
+}
+
+func newSettingsUpdateRequest(target string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, target, bytes.NewReader(body))
+
+	return req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+}
+
+func Test_settingsUpdate_authenticationMethodAudit(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationInternal
+	settings.LDAPSettings.URL = "ldap.mydomain.tld:389"
+	settings.LDAPSettings.AnonymousMode = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	updateAuthenticationMethod := func(method portainer.AuthenticationMethod) {
+		body, err := json.Marshal(map[string]any{"AuthenticationMethod": method})
+		is.NoError(err)
+
+		handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+		is.Nil(handlerErr)
+	}
+
+	updateAuthenticationMethod(portainer.AuthenticationInternal)
+	updateAuthenticationMethod(portainer.AuthenticationLDAP)
+
+	entries, err := store.AuditLog().ReadAll()
+	is.NoError(err)
+	is.Len(entries, 1, "only the actual changes are recorded")
+	is.Equal(portainer.AuditLogAuthenticationMethodChange, entries[0].Action)
+	is.Equal(portainer.UserID(1), entries[0].UserID)
+	is.Equal("internal", entries[0].Before)
+	is.Equal("ldap", entries[0].After)
+}
+
+type failingSettingsService struct {
+	dataservices.SettingsService
+}
+
+func (service failingSettingsService) UpdateSettings(settings *portainer.Settings) error {
+	return errors.New("update failed")
+}
+
+type failingSettingsTx struct {
+	dataservices.DataStoreTx
+}
+
+func (tx failingSettingsTx) Settings() dataservices.SettingsService {
+	return failingSettingsService{SettingsService: tx.DataStoreTx.Settings()}
+}
+
+// failingSettingsStore is a store whose transactions cannot persist the settings
+type failingSettingsStore struct {
+	dataservices.DataStore
+}
+
+func (store failingSettingsStore) UpdateTx(fn func(dataservices.DataStoreTx) error) error {
+	return store.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return fn(failingSettingsTx{DataStoreTx: tx})
+	})
+}
+
+type sessionDurationJWTServiceStub struct {
+	dataservices.JWTService
+	sessionDuration time.Duration
+}
+
+func (service *sessionDurationJWTServiceStub) SetUserSessionDuration(userSessionDuration time.Duration) {
+	service.sessionDuration = userSessionDuration
+}
+
+func (service *sessionDurationJWTServiceStub) SetTokenIssueFloor(floor int64) {}
+
+func Test_settingsUpdate_userSessionTimeoutAppliedAfterCommit(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	jwtService := &sessionDurationJWTServiceStub{sessionDuration: 8 * time.Hour}
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.FileService = fileService
+	h.JWTService = jwtService
+
+	body, err := json.Marshal(map[string]any{"UserSessionTimeout": "1h"})
+	is.NoError(err)
+
+	h.DataStore = failingSettingsStore{DataStore: store}
+	handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	is.NotNil(handlerErr)
+	is.Equal(8*time.Hour, jwtService.sessionDuration, "the session duration is unchanged when the settings are not persisted")
+
+	h.DataStore = store
+	handlerErr = h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	is.Nil(handlerErr)
+	is.Equal(time.Hour, jwtService.sessionDuration)
+}
+
+func Test_settingsUpdate_triggerSnapshotNow(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	snapshotService := &snapshotServiceStub{}
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.SnapshotService = snapshotService
+
+	updateSettings := func(target string, payload map[string]any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		handlerErr := h.settingsUpdate(rr, newSettingsUpdateRequest(target, body))
+		is.Nil(handlerErr)
+
+		return rr
+	}
+
+	updateSettings("/settings?dryRun=true", map[string]any{"SnapshotInterval": "2m", "TriggerSnapshotNow": true})
+	is.Zero(snapshotService.snapshots, "a dry-run does not schedule a snapshot")
+	is.Empty(snapshotService.interval, "a rolled back update does not change the scheduler")
+
+	rr := updateSettings("/settings", map[string]any{"SnapshotInterval": "2m", "TriggerSnapshotNow": true})
+	is.Equal(1, snapshotService.snapshots)
+	is.Equal("2m", snapshotService.interval)
+
+	var resp settingsUpdateResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.True(resp.SnapshotScheduled)
+}
+
+func Test_settingsUpdate_incompleteAuthenticationSettings(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationInternal
+	settings.LDAPSettings.AnonymousMode = false
+	settings.OAuthSettings.ClientID = "portainer"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	updateSettings := func(payload map[string]any) *httperror.HandlerError {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		return h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	}
+
+	handlerErr := updateSettings(map[string]any{"AuthenticationMethod": 2})
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode)
+	is.Contains(handlerErr.Message, "LDAPSettings.URL, LDAPSettings.ReaderDN")
+
+	handlerErr = updateSettings(map[string]any{
+		"AuthenticationMethod": 3,
+		"OAuthSettings":        map[string]any{"AuthorizationURI": "https://sso.mydomain.tld/authorize"},
+	})
+	is.NotNil(handlerErr)
+	is.Contains(handlerErr.Message, "OAuthSettings.AccessTokenURI, OAuthSettings.ResourceURI")
+	is.NotContains(handlerErr.Message, "ClientID", "the stored client ID counts")
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(portainer.AuthenticationInternal, settings.AuthenticationMethod, "the settings are not persisted")
+
+	handlerErr = updateSettings(map[string]any{
+		"AuthenticationMethod": 3,
+		"OAuthSettings": map[string]any{
+			"AuthorizationURI": "https://sso.mydomain.tld/authorize",
+			"AccessTokenURI":   "https://sso.mydomain.tld/token",
+			"ResourceURI":      "https://sso.mydomain.tld/userinfo",
+		},
+	})
+	is.Nil(handlerErr)
+}
+
+func Test_settingsUpdate_snapshotWorkerCount(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	snapshotService := &snapshotServiceStub{}
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.SnapshotService = snapshotService
+
+	updateSettings := func(workers int) (*httptest.ResponseRecorder, *httperror.HandlerError) {
+		body, err := json.Marshal(map[string]any{"SnapshotWorkerCount": workers})
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		return rr, h.settingsUpdate(rr, newSettingsUpdateRequest("/settings", body))
+	}
+
+	_, handlerErr := updateSettings(0)
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode)
+
+	rr, handlerErr := updateSettings(8)
+	is.Nil(handlerErr)
+	is.Equal(8, snapshotService.workers, "the worker pool is resized without a restart")
+
+	var resp settingsUpdateResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Empty(resp.Warnings)
+
+	rr, handlerErr = updateSettings(1000)
+	is.Nil(handlerErr)
+	is.Equal(MaxSnapshotWorkerCount, snapshotService.workers)
+
+	resp = settingsUpdateResponse{}
+	is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+	is.Equal(MaxSnapshotWorkerCount, resp.SnapshotWorkerCount)
+	is.Len(resp.Warnings, 1)
+}
+
+func Test_settingsUpdate_passwordHashCost(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	cryptoService := &crypto.Service{}
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.CryptoService = cryptoService
+
+	body, err := json.Marshal(map[string]any{"InternalAuthSettings": portainer.InternalAuthSettings{RequiredPasswordLength: 12, PasswordHashCost: bcrypt.MinCost}})
+	is.NoError(err)
+
+	handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	is.Nil(handlerErr)
+
+	hash, err := cryptoService.Hash("Passw0rd!")
+	is.NoError(err)
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	is.NoError(err)
+	is.Equal(bcrypt.MinCost, cost, "the new cost is used without a restart")
+}
+
+func Test_settingsUpdate_ifMatch(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	initial, err := store.Settings().Settings()
+	is.NoError(err)
+
+	inspect := httptest.NewRecorder()
+	is.Nil(h.settingsInspect(inspect, httptest.NewRequest(http.MethodGet, "/settings", nil)))
+	etag := inspect.Header().Get("ETag")
+	is.NotEmpty(etag)
+
+	updateSettings := func(ifMatch string) (*httptest.ResponseRecorder, *httperror.HandlerError) {
+		req := newSettingsUpdateRequest("/settings", []byte(`{"EnableTelemetry":false}`))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		return rr, h.settingsUpdate(rr, req)
+	}
+
+	rr, handlerErr := updateSettings(etag)
+	is.Nil(handlerErr)
+	is.NotEqual(etag, rr.Header().Get("ETag"), "the revision is bumped by the update")
+
+	_, handlerErr = updateSettings(etag)
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusPreconditionFailed, handlerErr.StatusCode, "the update is based on outdated settings")
+
+	_, handlerErr = updateSettings(`W/"41", ` + rr.Header().Get("ETag"))
+	is.Nil(handlerErr, "any of the listed tags can match")
+
+	_, handlerErr = updateSettings("")
+	is.Nil(handlerErr, "the check is skipped without If-Match")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(initial.Revision+3, settings.Revision)
+
+	// the writes made outside of the settings update, e.g. a session revocation, bump the revision as well
+	etag = settingsETag(settings)
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	_, handlerErr = updateSettings(etag)
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusPreconditionFailed, handlerErr.StatusCode)
+}
+
+func Test_settingsUpdate_disabledFeatures(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	updateDisabledFeatures := func(features ...string) *httperror.HandlerError {
+		body, err := json.Marshal(map[string]any{"DisabledFeatures": append([]string{}, features...)})
+		is.NoError(err)
+
+		return h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	}
+
+	handlerErr := updateDisabledFeatures("kubectlShell", "appTemplate")
+	is.NotNil(handlerErr)
+	is.Equal(http.StatusBadRequest, handlerErr.StatusCode, "unknown features are rejected")
+
+	is.Nil(updateDisabledFeatures("kubectlShell", "appTemplates", "kubectlShell"))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal([]portainer.UIFeature{portainer.UIFeatureAppTemplates, portainer.UIFeatureKubectlShell}, settings.DisabledFeatures)
+	is.Equal(settings.DisabledFeatures, generatePublicSettings(settings).DisabledFeatures, "the UI of every user reads them")
+
+	is.Nil(updateDisabledFeatures(), "an empty list enables every feature")
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Empty(settings.DisabledFeatures)
+}
+
+func Test_settingsUpdate_errorCodes(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+
+	tests := []struct {
+		payload    map[string]any
+		statusCode int
+		code       string
+	}{
+		{payload: map[string]any{"AuthenticationMethod": 4}, statusCode: http.StatusBadRequest, code: httperrors.CodeInvalidAuthMethod},
+		{payload: map[string]any{"HelmRepositoryURL": "not a url"}, statusCode: http.StatusBadRequest, code: httperrors.CodeHelmURLInvalid},
+		{payload: map[string]any{"SnapshotWorkerCount": 0}, statusCode: http.StatusBadRequest, code: httperrors.CodeSnapshotWorkerCountInvalid},
+		{payload: map[string]any{"InternalAuthSettings": map[string]any{"PasswordHashCost": 2}}, statusCode: http.StatusBadRequest, code: httperrors.CodePasswordPolicyInvalid},
+		{payload: map[string]any{"LDAPSettings": map[string]any{"ReaderDN": "ou=users,dc"}}, statusCode: http.StatusBadRequest, code: httperrors.CodeLDAPDistinguishedNameInvalid},
+		{payload: map[string]any{"AuthenticationMethod": 3}, statusCode: http.StatusBadRequest, code: httperrors.CodeAuthSettingsIncomplete},
+	}
+
+	for _, test := range tests {
+		body, err := json.Marshal(test.payload)
+		is.NoError(err)
+
+		handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+		if !is.NotNil(handlerErr, test.payload) {
+			continue
+		}
+
+		is.Equal(test.statusCode, handlerErr.StatusCode, test.payload)
+		is.Equal(test.code, httperror.ErrorCode(handlerErr), test.payload)
+	}
+}
+
+// Test_updateSettings_noTx runs the same update through a transaction and through the deprecated non transactional
+// path, so that both paths keep saving the same settings until the latter is removed
+func Test_updateSettings_noTx(t *testing.T) {
+	is := assert.New(t)
+
+	body := []byte(`{
+		"LogoURL": "https://example.com/logo.png",
+		"SnapshotInterval": "10m",
+		"EnableTelemetry": false,
+		"EnforceEdgeID": true,
+		"EdgeAgentCheckinInterval": 10,
+		"UserSessionTimeout": "12h",
+		"BlackListedLabels": [{"name": "internal", "value": "true"}],
+		"KubectlShellImage": "portainer/kubectl-shell:latest"
+	}`)
+
+	update := func(tx bool) *portainer.Settings {
+		_, store := datastore.MustNewTestStore(t, true, false)
+
+		fileService, err := filesystem.NewService(t.TempDir(), "")
+		is.NoError(err)
+
+		h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+		h.DataStore = store
+		h.FileService = fileService
+
+		var payload settingsUpdatePayload
+		is.NoError(json.Unmarshal(body, &payload))
+		is.NoError(payload.Validate(nil))
+
+		if !tx {
+			_, err := h.updateSettings(store, payload)
+			is.NoError(err)
+		} else {
+			is.NoError(store.UpdateTx(func(tx dataservices.DataStoreTx) error {
+				_, err := h.updateSettings(tx, payload)
+				return err
+			}))
+		}
+
+		settings, err := store.Settings().Settings()
+		is.NoError(err)
+
+		return settings
+	}
+
+	settings := update(true)
+	is.Equal("https://example.com/logo.png", settings.LogoURL)
+	is.Equal(10, settings.EdgeAgentCheckinInterval)
+
+	is.Equal(settings, update(false))
+}
+
+func Test_settingsUpdate_outboundProxyAppliedAfterCommit(t *testing.T) {
+	is := assert.New(t)
+	defer client.SetOutboundProxy("")
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+
+	proxied := func() string {
+		req := httptest.NewRequest(http.MethodGet, "https://portainer.io", nil)
+		proxyURL, err := client.OutboundProxy(req)
+		is.NoError(err)
+
+		if proxyURL == nil {
+			return ""
+		}
+
+		return proxyURL.String()
+	}
+
+	t.Setenv("HTTPS_PROXY", "")
+
+	// the transaction of a dry run is rolled back once the settings are updated
+	handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings?dryRun=true", []byte(`{"OutboundProxyURL": "http://proxy.local:3128"}`)))
+	is.Nil(handlerErr)
+	is.Empty(proxied(), "a rolled back update does not change the proxy")
+
+	handlerErr = h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", []byte(`{"OutboundProxyURL": "http://proxy.local:3128"}`)))
+	is.Nil(handlerErr)
+	is.Equal("http://proxy.local:3128", proxied())
+}
@@ -0,0 +1,53 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// DefaultValidationTimeout bounds each network call made to validate the settings when the handler does not set one
+const DefaultValidationTimeout = 10 * time.Second
+
+func (handler *Handler) validationTimeout() time.Duration {
+	if handler.ValidationTimeout <= 0 {
+		return DefaultValidationTimeout
+	}
+
+	return handler.ValidationTimeout
+}
+
+// validationContext bounds a network call made to validate the settings, the call is cancelled as well when the
+// request it validates is aborted
+func (handler *Handler) validationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithTimeout(ctx, handler.validationTimeout())
+}
+
+// isValidationTimeout returns true when a validation call failed because the remote server did not answer in time
+func isValidationTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func (handler *Handler) validationTimeoutError(target string, err error) *httperror.HandlerError {
+	return &httperror.HandlerError{
+		StatusCode: http.StatusGatewayTimeout,
+		Message:    fmt.Sprintf("The %s did not answer within %s. Check that it is reachable from Portainer and retry", target, handler.validationTimeout()),
+		Err:        err,
+		Code:       httperrors.CodeSettingsValidationTimeout,
+	}
+}
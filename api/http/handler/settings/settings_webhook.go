@@ -0,0 +1,62 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// settingsWebhookTimeout bounds each delivery attempt of the settings change webhook
+const settingsWebhookTimeout = 10 * time.Second
+
+// settingsWebhookRetryDelays are the delays before the retries of a failed delivery of the settings change webhook
+var settingsWebhookRetryDelays = []time.Duration{5 * time.Second, 30 * time.Second}
+
+// notifySettingsChange posts the change event to the webhook, the failures are only logged
+func (handler *Handler) notifySettingsChange(webhookURL string, event settingsChangeEvent) {
+	err := deliverSettingsChange(handler.webhookClient, webhookURL, event, settingsWebhookRetryDelays)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to notify the settings change webhook")
+	}
+}
+
+// deliverSettingsChange posts the change event to the webhook and retries after each delay when the delivery fails
+func deliverSettingsChange(httpClient *http.Client, webhookURL string, event settingsChangeEvent, retryDelays []time.Duration) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = postSettingsChange(httpClient, webhookURL, body)
+	for _, delay := range retryDelays {
+		if err == nil {
+			return nil
+		}
+
+		log.Debug().Err(err).Dur("retry_in", delay).Msg("failed notifying the settings change webhook")
+
+		time.Sleep(delay)
+		err = postSettingsChange(httpClient, webhookURL, body)
+	}
+
+	return err
+}
+
+func postSettingsChange(httpClient *http.Client, webhookURL string, body []byte) error {
+	resp, err := httpClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed calling the settings change webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the settings change webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
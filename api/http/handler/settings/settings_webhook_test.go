@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deliverSettingsChange(t *testing.T) {
+	is := assert.New(t)
+
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	event := settingsChangeEvent{Changes: []settingsFieldChange{{Field: "EnableTelemetry", Old: true, New: false}}}
+
+	is.Error(deliverSettingsChange(srv.Client(), srv.URL, event, []time.Duration{0}))
+	is.Equal(2, attempts)
+
+	is.NoError(deliverSettingsChange(srv.Client(), srv.URL, event, []time.Duration{0}), "the delivery is retried")
+	is.Equal(3, attempts)
+}
+
+func Test_settingsUpdate_changeWebhook(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	events := make(chan settingsChangeEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event settingsChangeEvent
+		is.NoError(json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer srv.Close()
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	h.DataStore = store
+	h.FileService = fileService
+	h.webhookClient = srv.Client()
+
+	body, err := json.Marshal(map[string]any{"SettingsChangeWebhookURL": srv.URL})
+	is.NoError(err)
+
+	handlerErr := h.settingsUpdate(httptest.NewRecorder(), newSettingsUpdateRequest("/settings", body))
+	is.Nil(handlerErr)
+
+	select {
+	case event := <-events:
+		is.Equal([]settingsFieldChange{{Field: "SettingsChangeWebhookURL", Old: redactedValue, New: redactedValue}}, event.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook was not notified")
+	}
+
+	payload := settingsUpdatePayload{SettingsChangeWebhookURL: new(string)}
+	*payload.SettingsChangeWebhookURL = "not a url"
+	is.Error(payload.Validate(nil))
+}
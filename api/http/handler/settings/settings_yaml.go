@@ -0,0 +1,120 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"gopkg.in/yaml.v3"
+)
+
+const yamlContentType = "application/yaml"
+
+// isYAMLMediaType returns true for the media types commonly used for YAML
+func isYAMLMediaType(mediaType string) bool {
+	switch mediaType {
+	case yamlContentType, "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+
+	return false
+}
+
+// acceptsYAML returns true when the Accept header of the request lists a YAML media type before any JSON one, the
+// settings are written as JSON otherwise
+func acceptsYAML(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		if isYAMLMediaType(mediaType) {
+			return true
+		}
+
+		if mediaType == "application/json" {
+			return false
+		}
+	}
+
+	return false
+}
+
+// isYAMLPayload returns true when the Content-Type header of the request is a YAML media type
+func isYAMLPayload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && isYAMLMediaType(mediaType)
+}
+
+// decodeAndValidatePayload decodes the JSON or YAML body of the request, depending on its Content-Type, and validates
+// it. The YAML is converted to JSON first, so that the fields have the same names and the payload is decoded and
+// validated exactly like a JSON one
+func decodeAndValidatePayload(r *http.Request, payload request.PayloadValidation) error {
+	if !isYAMLPayload(r) {
+		return request.DecodeAndValidateJSONPayload(r, payload)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	var document any
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return err
+	}
+
+	// the mappings with keys that are not strings cannot be converted to JSON and are rejected by the marshaling
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	return request.DecodeAndValidateJSONPayload(r, payload)
+}
+
+// writeYAML writes the value as YAML, with the field names and the field order of its JSON encoding
+func writeYAML(w http.ResponseWriter, value any) *httperror.HandlerError {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return httperror.InternalServerError("Unable to encode the settings", err)
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the order of the fields
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return httperror.InternalServerError("Unable to encode the settings as YAML", err)
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return httperror.InternalServerError("Unable to encode the settings as YAML", err)
+	}
+
+	w.Header().Set("Content-Type", yamlContentType)
+	_, _ = w.Write(buf.Bytes())
+
+	return nil
+}
+
+// blockStyle replaces the flow style and the quoted strings of the nodes decoded from JSON by the block style and the
+// plain strings of the YAML manifests, the strings that would be read as another type are still quoted by the encoder
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_acceptsYAML(t *testing.T) {
+	is := assert.New(t)
+
+	accepts := func(accept string) bool {
+		r := httptest.NewRequest(http.MethodGet, "/settings", nil)
+		r.Header.Set("Accept", accept)
+
+		return acceptsYAML(r)
+	}
+
+	is.False(accepts(""), "JSON is the default")
+	is.False(accepts("*/*"))
+	is.False(accepts("application/json"))
+	is.True(accepts("application/yaml"))
+	is.True(accepts("text/yaml; charset=utf-8"))
+	is.True(accepts("application/x-yaml, application/json"))
+	is.False(accepts("application/json, application/yaml"))
+}
+
+func Test_decodeAndValidatePayload_yaml(t *testing.T) {
+	is := assert.New(t)
+
+	decode := func(contentType, body string) (settingsUpdatePayload, error) {
+		r := httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+
+		var payload settingsUpdatePayload
+		err := decodeAndValidatePayload(r, &payload)
+
+		return payload, err
+	}
+
+	fromJSON, err := decode("application/json", `{"SnapshotInterval": "10m", "EnableTelemetry": false, "OAuthSettings": {"ClientID": "portainer", "AllowedRedirectURIs": ["https://portainer.mydomain.tld"]}, "BlackListedLabels": [{"name": "app", "value": "internal"}]}`)
+	is.NoError(err)
+
+	fromYAML, err := decode("application/yaml; charset=utf-8", `
+SnapshotInterval: 10m
+EnableTelemetry: false
+OAuthSettings:
+  ClientID: portainer
+  AllowedRedirectURIs:
+    - https://portainer.mydomain.tld
+BlackListedLabels:
+  - name: app
+    value: internal
+`)
+	is.NoError(err)
+	is.Equal(fromJSON, fromYAML)
+
+	// the same validation applies to both formats
+	_, jsonErr := decode("application/json", `{"SnapshotInterval": "10s"}`)
+	_, yamlErr := decode("application/yaml", "SnapshotInterval: 10s")
+	is.Error(yamlErr)
+	is.Equal(jsonErr.Error(), yamlErr.Error())
+	is.Equal(httperrors.CodeSnapshotIntervalInvalid, httperror.ErrorCode(&httperror.HandlerError{Err: yamlErr}))
+
+	_, err = decode("application/yaml", "SnapshotInterval: [10m")
+	is.Error(err, "invalid YAML is rejected")
+
+	_, err = decode("application/yaml", "? [a, b]\n: c")
+	is.Error(err, "keys that are not strings are rejected")
+}
+
+func Test_writeYAML(t *testing.T) {
+	is := assert.New(t)
+
+	settings := &portainer.Settings{
+		SnapshotInterval:   "5m",
+		UserSessionTimeout: "8h",
+		BlackListedLabels:  []portainer.Pair{{Name: "app", Value: "true"}},
+		OAuthSettings:      portainer.OAuthSettings{KubeSecretKey: []byte("secret"), AllowedRedirectURIs: []string{"https://portainer.mydomain.tld"}},
+	}
+
+	rr := httptest.NewRecorder()
+	is.Nil(writeYAML(rr, settingsInspectResponse{Settings: settings}))
+	is.Equal(yamlContentType, rr.Header().Get("Content-Type"))
+
+	body := rr.Body.String()
+	is.Contains(body, "SnapshotInterval: 5m\n", "the fields keep their JSON names")
+	is.Contains(body, "value: \"true\"", "the strings read as another type are quoted")
+
+	// the YAML holds the same values as the JSON
+	var fromYAML any
+	is.NoError(yaml.Unmarshal(rr.Body.Bytes(), &fromYAML))
+	yamlAsJSON, err := json.Marshal(fromYAML)
+	is.NoError(err)
+
+	expected, err := json.Marshal(settingsInspectResponse{Settings: settings})
+	is.NoError(err)
+	is.JSONEq(string(expected), string(yamlAsJSON))
+}
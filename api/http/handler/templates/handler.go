@@ -29,5 +29,7 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.templateList))).Methods(http.MethodGet)
 	h.Handle("/templates/file",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.templateFile))).Methods(http.MethodPost)
+	h.Handle("/templates/upload",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.templateUpload))).Methods(http.MethodPost)
 	return h
 }
@@ -44,16 +44,15 @@ func (handler *Handler) ifRequestedTemplateExists(payload *filePayload) *httperr
 		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	resp, err := http.Get(settings.TemplatesURL)
+	data, err := handler.fetchTemplates(settings)
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve templates via the network", err)
+		return httperror.InternalServerError("Unable to retrieve templates", err)
 	}
-	defer resp.Body.Close()
 
 	var templates struct {
 		Templates []portainer.Template
 	}
-	err = json.NewDecoder(resp.Body).Decode(&templates)
+	err = json.Unmarshal(data, &templates)
 	if err != nil {
 		return httperror.InternalServerError("Unable to parse template file", err)
 	}
@@ -1,7 +1,6 @@
 package templates
 
 import (
-	"io"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
@@ -16,7 +15,7 @@ type listResponse struct {
 
 // @id TemplateList
 // @summary List available templates
-// @description List available templates.
+// @description List available templates, from the uploaded templates file when enabled in the settings or from the templates URL.
 // @description **Access policy**: authenticated
 // @tags templates
 // @security ApiKeyAuth
@@ -31,14 +30,13 @@ func (handler *Handler) templateList(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	resp, err := http.Get(settings.TemplatesURL)
+	templates, err := handler.fetchTemplates(settings)
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve templates via the network", err)
+		return httperror.InternalServerError("Unable to retrieve templates", err)
 	}
-	defer resp.Body.Close()
 
 	w.Header().Set("Content-Type", "application/json")
-	_, err = io.Copy(w, resp.Body)
+	_, err = w.Write(templates)
 	if err != nil {
 		return httperror.InternalServerError("Unable to write templates from templates URL", err)
 	}
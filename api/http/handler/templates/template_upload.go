@@ -0,0 +1,50 @@
+package templates
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type templateUploadResponse struct {
+	// Version of the templates file
+	Version string `json:"version" example:"2"`
+	// Number of templates in the file
+	TemplateCount int `json:"templateCount" example:"42"`
+}
+
+// @id TemplateUpload
+// @summary Upload an app templates file
+// @description Upload an app templates file that can be served instead of the templates URL, for instance when Portainer has no internet access.
+// @description The structure of the file is validated before it is stored. Enable UseLocalTemplatesFile in the settings to serve it.
+// @description **Access policy**: administrator
+// @tags templates
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @produce json
+// @param file formData file true "Templates file"
+// @success 200 {object} templateUploadResponse "Success"
+// @failure 400 "Invalid request or invalid templates file"
+// @failure 500 "Server error"
+// @router /templates/upload [post]
+func (handler *Handler) templateUpload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	data, _, err := request.RetrieveMultiPartFormFile(r, "file")
+	if err != nil {
+		return httperror.BadRequest("Invalid templates file. Ensure that the file is uploaded correctly", err)
+	}
+
+	file, err := parseTemplatesFile(data)
+	if err != nil {
+		return httperror.BadRequest("Invalid templates file", err)
+	}
+
+	_, err = handler.FileService.StoreTemplatesFile(data)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the templates file on disk", err)
+	}
+
+	return response.JSON(w, templateUploadResponse{Version: file.Version, TemplateCount: len(file.Templates)})
+}
@@ -0,0 +1,114 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+type templatesFile struct {
+	Version   string               `json:"version"`
+	Templates []portainer.Template `json:"templates"`
+}
+
+// fetchTemplates returns the raw app templates, from the uploaded file when enabled in the settings or from the templates URL
+func (handler *Handler) fetchTemplates(settings *portainer.Settings) ([]byte, error) {
+	if settings.UseLocalTemplatesFile {
+		return handler.FileService.GetTemplatesFile()
+	}
+
+	resp, err := http.Get(settings.TemplatesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseTemplatesFile decodes an app templates file and validates its structure, the returned error lists every problem found
+func parseTemplatesFile(data []byte) (*templatesFile, error) {
+	var file templatesFile
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	err := decoder.Decode(&file)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+
+		switch {
+		case errors.As(err, &syntaxErr):
+			line, column := position(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %s", line, column, syntaxErr.Error())
+		case errors.As(err, &typeErr):
+			return nil, fmt.Errorf("invalid value for the field %s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		default:
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+
+	problems := []string{}
+	if file.Version == "" {
+		problems = append(problems, "version is required")
+	}
+
+	if len(file.Templates) == 0 {
+		problems = append(problems, "templates cannot be empty")
+	}
+
+	for i, template := range file.Templates {
+		problems = append(problems, validateTemplate(i, template)...)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	return &file, nil
+}
+
+func validateTemplate(index int, template portainer.Template) []string {
+	problems := []string{}
+
+	invalid := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("templates[%d]: ", index)+fmt.Sprintf(format, args...))
+	}
+
+	if template.Title == "" {
+		invalid("title is required")
+	}
+
+	switch template.Type {
+	case portainer.ContainerTemplate:
+		if template.Image == "" {
+			invalid("image is required for a container template")
+		}
+	case portainer.SwarmStackTemplate, portainer.ComposeStackTemplate:
+		if template.Repository.URL == "" || template.Repository.StackFile == "" {
+			invalid("repository url and stackfile are required for a stack template")
+		}
+	default:
+		invalid("invalid type %d, must be one of 1 (container), 2 (swarm stack) or 3 (compose stack)", template.Type)
+	}
+
+	return problems
+}
+
+// position converts a byte offset to a line and column, both starting at 1
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
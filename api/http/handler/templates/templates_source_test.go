@@ -0,0 +1,41 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseTemplatesFile(t *testing.T) {
+	is := assert.New(t)
+
+	file, err := parseTemplatesFile([]byte(`{
+		"version": "2",
+		"templates": [
+			{"type": 1, "title": "Nginx", "image": "nginx:latest"},
+			{"type": 3, "title": "Wordpress", "repository": {"url": "https://github.com/portainer/templates", "stackfile": "stacks/wordpress/docker-compose.yml"}}
+		]
+	}`))
+	is.NoError(err)
+	is.Equal("2", file.Version)
+	is.Len(file.Templates, 2)
+
+	_, err = parseTemplatesFile([]byte("{\n\"version\": \"2\",\n\"templates\": [,]\n}"))
+	is.ErrorContains(err, "invalid JSON at line 3")
+
+	_, err = parseTemplatesFile([]byte(`{"version": 2, "templates": []}`))
+	is.ErrorContains(err, "invalid value for the field version")
+
+	_, err = parseTemplatesFile([]byte(`{
+		"templates": [
+			{"type": 1, "title": "Nginx"},
+			{"type": 2, "repository": {"url": "https://github.com/portainer/templates"}},
+			{"type": 4, "title": "Unknown"}
+		]
+	}`))
+	is.ErrorContains(err, "version is required")
+	is.ErrorContains(err, "templates[0]: image is required for a container template")
+	is.ErrorContains(err, "templates[1]: title is required")
+	is.ErrorContains(err, "templates[1]: repository url and stackfile are required for a stack template")
+	is.ErrorContains(err, "templates[2]: invalid type 4")
+}
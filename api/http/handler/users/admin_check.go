@@ -29,3 +29,20 @@ func (handler *Handler) adminCheck(w http.ResponseWriter, r *http.Request) *http
 
 	return response.Empty(w)
 }
+
+// countAdministrators returns the number of administrators, only the ones with a local password when localOnly is set
+func (handler *Handler) countAdministrators(localOnly bool) (int, error) {
+	users, err := handler.DataStore.User().UsersByRole(portainer.AdministratorRole)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, u := range users {
+		if !localOnly || u.Password != "" {
+			count++
+		}
+	}
+
+	return count, nil
+}
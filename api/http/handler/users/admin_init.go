@@ -3,8 +3,10 @@ package users
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -21,7 +23,7 @@ type adminInitPayload struct {
 
 func (payload *adminInitPayload) Validate(r *http.Request) error {
 	if govalidator.IsNull(payload.Username) || govalidator.Contains(payload.Username, " ") {
-		return errors.New("Invalid username. Must not contain any whitespace")
+		return httperror.WithCode(httperrors.CodeUsernameInvalid, errors.New("Invalid username. Must not contain any whitespace"))
 	}
 	if govalidator.IsNull(payload.Password) {
 		return errors.New("Invalid password")
@@ -58,8 +60,8 @@ func (handler *Handler) adminInit(w http.ResponseWriter, r *http.Request) *httpe
 		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Unable to create administrator user", Err: errAdminAlreadyInitialized}
 	}
 
-	if !handler.passwordStrengthChecker.Check(payload.Password) {
-		return httperror.BadRequest("Password does not meet the requirements", nil)
+	if strength := handler.passwordStrengthChecker.Evaluate(payload.Password, payload.Username); !strength.Strong {
+		return writePasswordStrengthFailure(w, strength)
 	}
 
 	user := &portainer.User{
@@ -71,6 +73,7 @@ func (handler *Handler) adminInit(w http.ResponseWriter, r *http.Request) *httpe
 	if err != nil {
 		return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 	}
+	user.PasswordUpdatedAt = time.Now().Unix()
 
 	err = handler.DataStore.User().Create(user)
 	if err != nil {
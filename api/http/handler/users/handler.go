@@ -2,28 +2,58 @@ package users
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/gorilla/mux"
 )
 
 var (
-	errUserAlreadyExists          = errors.New("User already exists")
-	errAdminAlreadyInitialized    = errors.New("An administrator user already exists")
-	errAdminCannotRemoveSelf      = errors.New("Cannot remove your own user account. Contact another administrator")
-	errCannotRemoveLastLocalAdmin = errors.New("Cannot remove the last local administrator account")
+	errUserAlreadyExists          = httperror.WithCode(httperrors.CodeUserAlreadyExists, errors.New("User already exists"))
+	errAdminAlreadyInitialized    = httperror.WithCode(httperrors.CodeAdminAlreadyInitialized, errors.New("An administrator user already exists"))
+	errAdminCannotRemoveSelf      = httperror.WithCode(httperrors.CodeCannotRemoveSelf, errors.New("Cannot remove your own user account. Contact another administrator"))
+	errCannotRemoveLastLocalAdmin = httperror.WithCode(httperrors.CodeLastAdmin, errors.New("Cannot remove the last local administrator account"))
+	errCannotDemoteLastAdmin      = httperror.WithCode(httperrors.CodeLastAdmin, errors.New("Cannot change the role of the last administrator account"))
+	errStaleAuthentication        = httperror.WithCode(httperrors.CodeReauthenticationRequired, errors.New("The session was authenticated too long ago"))
 	errCryptoHashFailure          = errors.New("Unable to hash data")
+	errTemporaryPasswordReuse     = httperror.WithCode(httperrors.CodePasswordReuse, errors.New("The new password must differ from the password set by an administrator"))
+	errPasswordReuse              = httperror.WithCode(httperrors.CodePasswordReuse, errors.New("The new password was used recently"))
+	errPasswordTooLong            = httperror.WithCode(httperrors.CodePasswordTooLong, fmt.Errorf("Invalid new password. Must not exceed %d bytes", crypto.MaxDataLength))
+	errInternalPasswordDisabled   = httperror.WithCode(httperrors.CodeInternalPasswordDisabled, errors.New("The user does not log in with an internal password under the current authentication method"))
+	errTwoFactorRequired          = httperror.WithCode(httperrors.CodeTwoFactorRequired, errors.New("The second factor of the user is required"))
+	errTwoFactorInvalid           = httperror.WithCode(httperrors.CodeTwoFactorInvalid, errors.New("The code of the second factor is invalid"))
+	errPasswordChangeLocked       = httperror.WithCode(httperrors.CodePasswordChangeLocked, errors.New("The password change is locked after too many failed attempts"))
 )
 
 func hideFields(user *portainer.User) {
 	user.Password = ""
+	user.PasswordHistory = nil
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+}
+
+type passwordStrengthFailure struct {
+	Message string `json:"message" example:"Password does not meet the requirements"`
+	Code    string `json:"code" example:"PASSWORD_TOO_WEAK"`
+	security.PasswordStrengthResult
+}
+
+// writePasswordStrengthFailure rejects a weak password with the rules it does not satisfy
+func writePasswordStrengthFailure(w http.ResponseWriter, result security.PasswordStrengthResult) *httperror.HandlerError {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	return response.JSON(w, passwordStrengthFailure{Message: "Password does not meet the requirements", Code: httperrors.CodePasswordTooWeak, PasswordStrengthResult: result})
 }
 
 // Handler is the HTTP handler used to handle user operations.
@@ -34,7 +64,9 @@ type Handler struct {
 	demoService             *demo.Service
 	DataStore               dataservices.DataStore
 	CryptoService           portainer.CryptoService
+	JWTService              dataservices.JWTService
 	passwordStrengthChecker security.PasswordStrengthChecker
+	passwordChangeLimiter   *passwordChangeLimiter
 	AdminCreationDone       chan<- struct{}
 }
 
@@ -46,6 +78,7 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 		apiKeyService:           apiKeyService,
 		demoService:             demoService,
 		passwordStrengthChecker: passwordStrengthChecker,
+		passwordChangeLimiter:   newPasswordChangeLimiter(passwordChangeLimiterSize),
 	}
 
 	adminRouter := h.NewRoute().Subrouter()
@@ -64,6 +97,8 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	publicRouter.Use(bouncer.PublicAccess)
 
 	adminRouter.Handle("/users", httperror.LoggerHandler(h.userCreate)).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/users/password-strength", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userPasswordStrength))).Methods(http.MethodPost)
+	adminRouter.Handle("/users/security/status", httperror.LoggerHandler(h.userSecurityStatus)).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users", httperror.LoggerHandler(h.userList)).Methods(http.MethodGet)
 	restrictedRouter.Handle("/users/{id}", httperror.LoggerHandler(h.userInspect)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/users/{id}", httperror.LoggerHandler(h.userUpdate)).Methods(http.MethodPut)
@@ -71,8 +106,12 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	restrictedRouter.Handle("/users/{id}/tokens", httperror.LoggerHandler(h.userGetAccessTokens)).Methods(http.MethodGet)
 	restrictedRouter.Handle("/users/{id}/tokens", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCreateAccessToken))).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users/{id}/tokens/{keyID}", httperror.LoggerHandler(h.userRemoveAccessToken)).Methods(http.MethodDelete)
+	authenticatedRouter.Handle("/users/{id}/kubeconfig-expiry", httperror.LoggerHandler(h.userUpdateKubeconfigExpiry)).Methods(http.MethodPut)
 	restrictedRouter.Handle("/users/{id}/memberships", httperror.LoggerHandler(h.userMemberships)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/users/{id}/passwd", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userUpdatePassword))).Methods(http.MethodPut)
+	authenticatedRouter.Handle("/users/{id}/revoke-sessions", httperror.LoggerHandler(h.userRevokeSessions)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/force-password-change", httperror.LoggerHandler(h.userForcePasswordChange)).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/users/{id}/passwd/check", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCheckPassword))).Methods(http.MethodPost)
 	publicRouter.Handle("/users/admin/check", httperror.LoggerHandler(h.adminCheck)).Methods(http.MethodGet)
 	publicRouter.Handle("/users/admin/init", httperror.LoggerHandler(h.adminInit)).Methods(http.MethodPost)
 
@@ -0,0 +1,83 @@
+package users
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const passwordChangeLimiterSize = 1024
+
+type passwordChangeAttempts struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+// passwordChangeLimiter counts the consecutive failed password changes of each user, in memory
+type passwordChangeLimiter struct {
+	mu sync.Mutex
+	// cache type [portainer.UserID]passwordChangeAttempts
+	cache *lru.Cache
+}
+
+func newPasswordChangeLimiter(size int) *passwordChangeLimiter {
+	cache, _ := lru.New(size)
+
+	return &passwordChangeLimiter{cache: cache}
+}
+
+// retryAfter returns the remaining lockout of the user, 0 when the user is not locked out
+func (limiter *passwordChangeLimiter) retryAfter(userID portainer.UserID, now time.Time) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	val, ok := limiter.cache.Get(userID)
+	if !ok {
+		return 0
+	}
+
+	attempts := val.(passwordChangeAttempts)
+	if now.Before(attempts.lockedUntil) {
+		return attempts.lockedUntil.Sub(now)
+	}
+
+	return 0
+}
+
+// fail records a failed attempt, the user is locked out for the lockout duration once the threshold is reached
+func (limiter *passwordChangeLimiter) fail(userID portainer.UserID, threshold int, lockout time.Duration, now time.Time) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	var attempts passwordChangeAttempts
+	if val, ok := limiter.cache.Get(userID); ok {
+		attempts = val.(passwordChangeAttempts)
+	}
+
+	attempts.failures++
+	if attempts.failures >= threshold {
+		attempts = passwordChangeAttempts{lockedUntil: now.Add(lockout)}
+	}
+
+	limiter.cache.Add(userID, attempts)
+}
+
+func (limiter *passwordChangeLimiter) reset(userID portainer.UserID) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.cache.Remove(userID)
+}
+
+// passwordChangeLockout returns the lockout duration configured in the settings, the login lockout duration by default
+func passwordChangeLockout(settings *portainer.Settings) time.Duration {
+	lockout, err := time.ParseDuration(settings.InternalAuthSettings.PasswordChangeLockoutDuration)
+	if err != nil || lockout <= 0 {
+		return portainer.DefaultLoginLockoutDuration
+	}
+
+	return lockout
+}
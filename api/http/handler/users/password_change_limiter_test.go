@@ -0,0 +1,30 @@
+package users
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_passwordChangeLimiter(t *testing.T) {
+	is := assert.New(t)
+
+	limiter := newPasswordChangeLimiter(passwordChangeLimiterSize)
+	now := time.Now()
+
+	limiter.fail(1, 3, time.Minute, now)
+	limiter.fail(1, 3, time.Minute, now)
+	is.Zero(limiter.retryAfter(1, now), "the threshold is not reached")
+
+	limiter.fail(1, 3, time.Minute, now)
+	is.Equal(time.Minute, limiter.retryAfter(1, now))
+	is.Zero(limiter.retryAfter(2, now), "other users are not locked out")
+	is.Zero(limiter.retryAfter(1, now.Add(time.Minute)), "the lockout expires")
+
+	limiter.fail(2, 3, time.Minute, now)
+	limiter.fail(2, 3, time.Minute, now)
+	limiter.reset(2)
+	limiter.fail(2, 3, time.Minute, now)
+	is.Zero(limiter.retryAfter(2, now), "a successful change resets the counter")
+}
@@ -0,0 +1,129 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type userCheckPasswordPayload struct {
+	// Candidate password
+	Password string `example:"new_passwd" validate:"required"`
+}
+
+func (payload *userCheckPasswordPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Password) {
+		return errors.New("Invalid password")
+	}
+
+	return nil
+}
+
+type userCheckPasswordResponse struct {
+	// Whether the password would be accepted as the new password of the user
+	Valid bool                          `json:"valid" example:"true"`
+	Rules []security.PasswordRuleResult `json:"rules"`
+}
+
+// @id UserCheckPassword
+// @summary Check a candidate password against the password policies
+// @description Evaluate every password rule for a candidate password of the specified user and report the result of each rule.
+// @description Only the enforced rules prevent the password from being used when updating the password.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body userCheckPasswordPayload true "details"
+// @success 200 {object} userCheckPasswordResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/passwd/check [post]
+func (handler *Handler) userCheckPassword(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
+		return httperror.Forbidden("Permission denied to check the password of the user", httperrors.ErrUnauthorized)
+	}
+
+	var payload userCheckPasswordPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	rules := security.EvaluatePasswordRules(payload.Password, settings, user.Username)
+	rules = append(rules, handler.passwordHistoryRule(payload.Password, user, tokenData, settings))
+
+	return response.JSON(w, userCheckPasswordResponse{
+		Valid: security.PasswordRulesPassed(rules),
+		Rules: rules,
+	})
+}
+
+// passwordHistoryRule checks that the password differs from the current one and the recent ones, which is enforced
+// by userUpdatePassword when a password history depth is configured, or when users replace a temporary password
+// set by an administrator
+func (handler *Handler) passwordHistoryRule(password string, user *portainer.User, tokenData *portainer.TokenData, settings *portainer.Settings) security.PasswordRuleResult {
+	depth := settings.InternalAuthSettings.PasswordHistoryDepth
+
+	result := security.PasswordRuleResult{
+		Rule:     security.PasswordRuleHistory,
+		Status:   security.PasswordRulePassed,
+		Enforced: depth > 0 || (settings.InternalAuthSettings.RejectTemporaryPasswordReuse && user.PasswordSetByAdmin && tokenData.ID == user.ID),
+		Message:  "should differ from the current password",
+	}
+
+	if depth > 1 {
+		result.Message = fmt.Sprintf("should differ from the last %d passwords", depth)
+	}
+
+	if user.Password == "" {
+		result.Status = security.PasswordRuleSkipped
+		return result
+	}
+
+	history := passwordHistory(user, depth)
+	if len(history) == 0 {
+		history = []string{user.Password}
+	}
+
+	if handler.passwordInHistory(password, history) {
+		result.Status = security.PasswordRuleFailed
+	}
+
+	return result
+}
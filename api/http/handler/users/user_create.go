@@ -3,8 +3,10 @@ package users
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -21,11 +23,11 @@ type userCreatePayload struct {
 
 func (payload *userCreatePayload) Validate(r *http.Request) error {
 	if govalidator.IsNull(payload.Username) || govalidator.Contains(payload.Username, " ") {
-		return errors.New("Invalid username. Must not contain any whitespace")
+		return httperror.WithCode(httperrors.CodeUsernameInvalid, errors.New("Invalid username. Must not contain any whitespace"))
 	}
 
 	if payload.Role != 1 && payload.Role != 2 {
-		return errors.New("Invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
+		return httperror.WithCode(httperrors.CodeRoleInvalid, errors.New("Invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)"))
 	}
 	return nil
 }
@@ -79,14 +81,16 @@ func (handler *Handler) userCreate(w http.ResponseWriter, r *http.Request) *http
 	}
 
 	if settings.AuthenticationMethod == portainer.AuthenticationInternal {
-		if !handler.passwordStrengthChecker.Check(payload.Password) {
-			return httperror.BadRequest("Password does not meet the requirements", nil)
+		if strength := handler.passwordStrengthChecker.Evaluate(payload.Password, payload.Username); !strength.Strong {
+			return writePasswordStrengthFailure(w, strength)
 		}
 
 		user.Password, err = handler.CryptoService.Hash(payload.Password)
 		if err != nil {
 			return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 		}
+		user.PasswordSetByAdmin = true
+		user.PasswordUpdatedAt = time.Now().Unix()
 	}
 
 	err = handler.DataStore.User().Create(user)
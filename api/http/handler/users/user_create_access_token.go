@@ -2,6 +2,7 @@ package users
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
@@ -16,6 +17,8 @@ import (
 
 type userAccessTokenCreatePayload struct {
 	Description string `validate:"required" example:"github-api-key" json:"description"`
+	// Allows an administrator to exceed the maximum number of API keys per user
+	Override bool `example:"false" json:"override"`
 }
 
 func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
@@ -40,6 +43,8 @@ type accessTokenResponse struct {
 // @summary Generate an API key for a user
 // @description Generates an API key for a user.
 // @description Only the calling user can generate a token for themselves.
+// @description The creation is refused when the user already owns the maximum number of API keys defined in the settings,
+// @description unless an administrator sets the override flag.
 // @description **Access policy**: restricted
 // @tags users
 // @security jwt
@@ -85,6 +90,28 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		return httperror.BadRequest("Unable to find a user", err)
 	}
 
+	if payload.Override && tokenData.Role != portainer.AdministratorRole {
+		return httperror.Forbidden("Permission denied to override the API key limit", httperrors.ErrUnauthorized)
+	}
+
+	if !payload.Override {
+		settings, err := handler.DataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+		}
+
+		if settings.MaxAPIKeysPerUser > 0 {
+			apiKeys, err := handler.apiKeyService.GetAPIKeys(user.ID)
+			if err != nil {
+				return httperror.InternalServerError("Unable to retrieve the user API keys", err)
+			}
+
+			if len(apiKeys) >= settings.MaxAPIKeysPerUser {
+				return httperror.BadRequest("Unable to create an API key", fmt.Errorf("the user already owns the maximum number of %d API keys", settings.MaxAPIKeysPerUser))
+			}
+		}
+	}
+
 	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateApiKey(*user, payload.Description)
 	if err != nil {
 		return httperror.InternalServerError("Internal Server Error", err)
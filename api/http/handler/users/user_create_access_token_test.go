@@ -154,3 +154,78 @@ this string is longer than 128 characters and hence this will fail.
 		}
 	}
 }
+
+func Test_userCreateAccessToken_limit(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.MaxAPIKeysPerUser = 1
+	err = store.Settings().UpdateSettings(settings)
+	is.NoError(err)
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	createToken := func(userID portainer.UserID, token string, data userAccessTokenCreatePayload) int {
+		payload, err := json.Marshal(data)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/tokens", userID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("standard user cannot exceed the limit", func(t *testing.T) {
+		is.Equal(http.StatusCreated, createToken(user.ID, jwt, userAccessTokenCreatePayload{Description: "first"}))
+		is.Equal(http.StatusBadRequest, createToken(user.ID, jwt, userAccessTokenCreatePayload{Description: "second"}))
+	})
+
+	t.Run("standard user cannot override the limit", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, createToken(user.ID, jwt, userAccessTokenCreatePayload{Description: "second", Override: true}))
+	})
+
+	t.Run("admin can override the limit", func(t *testing.T) {
+		is.Equal(http.StatusCreated, createToken(adminUser.ID, adminJWT, userAccessTokenCreatePayload{Description: "first"}))
+		is.Equal(http.StatusBadRequest, createToken(adminUser.ID, adminJWT, userAccessTokenCreatePayload{Description: "second"}))
+		is.Equal(http.StatusCreated, createToken(adminUser.ID, adminJWT, userAccessTokenCreatePayload{Description: "second", Override: true}))
+	})
+
+	t.Run("user profile exposes the API key count", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", adminJWT))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp userInspectResponse
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		is.NoError(err, "response should be json")
+		is.Equal(2, resp.APIKeyCount)
+	})
+}
@@ -64,18 +64,11 @@ func (handler *Handler) deleteAdminUser(w http.ResponseWriter, user *portainer.U
 		return handler.deleteUser(w, user)
 	}
 
-	users, err := handler.DataStore.User().ReadAll()
+	localAdminCount, err := handler.countAdministrators(true)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve users from the database", err)
 	}
 
-	localAdminCount := 0
-	for _, u := range users {
-		if u.Role == portainer.AdministratorRole && u.Password != "" {
-			localAdminCount++
-		}
-	}
-
 	if localAdminCount < 2 {
 		return httperror.InternalServerError("Cannot remove local administrator user", errCannotRemoveLastLocalAdmin)
 	}
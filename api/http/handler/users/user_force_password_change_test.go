@@ -0,0 +1,106 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userForcePasswordChange(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	err = store.User().Create(admin)
+	is.NoError(err, "error creating admin")
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Password: hash}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.RequiredPasswordLength = 1
+	err = store.Settings().UpdateSettings(settings)
+	is.NoError(err)
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: admin.ID, Username: admin.Username, Role: admin.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	forcePasswordChange := func(jwt string) int {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/force-password-change", user.ID), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	updatePassword := func(data userUpdatePasswordPayload) int {
+		payload, err := json.Marshal(data)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", userJWT))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("standard user cannot force a password change", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, forcePasswordChange(userJWT))
+	})
+
+	t.Run("admin forces a password change", func(t *testing.T) {
+		is.Equal(http.StatusNoContent, forcePasswordChange(adminJWT))
+
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.True(user.ForcePasswordChange)
+	})
+
+	t.Run("the current password is still required", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, updatePassword(userUpdatePasswordPayload{Password: "wrong-password", NewPassword: "new-password"}))
+	})
+
+	t.Run("changing the password clears the flag", func(t *testing.T) {
+		is.Equal(http.StatusOK, updatePassword(userUpdatePasswordPayload{Password: "current-password", NewPassword: "new-password"}))
+
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.False(user.ForcePasswordChange)
+	})
+}
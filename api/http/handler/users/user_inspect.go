@@ -11,6 +11,14 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
+type userInspectResponse struct {
+	portainer.User
+	// Number of API keys owned by the user
+	APIKeyCount int `json:"APIKeyCount" example:"1"`
+	// Unix timestamp at which the password expires, 0 when it does not
+	PasswordExpiresAt int64 `json:"PasswordExpiresAt" example:"1595175600"`
+}
+
 // @id UserInspect
 // @summary Inspect a user
 // @description Retrieve details about a user.
@@ -21,7 +29,7 @@ import (
 // @security jwt
 // @produce json
 // @param id path int true "User identifier"
-// @success 200 {object} portainer.User "Success"
+// @success 200 {object} userInspectResponse "Success"
 // @failure 400 "Invalid request"
 // @failure 403 "Permission denied"
 // @failure 404 "User not found"
@@ -49,6 +57,20 @@ func (handler *Handler) userInspect(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
 	}
 
+	apiKeys, err := handler.apiKeyService.GetAPIKeys(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the user API keys", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
 	hideFields(user)
-	return response.JSON(w, user)
+	return response.JSON(w, userInspectResponse{
+		User:              *user,
+		APIKeyCount:       len(apiKeys),
+		PasswordExpiresAt: security.PasswordExpiresAt(user, settings.InternalAuthSettings.PasswordExpiryDays),
+	})
 }
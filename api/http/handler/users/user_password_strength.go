@@ -0,0 +1,73 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// passwordStrengthMaxPayloadSize bounds the request body, candidate passwords are short
+const passwordStrengthMaxPayloadSize = 4096
+
+var errInvalidPasswordStrengthPayload = errors.New("Invalid password strength payload")
+
+type userPasswordStrengthPayload struct {
+	// Candidate password
+	Password string `example:"new_passwd" validate:"required"`
+}
+
+func (payload *userPasswordStrengthPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Password) {
+		return errors.New("Invalid password")
+	}
+
+	return nil
+}
+
+// @id UserPasswordStrength
+// @summary Evaluate the strength of a candidate password
+// @description Evaluate the strength of a candidate password for the current user, without storing it.
+// @description The response holds the score of the password and the rules it does not satisfy.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body userPasswordStrengthPayload true "details"
+// @success 200 {object} security.PasswordStrengthResult "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Too many requests"
+// @failure 500 "Server error"
+// @router /users/password-strength [post]
+func (handler *Handler) userPasswordStrength(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	// the body is read manually so that it can be zeroed, and decoding errors are not forwarded because they can quote the password
+	body, err := io.ReadAll(io.LimitReader(r.Body, passwordStrengthMaxPayloadSize))
+	defer clear(body)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", errInvalidPasswordStrengthPayload)
+	}
+
+	var payload userPasswordStrengthPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", errInvalidPasswordStrengthPayload)
+	}
+
+	if err := payload.Validate(r); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	return response.JSON(w, handler.passwordStrengthChecker.Evaluate(payload.Password, tokenData.Username))
+}
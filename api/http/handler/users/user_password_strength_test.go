@@ -0,0 +1,75 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userPasswordStrength(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.RequiredPasswordLength = 12
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	evaluate := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/password-strength", strings.NewReader(body))
+		req.Header.Add("Authorization", "Bearer "+jwt)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("a standard user evaluates a candidate password", func(t *testing.T) {
+		rr := evaluate(`{"Password": "standard-pass"}`)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var result security.PasswordStrengthResult
+		is.NoError(json.NewDecoder(rr.Body).Decode(&result))
+		is.True(result.Strong)
+		is.Less(result.Score, 100)
+		is.NotEmpty(result.FailedRules)
+	})
+
+	t.Run("decoding errors do not quote the password", func(t *testing.T) {
+		rr := evaluate(`{"Password": "secret-value`)
+		is.Equal(http.StatusBadRequest, rr.Code)
+		is.NotContains(rr.Body.String(), "secret-value")
+	})
+
+	t.Run("the password is required", func(t *testing.T) {
+		is.Equal(http.StatusBadRequest, evaluate(`{}`).Code)
+	})
+}
@@ -0,0 +1,75 @@
+package users
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type userRevokeSessionsResponse struct {
+	// Unix timestamp before which the sessions of the user are invalidated
+	InvalidatedAt int64 `json:"invalidatedAt" example:"1587399600"`
+	// Whether the session used for the request was invalidated and the user must log in again
+	MustReauthenticate bool `json:"mustReauthenticate" example:"false"`
+}
+
+// @id UserRevokeSessions
+// @summary Revoke the sessions of a user
+// @description Invalidate every session of the user without changing the password, e.g. when a device is lost.
+// @description The user must log in again everywhere, the API keys of the user are not revoked.
+// @description **Access policy**: authenticated, administrators or the user
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} userRevokeSessionsResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/revoke-sessions [post]
+func (handler *Handler) userRevokeSessions(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	if handler.demoService.IsDemoUser(portainer.UserID(userID)) {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
+		return httperror.Forbidden("Permission denied to revoke the sessions of the user", httperrors.ErrUnauthorized)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	user.TokenIssueAt = time.Now().Unix()
+
+	err = handler.DataStore.User().Update(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.JSON(w, userRevokeSessionsResponse{
+		InvalidatedAt:      user.TokenIssueAt,
+		MustReauthenticate: handler.sessionInvalidated(r, tokenData, user),
+	})
+}
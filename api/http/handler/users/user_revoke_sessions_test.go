@@ -0,0 +1,76 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userRevokeSessions(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	other := &portainer.User{Username: "other", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(other))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+
+	revokeSessions := func(requester *portainer.User) *httptest.ResponseRecorder {
+		token, err := jwtService.GenerateToken(&portainer.TokenData{ID: requester.ID, Username: requester.Username, Role: requester.Role})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/revoke-sessions", user.ID), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("another user cannot revoke the sessions", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, revokeSessions(other).Code)
+	})
+
+	for _, requester := range []*portainer.User{admin, user} {
+		t.Run(requester.Username+" revokes the sessions", func(t *testing.T) {
+			rr := revokeSessions(requester)
+			is.Equal(http.StatusOK, rr.Code)
+
+			var resp userRevokeSessionsResponse
+			is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+			is.NotZero(resp.InvalidatedAt)
+
+			revoked, err := store.User().Read(user.ID)
+			is.NoError(err)
+			is.Equal(resp.InvalidatedAt, revoked.TokenIssueAt)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const maxSecurityStatusUsers = 500
+
+type userSecurityStatusPayload struct {
+	// Identifiers of the users to inspect
+	UserIDs []portainer.UserID `validate:"required" json:"userIds" example:"1,2"`
+}
+
+func (payload *userSecurityStatusPayload) Validate(r *http.Request) error {
+	if len(payload.UserIDs) == 0 {
+		return errors.New("invalid user identifiers. cannot be empty")
+	}
+
+	if len(payload.UserIDs) > maxSecurityStatusUsers {
+		return fmt.Errorf("invalid user identifiers. cannot contain more than %d identifiers", maxSecurityStatusUsers)
+	}
+
+	return nil
+}
+
+type userSecurityStatus struct {
+	ID       portainer.UserID   `json:"id" example:"1"`
+	Username string             `json:"username" example:"bob"`
+	Role     portainer.UserRole `json:"role" example:"1"`
+	// Whether the current password was set by an administrator
+	PasswordSetByAdmin bool `json:"passwordSetByAdmin" example:"false"`
+	// Age of the password in seconds, 0 when the password change was never recorded
+	PasswordAge int64 `json:"passwordAge" example:"86400"`
+	// Unix timestamp of the last successful login, 0 when the user never logged in since it is recorded
+	LastLoginAt int64 `json:"lastLoginAt" example:"1587399600"`
+	// Number of API keys owned by the user
+	APIKeyCount int `json:"apiKeyCount" example:"1"`
+}
+
+// @id UserSecurityStatus
+// @summary Inspect the security status of several users
+// @description Retrieve the security related status of a list of users in a single call. Password hashes are never returned.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body userSecurityStatusPayload true "User identifiers"
+// @success 200 {array} userSecurityStatus "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/security/status [post]
+func (handler *Handler) userSecurityStatus(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload userSecurityStatusPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	now := time.Now().Unix()
+
+	statuses := make([]userSecurityStatus, 0, len(payload.UserIDs))
+	for _, userID := range payload.UserIDs {
+		user, err := handler.DataStore.User().Read(userID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound(fmt.Sprintf("Unable to find the user %d inside the database", userID), err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+		}
+
+		apiKeys, err := handler.apiKeyService.GetAPIKeys(user.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the user API keys", err)
+		}
+
+		status := userSecurityStatus{
+			ID:                 user.ID,
+			Username:           user.Username,
+			Role:               user.Role,
+			PasswordSetByAdmin: user.PasswordSetByAdmin,
+			LastLoginAt:        user.LastLoginAt,
+			APIKeyCount:        len(apiKeys),
+		}
+
+		if user.PasswordUpdatedAt > 0 {
+			status.PasswordAge = now - user.PasswordUpdatedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return response.JSON(w, statuses)
+}
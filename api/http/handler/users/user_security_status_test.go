@@ -0,0 +1,79 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userSecurityStatus(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole, Password: "hash"}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole, Password: "hash", PasswordSetByAdmin: true, PasswordUpdatedAt: time.Now().Add(-time.Hour).Unix()}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	request := func(token string, userIDs ...portainer.UserID) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(userSecurityStatusPayload{UserIDs: userIDs})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/security/status", bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("admin retrieves the status of several users", func(t *testing.T) {
+		rr := request(adminJWT, adminUser.ID, user.ID)
+		is.Equal(http.StatusOK, rr.Code)
+		is.NotContains(rr.Body.String(), "hash")
+
+		var statuses []userSecurityStatus
+		err := json.NewDecoder(rr.Body).Decode(&statuses)
+		is.NoError(err, "response should be json")
+		is.Len(statuses, 2)
+		is.True(statuses[1].PasswordSetByAdmin)
+		is.GreaterOrEqual(statuses[1].PasswordAge, int64(3600))
+	})
+
+	t.Run("unknown user is reported", func(t *testing.T) {
+		is.Equal(http.StatusNotFound, request(adminJWT, 42).Code)
+	})
+
+	t.Run("standard user is denied", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, request(jwt, user.ID).Code)
+	})
+}
@@ -31,11 +31,11 @@ type userUpdatePayload struct {
 
 func (payload *userUpdatePayload) Validate(r *http.Request) error {
 	if govalidator.Contains(payload.Username, " ") {
-		return errors.New("invalid username. Must not contain any whitespace")
+		return httperror.WithCode(httperrors.CodeUsernameInvalid, errors.New("invalid username. Must not contain any whitespace"))
 	}
 
 	if payload.Role != 0 && payload.Role != 1 && payload.Role != 2 {
-		return errors.New("invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
+		return httperror.WithCode(httperrors.CodeRoleInvalid, errors.New("invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)"))
 	}
 	return nil
 }
@@ -55,7 +55,7 @@ func (payload *userUpdatePayload) Validate(r *http.Request) error {
 // @failure 400 "Invalid request"
 // @failure 403 "Permission denied"
 // @failure 404 "User not found"
-// @failure 409 "Username already exist"
+// @failure 409 "Username already exist, or the last administrator tries to change their own role"
 // @failure 500 "Server error"
 // @router /users/{id} [put]
 func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -112,6 +112,9 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 			return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 		}
 		user.TokenIssueAt = time.Now().Unix()
+		user.PasswordSetByAdmin = tokenData.ID != user.ID
+		user.PasswordUpdatedAt = user.TokenIssueAt
+		user.PasswordExpired = false
 	}
 
 	if payload.Theme != nil {
@@ -120,6 +123,24 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		}
 	}
 
+	if payload.Role != 0 && user.ID == tokenData.ID && user.Role == portainer.AdministratorRole && portainer.UserRole(payload.Role) != portainer.AdministratorRole {
+		settings, err := handler.DataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+		}
+
+		if !settings.AllowLastAdminSelfDemotion {
+			adminCount, err := handler.countAdministrators(false)
+			if err != nil {
+				return httperror.InternalServerError("Unable to retrieve users from the database", err)
+			}
+
+			if adminCount < 2 {
+				return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Cannot change your own role as the last administrator. Promote another administrator first", Err: errCannotDemoteLastAdmin}
+			}
+		}
+	}
+
 	if payload.Role != 0 {
 		user.Role = portainer.UserRole(payload.Role)
 		user.TokenIssueAt = time.Now().Unix()
@@ -133,8 +154,7 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 	// remove all of the users persisted API keys
 	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
 
-	// hide the password field in the response payload
-	user.Password = ""
+	hideFields(user)
 
 	return response.JSON(w, user)
 }
@@ -0,0 +1,114 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type userUpdateKubeconfigExpiryPayload struct {
+	// Expiry of the kubeconfigs generated for the user, bounded by the global expiry. Empty to use the global expiry
+	KubeconfigExpiry string `example:"8h"`
+}
+
+func (payload *userUpdateKubeconfigExpiryPayload) Validate(r *http.Request) error {
+	if payload.KubeconfigExpiry == "" {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(payload.KubeconfigExpiry); err != nil {
+		return errors.New("Invalid Kubeconfig Expiry")
+	}
+
+	return nil
+}
+
+type userUpdateKubeconfigExpiryResponse struct {
+	// Expiry of the kubeconfigs generated for the user, empty when the global expiry applies
+	KubeconfigExpiry string `json:"KubeconfigExpiry" example:"8h"`
+	// Adjustments made to the requested expiry
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+// @id UserUpdateKubeconfigExpiry
+// @summary Update the kubeconfig expiry of a user
+// @description Set the expiry of the kubeconfigs generated for the user. An expiry exceeding the global KubeconfigExpiry setting
+// @description is lowered to the global expiry, and the response holds a warning. An empty expiry restores the global expiry.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body userUpdateKubeconfigExpiryPayload true "details"
+// @success 200 {object} userUpdateKubeconfigExpiryResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/kubeconfig-expiry [put]
+func (handler *Handler) userUpdateKubeconfigExpiry(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
+		return httperror.Forbidden("Permission denied to update user", httperrors.ErrUnauthorized)
+	}
+
+	var payload userUpdateKubeconfigExpiryPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	var warnings []string
+
+	user.KubeconfigExpiry = payload.KubeconfigExpiry
+	if payload.KubeconfigExpiry != "" {
+		expiry, clamped, err := jwt.KubeconfigExpiry(settings.KubeconfigExpiry, payload.KubeconfigExpiry)
+		if err != nil {
+			return httperror.InternalServerError("Unable to compute the kubeconfig expiry", err)
+		}
+
+		if clamped {
+			user.KubeconfigExpiry = expiry.String()
+			warnings = append(warnings, fmt.Sprintf("The kubeconfig expiry %s exceeds the maximum of %s and was lowered to it", payload.KubeconfigExpiry, user.KubeconfigExpiry))
+		}
+	}
+
+	err = handler.DataStore.User().Update(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.JSON(w, userUpdateKubeconfigExpiryResponse{KubeconfigExpiry: user.KubeconfigExpiry, Warnings: warnings})
+}
@@ -0,0 +1,97 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUpdateKubeconfigExpiry(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.KubeconfigExpiry = "24h"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	updateExpiry := func(expiry string) (int, userUpdateKubeconfigExpiryResponse) {
+		payload, err := json.Marshal(userUpdateKubeconfigExpiryPayload{KubeconfigExpiry: expiry})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/kubeconfig-expiry", user.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		var resp userUpdateKubeconfigExpiryResponse
+		if rr.Code == http.StatusOK {
+			is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		}
+
+		return rr.Code, resp
+	}
+
+	storedExpiry := func() string {
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		return user.KubeconfigExpiry
+	}
+
+	t.Run("invalid expiry is rejected", func(t *testing.T) {
+		code, _ := updateExpiry("tomorrow")
+		is.Equal(http.StatusBadRequest, code)
+	})
+
+	t.Run("expiry within the maximum is kept", func(t *testing.T) {
+		code, resp := updateExpiry("8h")
+		is.Equal(http.StatusOK, code)
+		is.Equal("8h", resp.KubeconfigExpiry)
+		is.Empty(resp.Warnings)
+		is.Equal("8h", storedExpiry())
+	})
+
+	t.Run("expiry above the maximum is clamped", func(t *testing.T) {
+		code, resp := updateExpiry("48h")
+		is.Equal(http.StatusOK, code)
+		is.Equal("24h0m0s", resp.KubeconfigExpiry)
+		is.Len(resp.Warnings, 1)
+		is.Equal("24h0m0s", storedExpiry())
+	})
+
+	t.Run("empty expiry restores the global expiry", func(t *testing.T) {
+		code, resp := updateExpiry("")
+		is.Equal(http.StatusOK, code)
+		is.Empty(resp.KubeconfigExpiry)
+		is.Empty(storedExpiry())
+	})
+}
@@ -2,10 +2,14 @@ package users
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -13,6 +17,7 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/rs/zerolog/log"
 )
 
 type userUpdatePasswordPayload struct {
@@ -20,6 +25,15 @@ type userUpdatePasswordPayload struct {
 	Password string `example:"passwd" validate:"required"`
 	// New Password
 	NewPassword string `example:"new_passwd" validate:"required"`
+	// TOTP code of the second factor of the user, required for the administrators when RequireTwoFactorForAdmins is set
+	TOTPCode string `example:"123456"`
+}
+
+type userUpdatePasswordResponse struct {
+	// Unix timestamp before which the sessions of the user are invalidated
+	InvalidatedAt int64 `json:"invalidatedAt" example:"1587399600"`
+	// Whether the session used for the request was invalidated and the user must log in again
+	MustReauthenticate bool `json:"mustReauthenticate" example:"true"`
 }
 
 func (payload *userUpdatePasswordPayload) Validate(r *http.Request) error {
@@ -29,12 +43,27 @@ func (payload *userUpdatePasswordPayload) Validate(r *http.Request) error {
 	if govalidator.IsNull(payload.NewPassword) {
 		return errors.New("Invalid new password")
 	}
+	// the length is counted in bytes, a character can be encoded in up to 4 bytes
+	if len(payload.NewPassword) > crypto.MaxDataLength {
+		return errPasswordTooLong
+	}
 	return nil
 }
 
 // @id UserUpdatePassword
 // @summary Update password for a user
 // @description Update password for the specified user.
+// @description When a reauthentication window is configured in the settings, the session must have been authenticated within the window.
+// @description Every session of the user is invalidated, the response tells whether the session used for the request must log in again.
+// @description The current password is required even when the password change was forced by an administrator.
+// @description When the authentication method is LDAP or OAuth, only the initial administrator and the administrators allowed by the
+// @description local admin fallback have an internal password, the password of the other users cannot be changed.
+// @description When RequireTwoFactorForAdmins is set in the settings, the password of an administrator can only be changed with a valid
+// @description TOTP code of the second factor of the administrator, an invalid code counts as a failed password change.
+// @description A code can only be used once.
+// @description A new password longer than 72 bytes is rejected, bcrypt would ignore the bytes beyond. The length is counted in bytes
+// @description of the UTF-8 encoding, a password with multibyte characters is rejected with fewer than 72 characters.
+// @description A new password that is not strong enough is rejected with the score of the password and the rules it does not satisfy.
 // @description **Access policy**: authenticated
 // @tags users
 // @security ApiKeyAuth
@@ -43,10 +72,13 @@ func (payload *userUpdatePasswordPayload) Validate(r *http.Request) error {
 // @produce json
 // @param id path int true "identifier"
 // @param body body userUpdatePasswordPayload true "details"
-// @success 204 "Success"
+// @success 200 {object} userUpdatePasswordResponse "Success"
 // @failure 400 "Invalid request"
-// @failure 403 "Permission denied"
+// @failure 401 "Session authenticated too long ago, log in again"
+// @failure 403 "Permission denied, current password mismatch or missing or invalid second factor"
 // @failure 404 "User not found"
+// @failure 409 "The password of the user is managed by the external authentication provider"
+// @failure 429 "Too many failed password changes, retry after the delay of the Retry-After header"
 // @failure 500 "Server error"
 // @router /users/{id}/passwd [put]
 func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -55,10 +87,6 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 		return httperror.BadRequest("Invalid user identifier route variable", err)
 	}
 
-	if handler.demoService.IsDemoUser(portainer.UserID(userID)) {
-		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
-	}
-
 	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
@@ -81,26 +109,163 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
 	}
 
-	err = handler.CryptoService.CompareHashAndData(user.Password, payload.Password)
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !security.CanManageInternalPassword(user, settings) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The password is managed by the external authentication provider", Err: errInternalPasswordDisabled}
+	}
+
+	err = checkRecentAuthentication(settings, tokenData, time.Now())
+	if err != nil {
+		return httperror.Unauthorized("The session is too old to change the password. Please log in again", err)
+	}
+
+	maxFailedAttempts := settings.InternalAuthSettings.PasswordChangeMaxFailedAttempts
+	if maxFailedAttempts > 0 {
+		if retryAfter := handler.passwordChangeLimiter.retryAfter(user.ID, time.Now()); retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+			return &httperror.HandlerError{StatusCode: http.StatusTooManyRequests, Message: "Too many failed password changes. Please try again later", Err: errPasswordChangeLocked}
+		}
+	}
+
+	// the password is hashed again below with the current cost, an outdated hash needs no special handling
+	_, err = handler.CryptoService.CompareHashAndData(user.Password, payload.Password)
 	if err != nil {
-		return httperror.Forbidden("Current password doesn't match", errors.New("Current password does not match the password provided. Please try again"))
+		if maxFailedAttempts > 0 {
+			handler.passwordChangeLimiter.fail(user.ID, maxFailedAttempts, passwordChangeLockout(settings), time.Now())
+		}
+
+		return httperror.Forbidden("Current password doesn't match", errors.New("Current password does not match the password provided. Please try again")).WithCode(httperrors.CodePasswordMismatch)
+	}
+
+	if settings.RequireTwoFactorForAdmins && user.Role == portainer.AdministratorRole {
+		if user.TOTPSecret == "" || payload.TOTPCode == "" {
+			return httperror.Forbidden("A second factor is required to change the password of an administrator", errTwoFactorRequired)
+		}
+
+		step, ok := security.ValidateTOTP(user.TOTPSecret, payload.TOTPCode, time.Now(), user.TOTPLastStep)
+		if !ok {
+			if maxFailedAttempts > 0 {
+				handler.passwordChangeLimiter.fail(user.ID, maxFailedAttempts, passwordChangeLockout(settings), time.Now())
+			}
+
+			return httperror.Forbidden("Invalid second factor code", errTwoFactorInvalid)
+		}
+
+		// the step is persisted with the new password, the code cannot be replayed
+		user.TOTPLastStep = step
 	}
 
-	if !handler.passwordStrengthChecker.Check(payload.NewPassword) {
-		return httperror.BadRequest("Password does not meet the requirements", nil)
+	if user.PasswordSetByAdmin && tokenData.ID == user.ID && payload.NewPassword == payload.Password && settings.InternalAuthSettings.RejectTemporaryPasswordReuse {
+		return httperror.BadRequest("New password must differ from the temporary password", errTemporaryPasswordReuse)
 	}
 
+	if strength := handler.passwordStrengthChecker.Evaluate(payload.NewPassword, user.Username); !strength.Strong {
+		return writePasswordStrengthFailure(w, strength)
+	}
+
+	history := passwordHistory(user, settings.InternalAuthSettings.PasswordHistoryDepth)
+	if handler.passwordInHistory(payload.NewPassword, history) {
+		return httperror.BadRequest(fmt.Sprintf("The new password must differ from the last %d passwords", settings.InternalAuthSettings.PasswordHistoryDepth), errPasswordReuse)
+	}
+
+	user.PasswordHistory = history
+
 	user.Password, err = handler.CryptoService.Hash(payload.NewPassword)
 	if err != nil {
 		return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 	}
 
 	user.TokenIssueAt = time.Now().Unix()
+	user.PasswordSetByAdmin = tokenData.ID != user.ID
+	user.PasswordUpdatedAt = user.TokenIssueAt
+	user.PasswordExpired = false
+	user.ForcePasswordChange = false
 
 	err = handler.DataStore.User().Update(user.ID, user)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
 	}
 
-	return response.Empty(w)
+	handler.passwordChangeLimiter.reset(user.ID)
+
+	return response.JSON(w, userUpdatePasswordResponse{
+		InvalidatedAt:      user.TokenIssueAt,
+		MustReauthenticate: handler.sessionInvalidated(r, tokenData, user),
+	})
+}
+
+// sessionInvalidated returns whether the JWT used for the request was invalidated by the password change of the user.
+// API keys are not invalidated, neither are the sessions of administrators changing the password of another user
+func (handler *Handler) sessionInvalidated(r *http.Request, tokenData *portainer.TokenData, user *portainer.User) bool {
+	if tokenData.ID != user.ID {
+		return false
+	}
+
+	token, err := security.ExtractBearerToken(r)
+	if err != nil {
+		return false
+	}
+
+	_, err = handler.JWTService.ParseAndVerifyToken(token)
+	if err == nil {
+		// tokens are invalidated by comparing timestamps in seconds, a token issued within the same second is still valid
+		log.Warn().Int("user_id", int(user.ID)).Msg("the session used to change the password is still valid, it was issued in the same second or the clock was skewed")
+
+		return false
+	}
+
+	return true
+}
+
+// passwordHistory returns the hashes of the passwords that cannot be reused, the current one and the most recent
+// previous ones, up to depth entries. It is stored as the history of the user when the password changes
+func passwordHistory(user *portainer.User, depth int) []string {
+	if depth <= 0 {
+		return nil
+	}
+
+	history := append([]string{}, user.PasswordHistory...)
+	if user.Password != "" {
+		history = append(history, user.Password)
+	}
+
+	if len(history) > depth {
+		history = history[len(history)-depth:]
+	}
+
+	return history
+}
+
+func (handler *Handler) passwordInHistory(password string, history []string) bool {
+	for _, hash := range history {
+		if _, err := handler.CryptoService.CompareHashAndData(hash, password); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRecentAuthentication returns an error when the session was authenticated before the password change reauthentication window.
+// Sessions opened with an API key have no authentication time and are always rejected when the window is enabled.
+func checkRecentAuthentication(settings *portainer.Settings, tokenData *portainer.TokenData, now time.Time) error {
+	if settings.PasswordChangeReauthenticationWindow == "" {
+		return nil
+	}
+
+	window, err := time.ParseDuration(settings.PasswordChangeReauthenticationWindow)
+	if err != nil || window <= 0 {
+		return nil
+	}
+
+	if tokenData.AuthenticatedAt == 0 || now.Sub(time.Unix(tokenData.AuthenticatedAt, 0)) > window {
+		return errStaleAuthentication
+	}
+
+	return nil
 }
@@ -0,0 +1,416 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUpdatePassword_temporaryPassword(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	temporaryPassword := "temporary-password"
+	hash, err := cryptoService.Hash(temporaryPassword)
+	is.NoError(err)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Password: hash, PasswordSetByAdmin: true, PasswordExpired: true}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.RequiredPasswordLength = 1
+	settings.InternalAuthSettings.RejectTemporaryPasswordReuse = true
+	err = store.Settings().UpdateSettings(settings)
+	is.NoError(err)
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	updatePassword := func(data userUpdatePasswordPayload) int {
+		payload, err := json.Marshal(data)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("user cannot keep the temporary password", func(t *testing.T) {
+		is.Equal(http.StatusBadRequest, updatePassword(userUpdatePasswordPayload{Password: temporaryPassword, NewPassword: temporaryPassword}))
+	})
+
+	t.Run("user replaces the temporary password", func(t *testing.T) {
+		is.Equal(http.StatusOK, updatePassword(userUpdatePasswordPayload{Password: temporaryPassword, NewPassword: "new-password"}))
+
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.False(user.PasswordSetByAdmin)
+		is.False(user.PasswordExpired, "the expired flag should be cleared")
+	})
+}
+
+func Test_checkRecentAuthentication(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+	settings := &portainer.Settings{}
+	recent := &portainer.TokenData{AuthenticatedAt: now.Add(-5 * time.Minute).Unix()}
+	stale := &portainer.TokenData{AuthenticatedAt: now.Add(-time.Hour).Unix()}
+	apiKey := &portainer.TokenData{}
+
+	is.NoError(checkRecentAuthentication(settings, stale, now), "the check is disabled by default")
+
+	settings.PasswordChangeReauthenticationWindow = "15m"
+	is.NoError(checkRecentAuthentication(settings, recent, now))
+	is.ErrorIs(checkRecentAuthentication(settings, stale, now), errStaleAuthentication)
+	is.ErrorIs(checkRecentAuthentication(settings, apiKey, now), errStaleAuthentication)
+}
+
+func Test_passwordHistory(t *testing.T) {
+	is := assert.New(t)
+
+	cryptoService := &crypto.Service{}
+	h := &Handler{CryptoService: cryptoService}
+
+	hashes := make([]string, 4)
+	for i := range hashes {
+		hash, err := cryptoService.Hash(fmt.Sprintf("password-%d", i))
+		is.NoError(err)
+		hashes[i] = hash
+	}
+
+	user := &portainer.User{Password: hashes[3], PasswordHistory: hashes[:3]}
+
+	is.Nil(passwordHistory(user, 0), "the history is disabled")
+
+	history := passwordHistory(user, 3)
+	is.Equal(hashes[1:], history, "the current password is pushed and the oldest ones are trimmed")
+
+	is.True(h.passwordInHistory("password-3", history), "the current password cannot be reused")
+	is.True(h.passwordInHistory("password-1", history))
+	is.False(h.passwordInHistory("password-0", history), "trimmed passwords can be reused")
+}
+
+func Test_userUpdatePassword_lockout(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Password: hash}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.RequiredPasswordLength = 1
+	settings.InternalAuthSettings.PasswordChangeMaxFailedAttempts = 2
+	settings.InternalAuthSettings.PasswordChangeLockoutDuration = "10m"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	updatePassword := func(password string) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(userUpdatePasswordPayload{Password: password, NewPassword: "new-password"})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	is.Equal(http.StatusForbidden, updatePassword("wrong-password").Code)
+	is.Equal(http.StatusForbidden, updatePassword("wrong-password").Code)
+
+	rr := updatePassword("current-password")
+	is.Equal(http.StatusTooManyRequests, rr.Code, "the correct password is rejected during the lockout")
+	is.Equal("600", rr.Header().Get("Retry-After"))
+}
+
+func Test_userUpdatePassword_weakPassword(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Password: hash}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.RequiredPasswordLength = 12
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	payload, err := json.Marshal(userUpdatePasswordPayload{Password: "current-password", NewPassword: "standard"})
+	is.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusBadRequest, rr.Code)
+
+	var failure passwordStrengthFailure
+	is.NoError(json.NewDecoder(rr.Body).Decode(&failure))
+	is.Equal("Password does not meet the requirements", failure.Message)
+	is.False(failure.Strong)
+
+	rules := map[string]bool{}
+	for _, rule := range failure.FailedRules {
+		rules[rule.Rule] = rule.Enforced
+	}
+	is.Equal(map[string]bool{
+		security.PasswordRuleLength:             true,
+		security.PasswordRuleClasses:            false,
+		security.PasswordRuleEntropy:            false,
+		security.PasswordRuleUsernameSimilarity: false,
+	}, rules)
+}
+
+func Test_userUpdatePassword_externalAuthentication(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	// the initial administrator always has a local password
+	is.NoError(store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole, Password: hash}))
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Password: hash}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationOAuth
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	payload, err := json.Marshal(userUpdatePasswordPayload{Password: "current-password", NewPassword: "a-new-Password-1234"})
+	is.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), bytes.NewBuffer(payload))
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusConflict, rr.Code)
+
+	stored, err := store.User().Read(user.ID)
+	is.NoError(err)
+	is.Equal(hash, stored.Password, "the password is not changed")
+}
+
+func Test_userUpdatePassword_twoFactor(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole, Password: hash, TOTPSecret: secret}
+	is.NoError(store.User().Create(admin))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.RequireTwoFactorForAdmins = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: admin.ID, Username: admin.Username, Role: admin.Role})
+
+	updatePassword := func(code string) (int, string) {
+		payload, err := json.Marshal(userUpdatePasswordPayload{Password: "current-password", NewPassword: "a-new-Password-1234", TOTPCode: code})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", admin.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		var body struct {
+			Code string `json:"code"`
+		}
+		_ = json.NewDecoder(rr.Body).Decode(&body)
+
+		return rr.Code, body.Code
+	}
+
+	status, code := updatePassword("")
+	is.Equal(http.StatusForbidden, status)
+	is.Equal(httperrors.CodeTwoFactorRequired, code)
+
+	validCode, err := security.GenerateTOTP(secret, time.Now())
+	is.NoError(err)
+
+	invalidCode := "000000"
+	if validCode == invalidCode {
+		invalidCode = "111111"
+	}
+
+	status, code = updatePassword(invalidCode)
+	is.Equal(http.StatusForbidden, status)
+	is.Equal(httperrors.CodeTwoFactorInvalid, code)
+
+	status, _ = updatePassword(validCode)
+	is.Equal(http.StatusOK, status)
+
+	persisted, err := store.User().Read(admin.ID)
+	is.NoError(err)
+	is.NotZero(persisted.TOTPLastStep, "the time step of the code is remembered")
+
+	// the password and the session are restored so that only the second factor can fail
+	persisted.Password = admin.Password
+	persisted.TokenIssueAt = 0
+	is.NoError(store.User().Update(persisted.ID, persisted))
+
+	status, code = updatePassword(validCode)
+	is.Equal(http.StatusForbidden, status)
+	is.Equal(httperrors.CodeTwoFactorInvalid, code, "a code cannot be replayed")
+}
+
+func Test_userUpdatePasswordPayload_maxLength(t *testing.T) {
+	is := assert.New(t)
+
+	validate := func(newPassword string) error {
+		payload := userUpdatePasswordPayload{Password: "current-password", NewPassword: newPassword}
+		return payload.Validate(nil)
+	}
+
+	is.NoError(validate(strings.Repeat("a", crypto.MaxDataLength)))
+	is.ErrorIs(validate(strings.Repeat("a", crypto.MaxDataLength+1)), errPasswordTooLong)
+
+	// "é" is encoded in 2 bytes and "😀" in 4, the length is counted in bytes rather than in characters
+	is.NoError(validate(strings.Repeat("é", crypto.MaxDataLength/2)))
+	is.ErrorIs(validate(strings.Repeat("é", crypto.MaxDataLength/2+1)), errPasswordTooLong, "37 characters exceed 72 bytes")
+	is.ErrorIs(validate(strings.Repeat("😀", 20)), errPasswordTooLong, "20 characters exceed 72 bytes")
+}
+
+type jwtServiceStub struct {
+	dataservices.JWTService
+	err error
+}
+
+func (service *jwtServiceStub) ParseAndVerifyToken(token string) (*portainer.TokenData, error) {
+	return &portainer.TokenData{}, service.err
+}
+
+func Test_sessionInvalidated(t *testing.T) {
+	is := assert.New(t)
+
+	jwtService := &jwtServiceStub{err: errors.New("invalid JWT token")}
+	h := &Handler{JWTService: jwtService}
+
+	user := &portainer.User{ID: 2}
+
+	req := httptest.NewRequest(http.MethodPut, "/users/2/passwd", nil)
+	req.Header.Add("Authorization", "Bearer token")
+
+	is.True(h.sessionInvalidated(req, &portainer.TokenData{ID: 2}, user))
+	is.False(h.sessionInvalidated(req, &portainer.TokenData{ID: 1}, user), "the session of an administrator changing the password of another user is kept")
+	is.False(h.sessionInvalidated(httptest.NewRequest(http.MethodPut, "/users/2/passwd", nil), &portainer.TokenData{ID: 2}, user), "API keys are not invalidated")
+
+	jwtService.err = nil
+	is.False(h.sessionInvalidated(req, &portainer.TokenData{ID: 2}, user), "the token is still valid")
+}
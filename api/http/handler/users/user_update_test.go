@@ -1,6 +1,9 @@
 package users
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +12,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/jwt"
 	"github.com/stretchr/testify/assert"
@@ -54,3 +58,53 @@ func Test_updateUserRemovesAccessTokens(t *testing.T) {
 		is.Equal(0, len(keys))
 	})
 }
+
+func Test_userUpdate_lastAdminSelfDemotion(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(admin)
+	is.NoError(err, "error creating user")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+
+	jwt, _ := jwtService.GenerateToken(&portainer.TokenData{ID: admin.ID, Username: admin.Username, Role: admin.Role})
+
+	demote := func() int {
+		payload, err := json.Marshal(userUpdatePayload{Role: int(portainer.StandardUserRole)})
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", admin.ID), bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("last administrator cannot demote themselves", func(t *testing.T) {
+		is.Equal(http.StatusConflict, demote())
+	})
+
+	t.Run("administrator can demote themselves when another administrator exists", func(t *testing.T) {
+		err := store.User().Create(&portainer.User{Username: "other-admin", Role: portainer.AdministratorRole})
+		is.NoError(err)
+
+		is.Equal(http.StatusOK, demote())
+
+		user, err := store.User().Read(admin.ID)
+		is.NoError(err)
+		is.Equal(portainer.StandardUserRole, user.Role)
+	})
+}
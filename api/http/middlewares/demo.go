@@ -1,7 +1,9 @@
 package middlewares
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/portainer/portainer/api/http/errors"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -22,3 +24,69 @@ func RestrictDemoEnv(isDemo func() bool) mux.MiddlewareFunc {
 		})
 	}
 }
+
+// DemoBlockedRoute identifies an API route that is not available in demo environments
+type DemoBlockedRoute struct {
+	Method string
+	// Path template using the gorilla/mux syntax, e.g. /api/users/{id}
+	Path string
+}
+
+// DefaultDemoBlockedRoutes lists the destructive routes that are blocked in demo environments, the routes given with
+// the --demo-blocked-route flag are blocked as well
+var DefaultDemoBlockedRoutes = []DemoBlockedRoute{
+	{Method: http.MethodDelete, Path: "/api/users/{id}"},
+	{Method: http.MethodPut, Path: "/api/users/{id}/passwd"},
+	{Method: http.MethodDelete, Path: "/api/teams/{id}"},
+	{Method: http.MethodDelete, Path: "/api/endpoints/{id}"},
+	{Method: http.MethodDelete, Path: "/api/endpoint_groups/{id}"},
+	{Method: http.MethodDelete, Path: "/api/registries/{id}"},
+	{Method: http.MethodPut, Path: "/api/ssl"},
+}
+
+// ParseDemoBlockedRoute parses a route formatted as "METHOD /api/path", e.g. "DELETE /api/stacks/{id}"
+func ParseDemoBlockedRoute(route string) (DemoBlockedRoute, error) {
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	path = strings.TrimSpace(path)
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		return DemoBlockedRoute{}, fmt.Errorf("invalid demo blocked route %q, expected METHOD /api/path", route)
+	}
+
+	return DemoBlockedRoute{Method: strings.ToUpper(method), Path: path}, nil
+}
+
+// DemoBlockedRoutes returns the default blocked routes followed by the given routes
+func DemoBlockedRoutes(routes []string) ([]DemoBlockedRoute, error) {
+	blocked := append([]DemoBlockedRoute{}, DefaultDemoBlockedRoutes...)
+	for _, route := range routes {
+		blockedRoute, err := ParseDemoBlockedRoute(route)
+		if err != nil {
+			return nil, err
+		}
+
+		blocked = append(blocked, blockedRoute)
+	}
+
+	return blocked, nil
+}
+
+// RestrictDemoRoutes restricts the given routes on demo environments, the blocked requests are forbidden like the
+// demo checks of the handlers
+func RestrictDemoRoutes(isDemo func() bool, routes []DemoBlockedRoute) mux.MiddlewareFunc {
+	blocked := mux.NewRouter()
+	for _, route := range routes {
+		blocked.NewRoute().Methods(route.Method).Path(route.Path)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var match mux.RouteMatch
+			if !isDemo() || !blocked.Match(r, &match) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			httperror.WriteError(w, http.StatusForbidden, errors.ErrNotAvailableInDemo.Error(), errors.ErrNotAvailableInDemo)
+		})
+	}
+}
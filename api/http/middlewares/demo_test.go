@@ -39,3 +39,49 @@ func Test_notDemoEnvironment_shouldSucceed(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.StatusCode)
 
 }
+
+func Test_restrictDemoRoutes(t *testing.T) {
+	routes := []DemoBlockedRoute{{Method: http.MethodDelete, Path: "/api/users/{id}"}}
+
+	h := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		isDemo bool
+		method string
+		path   string
+		want   int
+	}{
+		{true, http.MethodDelete, "/api/users/2", http.StatusForbidden},
+		{true, http.MethodGet, "/api/users/2", http.StatusOK},
+		{true, http.MethodDelete, "/api/users/2/tokens/1", http.StatusOK},
+		{false, http.MethodDelete, "/api/users/2", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+
+		isDemo := test.isDemo
+		RestrictDemoRoutes(func() bool { return isDemo }, routes).Middleware(h).ServeHTTP(w, r)
+
+		assert.Equal(t, test.want, w.Result().StatusCode, "%s %s (demo: %t)", test.method, test.path, test.isDemo)
+	}
+}
+
+func Test_demoBlockedRoutes(t *testing.T) {
+	is := assert.New(t)
+
+	routes, err := DemoBlockedRoutes(nil)
+	is.NoError(err)
+	is.Equal(DefaultDemoBlockedRoutes, routes)
+
+	routes, err = DemoBlockedRoutes([]string{"delete /api/stacks/{id}"})
+	is.NoError(err)
+	is.Len(routes, len(DefaultDemoBlockedRoutes)+1)
+	is.Equal(DemoBlockedRoute{Method: http.MethodDelete, Path: "/api/stacks/{id}"}, routes[len(routes)-1])
+
+	for _, route := range []string{"", "DELETE", "/api/stacks/{id}", "DELETE api/stacks"} {
+		_, err = DemoBlockedRoutes([]string{route})
+		is.Error(err, route)
+	}
+}
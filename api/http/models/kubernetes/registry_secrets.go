@@ -0,0 +1,17 @@
+package kubernetes
+
+type (
+	// K8sRegistrySecret describes a registry secret created by Portainer, the credentials are never included
+	K8sRegistrySecret struct {
+		Name         string `json:"Name"`
+		Namespace    string `json:"Namespace"`
+		Type         string `json:"Type"`
+		CreationDate string `json:"CreationDate"`
+		// Registry identifier found in the secret annotations
+		RegistryID string `json:"RegistryID"`
+		// Registry type found in the secret labels
+		RegistryType string `json:"RegistryType"`
+		// Registry URLs referenced by the docker config of the secret
+		RegistryURLs []string `json:"RegistryURLs"`
+	}
+)
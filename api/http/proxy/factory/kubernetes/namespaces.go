@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/pkg/errors"
-	portainer "github.com/portainer/portainer/api"
 )
 
 func (transport *baseTransport) proxyNamespaceDeleteOperation(request *http.Request, namespace string) (*http.Response, error) {
@@ -32,13 +31,9 @@ func (transport *baseTransport) proxyNamespaceDeleteOperation(request *http.Requ
 			}
 
 			if len(namespaces) != len(registryAccessPolicies.Namespaces) {
-				updatedAccessPolicies := portainer.RegistryAccessPolicies{
-					Namespaces:         namespaces,
-					UserAccessPolicies: registryAccessPolicies.UserAccessPolicies,
-					TeamAccessPolicies: registryAccessPolicies.TeamAccessPolicies,
-				}
+				registryAccessPolicies.Namespaces = namespaces
 
-				registry.RegistryAccesses[endpointID] = updatedAccessPolicies
+				registry.RegistryAccesses[endpointID] = registryAccessPolicies
 				err := transport.dataStore.Registry().Update(registry.ID, &registry)
 				if err != nil {
 					return nil, err
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
 )
 
 // IsAdmin returns true if the logged-in user is an admin
@@ -117,11 +118,7 @@ func AuthorizedIsAdmin(context *RestrictedRequestContext) bool {
 // It will check if the user is part of the authorized users or part of a team that is
 // listed in the authorized teams of the environment(endpoint) and the associated group.
 func AuthorizedEndpointAccess(endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup, userID portainer.UserID, memberships []portainer.TeamMembership) bool {
-	groupAccess := AuthorizedAccess(userID, memberships, endpointGroup.UserAccessPolicies, endpointGroup.TeamAccessPolicies)
-	if !groupAccess {
-		return AuthorizedAccess(userID, memberships, endpoint.UserAccessPolicies, endpoint.TeamAccessPolicies)
-	}
-	return true
+	return explainEndpointPolicies(endpoint, endpointGroup, userID, memberships).Authorized
 }
 
 // authorizedEndpointGroupAccess ensure that the user can access the specified environment(endpoint) group.
@@ -160,3 +157,85 @@ func AuthorizedAccess(userID portainer.UserID, memberships []portainer.TeamMembe
 
 	return false
 }
+
+// EndpointAccessDecision describes why a user is or isn't allowed to access an environment(endpoint)
+type EndpointAccessDecision struct {
+	Authorized bool
+	// Factor that granted the access, empty when access is denied
+	Factor EndpointAccessFactor
+	// Access policy that granted the access, nil when access is granted by role or denied
+	Policy *portainer.AccessPolicy
+	// Team that granted the access when Factor is a team access policy
+	TeamID portainer.TeamID
+}
+
+// EndpointAccessFactor represents the deciding factor of an EndpointAccessDecision
+type EndpointAccessFactor string
+
+const (
+	// EndpointAccessByRole means the access is granted by the user role (administrator)
+	EndpointAccessByRole EndpointAccessFactor = "role"
+	// EndpointAccessByGroupUserPolicy means the access is granted by a user access policy of the environment group
+	EndpointAccessByGroupUserPolicy EndpointAccessFactor = "groupUserAccessPolicy"
+	// EndpointAccessByGroupTeamPolicy means the access is granted by a team access policy of the environment group
+	EndpointAccessByGroupTeamPolicy EndpointAccessFactor = "groupTeamAccessPolicy"
+	// EndpointAccessByUserPolicy means the access is granted by a user access policy of the environment
+	EndpointAccessByUserPolicy EndpointAccessFactor = "userAccessPolicy"
+	// EndpointAccessByTeamPolicy means the access is granted by a team access policy of the environment
+	EndpointAccessByTeamPolicy EndpointAccessFactor = "teamAccessPolicy"
+)
+
+// ExplainEndpointAccess evaluates the same rules as AuthorizedEndpointAccess but also reports
+// which access policy granted the access
+func ExplainEndpointAccess(endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup, userID portainer.UserID, role portainer.UserRole, memberships []portainer.TeamMembership) EndpointAccessDecision {
+	if role == portainer.AdministratorRole {
+		return EndpointAccessDecision{Authorized: true, Factor: EndpointAccessByRole}
+	}
+
+	return explainEndpointPolicies(endpoint, endpointGroup, userID, memberships)
+}
+
+// EndpointAccess returns the decision enforced by AuthorizedEndpointOperation for the user of the token
+func EndpointAccess(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, tokenData *portainer.TokenData) (EndpointAccessDecision, error) {
+	if tokenData.Role == portainer.AdministratorRole {
+		return ExplainEndpointAccess(endpoint, nil, tokenData.ID, tokenData.Role, nil), nil
+	}
+
+	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	if err != nil {
+		return EndpointAccessDecision{}, err
+	}
+
+	group, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil {
+		return EndpointAccessDecision{}, err
+	}
+
+	return ExplainEndpointAccess(endpoint, group, tokenData.ID, tokenData.Role, memberships), nil
+}
+
+func explainEndpointPolicies(endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup, userID portainer.UserID, memberships []portainer.TeamMembership) EndpointAccessDecision {
+	if decision, ok := explainAccess(userID, memberships, endpointGroup.UserAccessPolicies, endpointGroup.TeamAccessPolicies, EndpointAccessByGroupUserPolicy, EndpointAccessByGroupTeamPolicy); ok {
+		return decision
+	}
+
+	if decision, ok := explainAccess(userID, memberships, endpoint.UserAccessPolicies, endpoint.TeamAccessPolicies, EndpointAccessByUserPolicy, EndpointAccessByTeamPolicy); ok {
+		return decision
+	}
+
+	return EndpointAccessDecision{}
+}
+
+func explainAccess(userID portainer.UserID, memberships []portainer.TeamMembership, userAccessPolicies portainer.UserAccessPolicies, teamAccessPolicies portainer.TeamAccessPolicies, userFactor, teamFactor EndpointAccessFactor) (EndpointAccessDecision, bool) {
+	if policy, ok := userAccessPolicies[userID]; ok {
+		return EndpointAccessDecision{Authorized: true, Factor: userFactor, Policy: &policy}, true
+	}
+
+	for _, membership := range memberships {
+		if policy, ok := teamAccessPolicies[membership.TeamID]; ok {
+			return EndpointAccessDecision{Authorized: true, Factor: teamFactor, Policy: &policy, TeamID: membership.TeamID}, true
+		}
+	}
+
+	return EndpointAccessDecision{}, false
+}
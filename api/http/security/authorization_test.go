@@ -0,0 +1,73 @@
+package security
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExplainEndpointAccess(t *testing.T) {
+	is := assert.New(t)
+
+	endpoint := &portainer.Endpoint{
+		ID:                 1,
+		UserAccessPolicies: portainer.UserAccessPolicies{2: {RoleID: 3}},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{5: {RoleID: 4}},
+	}
+	group := &portainer.EndpointGroup{
+		ID:                 1,
+		TeamAccessPolicies: portainer.TeamAccessPolicies{6: {}},
+	}
+
+	decision := ExplainEndpointAccess(endpoint, group, 1, portainer.AdministratorRole, nil)
+	is.True(decision.Authorized)
+	is.Equal(EndpointAccessByRole, decision.Factor)
+
+	decision = ExplainEndpointAccess(endpoint, group, 2, portainer.StandardUserRole, nil)
+	is.True(decision.Authorized)
+	is.Equal(EndpointAccessByUserPolicy, decision.Factor)
+	is.Equal(portainer.RoleID(3), decision.Policy.RoleID)
+
+	decision = ExplainEndpointAccess(endpoint, group, 7, portainer.StandardUserRole, []portainer.TeamMembership{{UserID: 7, TeamID: 5}})
+	is.True(decision.Authorized)
+	is.Equal(EndpointAccessByTeamPolicy, decision.Factor)
+	is.Equal(portainer.TeamID(5), decision.TeamID)
+
+	decision = ExplainEndpointAccess(endpoint, group, 7, portainer.StandardUserRole, []portainer.TeamMembership{{UserID: 7, TeamID: 6}})
+	is.True(decision.Authorized)
+	is.Equal(EndpointAccessByGroupTeamPolicy, decision.Factor)
+
+	decision = ExplainEndpointAccess(endpoint, group, 8, portainer.StandardUserRole, nil)
+	is.False(decision.Authorized)
+	is.Empty(decision.Factor)
+}
+
+func Test_EndpointAccess(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	group := &portainer.EndpointGroup{ID: 2, Name: "group", TeamAccessPolicies: portainer.TeamAccessPolicies{6: {RoleID: 1}}}
+	is.NoError(store.EndpointGroup().Create(group))
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: 7, TeamID: 6}))
+
+	endpoint := &portainer.Endpoint{ID: 1, GroupID: group.ID}
+
+	decision, err := EndpointAccess(store, endpoint, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole})
+	is.NoError(err)
+	is.Equal(EndpointAccessByRole, decision.Factor)
+
+	decision, err = EndpointAccess(store, endpoint, &portainer.TokenData{ID: 7, Role: portainer.StandardUserRole})
+	is.NoError(err)
+	is.True(decision.Authorized)
+	is.Equal(EndpointAccessByGroupTeamPolicy, decision.Factor)
+	is.Equal(portainer.TeamID(6), decision.TeamID)
+
+	decision, err = EndpointAccess(store, endpoint, &portainer.TokenData{ID: 8, Role: portainer.StandardUserRole})
+	is.NoError(err)
+	is.False(decision.Authorized)
+	is.Equal(decision.Authorized, AuthorizedEndpointAccess(endpoint, group, 8, nil))
+}
@@ -125,21 +125,12 @@ func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endp
 		return err
 	}
 
-	if tokenData.Role == portainer.AdministratorRole {
-		return nil
-	}
-
-	memberships, err := bouncer.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
-	if err != nil {
-		return err
-	}
-
-	group, err := bouncer.dataStore.EndpointGroup().Read(endpoint.GroupID)
+	decision, err := EndpointAccess(bouncer.dataStore, endpoint, tokenData)
 	if err != nil {
 		return err
 	}
 
-	if !AuthorizedEndpointAccess(endpoint, group, tokenData.ID, memberships) {
+	if !decision.Authorized {
 		return httperrors.ErrEndpointAccessDenied
 	}
 
@@ -305,7 +296,7 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 // JWTAuthLookup looks up a valid bearer in the request.
 func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenData {
 	// get token from the Authorization header or query parameter
-	token, err := extractBearerToken(r)
+	token, err := ExtractBearerToken(r)
 	if err != nil {
 		return nil
 	}
@@ -356,8 +347,8 @@ func (bouncer *RequestBouncer) apiKeyLookup(r *http.Request) *portainer.TokenDat
 	return tokenData
 }
 
-// extractBearerToken extracts the Bearer token from the request header or query parameter and returns the token.
-func extractBearerToken(r *http.Request) (string, error) {
+// ExtractBearerToken extracts the Bearer token from the request header or query parameter and returns the token.
+func ExtractBearerToken(r *http.Request) (string, error) {
 	// Optionally, token might be set via the "token" query parameter.
 	// For example, in websocket requests
 	token := r.URL.Query().Get("token")
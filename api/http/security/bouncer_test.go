@@ -144,7 +144,7 @@ func Test_extractBearerToken(t *testing.T) {
 	for _, test := range tt {
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
 		req.Header.Set(test.requestHeader, test.requestHeaderValue)
-		apiKey, err := extractBearerToken(req)
+		apiKey, err := ExtractBearerToken(req)
 		is.Equal(test.wantToken, apiKey)
 		if !test.succeeds {
 			is.Error(err, "Should return error")
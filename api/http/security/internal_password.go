@@ -0,0 +1,23 @@
+package security
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+// IsInitialAdmin returns true for the administrator created at the initialization, it always logs in with its local
+// password
+func IsInitialAdmin(user *portainer.User) bool {
+	return int(user.ID) == 1
+}
+
+// IsLocalAdminFallback returns true when the administrator can log in with a local password while the authentication
+// method is external, a safety valve when the LDAP or OAuth settings are broken
+func IsLocalAdminFallback(user *portainer.User, settings *portainer.Settings) bool {
+	return settings.EnableLocalAdminFallback && user.Role == portainer.AdministratorRole && user.Password != ""
+}
+
+// CanManageInternalPassword returns true when the user logs in with an internal password under the authentication
+// method of the settings. The password of the other users is never checked, changing it would have no effect
+func CanManageInternalPassword(user *portainer.User, settings *portainer.Settings) bool {
+	return settings.AuthenticationMethod == portainer.AuthenticationInternal || IsInitialAdmin(user) || IsLocalAdminFallback(user, settings)
+}
@@ -0,0 +1,31 @@
+package security
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanManageInternalPassword(t *testing.T) {
+	is := assert.New(t)
+
+	initialAdmin := &portainer.User{ID: 1, Role: portainer.AdministratorRole, Password: "hash"}
+	admin := &portainer.User{ID: 2, Role: portainer.AdministratorRole, Password: "hash"}
+	user := &portainer.User{ID: 3, Role: portainer.StandardUserRole, Password: "hash"}
+
+	internal := &portainer.Settings{AuthenticationMethod: portainer.AuthenticationInternal}
+	is.True(CanManageInternalPassword(user, internal))
+	is.True(CanManageInternalPassword(admin, internal))
+
+	oauth := &portainer.Settings{AuthenticationMethod: portainer.AuthenticationOAuth}
+	is.True(CanManageInternalPassword(initialAdmin, oauth), "the initial administrator always has a local password")
+	is.False(CanManageInternalPassword(admin, oauth))
+	is.False(CanManageInternalPassword(user, oauth))
+
+	fallback := &portainer.Settings{AuthenticationMethod: portainer.AuthenticationLDAP, EnableLocalAdminFallback: true}
+	is.True(CanManageInternalPassword(admin, fallback))
+	is.False(CanManageInternalPassword(user, fallback), "the fallback is reserved to administrators")
+	is.False(CanManageInternalPassword(&portainer.User{ID: 4, Role: portainer.AdministratorRole}, fallback), "an administrator without a local password cannot fall back")
+}
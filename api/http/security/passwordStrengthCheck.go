@@ -8,6 +8,17 @@ import (
 
 type PasswordStrengthChecker interface {
 	Check(password string) bool
+	Evaluate(password, username string) PasswordStrengthResult
+}
+
+// PasswordStrengthResult is the detailed outcome of a password strength check
+type PasswordStrengthResult struct {
+	// Whether the password satisfies every enforced rule
+	Strong bool `json:"strong" example:"false"`
+	// Percentage of the evaluated rules satisfied by the password
+	Score int `json:"score" example:"60"`
+	// Rules the password does not satisfy, enforced or recommended
+	FailedRules []PasswordRuleResult `json:"failedRules"`
 }
 
 type passwordStrengthChecker struct {
@@ -22,14 +33,43 @@ func NewPasswordStrengthChecker(settings settingsService) *passwordStrengthCheck
 
 // Check returns true if the password is strong enough
 func (c *passwordStrengthChecker) Check(password string) bool {
+	return c.Evaluate(password, "").Strong
+}
+
+// Evaluate runs the password rules and returns the rules the password does not satisfy.
+// The password is considered strong when the settings cannot be retrieved
+func (c *passwordStrengthChecker) Evaluate(password, username string) PasswordStrengthResult {
 	s, err := c.settings.Settings()
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to fetch Portainer settings to validate user password")
 
-		return true
+		return PasswordStrengthResult{Strong: true, FailedRules: []PasswordRuleResult{}}
+	}
+
+	results := EvaluatePasswordRules(password, s, username)
+
+	failedRules := []PasswordRuleResult{}
+	evaluated := 0
+	for _, result := range results {
+		switch result.Status {
+		case PasswordRuleFailed:
+			failedRules = append(failedRules, result)
+			evaluated++
+		case PasswordRulePassed:
+			evaluated++
+		}
 	}
 
-	return len(password) >= s.InternalAuthSettings.RequiredPasswordLength
+	score := 100
+	if evaluated > 0 {
+		score = (evaluated - len(failedRules)) * 100 / evaluated
+	}
+
+	return PasswordStrengthResult{
+		Strong:      PasswordRulesPassed(results),
+		Score:       score,
+		FailedRules: failedRules,
+	}
 }
 
 type settingsService interface {
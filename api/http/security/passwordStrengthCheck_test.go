@@ -1,6 +1,7 @@
 package security
 
 import (
+	"reflect"
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
@@ -36,6 +37,34 @@ func TestStrengthCheck(t *testing.T) {
 	}
 }
 
+func TestStrengthEvaluate(t *testing.T) {
+	checker := NewPasswordStrengthChecker(settingsStub{minLength: 12})
+
+	failedRules := func(result PasswordStrengthResult) []string {
+		rules := []string{}
+		for _, rule := range result.FailedRules {
+			rules = append(rules, rule.Rule)
+		}
+		return rules
+	}
+
+	result := checker.Evaluate("password", "bob")
+	if result.Strong {
+		t.Error("Evaluate() reported a short password as strong")
+	}
+	if got, want := failedRules(result), []string{PasswordRuleLength, PasswordRuleClasses, PasswordRuleEntropy, PasswordRuleCommonList}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() failed rules = %v, want %v", got, want)
+	}
+	if result.Score != 20 {
+		t.Errorf("Evaluate() score = %d, want 20", result.Score)
+	}
+
+	result = checker.Evaluate("Correct-Horse-42", "bob")
+	if !result.Strong || len(result.FailedRules) != 0 || result.Score != 100 {
+		t.Errorf("Evaluate() = %+v, want a strong password satisfying every rule", result)
+	}
+}
+
 type settingsStub struct {
 	minLength int
 }
@@ -0,0 +1,24 @@
+package security
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// PasswordExpiresAt returns the unix timestamp at which the password of the user expires. It is 0 when the expiry is
+// disabled or when the date of the last password change is unknown, e.g. for users created before it was recorded
+func PasswordExpiresAt(user *portainer.User, expiryDays int) int64 {
+	if expiryDays <= 0 || user.PasswordUpdatedAt == 0 {
+		return 0
+	}
+
+	return time.Unix(user.PasswordUpdatedAt, 0).AddDate(0, 0, expiryDays).Unix()
+}
+
+// IsPasswordExpired returns true when the password of the user expired at the given time
+func IsPasswordExpired(user *portainer.User, expiryDays int, now time.Time) bool {
+	expiresAt := PasswordExpiresAt(user, expiryDays)
+
+	return expiresAt != 0 && now.Unix() >= expiresAt
+}
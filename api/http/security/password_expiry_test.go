@@ -0,0 +1,25 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordExpiry(t *testing.T) {
+	is := assert.New(t)
+
+	updatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := &portainer.User{PasswordUpdatedAt: updatedAt.Unix()}
+
+	is.Zero(PasswordExpiresAt(user, 0), "an expiry of 0 days disables it")
+	is.Zero(PasswordExpiresAt(&portainer.User{}, 30), "an unknown password change date never expires")
+	is.Equal(updatedAt.AddDate(0, 0, 30).Unix(), PasswordExpiresAt(user, 30))
+
+	is.False(IsPasswordExpired(user, 0, updatedAt.AddDate(10, 0, 0)))
+	is.False(IsPasswordExpired(user, 30, updatedAt.AddDate(0, 0, 29)))
+	is.True(IsPasswordExpired(user, 30, updatedAt.AddDate(0, 0, 30)))
+}
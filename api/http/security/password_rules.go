@@ -0,0 +1,189 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// PasswordRuleStatus is the outcome of a password rule
+type PasswordRuleStatus string
+
+const (
+	PasswordRulePassed  PasswordRuleStatus = "pass"
+	PasswordRuleFailed  PasswordRuleStatus = "fail"
+	PasswordRuleSkipped PasswordRuleStatus = "skipped"
+)
+
+const (
+	PasswordRuleLength             = "length"
+	PasswordRuleClasses            = "classes"
+	PasswordRuleEntropy            = "entropy"
+	PasswordRuleHistory            = "history"
+	PasswordRuleBreach             = "breach"
+	PasswordRuleCommonList         = "commonList"
+	PasswordRuleUsernameSimilarity = "usernameSimilarity"
+)
+
+const (
+	recommendedPasswordClasses = 3
+	recommendedPasswordEntropy = 50
+)
+
+// commonPasswords is a short list of the most used passwords
+var commonPasswords = map[string]bool{
+	"123456": true, "123456789": true, "12345678": true, "1234567890": true, "12345": true,
+	"password": true, "password1": true, "password123": true, "qwerty": true, "qwerty123": true,
+	"qwertyuiop": true, "abc123": true, "111111": true, "123123": true, "000000": true,
+	"iloveyou": true, "admin": true, "admin123": true, "administrator": true, "letmein": true,
+	"welcome": true, "welcome1": true, "monkey": true, "dragon": true, "football": true,
+	"baseball": true, "sunshine": true, "princess": true, "master": true, "shadow": true,
+	"superman": true, "trustno1": true, "changeme": true, "passw0rd": true, "p@ssw0rd": true,
+	"portainer": true, "portainer123": true, "docker": true, "kubernetes": true, "secret": true,
+}
+
+// PasswordRuleResult is the outcome of a single password rule
+type PasswordRuleResult struct {
+	Rule   string             `json:"rule" example:"length"`
+	Status PasswordRuleStatus `json:"status" example:"pass"`
+	// Whether a failure of the rule prevents the password from being used
+	Enforced bool   `json:"enforced" example:"true"`
+	Message  string `json:"message" example:"must contain at least 12 characters"`
+}
+
+// EvaluatePasswordRules runs every password rule that does not require the stored credentials of the user.
+// Only the length rule is enforced, the other rules are recommendations.
+func EvaluatePasswordRules(password string, settings *portainer.Settings, username string) []PasswordRuleResult {
+	return []PasswordRuleResult{
+		lengthRule(password, settings.InternalAuthSettings.RequiredPasswordLength),
+		classesRule(password, settings.InternalAuthSettings.PassphraseMinLength),
+		entropyRule(password),
+		commonListRule(password),
+		usernameSimilarityRule(password, username),
+		{
+			Rule:    PasswordRuleBreach,
+			Status:  PasswordRuleSkipped,
+			Message: "no breached passwords source is configured",
+		},
+	}
+}
+
+// PasswordRulesPassed returns false when an enforced rule failed
+func PasswordRulesPassed(results []PasswordRuleResult) bool {
+	for _, result := range results {
+		if result.Enforced && result.Status == PasswordRuleFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newPasswordRuleResult(rule string, passed, enforced bool, message string) PasswordRuleResult {
+	status := PasswordRulePassed
+	if !passed {
+		status = PasswordRuleFailed
+	}
+
+	return PasswordRuleResult{Rule: rule, Status: status, Enforced: enforced, Message: message}
+}
+
+func lengthRule(password string, requiredLength int) PasswordRuleResult {
+	return newPasswordRuleResult(PasswordRuleLength, len(password) >= requiredLength, true,
+		fmt.Sprintf("must contain at least %d characters", requiredLength))
+}
+
+// classesRule is waived for passphrases, long passwords are preferred over complex ones
+func classesRule(password string, passphraseMinLength int) PasswordRuleResult {
+	if passphraseMinLength > 0 && len(password) >= passphraseMinLength {
+		return PasswordRuleResult{
+			Rule:    PasswordRuleClasses,
+			Status:  PasswordRuleSkipped,
+			Message: fmt.Sprintf("waived for passphrases of at least %d characters", passphraseMinLength),
+		}
+	}
+
+	return newPasswordRuleResult(PasswordRuleClasses, passwordClasses(password) >= recommendedPasswordClasses, false,
+		fmt.Sprintf("should mix at least %d of lowercase letters, uppercase letters, digits and symbols", recommendedPasswordClasses))
+}
+
+func entropyRule(password string) PasswordRuleResult {
+	return newPasswordRuleResult(PasswordRuleEntropy, passwordEntropy(password) >= recommendedPasswordEntropy, false,
+		fmt.Sprintf("should have an estimated entropy of at least %d bits", recommendedPasswordEntropy))
+}
+
+func commonListRule(password string) PasswordRuleResult {
+	return newPasswordRuleResult(PasswordRuleCommonList, !commonPasswords[strings.ToLower(password)], false,
+		"should not be a commonly used password")
+}
+
+func usernameSimilarityRule(password, username string) PasswordRuleResult {
+	similar := username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username))
+
+	return newPasswordRuleResult(PasswordRuleUsernameSimilarity, !similar, false,
+		"should not contain the username")
+}
+
+type passwordCharacterClasses struct {
+	lower, upper, digit, symbol bool
+}
+
+func characterClassesOf(password string) passwordCharacterClasses {
+	var classes passwordCharacterClasses
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			classes.lower = true
+		case unicode.IsUpper(r):
+			classes.upper = true
+		case unicode.IsDigit(r):
+			classes.digit = true
+		default:
+			classes.symbol = true
+		}
+	}
+
+	return classes
+}
+
+// passwordClasses returns the number of character classes used by the password
+func passwordClasses(password string) int {
+	classes := characterClassesOf(password)
+
+	count := 0
+	for _, used := range []bool{classes.lower, classes.upper, classes.digit, classes.symbol} {
+		if used {
+			count++
+		}
+	}
+
+	return count
+}
+
+// passwordEntropy estimates the entropy in bits of a password from its length and the size of the character classes it uses
+func passwordEntropy(password string) float64 {
+	classes := characterClassesOf(password)
+
+	poolSize := 0
+	if classes.lower {
+		poolSize += 26
+	}
+	if classes.upper {
+		poolSize += 26
+	}
+	if classes.digit {
+		poolSize += 10
+	}
+	if classes.symbol {
+		poolSize += 33
+	}
+
+	if poolSize == 0 {
+		return 0
+	}
+
+	return float64(len([]rune(password))) * math.Log2(float64(poolSize))
+}
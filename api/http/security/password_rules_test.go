@@ -0,0 +1,65 @@
+package security
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePasswordRules(t *testing.T) {
+	is := assert.New(t)
+
+	settings := &portainer.Settings{InternalAuthSettings: portainer.InternalAuthSettings{RequiredPasswordLength: 12}}
+
+	statuses := func(results []PasswordRuleResult) map[string]PasswordRuleStatus {
+		m := map[string]PasswordRuleStatus{}
+		for _, result := range results {
+			m[result.Rule] = result.Status
+		}
+		return m
+	}
+
+	results := EvaluatePasswordRules("password", settings, "bob")
+	is.False(PasswordRulesPassed(results))
+	is.Equal(map[string]PasswordRuleStatus{
+		PasswordRuleLength:             PasswordRuleFailed,
+		PasswordRuleClasses:            PasswordRuleFailed,
+		PasswordRuleEntropy:            PasswordRuleFailed,
+		PasswordRuleCommonList:         PasswordRuleFailed,
+		PasswordRuleUsernameSimilarity: PasswordRulePassed,
+		PasswordRuleBreach:             PasswordRuleSkipped,
+	}, statuses(results))
+
+	results = EvaluatePasswordRules("bob-likes-portainer", settings, "Bob")
+	is.True(PasswordRulesPassed(results), "only the length is enforced")
+	is.Equal(PasswordRuleFailed, statuses(results)[PasswordRuleUsernameSimilarity])
+
+	results = EvaluatePasswordRules("Correct-Horse-42", settings, "bob")
+	is.True(PasswordRulesPassed(results))
+	for _, result := range results {
+		is.NotEqual(PasswordRuleFailed, result.Status, result.Rule)
+	}
+}
+
+func TestEvaluatePasswordRules_passphrase(t *testing.T) {
+	is := assert.New(t)
+
+	settings := &portainer.Settings{InternalAuthSettings: portainer.InternalAuthSettings{RequiredPasswordLength: 12, PassphraseMinLength: 16}}
+
+	classesStatus := func(password string) PasswordRuleStatus {
+		for _, result := range EvaluatePasswordRules(password, settings, "bob") {
+			if result.Rule == PasswordRuleClasses {
+				return result.Status
+			}
+		}
+		return ""
+	}
+
+	is.Equal(PasswordRuleFailed, classesStatus("shortpassphrase"))
+	is.Equal(PasswordRuleSkipped, classesStatus("correct horse battery staple"))
+
+	settings.InternalAuthSettings.PassphraseMinLength = 0
+	is.Equal(PasswordRuleFailed, classesStatus("correct horse battery staple"), "the passphrase mode is disabled")
+}
@@ -0,0 +1,88 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is the number of periods accepted before and after the current one, to absorb the clock drift of the
+	// authenticator app
+	totpSkew = 1
+)
+
+// GenerateTOTP returns the time-based one-time password (RFC 6238) of the base32 secret at now
+func GenerateTOTP(secret string, now time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, uint64(now.Unix()/int64(totpPeriod.Seconds()))), nil
+}
+
+// ValidateTOTP returns the time step of the code when it is the time-based one-time password (RFC 6238) of the base32
+// secret at now, or at one of the periods around it. The time steps up to lastStep were already used and are rejected,
+// so that a code cannot be replayed
+func ValidateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	counter := now.Unix() / int64(totpPeriod.Seconds())
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		step := counter + int64(skew)
+		if step <= lastStep {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step))), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// decodeTOTPSecret decodes the secret as displayed by the authenticator apps, in any case, with or without spaces
+// and padding
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) == 0 {
+		return nil, errors.New("empty TOTP secret")
+	}
+
+	return key, nil
+}
+
+// hotp returns the HMAC-based one-time password (RFC 4226) of the key for the counter
+func hotp(key []byte, counter uint64) string {
+	mac := hmac.New(sha1.New, key)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
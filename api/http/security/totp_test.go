@@ -0,0 +1,46 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validTOTP(secret, code string, now time.Time, lastStep int64) bool {
+	_, ok := ValidateTOTP(secret, code, now, lastStep)
+	return ok
+}
+
+func TestValidateTOTP(t *testing.T) {
+	is := assert.New(t)
+
+	// the SHA-1 test vectors of RFC 6238, truncated to 6 digits. The secret is "12345678901234567890" in base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	is.True(validTOTP(secret, "287082", time.Unix(59, 0), 0))
+	is.True(validTOTP(secret, "081804", time.Unix(1111111109, 0), 0))
+	is.True(validTOTP(secret, "050471", time.Unix(1111111111, 0), 0))
+	is.True(validTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", "287082", time.Unix(59, 0), 0), "the secret is case and space insensitive")
+
+	is.True(validTOTP(secret, "081804", time.Unix(1111111109+30, 0), 0), "the code of the previous period is accepted")
+	is.False(validTOTP(secret, "081804", time.Unix(1111111109+90, 0), 0), "the codes of older periods are rejected")
+
+	code, err := GenerateTOTP(secret, time.Unix(1234567890, 0))
+	is.NoError(err)
+	is.Equal("005924", code)
+
+	_, err = GenerateTOTP("", time.Unix(59, 0))
+	is.Error(err)
+
+	is.False(validTOTP(secret, "287083", time.Unix(59, 0), 0))
+	is.False(validTOTP(secret, "28708", time.Unix(59, 0), 0))
+	is.False(validTOTP("", "287082", time.Unix(59, 0), 0))
+	is.False(validTOTP("not base32!", "287082", time.Unix(59, 0), 0))
+
+	step, ok := ValidateTOTP(secret, "081804", time.Unix(1111111109, 0), 0)
+	is.True(ok)
+	is.Equal(int64(1111111109/30), step)
+	is.False(validTOTP(secret, "081804", time.Unix(1111111109, 0), step), "a code cannot be used twice")
+	is.True(validTOTP(secret, "050471", time.Unix(1111111111, 0), step), "the code of a later period is accepted")
+}
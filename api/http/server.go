@@ -77,6 +77,9 @@ type Server struct {
 	BindAddress                 string
 	BindAddressHTTPS            string
 	HTTPEnabled                 bool
+	EdgeEnforceHTTPS            bool
+	MaxUserSessionTimeout       time.Duration
+	SettingsOverrides           []portainer.SettingsOverride
 	AssetsPath                  string
 	Status                      *portainer.Status
 	ReverseTunnelService        portainer.ReverseTunnelService
@@ -108,6 +111,7 @@ type Server struct {
 	ShutdownTrigger             context.CancelFunc
 	StackDeployer               deployments.StackDeployer
 	DemoService                 *demo.Service
+	DemoBlockedRoutes           []middlewares.DemoBlockedRoute
 	UpgradeService              upgrade.Service
 	AdminCreationDone           chan struct{}
 }
@@ -184,6 +188,7 @@ func (server *Server) Start() error {
 	var endpointGroupHandler = endpointgroups.NewHandler(requestBouncer)
 	endpointGroupHandler.AuthorizationService = server.AuthorizationService
 	endpointGroupHandler.DataStore = server.DataStore
+	endpointGroupHandler.K8sClientFactory = server.KubernetesClientFactory
 
 	var endpointProxyHandler = endpointproxy.NewHandler(requestBouncer)
 	endpointProxyHandler.DataStore = server.DataStore
@@ -221,11 +226,16 @@ func (server *Server) Start() error {
 	resourceControlHandler.DataStore = server.DataStore
 
 	var settingsHandler = settings.NewHandler(requestBouncer, server.DemoService)
+	settingsHandler.CryptoService = server.CryptoService
 	settingsHandler.DataStore = server.DataStore
 	settingsHandler.FileService = server.FileService
 	settingsHandler.JWTService = server.JWTService
 	settingsHandler.LDAPService = server.LDAPService
+	settingsHandler.OAuthService = server.OAuthService
 	settingsHandler.SnapshotService = server.SnapshotService
+	settingsHandler.EdgeEnforceHTTPS = server.EdgeEnforceHTTPS
+	settingsHandler.MaxUserSessionTimeout = server.MaxUserSessionTimeout
+	settingsHandler.Overrides = server.SettingsOverrides
 
 	var sslHandler = sslhandler.NewHandler(requestBouncer)
 	sslHandler.SSLService = server.SSLService
@@ -277,6 +287,7 @@ func (server *Server) Start() error {
 	var userHandler = users.NewHandler(requestBouncer, rateLimiter, server.APIKeyService, server.DemoService, passwordStrengthChecker)
 	userHandler.DataStore = server.DataStore
 	userHandler.CryptoService = server.CryptoService
+	userHandler.JWTService = server.JWTService
 	userHandler.AdminCreationDone = server.AdminCreationDone
 
 	var websocketHandler = websocket.NewHandler(server.KubernetesTokenCacheManager, requestBouncer)
@@ -333,6 +344,8 @@ func (server *Server) Start() error {
 
 	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.Handler))
 
+	handler = middlewares.RestrictDemoRoutes(server.DemoService.IsDemo, server.DemoBlockedRoutes)(handler)
+
 	handler = middlewares.WithSlowRequestsLogger(handler)
 
 	if server.HTTPEnabled {
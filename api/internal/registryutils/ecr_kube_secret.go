@@ -5,14 +5,8 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 )
 
-func isRegistryAssignedToNamespace(registry portainer.Registry, endpointID portainer.EndpointID, namespace string) (in bool) {
-	for _, ns := range registry.RegistryAccesses[endpointID].Namespaces {
-		if ns == namespace {
-			return true
-		}
-	}
-
-	return
+func isRegistryAssignedToNamespace(registry portainer.Registry, endpoint *portainer.Endpoint, namespace string) (in bool) {
+	return HasNamespaceAccess(EffectiveAccess(&registry, endpoint.ID, endpoint.GroupID).Namespaces, namespace)
 }
 
 func RefreshEcrSecret(cli portainer.KubeClient, endpoint *portainer.Endpoint, dataStore dataservices.DataStore, namespace string) (err error) {
@@ -26,7 +20,7 @@ func RefreshEcrSecret(cli portainer.KubeClient, endpoint *portainer.Endpoint, da
 			continue
 		}
 
-		if !isRegistryAssignedToNamespace(registry, endpoint.ID, namespace) {
+		if !isRegistryAssignedToNamespace(registry, endpoint, namespace) {
 			continue
 		}
 
@@ -0,0 +1,63 @@
+package registryutils
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// EffectiveAccess returns the access of an environment to the registry, the namespaces of its group are merged with
+// its own namespaces unless it excludes the access of its group
+func EffectiveAccess(registry *portainer.Registry, endpointID portainer.EndpointID, groupID portainer.EndpointGroupID) portainer.RegistryAccessPolicies {
+	access := registry.RegistryAccesses[endpointID]
+	if access.ExcludeGroupAccess {
+		return access
+	}
+
+	groupAccess, ok := registry.GroupRegistryAccesses[groupID]
+	if !ok {
+		return access
+	}
+
+	namespaces := slices.Clone(access.Namespaces)
+	for _, namespace := range groupAccess.Namespaces {
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	access.Namespaces = namespaces
+
+	return access
+}
+
+// ReconcileGroupMove updates the registry secrets of a Kubernetes environment moved from the group previousGroupID to
+// its current group, the namespaces inherited from the previous group are replaced by the ones of the current group.
+// The client is only created when the namespaces of a registry change
+func ReconcileGroupMove(registries []portainer.Registry, endpoint *portainer.Endpoint, previousGroupID portainer.EndpointGroupID, kubeClient func() (portainer.KubeClient, error)) error {
+	if endpoint.GroupID == previousGroupID {
+		return nil
+	}
+
+	var cli portainer.KubeClient
+	for _, registry := range registries {
+		previous := EffectiveAccess(&registry, endpoint.ID, previousGroupID).Namespaces
+		current := EffectiveAccess(&registry, endpoint.ID, endpoint.GroupID).Namespaces
+		if slices.Equal(previous, current) {
+			continue
+		}
+
+		if cli == nil {
+			var err error
+			if cli, err = kubeClient(); err != nil {
+				return err
+			}
+		}
+
+		err := UpdateKubeAccess(cli, &registry, previous, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
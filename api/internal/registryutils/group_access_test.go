@@ -0,0 +1,60 @@
+package registryutils
+
+import (
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EffectiveAccess(t *testing.T) {
+	is := assert.New(t)
+
+	registry := &portainer.Registry{
+		RegistryAccesses: portainer.RegistryAccesses{
+			1: {Namespaces: []string{"default", "dev"}},
+			2: {Namespaces: []string{"dev"}, ExcludeGroupAccess: true},
+		},
+		GroupRegistryAccesses: portainer.GroupRegistryAccesses{
+			10: {Namespaces: []string{"dev", "prod"}},
+		},
+	}
+
+	is.Equal([]string{"default", "dev", "prod"}, EffectiveAccess(registry, 1, 10).Namespaces, "the namespaces are merged")
+	is.Equal([]string{"dev"}, EffectiveAccess(registry, 2, 10).Namespaces, "the environment excludes the access of its group")
+	is.Equal([]string{"dev", "prod"}, EffectiveAccess(registry, 3, 10).Namespaces, "the access is inherited without an access of the environment")
+	is.Equal([]string{"default", "dev"}, EffectiveAccess(registry, 1, 20).Namespaces)
+	is.Equal([]string{"default", "dev"}, registry.RegistryAccesses[1].Namespaces, "the access of the environment is not modified")
+}
+
+func Test_ReconcileGroupMove(t *testing.T) {
+	is := assert.New(t)
+
+	registries := []portainer.Registry{
+		{ID: 1, GroupRegistryAccesses: portainer.GroupRegistryAccesses{10: {Namespaces: []string{"prod"}}, 20: {Namespaces: []string{"staging"}}}},
+		{ID: 2, RegistryAccesses: portainer.RegistryAccesses{1: {Namespaces: []string{"default"}}}},
+	}
+
+	cli := &kubeClientStub{secrets: map[string]bool{"prod": true, "default": true}}
+	clients := 0
+	kubeClient := func() (portainer.KubeClient, error) {
+		clients++
+		return cli, nil
+	}
+
+	endpoint := &portainer.Endpoint{ID: 1, GroupID: 20}
+	is.NoError(ReconcileGroupMove(registries, endpoint, 10, kubeClient))
+	is.Equal(map[string]bool{"staging": true, "default": true}, cli.secrets)
+	is.Equal(1, clients)
+
+	endpoint.GroupID = 30
+	is.NoError(ReconcileGroupMove(registries[1:], endpoint, 20, kubeClient))
+	is.Equal(1, clients, "no client is created when no namespace changes")
+
+	err := ReconcileGroupMove(registries, endpoint, 10, func() (portainer.KubeClient, error) {
+		return nil, errors.New("unreachable")
+	})
+	is.Error(err)
+}
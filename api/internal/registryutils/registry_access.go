@@ -0,0 +1,437 @@
+package registryutils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DefaultMaxAccessPolicies is the maximum number of user and team access policies in a registry access update
+	// when the limit is not configured in the settings
+	DefaultMaxAccessPolicies = 1000
+	// DefaultMaxAccessNamespaces is the maximum number of namespaces in a registry access update
+	// when the limit is not configured in the settings
+	DefaultMaxAccessNamespaces = 500
+	// MaxAccessHistory is the number of registry access changes kept for each registry
+	MaxAccessHistory = 100
+	// AccessUndoWindow is how long the previous registry access of an environment or a group can be restored after
+	// a change
+	AccessUndoWindow = 15 * time.Minute
+	// AllNamespaces is the namespace entry granting the access of a registry to every namespace of an environment,
+	// including the namespaces created later
+	AllNamespaces = "*"
+)
+
+// Outcomes of the reconciliation of the registry secret of a namespace
+const (
+	NamespaceSecretDeleted        = "deleted"
+	NamespaceSecretFailed         = "failed"
+	NamespaceSecretRolledBack     = "rolledBack"
+	NamespaceSecretRollbackFailed = "rollbackFailed"
+)
+
+// NamespaceSecretResult is the outcome of the reconciliation of the registry secret of a namespace
+type NamespaceSecretResult struct {
+	Namespace string `json:"namespace" example:"default"`
+	// One of deleted, failed, rolledBack or rollbackFailed
+	Status string `json:"status" example:"rolledBack"`
+	Error  string `json:"error,omitempty"`
+}
+
+// KubeAccessSummary describes the namespaces of a successful reconciliation, the wildcard is expanded to the
+// namespaces of the environment
+type KubeAccessSummary struct {
+	// Namespaces that gained the access, their secret was created
+	Added []string `json:"added" example:"dev"`
+	// Namespaces that lost the access, their secret was deleted
+	Removed []string `json:"removed" example:"staging"`
+	// Namespaces that kept the access, their secret was recreated when Refreshed is set
+	Unchanged []string `json:"unchanged" example:"default"`
+	// Namespaces that were deleted before their secret could be created
+	Skipped []string `json:"skipped" example:"feature-branch"`
+	// Whether the secrets of the unchanged namespaces were recreated
+	Refreshed bool `json:"refreshed" example:"false"`
+}
+
+// ErrUnknownNamespaces is wrapped by the error of ValidateKubeNamespaces when namespaces do not exist in the environment
+var ErrUnknownNamespaces = errors.New("unknown namespaces")
+
+// KubeAccessError is returned when the registry secrets of an environment cannot be reconciled. The results list what
+// was done in each namespace before the failure, the secrets already created are rolled back and the secrets already
+// deleted are restored
+type KubeAccessError struct {
+	Err     error
+	Results []NamespaceSecretResult
+}
+
+func (e *KubeAccessError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *KubeAccessError) Unwrap() error {
+	return e.Err
+}
+
+// AccessLimits returns the maximum number of access policies and namespaces in a registry access update
+func AccessLimits(settings *portainer.Settings) (policies, namespaces int) {
+	policies, namespaces = settings.MaxRegistryAccessPolicies, settings.MaxRegistryAccessNamespaces
+
+	if policies == 0 {
+		policies = DefaultMaxAccessPolicies
+	}
+
+	if namespaces == 0 {
+		namespaces = DefaultMaxAccessNamespaces
+	}
+
+	return policies, namespaces
+}
+
+// RecordAccessChange appends the change to the access history of the registry, only the latest changes are kept
+func RecordAccessChange(registry *portainer.Registry, change portainer.RegistryAccessChange) {
+	registry.AccessHistory = append(registry.AccessHistory, change)
+
+	if len(registry.AccessHistory) > MaxAccessHistory {
+		registry.AccessHistory = registry.AccessHistory[len(registry.AccessHistory)-MaxAccessHistory:]
+	}
+}
+
+// UndoableChange returns the latest change of the access of the environment when it can still be undone, the change
+// must be more recent than the undo window and must not be an undo itself
+func UndoableChange(registry *portainer.Registry, endpointID portainer.EndpointID, now time.Time, window time.Duration) (portainer.RegistryAccessChange, bool) {
+	return undoableChange(registry, now, window, func(change portainer.RegistryAccessChange) bool {
+		return change.EndpointID == endpointID && change.EndpointGroupID == 0
+	})
+}
+
+// UndoableGroupChange returns the latest change of the access granted to the environment group when it can still be
+// undone, with the same rules as UndoableChange
+func UndoableGroupChange(registry *portainer.Registry, endpointGroupID portainer.EndpointGroupID, now time.Time, window time.Duration) (portainer.RegistryAccessChange, bool) {
+	return undoableChange(registry, now, window, func(change portainer.RegistryAccessChange) bool {
+		return change.EndpointID == 0 && change.EndpointGroupID == endpointGroupID
+	})
+}
+
+func undoableChange(registry *portainer.Registry, now time.Time, window time.Duration, matches func(portainer.RegistryAccessChange) bool) (portainer.RegistryAccessChange, bool) {
+	for i := len(registry.AccessHistory) - 1; i >= 0; i-- {
+		change := registry.AccessHistory[i]
+		if !matches(change) {
+			continue
+		}
+
+		if change.Undo || now.Sub(time.Unix(change.Timestamp, 0)) > window {
+			return portainer.RegistryAccessChange{}, false
+		}
+
+		return change, true
+	}
+
+	return portainer.RegistryAccessChange{}, false
+}
+
+// HasNamespaceAccess returns true when the namespace is listed in the namespaces or when they contain the wildcard
+func HasNamespaceAccess(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == namespace || ns == AllNamespaces {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpandNamespaces replaces the wildcard with the namespaces currently existing in the environment
+func ExpandNamespaces(cli portainer.KubeClient, namespaces []string) ([]string, error) {
+	if !toSet(namespaces)[AllNamespaces] {
+		return namespaces, nil
+	}
+
+	existingNamespaces, err := cli.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := make([]string, 0, len(existingNamespaces))
+	for namespace := range existingNamespaces {
+		expanded = append(expanded, namespace)
+	}
+
+	return expanded, nil
+}
+
+// UnknownNamespaces returns the namespaces that do not exist in the environment, the wildcard is always known
+func UnknownNamespaces(cli portainer.KubeClient, namespaces []string) ([]string, error) {
+	if len(namespaces) == 0 || (len(namespaces) == 1 && namespaces[0] == AllNamespaces) {
+		return nil, nil
+	}
+
+	existingNamespaces, err := cli.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, namespace := range namespaces {
+		if _, ok := existingNamespaces[namespace]; !ok && namespace != AllNamespaces {
+			unknown = append(unknown, namespace)
+		}
+	}
+
+	return unknown, nil
+}
+
+// ValidateNamespaces returns an error when the namespaces of an access contain an empty or a duplicate namespace
+func ValidateNamespaces(namespaces []string) error {
+	seen := make(stringSet, len(namespaces))
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			return errors.New("invalid empty namespace")
+		}
+
+		if seen[namespace] {
+			return fmt.Errorf("duplicate namespace: %s", namespace)
+		}
+		seen[namespace] = true
+	}
+
+	return nil
+}
+
+// ValidateKubeNamespaces returns an error wrapping ErrUnknownNamespaces when namespaces of an access do not exist
+// in the environment, the other errors come from the retrieval of the namespaces
+func ValidateKubeNamespaces(cli portainer.KubeClient, namespaces []string) error {
+	unknown, err := UnknownNamespaces(cli, namespaces)
+	if err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownNamespaces, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// UpdateKubeAccess reconciles the registry secrets of an environment, the secrets of the namespaces that lost
+// the access are removed and the secrets of the namespaces that gained it are created.
+// The wildcard is expanded to the existing namespaces, a namespace deleted between the enumeration and the creation
+// of its secret is skipped since there is nothing left to grant the access to
+func UpdateKubeAccess(cli portainer.KubeClient, registry *portainer.Registry, oldNamespaces, newNamespaces []string) error {
+	_, err := ReconcileKubeAccess(cli, registry, oldNamespaces, newNamespaces, false)
+	return err
+}
+
+// RefreshKubeAccess reconciles the registry secrets like UpdateKubeAccess and also recreates the secrets of the
+// namespaces that keep the access, e.g. a namespace deleted and recreated with the same name lost its secret
+func RefreshKubeAccess(cli portainer.KubeClient, registry *portainer.Registry, oldNamespaces, newNamespaces []string) error {
+	_, err := ReconcileKubeAccess(cli, registry, oldNamespaces, newNamespaces, true)
+	return err
+}
+
+// ReconcileKubeAccess reconciles the registry secrets like UpdateKubeAccess, or like RefreshKubeAccess when refresh is
+// set, and describes the namespaces of the reconciliation
+func ReconcileKubeAccess(cli portainer.KubeClient, registry *portainer.Registry, oldNamespaces, newNamespaces []string, refresh bool) (KubeAccessSummary, error) {
+	oldNamespaces, err := ExpandNamespaces(cli, oldNamespaces)
+	if err != nil {
+		return KubeAccessSummary{}, err
+	}
+
+	newNamespaces, err = ExpandNamespaces(cli, newNamespaces)
+	if err != nil {
+		return KubeAccessSummary{}, err
+	}
+
+	oldNamespacesSet := toSet(oldNamespaces)
+	newNamespacesSet := toSet(newNamespaces)
+
+	summary := KubeAccessSummary{
+		Added:     setDifference(newNamespacesSet, oldNamespacesSet).sorted(),
+		Removed:   setDifference(oldNamespacesSet, newNamespacesSet).sorted(),
+		Unchanged: setIntersection(oldNamespacesSet, newNamespacesSet).sorted(),
+		Skipped:   []string{},
+		Refreshed: refresh,
+	}
+
+	var recreated []string
+	if refresh {
+		recreated = summary.Unchanged
+	}
+
+	// the names are validated before any change so that an invalid template does not leave the access half applied
+	for _, namespace := range append(slices.Clone(summary.Added), recreated...) {
+		if err := ValidateSecretName(registry, namespace); err != nil {
+			return KubeAccessSummary{}, err
+		}
+	}
+
+	// the created namespaces are reported by the rollback since their secrets do not outlive a failure, the
+	// namespaces that lost their secret have it restored
+	var results []NamespaceSecretResult
+	var created, deleted []string
+
+	fail := func(namespace string, err error) error {
+		results = append(results, NamespaceSecretResult{Namespace: namespace, Status: NamespaceSecretFailed, Error: err.Error()})
+		results = append(results, rollbackCreatedSecrets(cli, registry, created)...)
+		results = append(results, restoreDeletedSecrets(cli, registry, deleted)...)
+
+		return &KubeAccessError{Err: err, Results: results}
+	}
+
+	for _, namespace := range summary.Removed {
+		err := cli.DeleteRegistrySecret(registry, namespace)
+		if err != nil {
+			return KubeAccessSummary{}, fail(namespace, err)
+		}
+
+		deleted = append(deleted, namespace)
+		results = append(results, NamespaceSecretResult{Namespace: namespace, Status: NamespaceSecretDeleted})
+	}
+
+	// the secrets are recreated one namespace at a time, so that a failure leaves at most one namespace to restore
+	for _, namespace := range recreated {
+		err := cli.DeleteRegistrySecret(registry, namespace)
+		if err != nil {
+			return KubeAccessSummary{}, fail(namespace, err)
+		}
+
+		err = cli.CreateRegistrySecret(registry, namespace)
+		if k8serrors.IsNotFound(err) {
+			summary.Skipped = append(summary.Skipped, namespace)
+			continue
+		} else if err != nil {
+			deleted = append(deleted, namespace)
+			return KubeAccessSummary{}, fail(namespace, err)
+		}
+	}
+
+	for _, namespace := range summary.Added {
+		err := cli.CreateRegistrySecret(registry, namespace)
+		if k8serrors.IsNotFound(err) {
+			summary.Skipped = append(summary.Skipped, namespace)
+			continue
+		} else if err != nil {
+			return KubeAccessSummary{}, fail(namespace, err)
+		}
+
+		created = append(created, namespace)
+	}
+
+	slices.Sort(summary.Skipped)
+
+	return summary, nil
+}
+
+// rollbackCreatedSecrets removes the secrets created by a reconciliation that failed, so that they do not leak
+func rollbackCreatedSecrets(cli portainer.KubeClient, registry *portainer.Registry, namespaces []string) []NamespaceSecretResult {
+	results := make([]NamespaceSecretResult, 0, len(namespaces))
+
+	for _, namespace := range namespaces {
+		result := NamespaceSecretResult{Namespace: namespace, Status: NamespaceSecretRolledBack}
+
+		err := cli.DeleteRegistrySecret(registry, namespace)
+		if err != nil {
+			result.Status = NamespaceSecretRollbackFailed
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// restoreDeletedSecrets recreates the secrets deleted by a reconciliation that failed, a namespace deleted in the
+// meantime has nothing left to restore
+func restoreDeletedSecrets(cli portainer.KubeClient, registry *portainer.Registry, namespaces []string) []NamespaceSecretResult {
+	results := make([]NamespaceSecretResult, 0, len(namespaces))
+
+	for _, namespace := range namespaces {
+		result := NamespaceSecretResult{Namespace: namespace, Status: NamespaceSecretRolledBack}
+
+		err := cli.CreateRegistrySecret(registry, namespace)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			result.Status = NamespaceSecretRollbackFailed
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// CreateWildcardSecrets creates the secrets of the registries granted to every namespace of the environment
+// or its group in a namespace that was just created
+func CreateWildcardSecrets(cli portainer.KubeClient, dataStore dataservices.DataStore, endpoint *portainer.Endpoint, namespace string) error {
+	registries, err := dataStore.Registry().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, registry := range registries {
+		if !toSet(EffectiveAccess(&registry, endpoint.ID, endpoint.GroupID).Namespaces)[AllNamespaces] {
+			continue
+		}
+
+		err = cli.CreateRegistrySecret(&registry, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type stringSet map[string]bool
+
+func toSet(list []string) stringSet {
+	set := stringSet{}
+	for _, el := range list {
+		set[el] = true
+	}
+	return set
+}
+
+func (set stringSet) sorted() []string {
+	list := make([]string, 0, len(set))
+	for el := range set {
+		list = append(list, el)
+	}
+	slices.Sort(list)
+
+	return list
+}
+
+// setIntersection returns the elements of setA that are also in setB
+func setIntersection(setA stringSet, setB stringSet) stringSet {
+	set := stringSet{}
+
+	for el := range setA {
+		if setB[el] {
+			set[el] = true
+		}
+	}
+
+	return set
+}
+
+// setDifference returns the set difference setA - setB
+func setDifference(setA stringSet, setB stringSet) stringSet {
+	set := stringSet{}
+
+	for el := range setA {
+		if !setB[el] {
+			set[el] = true
+		}
+	}
+
+	return set
+}
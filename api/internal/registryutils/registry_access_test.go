@@ -0,0 +1,262 @@
+package registryutils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_RecordAccessChange(t *testing.T) {
+	is := assert.New(t)
+
+	registry := &portainer.Registry{}
+	for i := 0; i < MaxAccessHistory+5; i++ {
+		RecordAccessChange(registry, portainer.RegistryAccessChange{Timestamp: int64(i)})
+	}
+
+	is.Len(registry.AccessHistory, MaxAccessHistory)
+	is.Equal(int64(5), registry.AccessHistory[0].Timestamp, "the oldest changes are dropped")
+}
+
+func Test_UndoableChange(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Unix(10_000, 0)
+	registry := &portainer.Registry{AccessHistory: []portainer.RegistryAccessChange{
+		{EndpointID: 1, Timestamp: now.Add(-5 * time.Minute).Unix(), After: portainer.RegistryAccessPolicies{Namespaces: []string{"a"}}},
+		{EndpointID: 1, Timestamp: now.Add(-2 * time.Minute).Unix(), After: portainer.RegistryAccessPolicies{Namespaces: []string{"b"}}},
+		{EndpointID: 2, Timestamp: now.Add(-time.Minute).Unix()},
+		{EndpointID: 3, Timestamp: now.Add(-20 * time.Minute).Unix()},
+		{EndpointID: 4, Timestamp: now.Add(-time.Minute).Unix(), Undo: true},
+	}}
+
+	change, ok := UndoableChange(registry, 1, now, 15*time.Minute)
+	is.True(ok)
+	is.Equal([]string{"b"}, change.After.Namespaces, "the latest change of the environment is undone")
+
+	_, ok = UndoableChange(registry, 3, now, 15*time.Minute)
+	is.False(ok, "a change older than the window cannot be undone")
+
+	_, ok = UndoableChange(registry, 4, now, 15*time.Minute)
+	is.False(ok, "an undo cannot be undone")
+
+	_, ok = UndoableChange(registry, 5, now, 15*time.Minute)
+	is.False(ok)
+}
+
+func Test_UndoableGroupChange(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Unix(10_000, 0)
+	registry := &portainer.Registry{AccessHistory: []portainer.RegistryAccessChange{
+		{EndpointGroupID: 2, Timestamp: now.Add(-2 * time.Minute).Unix(), After: portainer.RegistryAccessPolicies{Namespaces: []string{"a"}}},
+		{EndpointID: 2, Timestamp: now.Add(-time.Minute).Unix()},
+	}}
+
+	change, ok := UndoableGroupChange(registry, 2, now, 15*time.Minute)
+	is.True(ok)
+	is.Equal([]string{"a"}, change.After.Namespaces, "the changes of the environments are not changes of the group")
+
+	_, ok = UndoableChange(registry, 0, now, 15*time.Minute)
+	is.False(ok, "the changes of the groups are not changes of an environment")
+
+	_, ok = UndoableGroupChange(registry, 2, now.Add(20*time.Minute), 15*time.Minute)
+	is.False(ok)
+}
+
+type kubeClientStub struct {
+	portainer.KubeClient
+	namespaces map[string]portainer.K8sNamespaceInfo
+	deleted    map[string]bool
+	secrets    map[string]bool
+	created    []string
+	// namespaces in which the creation or the deletion of the secret fails
+	failCreate map[string]bool
+	failDelete map[string]bool
+}
+
+func (kcl *kubeClientStub) GetNamespaces() (map[string]portainer.K8sNamespaceInfo, error) {
+	return kcl.namespaces, nil
+}
+
+func (kcl *kubeClientStub) CreateRegistrySecret(registry *portainer.Registry, namespace string) error {
+	if kcl.deleted[namespace] {
+		return k8serrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, namespace)
+	}
+
+	if kcl.failCreate[namespace] {
+		return errors.New("creation failed")
+	}
+
+	kcl.secrets[namespace] = true
+	kcl.created = append(kcl.created, namespace)
+	return nil
+}
+
+func (kcl *kubeClientStub) DeleteRegistrySecret(registry *portainer.Registry, namespace string) error {
+	if kcl.failDelete[namespace] {
+		return errors.New("deletion failed")
+	}
+
+	delete(kcl.secrets, namespace)
+	return nil
+}
+
+func Test_UpdateKubeAccess_wildcard(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{
+		namespaces: map[string]portainer.K8sNamespaceInfo{"default": {}, "dev": {}, "removed": {}},
+		deleted:    map[string]bool{"removed": true},
+		secrets:    map[string]bool{},
+	}
+	registry := &portainer.Registry{ID: 1}
+
+	is.NoError(UpdateKubeAccess(cli, registry, nil, []string{AllNamespaces}), "a namespace deleted after the enumeration is skipped")
+	is.Equal(map[string]bool{"default": true, "dev": true}, cli.secrets)
+
+	is.NoError(UpdateKubeAccess(cli, registry, []string{AllNamespaces}, []string{"dev"}))
+	is.Equal(map[string]bool{"dev": true}, cli.secrets)
+
+	is.True(HasNamespaceAccess([]string{AllNamespaces}, "prod"))
+	is.False(HasNamespaceAccess([]string{"dev"}, "prod"))
+}
+
+func Test_RefreshKubeAccess(t *testing.T) {
+	is := assert.New(t)
+
+	// the secret of "dev" was lost when the namespace was deleted and recreated
+	cli := &kubeClientStub{secrets: map[string]bool{"default": true}}
+	registry := &portainer.Registry{ID: 1}
+
+	is.NoError(UpdateKubeAccess(cli, registry, []string{"default", "dev"}, []string{"default", "dev"}))
+	is.Empty(cli.created, "the namespaces in both sets are left untouched")
+
+	is.NoError(RefreshKubeAccess(cli, registry, []string{"default", "dev"}, []string{"default", "dev", "prod"}))
+	is.ElementsMatch([]string{"default", "dev", "prod"}, cli.created, "the namespaces in both sets are recreated")
+	is.Equal(map[string]bool{"default": true, "dev": true, "prod": true}, cli.secrets)
+}
+
+func Test_ReconcileKubeAccess_summary(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{
+		namespaces: map[string]portainer.K8sNamespaceInfo{"default": {}, "dev": {}, "prod": {}, "removed": {}},
+		deleted:    map[string]bool{"removed": true},
+		secrets:    map[string]bool{"default": true, "staging": true},
+	}
+	registry := &portainer.Registry{ID: 1}
+
+	summary, err := ReconcileKubeAccess(cli, registry, []string{"default", "staging"}, []string{AllNamespaces}, false)
+	is.NoError(err)
+	is.Equal(KubeAccessSummary{
+		Added:     []string{"dev", "prod", "removed"},
+		Removed:   []string{"staging"},
+		Unchanged: []string{"default"},
+		Skipped:   []string{"removed"},
+	}, summary, "the wildcard is expanded and the deleted namespaces are reported as skipped")
+
+	summary, err = ReconcileKubeAccess(cli, registry, []string{"default"}, []string{"default"}, true)
+	is.NoError(err)
+	is.Equal(KubeAccessSummary{Added: []string{}, Removed: []string{}, Unchanged: []string{"default"}, Skipped: []string{}, Refreshed: true}, summary)
+}
+
+func Test_UpdateKubeAccess_rollback(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{
+		namespaces: map[string]portainer.K8sNamespaceInfo{"a": {}, "b": {}, "c": {}, "d": {}},
+		secrets:    map[string]bool{"old": true},
+		failCreate: map[string]bool{"c": true},
+		failDelete: map[string]bool{"b": true},
+	}
+	registry := &portainer.Registry{ID: 1}
+
+	err := UpdateKubeAccess(cli, registry, []string{"old"}, []string{AllNamespaces})
+
+	var kubeErr *KubeAccessError
+	is.ErrorAs(err, &kubeErr)
+
+	statuses := map[string]string{}
+	for _, result := range kubeErr.Results {
+		statuses[result.Namespace] = result.Status
+	}
+
+	is.Equal(NamespaceSecretFailed, statuses["c"])
+	is.Equal(NamespaceSecretRolledBack, statuses["old"], "the deleted secrets are restored")
+	is.True(cli.secrets["old"])
+	for _, namespace := range cli.created {
+		if namespace == "old" {
+			continue
+		}
+
+		if namespace == "b" {
+			is.Equal(NamespaceSecretRollbackFailed, statuses[namespace], "the secrets that cannot be removed are reported")
+		} else {
+			is.Equal(NamespaceSecretRolledBack, statuses[namespace])
+			is.False(cli.secrets[namespace], "the created secrets are rolled back")
+		}
+	}
+}
+
+func Test_RefreshKubeAccess_rollback(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{
+		secrets:    map[string]bool{"a": true, "b": true, "c": true, "old": true},
+		failCreate: map[string]bool{"b": true},
+	}
+	registry := &portainer.Registry{ID: 1}
+
+	err := RefreshKubeAccess(cli, registry, []string{"a", "b", "c", "old"}, []string{"a", "b", "c"})
+
+	var kubeErr *KubeAccessError
+	is.ErrorAs(err, &kubeErr)
+	is.Equal([]NamespaceSecretResult{
+		{Namespace: "old", Status: NamespaceSecretDeleted},
+		{Namespace: "b", Status: NamespaceSecretFailed, Error: "creation failed"},
+		{Namespace: "old", Status: NamespaceSecretRolledBack},
+		{Namespace: "b", Status: NamespaceSecretRollbackFailed, Error: "creation failed"},
+	}, kubeErr.Results)
+
+	is.Equal([]string{"a", "old"}, cli.created, "the namespaces are recreated one at a time, the later ones are left untouched")
+	is.Equal(map[string]bool{"a": true, "c": true, "old": true}, cli.secrets)
+}
+
+func Test_UnknownNamespaces(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{namespaces: map[string]portainer.K8sNamespaceInfo{"default": {}, "dev": {}}}
+
+	unknown, err := UnknownNamespaces(cli, []string{"default", "dve", AllNamespaces, "prod"})
+	is.NoError(err)
+	is.Equal([]string{"dve", "prod"}, unknown)
+
+	unknown, err = UnknownNamespaces(cli, []string{"dev"})
+	is.NoError(err)
+	is.Empty(unknown)
+
+	unknown, err = UnknownNamespaces(cli, []string{AllNamespaces})
+	is.NoError(err)
+	is.Empty(unknown)
+}
+
+func Test_ValidateNamespaces(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateNamespaces(nil))
+	is.NoError(ValidateNamespaces([]string{"default", AllNamespaces}))
+	is.Error(ValidateNamespaces([]string{"default", ""}))
+	is.Error(ValidateNamespaces([]string{"default", "dev", "default"}))
+
+	cli := &kubeClientStub{namespaces: map[string]portainer.K8sNamespaceInfo{"default": {}}}
+
+	is.NoError(ValidateKubeNamespaces(cli, []string{"default"}))
+	is.ErrorIs(ValidateKubeNamespaces(cli, []string{"default", "prod"}), ErrUnknownNamespaces)
+}
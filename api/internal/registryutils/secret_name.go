@@ -0,0 +1,55 @@
+package registryutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/kubernetes/validation"
+)
+
+// Placeholders substituted in the secret name template of a registry
+const (
+	SecretNameRegistryIDPlaceholder = "{registryId}"
+	SecretNameNamespacePlaceholder  = "{namespace}"
+)
+
+// SecretName returns the name of the secret holding the credentials of the registry in the namespace.
+// It is rendered from the secret name template of the registry and defaults to registry-<registry ID>
+func SecretName(registry *portainer.Registry, namespace string) string {
+	if registry.SecretNameTemplate == "" {
+		return fmt.Sprintf("registry-%d", registry.ID)
+	}
+
+	return strings.NewReplacer(
+		SecretNameRegistryIDPlaceholder, strconv.Itoa(int(registry.ID)),
+		SecretNameNamespacePlaceholder, namespace,
+	).Replace(registry.SecretNameTemplate)
+}
+
+// ValidateSecretName returns an error when the name of the secret of the registry in the namespace is not
+// a valid Kubernetes object name
+func ValidateSecretName(registry *portainer.Registry, namespace string) error {
+	name := SecretName(registry, namespace)
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid registry secret name %q in namespace %s: %s", name, namespace, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// ValidateSecretNameTemplate returns an error when the template does not render a valid Kubernetes object name.
+// The template must contain the registry ID, otherwise the secrets of two registries could share the same name
+func ValidateSecretNameTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+
+	if !strings.Contains(template, SecretNameRegistryIDPlaceholder) {
+		return fmt.Errorf("invalid registry secret name template %q: it must contain %s", template, SecretNameRegistryIDPlaceholder)
+	}
+
+	return ValidateSecretName(&portainer.Registry{ID: 1, SecretNameTemplate: template}, "default")
+}
@@ -0,0 +1,49 @@
+package registryutils
+
+import (
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SecretName(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal("registry-3", SecretName(&portainer.Registry{ID: 3}, "dev"))
+	is.Equal("regcred-3-dev", SecretName(&portainer.Registry{ID: 3, SecretNameTemplate: "regcred-{registryId}-{namespace}"}, "dev"))
+	is.Equal("regcred", SecretName(&portainer.Registry{ID: 3, SecretNameTemplate: "regcred"}, "dev"))
+}
+
+func Test_ValidateSecretName(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSecretName(&portainer.Registry{ID: 3}, "dev"))
+	is.NoError(ValidateSecretName(&portainer.Registry{ID: 3, SecretNameTemplate: "{namespace}.regcred"}, "dev"))
+	is.Error(ValidateSecretName(&portainer.Registry{ID: 3, SecretNameTemplate: "RegCred"}, "dev"))
+	is.Error(ValidateSecretName(&portainer.Registry{ID: 3, SecretNameTemplate: "{namespace}-"}, "dev"))
+	is.Error(ValidateSecretName(&portainer.Registry{ID: 3, SecretNameTemplate: strings.Repeat("a", 250) + "-{namespace}"}, "dev"))
+}
+
+func Test_ValidateSecretNameTemplate(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSecretNameTemplate(""))
+	is.NoError(ValidateSecretNameTemplate("regcred-{registryId}-{namespace}"))
+	is.Error(ValidateSecretNameTemplate("regcred"), "the secrets of every registry would be named regcred")
+	is.Error(ValidateSecretNameTemplate("regcred-{namespace}"))
+	is.Error(ValidateSecretNameTemplate("Regcred-{registryId}"))
+}
+
+func Test_UpdateKubeAccess_invalidSecretName(t *testing.T) {
+	is := assert.New(t)
+
+	cli := &kubeClientStub{secrets: map[string]bool{}}
+	registry := &portainer.Registry{ID: 1, SecretNameTemplate: "regcred_{namespace}"}
+
+	err := UpdateKubeAccess(cli, registry, nil, []string{"dev"})
+	is.Error(err)
+	is.Empty(cli.created, "no secret is created when a name is invalid")
+}
@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	quietHoursLayout = "15:04"
+	// maxSkippedQuietHours is the number of windows looked ahead for the next snapshot
+	maxSkippedQuietHours = 7
+)
+
+// QuietHours is a parsed daily window without scheduled snapshots
+type QuietHours struct {
+	// start and end are the minutes since midnight in the wall clock of location
+	start, end int
+	location   *time.Location
+}
+
+// ParseQuietHours parses the quiet hours of the settings, nil is returned when they are disabled
+func ParseQuietHours(quietHours portainer.SnapshotQuietHours) (*QuietHours, error) {
+	if quietHours.Start == "" && quietHours.End == "" {
+		return nil, nil
+	}
+
+	start, err := time.Parse(quietHoursLayout, quietHours.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q, must be HH:MM", quietHours.Start)
+	}
+
+	end, err := time.Parse(quietHoursLayout, quietHours.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end %q, must be HH:MM", quietHours.End)
+	}
+
+	if start.Equal(end) {
+		return nil, errors.New("the start and the end must be different")
+	}
+
+	location, err := time.LoadLocation(quietHours.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", quietHours.Timezone)
+	}
+
+	return &QuietHours{
+		start:    start.Hour()*60 + start.Minute(),
+		end:      end.Hour()*60 + end.Minute(),
+		location: location,
+	}, nil
+}
+
+// Contains returns true when t is in the window. The wall clock of the timezone is compared, a window starting or
+// ending in an hour skipped by a DST transition starts or ends when the clock moves past it
+func (quietHours *QuietHours) Contains(t time.Time) bool {
+	if quietHours == nil {
+		return false
+	}
+
+	local := t.In(quietHours.location)
+	minutes := local.Hour()*60 + local.Minute()
+
+	if quietHours.start < quietHours.end {
+		return minutes >= quietHours.start && minutes < quietHours.end
+	}
+
+	return minutes >= quietHours.start || minutes < quietHours.end
+}
+
+// End returns the first instant after t at which the window ends
+func (quietHours *QuietHours) End(t time.Time) time.Time {
+	local := t.In(quietHours.location)
+
+	// the days are stepped on the calendar, a day lasts 23 or 25 hours across a DST transition
+	for day := 0; day <= 1; day++ {
+		end := time.Date(local.Year(), local.Month(), local.Day()+day, quietHours.end/60, quietHours.end%60, 0, 0, quietHours.location)
+		if end.After(t) {
+			return end
+		}
+	}
+
+	return t
+}
+
+// nextSnapshotTime returns the first tick of the schedule, after now and out of the quiet hours
+func nextSnapshotTime(lastTick time.Time, interval time.Duration, now time.Time, quietHours *QuietHours) time.Time {
+	if interval <= 0 {
+		return time.Time{}
+	}
+
+	next := lastTick.Add(interval)
+	if next.Before(now) {
+		next = next.Add(now.Sub(next).Truncate(interval) + interval)
+	}
+
+	// each step leaves a window, the bound guards against a schedule whose ticks always fall in one, like a daily
+	// interval starting during the quiet hours
+	for i := 0; i < maxSkippedQuietHours && quietHours.Contains(next); i++ {
+		skipped := quietHours.End(next).Sub(next)
+		next = next.Add((skipped + interval - 1) / interval * interval)
+	}
+
+	return next
+}
@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	is := assert.New(t)
+
+	quietHours, err := ParseQuietHours(portainer.SnapshotQuietHours{})
+	is.NoError(err)
+	is.Nil(quietHours, "the quiet hours are disabled")
+
+	_, err = ParseQuietHours(portainer.SnapshotQuietHours{Start: "08:00", End: "18:00", Timezone: "Europe/Paris"})
+	is.NoError(err)
+
+	_, err = ParseQuietHours(portainer.SnapshotQuietHours{Start: "8h", End: "18:00"})
+	is.Error(err)
+	_, err = ParseQuietHours(portainer.SnapshotQuietHours{Start: "08:00"})
+	is.Error(err)
+	_, err = ParseQuietHours(portainer.SnapshotQuietHours{Start: "08:00", End: "08:00"})
+	is.Error(err)
+	_, err = ParseQuietHours(portainer.SnapshotQuietHours{Start: "08:00", End: "18:00", Timezone: "Mars/Olympus"})
+	is.Error(err)
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	is := assert.New(t)
+
+	quietHours, err := ParseQuietHours(portainer.SnapshotQuietHours{Start: "22:00", End: "06:00", Timezone: "Europe/Paris"})
+	require.NoError(t, err)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	is.True(quietHours.Contains(time.Date(2024, 1, 10, 23, 0, 0, 0, paris)), "before midnight")
+	is.True(quietHours.Contains(time.Date(2024, 1, 11, 5, 59, 0, 0, paris)), "after midnight")
+	is.False(quietHours.Contains(time.Date(2024, 1, 11, 6, 0, 0, 0, paris)))
+	is.False(quietHours.Contains(time.Date(2024, 1, 11, 12, 0, 0, 0, paris)))
+	is.True(quietHours.Contains(time.Date(2024, 1, 10, 21, 30, 0, 0, time.UTC)), "the wall clock of the timezone is compared")
+
+	var disabled *QuietHours
+	is.False(disabled.Contains(time.Now()))
+}
+
+func TestQuietHoursEndAcrossDST(t *testing.T) {
+	is := assert.New(t)
+
+	quietHours, err := ParseQuietHours(portainer.SnapshotQuietHours{Start: "22:00", End: "06:00", Timezone: "Europe/Paris"})
+	require.NoError(t, err)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	// the clocks move forward on the night of the 30th of March 2024, the window lasts 7 hours
+	start := time.Date(2024, 3, 30, 22, 0, 0, 0, paris)
+	end := quietHours.End(start)
+	is.Equal(time.Date(2024, 3, 31, 6, 0, 0, 0, paris), end)
+	is.Equal(7*time.Hour, end.Sub(start))
+
+	// the clocks move back on the night of the 26th of October 2024, the window lasts 9 hours
+	start = time.Date(2024, 10, 26, 22, 0, 0, 0, paris)
+	is.Equal(9*time.Hour, quietHours.End(start).Sub(start))
+}
+
+func TestNextSnapshotTime(t *testing.T) {
+	is := assert.New(t)
+
+	quietHours, err := ParseQuietHours(portainer.SnapshotQuietHours{Start: "22:00", End: "06:00"})
+	require.NoError(t, err)
+
+	lastTick := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	is.Equal(lastTick.Add(time.Hour), nextSnapshotTime(lastTick, time.Hour, lastTick, quietHours))
+	is.Equal(lastTick.Add(3*time.Hour), nextSnapshotTime(lastTick, time.Hour, lastTick.Add(150*time.Minute), quietHours), "the missed ticks are skipped")
+	is.Equal(lastTick.Add(time.Hour), nextSnapshotTime(lastTick, time.Hour, lastTick, nil))
+
+	// the ticks keep their schedule, the first one at or after the end of the window runs
+	lastTick = time.Date(2024, 1, 10, 21, 50, 0, 0, time.UTC)
+	is.Equal(time.Date(2024, 1, 11, 6, 50, 0, 0, time.UTC), nextSnapshotTime(lastTick, time.Hour, lastTick, quietHours))
+
+	is.True(nextSnapshotTime(lastTick, 0, lastTick, quietHours).IsZero())
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
@@ -22,10 +24,17 @@ import (
 type Service struct {
 	dataStore                 dataservices.DataStore
 	snapshotIntervalCh        chan time.Duration
+	snapshotNowCh             chan struct{}
 	snapshotIntervalInSeconds float64
+	workerCount               atomic.Int32
+	quietHours                atomic.Pointer[QuietHours]
 	dockerSnapshotter         portainer.DockerSnapshotter
 	kubernetesSnapshotter     portainer.KubernetesSnapshotter
 	shutdownCtx               context.Context
+
+	// lastTick and interval describe the schedule of the snapshot loop, in nanoseconds
+	lastTick atomic.Int64
+	interval atomic.Int64
 }
 
 // NewService creates a new instance of a service
@@ -35,14 +44,28 @@ func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStor
 		return nil, err
 	}
 
-	return &Service{
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	service := &Service{
 		dataStore:                 dataStore,
 		snapshotIntervalCh:        make(chan time.Duration),
+		snapshotNowCh:             make(chan struct{}, 1),
 		snapshotIntervalInSeconds: interval,
 		dockerSnapshotter:         dockerSnapshotter,
 		kubernetesSnapshotter:     kubernetesSnapshotter,
 		shutdownCtx:               shutdownCtx,
-	}, nil
+	}
+	service.SetSnapshotWorkerCount(settings.SnapshotWorkerCount)
+
+	err = service.SetSnapshotQuietHours(settings.SnapshotQuietHours)
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid snapshot quiet hours, the snapshots are scheduled at any time")
+	}
+
+	return service, nil
 }
 
 // NewBackgroundSnapshotter queues snapshots of existing edge environments that
@@ -119,6 +142,45 @@ func (service *Service) SetSnapshotInterval(snapshotInterval string) error {
 	return nil
 }
 
+// SetSnapshotWorkerCount sets the number of environments snapshotted in parallel, it applies from the next snapshot.
+// The environments are snapshotted one at a time when the count is lower than 1
+func (service *Service) SetSnapshotWorkerCount(count int) {
+	service.workerCount.Store(int32(max(count, 1)))
+}
+
+// SetSnapshotQuietHours sets the daily window during which the scheduled snapshots are skipped, it applies from the
+// next scheduled snapshot. The snapshots triggered with SnapshotNow are not skipped
+func (service *Service) SetSnapshotQuietHours(quietHours portainer.SnapshotQuietHours) error {
+	parsed, err := ParseQuietHours(quietHours)
+	if err != nil {
+		return err
+	}
+
+	service.quietHours.Store(parsed)
+
+	return nil
+}
+
+// NextSnapshotTime returns the time of the next scheduled snapshot out of the quiet hours, the zero time before the
+// service is started
+func (service *Service) NextSnapshotTime() time.Time {
+	lastTick := service.lastTick.Load()
+	if lastTick == 0 {
+		return time.Time{}
+	}
+
+	return nextSnapshotTime(time.Unix(0, lastTick), time.Duration(service.interval.Load()), time.Now(), service.quietHours.Load())
+}
+
+// SnapshotNow schedules a snapshot of the environments(endpoints) without waiting for it, the requests made while
+// a snapshot is already scheduled are merged into it
+func (service *Service) SnapshotNow() {
+	select {
+	case service.snapshotNowCh <- struct{}{}:
+	default:
+	}
+}
+
 // SupportDirectSnapshot checks whether an environment(endpoint) can be used to trigger a direct a snapshot.
 // It is mostly true for all environments(endpoints) except Edge and Azure environments(endpoints).
 func SupportDirectSnapshot(endpoint *portainer.Endpoint) bool {
@@ -224,56 +286,97 @@ func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) err
 }
 
 func (service *Service) startSnapshotLoop() {
-	ticker := time.NewTicker(time.Duration(service.snapshotIntervalInSeconds) * time.Second)
+	interval := time.Duration(service.snapshotIntervalInSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	service.interval.Store(int64(interval))
 
-	err := service.snapshotEndpoints()
-	if err != nil {
-		log.Error().Err(err).Msg("background schedule error (environment snapshot)")
-	}
+	service.scheduledSnapshot(time.Now())
 
 	for {
 		select {
-		case <-ticker.C:
-			err := service.snapshotEndpoints()
-			if err != nil {
-				log.Error().Err(err).Msg("background schedule error (environment snapshot)")
-			}
+		case tick := <-ticker.C:
+			service.scheduledSnapshot(tick)
 		case <-service.shutdownCtx.Done():
 			log.Debug().Msg("shutting down snapshotting")
 			ticker.Stop()
 			return
 		case interval := <-service.snapshotIntervalCh:
 			ticker.Reset(interval)
+			service.interval.Store(int64(interval))
+			service.lastTick.Store(time.Now().UnixNano())
+		case <-service.snapshotNowCh:
+			err := service.snapshotEndpoints()
+			if err != nil {
+				log.Error().Err(err).Msg("background schedule error (environment snapshot)")
+			}
 		}
 	}
 }
 
+// scheduledSnapshot snapshots the environments unless the tick is in the quiet hours
+func (service *Service) scheduledSnapshot(tick time.Time) {
+	service.lastTick.Store(tick.UnixNano())
+
+	if service.quietHours.Load().Contains(tick) {
+		log.Debug().Msg("snapshot skipped during the quiet hours")
+
+		return
+	}
+
+	err := service.snapshotEndpoints()
+	if err != nil {
+		log.Error().Err(err).Msg("background schedule error (environment snapshot)")
+	}
+}
+
 func (service *Service) snapshotEndpoints() error {
 	endpoints, err := service.dataStore.Endpoint().Endpoints()
 	if err != nil {
 		return err
 	}
 
+	jobs := make(chan portainer.Endpoint)
+
+	var wg sync.WaitGroup
+	for i := int32(0); i < service.workerCount.Load(); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for endpoint := range jobs {
+				service.snapshotAndUpdateEndpoint(&endpoint)
+			}
+		}()
+	}
+
 	for _, endpoint := range endpoints {
 		if !SupportDirectSnapshot(&endpoint) || endpoint.URL == "" {
 			continue
 		}
 
-		snapshotError := service.SnapshotEndpoint(&endpoint)
-
-		if featureflags.IsEnabled(portainer.FeatureNoTx) {
-			updateEndpointStatus(service.dataStore, &endpoint, snapshotError)
-		} else {
-			service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
-				updateEndpointStatus(tx, &endpoint, snapshotError)
-				return nil
-			})
-		}
+		jobs <- endpoint
 	}
 
+	close(jobs)
+	wg.Wait()
+
 	return nil
 }
 
+func (service *Service) snapshotAndUpdateEndpoint(endpoint *portainer.Endpoint) {
+	snapshotError := service.SnapshotEndpoint(endpoint)
+
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		updateEndpointStatus(service.dataStore, endpoint, snapshotError)
+	} else {
+		service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			updateEndpointStatus(tx, endpoint, snapshotError)
+			return nil
+		})
+	}
+}
+
 func updateEndpointStatus(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, snapshotError error) {
 	latestEndpointReference, err := tx.Endpoint().Endpoint(endpoint.ID)
 	if latestEndpointReference == nil {
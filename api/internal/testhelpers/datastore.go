@@ -10,6 +10,7 @@ import (
 )
 
 type testDatastore struct {
+	auditLog                dataservices.AuditLogService
 	customTemplate          dataservices.CustomTemplateService
 	edgeGroup               dataservices.EdgeGroupService
 	edgeJob                 dataservices.EdgeJobService
@@ -46,6 +47,7 @@ func (d *testDatastore) ViewTx(func(dataservices.DataStoreTx) error) error   { r
 func (d *testDatastore) CheckCurrentEdition() error                         { return nil }
 func (d *testDatastore) MigrateData() error                                 { return nil }
 func (d *testDatastore) Rollback(force bool) error                          { return nil }
+func (d *testDatastore) AuditLog() dataservices.AuditLogService             { return d.auditLog }
 func (d *testDatastore) CustomTemplate() dataservices.CustomTemplateService { return d.customTemplate }
 func (d *testDatastore) EdgeGroup() dataservices.EdgeGroupService           { return d.edgeGroup }
 func (d *testDatastore) EdgeJob() dataservices.EdgeJobService               { return d.edgeJob }
@@ -118,6 +120,10 @@ func (s *stubSettingsService) UpdateSettings(settings *portainer.Settings) error
 	s.settings = settings
 	return nil
 }
+func (s *stubSettingsService) UpdateStatus(settings *portainer.Settings) error {
+	s.settings = settings
+	return nil
+}
 func WithSettingsService(settings *portainer.Settings) datastoreOption {
 	return func(d *testDatastore) {
 		d.settings = &stubSettingsService{
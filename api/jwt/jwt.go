@@ -3,9 +3,11 @@ package jwt
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -18,9 +20,15 @@ type scope string
 
 // Service represents a service for managing JWT tokens.
 type Service struct {
-	secrets            map[scope][]byte
-	userSessionTimeout time.Duration
-	dataStore          dataservices.DataStore
+	secrets   map[scope][]byte
+	dataStore dataservices.DataStore
+	// userSessionTimeout is the time.Duration of the user sessions, updated by the settings while tokens are checked
+	userSessionTimeout atomic.Int64
+	// tokenIssueFloor is the Unix timestamp before which the user session timeout applies to the issued tokens
+	// regardless of their expiry
+	tokenIssueFloor atomic.Int64
+	// sessionRevocationFloor is the Unix timestamp before which every issued token is rejected
+	sessionRevocationFloor atomic.Int64
 }
 
 type claims struct {
@@ -29,6 +37,7 @@ type claims struct {
 	Role                int    `json:"role"`
 	Scope               scope  `json:"scope"`
 	ForceChangePassword bool   `json:"forceChangePassword"`
+	AuthenticatedAt     int64  `json:"authenticatedAt,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -42,16 +51,31 @@ const (
 	kubeConfigScope = scope("kubeconfig")
 )
 
-// NewService initializes a new service. It will generate a random key that will be used to sign JWT tokens.
+// NewService initializes a new service. It will generate a random key that will be used to sign JWT tokens,
+// so the tokens issued before a restart are rejected. Any previously persisted signing key is removed.
 func NewService(userSessionDuration string, dataStore dataservices.DataStore) (*Service, error) {
+	return newService(userSessionDuration, dataStore, regenerateSecret)
+}
+
+// NewServiceWithPersistedSecret initializes a new service that signs the JWT tokens with the key persisted in the
+// database, generating it on the first start. Sessions survive restarts, at the cost of keeping the key valid until
+// the signing key mode is switched back to regenerate. The key is stored encrypted with the database encryption key,
+// so that it does not appear in clear in the exports of the database, e.g. in the backups
+func NewServiceWithPersistedSecret(userSessionDuration string, dataStore dataservices.DataStore, encryptionKey []byte) (*Service, error) {
+	return newService(userSessionDuration, dataStore, func(dataStore dataservices.DataStore) ([]byte, error) {
+		return getOrCreateSecret(dataStore, encryptionKey)
+	})
+}
+
+func newService(userSessionDuration string, dataStore dataservices.DataStore, loadSecret func(dataservices.DataStore) ([]byte, error)) (*Service, error) {
 	userSessionTimeout, err := time.ParseDuration(userSessionDuration)
 	if err != nil {
 		return nil, err
 	}
 
-	secret := securecookie.GenerateRandomKey(32)
-	if secret == nil {
-		return nil, errSecretGeneration
+	secret, err := loadSecret(dataStore)
+	if err != nil {
+		return nil, err
 	}
 
 	kubeSecret, err := getOrCreateKubeSecret(dataStore)
@@ -59,17 +83,80 @@ func NewService(userSessionDuration string, dataStore dataservices.DataStore) (*
 		return nil, err
 	}
 
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
 	service := &Service{
-		map[scope][]byte{
+		secrets: map[scope][]byte{
 			defaultScope:    secret,
 			kubeConfigScope: kubeSecret,
 		},
-		userSessionTimeout,
-		dataStore,
+		dataStore: dataStore,
 	}
+	service.userSessionTimeout.Store(int64(userSessionTimeout))
+	service.tokenIssueFloor.Store(settings.TokenIssueFloor)
+	service.sessionRevocationFloor.Store(settings.SessionRevocationFloor)
+
 	return service, nil
 }
 
+func regenerateSecret(dataStore dataservices.DataStore) ([]byte, error) {
+	secret := securecookie.GenerateRandomKey(32)
+	if secret == nil {
+		return nil, errSecretGeneration
+	}
+
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.JWTSigningKey != nil {
+		settings.JWTSigningKey = nil
+		err = dataStore.Settings().UpdateSettings(settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return secret, nil
+}
+
+func getOrCreateSecret(dataStore dataservices.DataStore, encryptionKey []byte) ([]byte, error) {
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.JWTSigningKey != nil {
+		secret, err := crypto.AesGcmDecrypt(settings.JWTSigningKey, encryptionKey)
+		if err == nil {
+			return secret, nil
+		}
+
+		log.Warn().Err(err).Msg("unable to decrypt the persisted JWT signing key, a new key is generated and every session is invalidated")
+	}
+
+	secret := securecookie.GenerateRandomKey(32)
+	if secret == nil {
+		return nil, errSecretGeneration
+	}
+
+	settings.JWTSigningKey, err = crypto.AesGcmEncrypt(secret, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dataStore.Settings().UpdateSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
 func getOrCreateKubeSecret(dataStore dataservices.DataStore) ([]byte, error) {
 	settings, err := dataStore.Settings().Settings()
 	if err != nil {
@@ -92,7 +179,7 @@ func getOrCreateKubeSecret(dataStore dataservices.DataStore) ([]byte, error) {
 }
 
 func (service *Service) defaultExpireAt() int64 {
-	return time.Now().Add(service.userSessionTimeout).Unix()
+	return time.Now().Add(time.Duration(service.userSessionTimeout.Load())).Unix()
 }
 
 // GenerateToken generates a new JWT token.
@@ -133,10 +220,25 @@ func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData,
 				return nil, errInvalidJWTToken
 			}
 
+			if service.sessionRevocationFloor.Load() > cl.IssuedAt {
+				return nil, errInvalidJWTToken
+			}
+
+			if cl.Scope != kubeConfigScope && service.exceedsTokenIssueFloor(cl.IssuedAt) {
+				return nil, errInvalidJWTToken
+			}
+
+			authenticatedAt := cl.AuthenticatedAt
+			if authenticatedAt == 0 {
+				// tokens issued before the authentication time was recorded
+				authenticatedAt = cl.IssuedAt
+			}
+
 			return &portainer.TokenData{
-				ID:       portainer.UserID(cl.UserID),
-				Username: cl.Username,
-				Role:     portainer.UserRole(cl.Role),
+				ID:              portainer.UserID(cl.UserID),
+				Username:        cl.Username,
+				Role:            portainer.UserRole(cl.Role),
+				AuthenticatedAt: authenticatedAt,
 			}, nil
 		}
 	}
@@ -159,7 +261,22 @@ func parseScope(token string) scope {
 
 // SetUserSessionDuration sets the user session duration
 func (service *Service) SetUserSessionDuration(userSessionDuration time.Duration) {
-	service.userSessionTimeout = userSessionDuration
+	service.userSessionTimeout.Store(int64(userSessionDuration))
+}
+
+// SetTokenIssueFloor makes the tokens issued before the floor expire once they are older than the user session duration
+func (service *Service) SetTokenIssueFloor(floor int64) {
+	service.tokenIssueFloor.Store(floor)
+}
+
+// SetSessionRevocationFloor rejects every token issued before the floor, whatever its user and scope
+func (service *Service) SetSessionRevocationFloor(floor int64) {
+	service.sessionRevocationFloor.Store(floor)
+}
+
+// exceedsTokenIssueFloor returns true when the token was issued before the floor and is older than the user session duration
+func (service *Service) exceedsTokenIssueFloor(issuedAt int64) bool {
+	return issuedAt < service.tokenIssueFloor.Load() && time.Since(time.Unix(issuedAt, 0)) > time.Duration(service.userSessionTimeout.Load())
 }
 
 func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt int64, scope scope) (string, error) {
@@ -179,15 +296,24 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		expiresAt = time.Now().Add(time.Hour * 8760 * 99).Unix()
 	}
 
+	now := time.Now().Unix()
+
+	// tokens generated for an existing session keep the time of the original authentication
+	authenticatedAt := data.AuthenticatedAt
+	if authenticatedAt == 0 {
+		authenticatedAt = now
+	}
+
 	cl := claims{
 		UserID:              int(data.ID),
 		Username:            data.Username,
 		Role:                int(data.Role),
 		Scope:               scope,
 		ForceChangePassword: data.ForceChangePassword,
+		AuthenticatedAt:     authenticatedAt,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expiresAt,
-			IssuedAt:  time.Now().Unix(),
+			IssuedAt:  now,
 		},
 	}
 
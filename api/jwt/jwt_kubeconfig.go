@@ -13,7 +13,17 @@ func (service *Service) GenerateTokenForKubeconfig(data *portainer.TokenData) (s
 		return "", err
 	}
 
-	expiryDuration, err := time.ParseDuration(settings.KubeconfigExpiry)
+	user, err := service.dataStore.User().Read(data.ID)
+	if err != nil {
+		return "", err
+	}
+
+	userExpiry := ""
+	if user != nil {
+		userExpiry = user.KubeconfigExpiry
+	}
+
+	expiryDuration, _, err := KubeconfigExpiry(settings.KubeconfigExpiry, userExpiry)
 	if err != nil {
 		return "", err
 	}
@@ -25,3 +35,28 @@ func (service *Service) GenerateTokenForKubeconfig(data *portainer.TokenData) (s
 
 	return service.generateSignedToken(data, expiryAt, kubeConfigScope)
 }
+
+// KubeconfigExpiry returns the expiry of the kubeconfigs of a user, the expiry chosen by the user bounded by the global
+// expiry, which applies when the user did not choose one. A 0 duration means that the kubeconfigs do not expire.
+// clamped is true when the expiry of the user exceeds the global expiry
+func KubeconfigExpiry(globalExpiry, userExpiry string) (expiry time.Duration, clamped bool, err error) {
+	maxExpiry, err := time.ParseDuration(globalExpiry)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if userExpiry == "" {
+		return maxExpiry, false, nil
+	}
+
+	expiry, err = time.ParseDuration(userExpiry)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if maxExpiry != 0 && (expiry == 0 || expiry > maxExpiry) {
+		return maxExpiry, true, nil
+	}
+
+	return expiry, false, nil
+}
@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	portainer "github.com/portainer/portainer/api"
@@ -26,7 +27,7 @@ func TestService_GenerateTokenForKubeconfig(t *testing.T) {
 
 	myFields := fields{
 		userSessionTimeout: "24h",
-		dataStore:          i.NewDatastore(i.WithSettingsService(mySettings)),
+		dataStore:          i.NewDatastore(i.WithSettingsService(mySettings), i.WithUsers(nil)),
 	}
 
 	myTokenData := &portainer.TokenData{
@@ -81,3 +82,35 @@ func TestService_GenerateTokenForKubeconfig(t *testing.T) {
 		})
 	}
 }
+
+func TestKubeconfigExpiry(t *testing.T) {
+	tests := []struct {
+		name         string
+		globalExpiry string
+		userExpiry   string
+		wantExpiry   time.Duration
+		wantClamped  bool
+		wantErr      bool
+	}{
+		{name: "global expiry without override", globalExpiry: "24h", wantExpiry: 24 * time.Hour},
+		{name: "override below the global expiry", globalExpiry: "24h", userExpiry: "8h", wantExpiry: 8 * time.Hour},
+		{name: "override above the global expiry", globalExpiry: "24h", userExpiry: "48h", wantExpiry: 24 * time.Hour, wantClamped: true},
+		{name: "override without expiry", globalExpiry: "24h", userExpiry: "0", wantExpiry: 24 * time.Hour, wantClamped: true},
+		{name: "global expiry disabled", globalExpiry: "0", userExpiry: "48h", wantExpiry: 48 * time.Hour},
+		{name: "invalid override", globalExpiry: "24h", userExpiry: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry, clamped, err := KubeconfigExpiry(tt.globalExpiry, tt.userExpiry)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantExpiry, expiry)
+			assert.Equal(t, tt.wantClamped, clamped)
+		})
+	}
+}
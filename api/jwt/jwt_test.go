@@ -1,15 +1,19 @@
 package jwt
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
 	i "github.com/portainer/portainer/api/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
 
+var testEncryptionKey = bytes.Repeat([]byte{1}, 32)
+
 func TestGenerateSignedToken(t *testing.T) {
 	dataStore := i.NewDatastore(i.WithSettingsService(&portainer.Settings{}))
 	svc, err := NewService("24h", dataStore)
@@ -55,3 +59,155 @@ func TestGenerateSignedToken_InvalidScope(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "invalid scope: testing", err.Error())
 }
+
+func TestPersistedSecret_SurvivesRestart(t *testing.T) {
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "Joe", Role: portainer.AdministratorRole}
+	err := dataStore.User().Create(user)
+	assert.NoError(t, err)
+
+	svc, err := NewServiceWithPersistedSecret("24h", dataStore, testEncryptionKey)
+	assert.NoError(t, err)
+
+	token, err := svc.GenerateToken(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role})
+	assert.NoError(t, err)
+
+	restarted, err := NewServiceWithPersistedSecret("24h", dataStore, testEncryptionKey)
+	assert.NoError(t, err)
+
+	_, err = restarted.ParseAndVerifyToken(token)
+	assert.NoError(t, err, "the session should survive a restart")
+
+	regenerated, err := NewService("24h", dataStore)
+	assert.NoError(t, err)
+
+	_, err = regenerated.ParseAndVerifyToken(token)
+	assert.Error(t, err, "the session should be invalidated when the key is regenerated")
+
+	settings, err := dataStore.Settings().Settings()
+	assert.NoError(t, err)
+	assert.Nil(t, settings.JWTSigningKey, "the persisted key should be removed when the key is regenerated")
+}
+
+func TestAuthenticatedAt_KeptForExistingSession(t *testing.T) {
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "Joe", Role: portainer.AdministratorRole}
+	err := dataStore.User().Create(user)
+	assert.NoError(t, err)
+
+	svc, err := NewService("24h", dataStore)
+	assert.NoError(t, err)
+
+	authenticatedAt := time.Now().Add(-time.Hour).Unix()
+	token, err := svc.GenerateToken(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role, AuthenticatedAt: authenticatedAt})
+	assert.NoError(t, err)
+
+	tokenData, err := svc.ParseAndVerifyToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, authenticatedAt, tokenData.AuthenticatedAt)
+
+	token, err = svc.GenerateToken(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role})
+	assert.NoError(t, err)
+
+	tokenData, err = svc.ParseAndVerifyToken(token)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), tokenData.AuthenticatedAt, 5, "a new authentication is recorded when none is provided")
+}
+
+func TestTokenIssueFloor(t *testing.T) {
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "Joe", Role: portainer.AdministratorRole}
+	err := dataStore.User().Create(user)
+	assert.NoError(t, err)
+
+	svc, err := NewService("24h", dataStore)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	oldToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		UserID:   int(user.ID),
+		Username: user.Username,
+		Role:     int(user.Role),
+		Scope:    defaultScope,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(22 * time.Hour).Unix(),
+			IssuedAt:  now.Add(-2 * time.Hour).Unix(),
+		},
+	}).SignedString(svc.secrets[defaultScope])
+	assert.NoError(t, err)
+
+	svc.SetUserSessionDuration(time.Hour)
+
+	_, err = svc.ParseAndVerifyToken(oldToken)
+	assert.NoError(t, err, "the token keeps its expiry without a floor")
+
+	svc.SetTokenIssueFloor(now.Unix())
+
+	_, err = svc.ParseAndVerifyToken(oldToken)
+	assert.Error(t, err, "the token issued before the floor exceeds the new session duration")
+
+	newToken, err := svc.GenerateToken(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role})
+	assert.NoError(t, err)
+
+	_, err = svc.ParseAndVerifyToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestSessionRevocationFloor(t *testing.T) {
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "Joe", Role: portainer.AdministratorRole}
+	err := dataStore.User().Create(user)
+	assert.NoError(t, err)
+
+	svc, err := NewService("24h", dataStore)
+	assert.NoError(t, err)
+
+	token, err := svc.GenerateToken(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role})
+	assert.NoError(t, err)
+
+	kubeconfigToken, err := svc.GenerateTokenForKubeconfig(&portainer.TokenData{Username: user.Username, ID: user.ID, Role: user.Role})
+	assert.NoError(t, err)
+
+	floor := time.Now().Add(time.Second).Unix()
+	svc.SetSessionRevocationFloor(floor)
+
+	_, err = svc.ParseAndVerifyToken(token)
+	assert.Error(t, err, "the token issued before the floor is revoked")
+
+	_, err = svc.ParseAndVerifyToken(kubeconfigToken)
+	assert.Error(t, err, "the kubeconfig tokens are revoked as well")
+
+	settings, err := dataStore.Settings().Settings()
+	assert.NoError(t, err)
+	settings.SessionRevocationFloor = floor
+	assert.NoError(t, dataStore.Settings().UpdateSettings(settings))
+
+	restarted, err := NewServiceWithPersistedSecret("24h", dataStore, testEncryptionKey)
+	assert.NoError(t, err)
+	assert.Equal(t, floor, restarted.sessionRevocationFloor.Load(), "the floor survives a restart")
+}
+
+func TestPersistedSecret_Encrypted(t *testing.T) {
+	_, dataStore := datastore.MustNewTestStore(t, true, false)
+
+	svc, err := NewServiceWithPersistedSecret("24h", dataStore, testEncryptionKey)
+	assert.NoError(t, err)
+
+	settings, err := dataStore.Settings().Settings()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, settings.JWTSigningKey)
+	assert.False(t, bytes.Contains(settings.JWTSigningKey, svc.secrets[defaultScope]), "the signing key is not stored in clear")
+
+	token, err := svc.GenerateToken(&portainer.TokenData{Username: "Joe", ID: 1, Role: portainer.AdministratorRole})
+	assert.NoError(t, err)
+
+	rotated, err := NewServiceWithPersistedSecret("24h", dataStore, bytes.Repeat([]byte{2}, 32))
+	assert.NoError(t, err, "a key that cannot be decrypted is replaced")
+
+	_, err = rotated.ParseAndVerifyToken(token)
+	assert.Error(t, err)
+}
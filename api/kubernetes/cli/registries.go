@@ -3,11 +3,13 @@ package cli
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
 	"github.com/portainer/portainer/api/internal/registryutils"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -33,7 +35,7 @@ type (
 )
 
 func (kcl *KubeClient) DeleteRegistrySecret(registry *portainer.Registry, namespace string) error {
-	err := kcl.cli.CoreV1().Secrets(namespace).Delete(context.TODO(), registrySecretName(registry), metav1.DeleteOptions{})
+	err := kcl.cli.CoreV1().Secrets(namespace).Delete(context.TODO(), registryutils.SecretName(registry, namespace), metav1.DeleteOptions{})
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return errors.Wrap(err, "failed removing secret")
 	}
@@ -64,7 +66,7 @@ func (kcl *KubeClient) CreateRegistrySecret(registry *portainer.Registry, namesp
 	secret := &v1.Secret{
 		TypeMeta: metav1.TypeMeta{},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: registrySecretName(registry),
+			Name: registryutils.SecretName(registry, namespace),
 			Labels: map[string]string{
 				labelRegistryType: strconv.Itoa(int(registry.Type)),
 			},
@@ -103,6 +105,39 @@ func (cli *KubeClient) IsRegistrySecret(namespace, secretName string) (bool, err
 
 }
 
-func registrySecretName(registry *portainer.Registry) string {
-	return fmt.Sprintf("registry-%d", registry.ID)
+// GetRegistrySecret returns the metadata of the secret created for the registry in the namespace, or nil when it does not exist.
+// The docker config is only parsed to list the registry URLs it references, the credentials are not returned.
+func (kcl *KubeClient) GetRegistrySecret(registry *portainer.Registry, namespace string) (*models.K8sRegistrySecret, error) {
+	secret, err := kcl.cli.CoreV1().Secrets(namespace).Get(context.TODO(), registryutils.SecretName(registry, namespace), metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "failed fetching secret")
+	}
+
+	registrySecret := &models.K8sRegistrySecret{
+		Name:         secret.Name,
+		Namespace:    secret.Namespace,
+		Type:         string(secret.Type),
+		CreationDate: secret.CreationTimestamp.Time.UTC().Format(time.RFC3339),
+		RegistryID:   secret.Annotations[annotationRegistryID],
+		RegistryType: secret.Labels[labelRegistryType],
+		RegistryURLs: []string{},
+	}
+
+	if data, ok := secret.Data[secretDockerConfigKey]; ok {
+		var config dockerConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, errors.Wrap(err, "failed parsing docker config")
+		}
+
+		for url := range config.Auths {
+			registrySecret.RegistryURLs = append(registrySecret.RegistryURLs, url)
+		}
+		sort.Strings(registrySecret.RegistryURLs)
+	}
+
+	return registrySecret, nil
 }
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_GetRegistrySecret(t *testing.T) {
+	is := assert.New(t)
+
+	kcl := &KubeClient{
+		cli:        kfake.NewSimpleClientset(),
+		instanceID: "instance",
+	}
+
+	registry := &portainer.Registry{
+		ID:             1,
+		Type:           portainer.CustomRegistry,
+		URL:            "registry.example.com",
+		Authentication: true,
+		Username:       "user",
+		Password:       "secret-password",
+	}
+
+	secret, err := kcl.GetRegistrySecret(registry, "default")
+	is.NoError(err)
+	is.Nil(secret, "secret should not exist yet")
+
+	err = kcl.CreateRegistrySecret(registry, "default")
+	is.NoError(err)
+
+	secret, err = kcl.GetRegistrySecret(registry, "default")
+	is.NoError(err)
+	is.Equal("registry-1", secret.Name)
+	is.Equal("1", secret.RegistryID)
+	is.Equal([]string{"registry.example.com"}, secret.RegistryURLs)
+	is.NotContains(fmt.Sprintf("%+v", secret), "secret-password")
+}
@@ -0,0 +1,166 @@
+package ldap
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// CAExpiryCheckInterval is the interval between two checks of the LDAP CA certificate expiry
+	CAExpiryCheckInterval = 12 * time.Hour
+
+	caExpiryWebhookTimeout = 10 * time.Second
+)
+
+type caExpiryWebhookPayload struct {
+	Message   string `json:"message"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// StartCAExpiryMonitor periodically checks the expiry of the configured LDAP CA certificate and
+// records a warning in the settings when it expires within the configured window
+func StartCAExpiryMonitor(s *scheduler.Scheduler, dataStore dataservices.DataStore) {
+	s.StartJobEvery(CAExpiryCheckInterval, func() error {
+		err := CheckCAExpiry(dataStore, time.Now())
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to check the expiry of the LDAP CA certificate")
+		}
+
+		// the job must keep running even when a check fails
+		return nil
+	})
+}
+
+// CheckCAExpiry updates the LDAP CA expiry warning of the settings and notifies the webhook when a new warning is raised
+func CheckCAExpiry(dataStore dataservices.DataStore, now time.Time) error {
+	var warning string
+	var expiresAt time.Time
+	var changed bool
+
+	err := dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err := tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		warning, expiresAt, err = caExpiryWarning(settings, now)
+		if err != nil {
+			return err
+		}
+
+		if warning == settings.LDAPCAExpiryWarning {
+			return nil
+		}
+
+		changed = true
+		settings.LDAPCAExpiryWarning = warning
+
+		// the warning is not an administrator change, the revision is kept so that a pending update is not rejected
+		return tx.Settings().UpdateStatus(settings)
+	})
+	if err != nil || !changed || warning == "" {
+		return err
+	}
+
+	log.Warn().Str("warning", warning).Msg("LDAP CA certificate expiry")
+
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	if settings.LDAPCAExpiryWebhookURL == "" {
+		return nil
+	}
+
+	return notifyCAExpiry(client.NewGuardedHTTPClient(caExpiryWebhookTimeout), settings.LDAPCAExpiryWebhookURL, warning, expiresAt)
+}
+
+// caExpiryWarning returns the warning to surface for the LDAP CA certificate, empty when the certificate is not about to expire
+func caExpiryWarning(settings *portainer.Settings, now time.Time) (string, time.Time, error) {
+	ldapSettings := settings.LDAPSettings
+	if settings.LDAPCAExpiryWarningDays <= 0 ||
+		!(ldapSettings.TLSConfig.TLS || ldapSettings.StartTLS) ||
+		ldapSettings.TLSConfig.TLSSkipVerify ||
+		ldapSettings.TLSConfig.TLSCACertPath == "" {
+		return "", time.Time{}, nil
+	}
+
+	expiresAt, err := CAExpiry(ldapSettings.TLSConfig.TLSCACertPath)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if now.After(expiresAt) {
+		return fmt.Sprintf("The LDAP CA certificate expired on %s", expiresAt.UTC().Format(time.RFC3339)), expiresAt, nil
+	}
+
+	window := time.Duration(settings.LDAPCAExpiryWarningDays) * 24 * time.Hour
+	if expiresAt.Sub(now) <= window {
+		return fmt.Sprintf("The LDAP CA certificate expires on %s", expiresAt.UTC().Format(time.RFC3339)), expiresAt, nil
+	}
+
+	return "", time.Time{}, nil
+}
+
+// CAExpiry returns the earliest expiry date of the certificates contained in the PEM file
+func CAExpiry(caCertPath string) (time.Time, error) {
+	data, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed reading the CA certificate")
+	}
+
+	var expiresAt time.Time
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "failed parsing the CA certificate")
+		}
+
+		if expiresAt.IsZero() || cert.NotAfter.Before(expiresAt) {
+			expiresAt = cert.NotAfter
+		}
+	}
+
+	if expiresAt.IsZero() {
+		return time.Time{}, errors.New("no certificate found in the CA file")
+	}
+
+	return expiresAt, nil
+}
+
+func notifyCAExpiry(httpClient *http.Client, webhookURL, warning string, expiresAt time.Time) error {
+	body, err := json.Marshal(caExpiryWebhookPayload{Message: warning, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed calling the LDAP CA expiry webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the LDAP CA expiry webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
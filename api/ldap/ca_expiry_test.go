@@ -0,0 +1,105 @@
+package ldap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeCACert(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap-ca"},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	return path
+}
+
+func TestCAExpiryWarning(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	newSettings := func(caCertPath string, days int) *portainer.Settings {
+		settings := &portainer.Settings{LDAPCAExpiryWarningDays: days}
+		settings.LDAPSettings.TLSConfig.TLS = true
+		settings.LDAPSettings.TLSConfig.TLSCACertPath = caCertPath
+
+		return settings
+	}
+
+	expiringSoon := writeCACert(t, now.Add(10*24*time.Hour))
+	expiringLater := writeCACert(t, now.Add(100*24*time.Hour))
+	expired := writeCACert(t, now.Add(-24*time.Hour))
+
+	warning, expiresAt, err := caExpiryWarning(newSettings(expiringSoon, 30), now)
+	require.NoError(t, err)
+	require.Contains(t, warning, "expires on")
+	require.True(t, expiresAt.Equal(now.Add(10*24*time.Hour)))
+
+	warning, _, err = caExpiryWarning(newSettings(expired, 30), now)
+	require.NoError(t, err)
+	require.Contains(t, warning, "expired on")
+
+	warning, _, err = caExpiryWarning(newSettings(expiringLater, 30), now)
+	require.NoError(t, err)
+	require.Empty(t, warning)
+
+	warning, _, err = caExpiryWarning(newSettings(expiringSoon, 0), now)
+	require.NoError(t, err)
+	require.Empty(t, warning, "the check is disabled")
+
+	settings := newSettings(expiringSoon, 30)
+	settings.LDAPSettings.TLSConfig.TLS = false
+	warning, _, err = caExpiryWarning(settings, now)
+	require.NoError(t, err)
+	require.Empty(t, warning, "TLS is not used")
+
+	_, _, err = caExpiryWarning(newSettings(filepath.Join(t.TempDir(), "missing.pem"), 30), now)
+	require.Error(t, err)
+}
+
+func TestCheckCAExpiry_keepsRevision(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	require.NoError(t, err)
+	settings.LDAPCAExpiryWarningDays = 30
+	settings.LDAPSettings.TLSConfig.TLS = true
+	settings.LDAPSettings.TLSConfig.TLSCACertPath = writeCACert(t, now.Add(10*24*time.Hour))
+	require.NoError(t, store.Settings().UpdateSettings(settings))
+
+	require.NoError(t, CheckCAExpiry(store, now))
+
+	updated, err := store.Settings().Settings()
+	require.NoError(t, err)
+	require.Contains(t, updated.LDAPCAExpiryWarning, "expires on")
+	require.Equal(t, settings.Revision, updated.Revision, "the warning does not invalidate the ETag of the settings")
+}
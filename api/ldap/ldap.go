@@ -3,6 +3,7 @@ package ldap
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	ldap "github.com/go-ldap/ldap/v3"
 	"github.com/pkg/errors"
@@ -11,6 +12,27 @@ import (
 	httperrors "github.com/portainer/portainer/api/http/errors"
 )
 
+// Stages of a settings check
+const (
+	CheckStageConnection = "connection"
+	CheckStageBind       = "bind"
+	CheckStageSearch     = "search"
+)
+
+// CheckError describes the stage at which a settings check failed
+type CheckError struct {
+	Stage string
+	Err   error
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("LDAP %s failed: %s", e.Stage, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
 var (
 	// errUserNotFound defines an error raised when the user is not found via LDAP search
 	// or that too many entries (> 1) are returned.
@@ -18,7 +40,10 @@ var (
 )
 
 // Service represents a service used to authenticate users against a LDAP/AD.
-type Service struct{}
+type Service struct {
+	mu   sync.Mutex
+	pool *connectionPool
+}
 
 func createConnection(settings *portainer.LDAPSettings) (*ldap.Conn, error) {
 	conn, err := createConnectionForURL(settings.URL, settings)
@@ -37,6 +62,11 @@ func createConnectionForURL(url string, settings *portainer.LDAPSettings) (*ldap
 		}
 		config.ServerName = strings.Split(url, ":")[0]
 
+		config.MinVersion, err = crypto.ParseTLSMinVersion(settings.TLSMinVersion)
+		if err != nil {
+			return nil, err
+		}
+
 		if settings.TLSConfig.TLS {
 			return ldap.DialTLS("tcp", url, config)
 		}
@@ -58,20 +88,12 @@ func createConnectionForURL(url string, settings *portainer.LDAPSettings) (*ldap
 }
 
 // AuthenticateUser is used to authenticate a user against a LDAP/AD.
-func (*Service) AuthenticateUser(username, password string, settings *portainer.LDAPSettings) error {
-
-	connection, err := createConnection(settings)
+func (service *Service) AuthenticateUser(username, password string, settings *portainer.LDAPSettings) error {
+	connection, release, err := service.connect(settings)
 	if err != nil {
 		return err
 	}
-	defer connection.Close()
-
-	if !settings.AnonymousMode {
-		err = connection.Bind(settings.ReaderDN, settings.Password)
-		if err != nil {
-			return err
-		}
-	}
+	defer release()
 
 	userDN, err := searchUser(username, connection, settings.SearchSettings)
 	if err != nil {
@@ -87,19 +109,12 @@ func (*Service) AuthenticateUser(username, password string, settings *portainer.
 }
 
 // GetUserGroups is used to retrieve user groups from LDAP/AD.
-func (*Service) GetUserGroups(username string, settings *portainer.LDAPSettings) ([]string, error) {
-	connection, err := createConnection(settings)
+func (service *Service) GetUserGroups(username string, settings *portainer.LDAPSettings) ([]string, error) {
+	connection, release, err := service.connect(settings)
 	if err != nil {
 		return nil, err
 	}
-	defer connection.Close()
-
-	if !settings.AnonymousMode {
-		err = connection.Bind(settings.ReaderDN, settings.Password)
-		if err != nil {
-			return nil, err
-		}
-	}
+	defer release()
 
 	userDN, err := searchUser(username, connection, settings.SearchSettings)
 	if err != nil {
@@ -112,23 +127,20 @@ func (*Service) GetUserGroups(username string, settings *portainer.LDAPSettings)
 }
 
 // SearchUsers searches for users with the specified settings
-func (*Service) SearchUsers(settings *portainer.LDAPSettings) ([]string, error) {
-	connection, err := createConnection(settings)
+func (service *Service) SearchUsers(settings *portainer.LDAPSettings) ([]string, error) {
+	connection, release, err := service.connect(settings)
 	if err != nil {
 		return nil, err
 	}
-	defer connection.Close()
+	defer release()
 
-	if !settings.AnonymousMode {
-		err = connection.Bind(settings.ReaderDN, settings.Password)
-		if err != nil {
-			return nil, err
-		}
-	}
+	return searchUsers(connection, settings.SearchSettings)
+}
 
+func searchUsers(connection *ldap.Conn, settings []portainer.LDAPSearchSettings) ([]string, error) {
 	users := map[string]bool{}
 
-	for _, searchSettings := range settings.SearchSettings {
+	for _, searchSettings := range settings {
 		searchRequest := ldap.NewSearchRequest(
 			searchSettings.BaseDN,
 			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
@@ -159,21 +171,14 @@ func (*Service) SearchUsers(settings *portainer.LDAPSettings) ([]string, error)
 }
 
 // SearchGroups searches for groups with the specified settings
-func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAPUser, error) {
+func (service *Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAPUser, error) {
 	type groupSet map[string]bool
 
-	connection, err := createConnection(settings)
+	connection, release, err := service.connect(settings)
 	if err != nil {
 		return nil, err
 	}
-	defer connection.Close()
-
-	if !settings.AnonymousMode {
-		err = connection.Bind(settings.ReaderDN, settings.Password)
-		if err != nil {
-			return nil, err
-		}
-	}
+	defer release()
 
 	userGroups := map[string]groupSet{}
 
@@ -286,6 +291,32 @@ func getGroupsByUser(userDN string, conn *ldap.Conn, settings []portainer.LDAPGr
 	return groups
 }
 
+// CheckSettings connects and binds to the LDAP server with the specified settings, then runs the user search.
+// It returns the number of users found, a failure is reported as a *CheckError.
+func (*Service) CheckSettings(settings *portainer.LDAPSettings) (int, error) {
+	connection, err := createConnection(settings)
+	if err != nil {
+		return 0, &CheckError{Stage: CheckStageConnection, Err: err}
+	}
+	defer connection.Close()
+
+	if !settings.AnonymousMode {
+		err = connection.Bind(settings.ReaderDN, settings.Password)
+	} else {
+		err = connection.UnauthenticatedBind("")
+	}
+	if err != nil {
+		return 0, &CheckError{Stage: CheckStageBind, Err: err}
+	}
+
+	users, err := searchUsers(connection, settings.SearchSettings)
+	if err != nil {
+		return 0, &CheckError{Stage: CheckStageSearch, Err: err}
+	}
+
+	return len(users), nil
+}
+
 // TestConnectivity is used to test a connection against the LDAP server using the credentials
 // specified in the LDAPSettings.
 func (*Service) TestConnectivity(settings *portainer.LDAPSettings) error {
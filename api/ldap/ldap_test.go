@@ -0,0 +1,38 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateConnectionForURL_minVersion(t *testing.T) {
+	is := assert.New(t)
+
+	// the server only speaks TLS 1.2, the connection fails during the handshake when TLS 1.3 is required
+	server := httptest.NewUnstartedServer(nil)
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	url := strings.TrimPrefix(server.URL, "https://")
+
+	connect := func(minVersion string) error {
+		conn, err := createConnectionForURL(url, &portainer.LDAPSettings{TLSConfig: portainer.TLSConfiguration{TLS: true, TLSSkipVerify: true}, TLSMinVersion: minVersion})
+		if err == nil {
+			conn.Close()
+		}
+
+		return err
+	}
+
+	is.NoError(connect(""))
+	is.NoError(connect("1.2"))
+	is.Error(connect("1.3"))
+	is.Error(connect("1.1"), "the deprecated versions are rejected")
+}
@@ -0,0 +1,198 @@
+package ldap
+
+import (
+	"fmt"
+	"time"
+
+	ldap "github.com/go-ldap/ldap/v3"
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// MaxPoolSize is the highest number of idle connections kept open to the LDAP server
+	MaxPoolSize = 100
+	// DefaultPoolIdleTimeout is used when the idle timeout is not configured in the settings
+	DefaultPoolIdleTimeout = 5 * time.Minute
+)
+
+type pooledConnection struct {
+	conn     *ldap.Conn
+	lastUsed time.Time
+}
+
+// connectionPool keeps the idle connections opened with one version of the connection settings
+type connectionPool struct {
+	key         string
+	size        int
+	idleTimeout time.Duration
+	idle        []pooledConnection
+	inUse       int
+}
+
+// poolKey identifies the settings a connection is opened with, a change of any of them requires new connections
+func poolKey(settings *portainer.LDAPSettings) string {
+	return fmt.Sprintf("%s|%t|%+v|%d|%s", settings.URL, settings.StartTLS, settings.TLSConfig, settings.PoolSize, settings.PoolIdleTimeout)
+}
+
+func poolIdleTimeout(settings *portainer.LDAPSettings) time.Duration {
+	idleTimeout, err := time.ParseDuration(settings.PoolIdleTimeout)
+	if err != nil || idleTimeout <= 0 {
+		return DefaultPoolIdleTimeout
+	}
+
+	return idleTimeout
+}
+
+// evictIdle closes the connections idle for longer than the idle timeout
+func (pool *connectionPool) evictIdle(now time.Time) {
+	idle := pool.idle[:0]
+	for _, pooled := range pool.idle {
+		if now.Sub(pooled.lastUsed) > pool.idleTimeout {
+			pooled.conn.Close()
+			continue
+		}
+
+		idle = append(idle, pooled)
+	}
+
+	pool.idle = idle
+}
+
+func (pool *connectionPool) close() {
+	for _, pooled := range pool.idle {
+		pooled.conn.Close()
+	}
+
+	pool.idle = nil
+}
+
+// connect returns a connection bound with the reader account, or anonymously in anonymous mode, and the function
+// releasing it. The connection is taken from the pool when it is enabled, a new one is opened otherwise
+func (service *Service) connect(settings *portainer.LDAPSettings) (*ldap.Conn, func(), error) {
+	if settings.PoolSize <= 0 {
+		conn, err := createConnection(settings)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = bindReader(conn, settings, false)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+
+		return conn, func() { conn.Close() }, nil
+	}
+
+	pool, conn := service.acquire(settings)
+	release := func() { service.release(pool, conn) }
+
+	// a pooled connection may have been closed by the server or left bound as the last authenticated user
+	if conn != nil {
+		if err := bindReader(conn, settings, true); err == nil {
+			return conn, release, nil
+		}
+
+		conn.Close()
+	}
+
+	conn, err := createConnection(settings)
+	if err == nil {
+		err = bindReader(conn, settings, false)
+	}
+
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		service.release(pool, nil)
+
+		return nil, nil, err
+	}
+
+	return conn, func() { service.release(pool, conn) }, nil
+}
+
+// acquire returns the pool of the settings, rebuilt when the settings changed, and one of its idle connections
+func (service *Service) acquire(settings *portainer.LDAPSettings) (*connectionPool, *ldap.Conn) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	key := poolKey(settings)
+	if service.pool == nil || service.pool.key != key {
+		if service.pool != nil {
+			service.pool.close()
+		}
+
+		service.pool = &connectionPool{key: key, size: min(settings.PoolSize, MaxPoolSize), idleTimeout: poolIdleTimeout(settings)}
+	}
+
+	pool := service.pool
+	pool.evictIdle(time.Now())
+	pool.inUse++
+
+	if n := len(pool.idle); n > 0 {
+		conn := pool.idle[n-1].conn
+		pool.idle = pool.idle[:n-1]
+
+		return pool, conn
+	}
+
+	return pool, nil
+}
+
+// release returns the connection to its pool, it is closed when the pool is full or was replaced
+func (service *Service) release(pool *connectionPool, conn *ldap.Conn) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	pool.inUse--
+
+	if conn == nil {
+		return
+	}
+
+	if conn.IsClosing() || service.pool != pool || len(pool.idle) >= pool.size {
+		conn.Close()
+		return
+	}
+
+	pool.idle = append(pool.idle, pooledConnection{conn: conn, lastUsed: time.Now()})
+}
+
+func bindReader(conn *ldap.Conn, settings *portainer.LDAPSettings, reused bool) error {
+	if !settings.AnonymousMode {
+		return conn.Bind(settings.ReaderDN, settings.Password)
+	}
+
+	// a new connection is anonymous until it is bound
+	if reused {
+		return conn.UnauthenticatedBind("")
+	}
+
+	return nil
+}
+
+// PoolStats returns the utilization of the pool of connections
+func (service *Service) PoolStats() portainer.LDAPPoolStats {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.pool == nil {
+		return portainer.LDAPPoolStats{}
+	}
+
+	return portainer.LDAPPoolStats{Size: service.pool.size, Idle: len(service.pool.idle), InUse: service.pool.inUse}
+}
+
+// ResetPool closes the idle connections, the connections in use are closed once released.
+// The pool is rebuilt with the settings of the next operation
+func (service *Service) ResetPool() {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.pool != nil {
+		service.pool.close()
+		service.pool = nil
+	}
+}
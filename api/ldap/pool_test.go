@@ -0,0 +1,56 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolIdleTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(DefaultPoolIdleTimeout, poolIdleTimeout(&portainer.LDAPSettings{}))
+	is.Equal(DefaultPoolIdleTimeout, poolIdleTimeout(&portainer.LDAPSettings{PoolIdleTimeout: "soon"}))
+	is.Equal(DefaultPoolIdleTimeout, poolIdleTimeout(&portainer.LDAPSettings{PoolIdleTimeout: "-1m"}))
+	is.Equal(30*time.Second, poolIdleTimeout(&portainer.LDAPSettings{PoolIdleTimeout: "30s"}))
+}
+
+func TestPoolKey(t *testing.T) {
+	is := assert.New(t)
+
+	settings := &portainer.LDAPSettings{URL: "ldap.local:389", ReaderDN: "cn=reader", PoolSize: 5}
+	key := poolKey(settings)
+
+	settings.ReaderDN = "cn=other"
+	is.Equal(key, poolKey(settings), "the reader is bound again on every use of a connection")
+
+	settings.StartTLS = true
+	is.NotEqual(key, poolKey(settings))
+}
+
+func TestPoolStats(t *testing.T) {
+	is := assert.New(t)
+
+	service := &Service{}
+	is.Equal(portainer.LDAPPoolStats{}, service.PoolStats())
+
+	settings := &portainer.LDAPSettings{URL: "ldap.local:389", PoolSize: MaxPoolSize + 1}
+
+	pool, conn := service.acquire(settings)
+	is.Nil(conn, "a new pool has no idle connection")
+	is.Equal(portainer.LDAPPoolStats{Size: MaxPoolSize, InUse: 1}, service.PoolStats())
+
+	service.release(pool, nil)
+	is.Equal(portainer.LDAPPoolStats{Size: MaxPoolSize}, service.PoolStats())
+
+	pool, _ = service.acquire(settings)
+	service.ResetPool()
+	is.Equal(portainer.LDAPPoolStats{}, service.PoolStats())
+
+	// releasing into a replaced pool does not change the current one
+	service.release(pool, nil)
+	is.Equal(portainer.LDAPPoolStats{}, service.PoolStats())
+}
@@ -0,0 +1,195 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// checkTimeout bounds each request of a settings check, so that an unreachable server does not hold the request
+const checkTimeout = 10 * time.Second
+
+// checkCode is the state, authorization code and access token sent by a settings check, the servers are expected to reject it
+const checkCode = "portainer-settings-check"
+
+// URLs checked by CheckSettings
+const (
+	CheckURLAuthorization = "AuthorizationURI"
+	CheckURLAccessToken   = "AccessTokenURI"
+	CheckURLResource      = "ResourceURI"
+)
+
+// URLCheckFailure describes why one of the URLs of the OAuth settings failed the check
+type URLCheckFailure struct {
+	// Settings field holding the URL, one of AuthorizationURI, AccessTokenURI or ResourceURI
+	Field   string `json:"field" example:"AccessTokenURI"`
+	URL     string `json:"url" example:"https://oauth.mydomain.tld/token"`
+	Message string `json:"message" example:"the client credentials were rejected: invalid_client"`
+}
+
+// CheckError lists the URLs that failed a settings check
+type CheckError struct {
+	Failures []URLCheckFailure
+}
+
+func (e *CheckError) Error() string {
+	fields := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		fields = append(fields, failure.Field)
+	}
+
+	return "OAuth check failed for " + strings.Join(fields, ", ")
+}
+
+// CheckSettings checks that the authorization, access token and resource URLs are reachable and answer like OAuth
+// endpoints. The access token URL is sent a dummy authorization code with the client credentials, so that rejected
+// credentials are reported while an invalid code is expected. A failure is reported as a *CheckError.
+func (*Service) CheckSettings(configuration *portainer.OAuthSettings) error {
+	client := &http.Client{
+		Timeout: checkTimeout,
+		// the authorization endpoint usually redirects to a login page, answering at all is enough
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	checks := []struct {
+		field string
+		url   string
+		check func(*http.Client, *portainer.OAuthSettings) error
+	}{
+		{CheckURLAuthorization, configuration.AuthorizationURI, checkAuthorizationURL},
+		{CheckURLAccessToken, configuration.AccessTokenURI, checkAccessTokenURL},
+		{CheckURLResource, configuration.ResourceURI, checkResourceURL},
+	}
+
+	var failures []URLCheckFailure
+	for _, c := range checks {
+		if err := c.check(client, configuration); err != nil {
+			failures = append(failures, URLCheckFailure{Field: c.field, URL: c.url, Message: err.Error()})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &CheckError{Failures: failures}
+	}
+
+	return nil
+}
+
+func checkAuthorizationURL(client *http.Client, configuration *portainer.OAuthSettings) error {
+	authURL := buildConfig(configuration).AuthCodeURL(checkCode)
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if resp.StatusCode == http.StatusOK && !hasContentType(resp, "text/html") {
+		return fmt.Errorf("unexpected content type %q, a login page is expected", resp.Header.Get("Content-Type"))
+	}
+
+	return nil
+}
+
+func checkAccessTokenURL(client *http.Client, configuration *portainer.OAuthSettings) error {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	_, err := buildConfig(configuration).Exchange(ctx, checkCode)
+	if err == nil {
+		return nil
+	}
+
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return err
+	}
+
+	resp := retrieveErr.Response
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if !hasContentType(resp, "application/json", "application/x-www-form-urlencoded", "text/plain") {
+		return fmt.Errorf("unexpected content type %q, a JSON or form encoded token response is expected", resp.Header.Get("Content-Type"))
+	}
+
+	if errorCode := tokenErrorCode(resp, retrieveErr.Body); errorCode == "invalid_client" || errorCode == "unauthorized_client" {
+		return fmt.Errorf("the client credentials were rejected: %s", errorCode)
+	}
+
+	return nil
+}
+
+func checkResourceURL(client *http.Client, configuration *portainer.OAuthSettings) error {
+	req, err := http.NewRequest(http.MethodGet, configuration.ResourceURI, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+checkCode)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the dummy token is expected to be rejected, the resource server only has to answer
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// tokenErrorCode returns the error code of a token error response, encoded in JSON or as a form like GitHub does
+func tokenErrorCode(resp *http.Response, body []byte) string {
+	if hasContentType(resp, "application/json") {
+		var tokenErr struct {
+			Error string `json:"error"`
+		}
+
+		if err := json.Unmarshal(body, &tokenErr); err == nil {
+			return tokenErr.Error
+		}
+
+		return ""
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+
+	return values.Get("error")
+}
+
+func hasContentType(resp *http.Response, contentTypes ...string) bool {
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, expected := range contentTypes {
+		if contentType == expected {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/oauth/oauthtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_CheckSettings(t *testing.T) {
+	is := assert.New(t)
+
+	srv, config := oauthtest.RunOAuthServer("valid-code", &portainer.OAuthSettings{ClientID: "client"})
+	defer srv.Close()
+
+	service := NewService()
+	is.NoError(service.CheckSettings(config))
+
+	rejectingClient := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer rejectingClient.Close()
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	invalidConfig := *config
+	invalidConfig.AccessTokenURI = rejectingClient.URL
+	invalidConfig.ResourceURI = notFound.URL
+
+	err := service.CheckSettings(&invalidConfig)
+
+	var checkErr *CheckError
+	is.True(errors.As(err, &checkErr))
+	is.Len(checkErr.Failures, 2)
+	is.Equal(CheckURLAccessToken, checkErr.Failures[0].Field)
+	is.Contains(checkErr.Failures[0].Message, "invalid_client")
+	is.Equal(CheckURLResource, checkErr.Failures[1].Field)
+	is.Equal(notFound.URL, checkErr.Failures[1].URL)
+}
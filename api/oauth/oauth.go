@@ -28,8 +28,9 @@ func NewService() *Service {
 // Authenticate takes an access code and exchanges it for an access token from portainer OAuthSettings token environment(endpoint).
 // On success, it will then return the username and token expiry time associated to authenticated user by fetching this information
 // from the resource server and matching it with the user identifier setting.
-func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings) (string, error) {
-	token, err := getOAuthToken(code, configuration)
+// The code verifier is sent with the code when the authorization was requested with a PKCE code challenge.
+func (*Service) Authenticate(code, codeVerifier string, configuration *portainer.OAuthSettings) (string, error) {
+	token, err := getOAuthToken(code, codeVerifier, configuration)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed retrieving oauth token")
 
@@ -69,14 +70,19 @@ func mergeSecondIntoFirst(base map[string]interface{}, overlap map[string]interf
 	return base
 }
 
-func getOAuthToken(code string, configuration *portainer.OAuthSettings) (*oauth2.Token, error) {
+func getOAuthToken(code, codeVerifier string, configuration *portainer.OAuthSettings) (*oauth2.Token, error) {
 	unescapedCode, err := url.QueryUnescape(code)
 	if err != nil {
 		return nil, err
 	}
 
+	var opts []oauth2.AuthCodeOption
+	if codeVerifier != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	}
+
 	config := buildConfig(configuration)
-	token, err := config.Exchange(context.Background(), unescapedCode)
+	token, err := config.Exchange(context.Background(), unescapedCode, opts...)
 	if err != nil {
 		return nil, err
 	}
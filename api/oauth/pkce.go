@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// NewPKCECodeVerifier returns a random code verifier of 43 characters, the shortest length allowed by RFC 7636
+func NewPKCECodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCECodeChallenge derives the code challenge sent to the authorization server from the code verifier
+func PKCECodeChallenge(codeVerifier, method string) (string, error) {
+	switch method {
+	case portainer.OAuthPKCEMethodS256:
+		sum := sha256.Sum256([]byte(codeVerifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+	case portainer.OAuthPKCEMethodPlain:
+		return codeVerifier, nil
+	}
+
+	return "", fmt.Errorf("unsupported PKCE challenge method %q", method)
+}
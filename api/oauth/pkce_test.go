@@ -0,0 +1,31 @@
+package oauth
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PKCECodeChallenge(t *testing.T) {
+	is := assert.New(t)
+
+	// example from RFC 7636, appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	challenge, err := PKCECodeChallenge(verifier, portainer.OAuthPKCEMethodS256)
+	is.NoError(err)
+	is.Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", challenge)
+
+	challenge, err = PKCECodeChallenge(verifier, portainer.OAuthPKCEMethodPlain)
+	is.NoError(err)
+	is.Equal(verifier, challenge)
+
+	_, err = PKCECodeChallenge(verifier, "S512")
+	is.Error(err)
+
+	generated, err := NewPKCECodeVerifier()
+	is.NoError(err)
+	is.Len(generated, 43)
+}
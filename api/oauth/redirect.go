@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ValidateRedirectURI returns an error when the URI cannot be registered as a redirect URI: it must be an absolute
+// http or https URL, without credentials nor fragment as required by RFC 6749
+func ValidateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("the scheme must be http or https")
+	}
+
+	if u.Host == "" {
+		return errors.New("the URL must be absolute")
+	}
+
+	if u.User != nil {
+		return errors.New("the URL must not contain credentials")
+	}
+
+	if u.Fragment != "" || u.RawFragment != "" {
+		return errors.New("the URL must not contain a fragment")
+	}
+
+	return nil
+}
+
+// IsAllowedRedirectURI returns true when the redirect URI is one of the allowed redirect URIs of the settings, or the
+// configured redirect URI when none is allowed. The URIs are compared as strings, a URI that merely looks alike, e.g.
+// with another path or a longer host, is rejected
+func IsAllowedRedirectURI(settings *portainer.OAuthSettings, redirectURI string) bool {
+	if redirectURI == "" {
+		return false
+	}
+
+	if len(settings.AllowedRedirectURIs) == 0 {
+		return redirectURI == settings.RedirectURI
+	}
+
+	return slices.Contains(settings.AllowedRedirectURIs, redirectURI)
+}
@@ -121,7 +121,10 @@ type (
 		Data                      *string
 		FeatureFlags              *[]string
 		DemoEnvironment           *bool
+		DemoBlockedRoutes         *[]string
 		EnableEdgeComputeFeatures *bool
+		EdgeEnforceHTTPS          *bool
+		JWTSigningKeyMode         *string
 		EndpointURL               *string
 		Labels                    *[]Pair
 		Logo                      *string
@@ -143,6 +146,7 @@ type (
 		InitialMmapSize           *int
 		MaxBatchSize              *int
 		MaxBatchDelay             *time.Duration
+		MaxUserSessionTimeout     *time.Duration
 		SecretKeyName             *string
 		LogLevel                  *string
 		LogMode                   *string
@@ -680,6 +684,22 @@ type (
 	// InternalAuthSettings represents settings used for the default 'internal' authentication
 	InternalAuthSettings struct {
 		RequiredPasswordLength int
+		// Whether a user must replace a password set by an administrator with a different one
+		RejectTemporaryPasswordReuse bool
+		// Number of previous passwords, including the current one, that cannot be reused. 0 disables the check
+		PasswordHistoryDepth int
+		// Number of consecutive failed password changes after which a user is locked out. 0 disables the lockout
+		PasswordChangeMaxFailedAttempts int
+		// Duration of the password change lockout, 15 minutes when empty
+		PasswordChangeLockoutDuration string `example:"15m"`
+		// Number of days after which a password expires and must be changed. 0 disables the expiry
+		PasswordExpiryDays int `example:"90"`
+		// Length from which a password is considered a passphrase and the character classes rule is waived. 0 disables the passphrase mode
+		PassphraseMinLength int `example:"16"`
+		// bcrypt cost of the password hashes, from 4 to 31. 0 uses the default cost of 10.
+		// Each increment doubles the CPU time spent hashing and verifying a password, at login as well.
+		// The existing hashes keep their cost until the password is changed
+		PasswordHashCost int `example:"12"`
 	}
 
 	// LDAPGroupSearchSettings represents settings used to search for groups in a LDAP server
@@ -714,11 +734,38 @@ type (
 		URL       string           `json:"URL" example:"myldap.domain.tld:389" validate:"hostname_port"`
 		TLSConfig TLSConfiguration `json:"TLSConfig"`
 		// Whether LDAP connection should use StartTLS
-		StartTLS            bool                      `json:"StartTLS" example:"true"`
+		StartTLS bool `json:"StartTLS" example:"true"`
+		// Minimum TLS protocol version of the LDAP connections with TLS or StartTLS, 1.2 or 1.3. 1.2 when empty
+		TLSMinVersion       string                    `json:"TLSMinVersion,omitempty" example:"1.2"`
 		SearchSettings      []LDAPSearchSettings      `json:"SearchSettings"`
 		GroupSearchSettings []LDAPGroupSearchSettings `json:"GroupSearchSettings"`
 		// Automatically provision users and assign them to matching LDAP group names
 		AutoCreateUsers bool `json:"AutoCreateUsers" example:"true"`
+		// Number of idle connections kept open to the LDAP server, 0 to open a connection for each operation
+		PoolSize int `json:"PoolSize" example:"10"`
+		// Duration after which an idle pooled connection is closed, 5 minutes when empty
+		PoolIdleTimeout string `json:"PoolIdleTimeout" example:"5m"`
+	}
+
+	// SnapshotQuietHours represents a daily window, in the wall clock of a timezone, without scheduled snapshots.
+	// The window spans midnight when End is before Start
+	SnapshotQuietHours struct {
+		// Start of the window, as HH:MM
+		Start string `json:"Start" example:"08:00"`
+		// End of the window, as HH:MM
+		End string `json:"End" example:"18:00"`
+		// IANA name of the timezone of Start and End, UTC when empty
+		Timezone string `json:"Timezone" example:"Europe/Paris"`
+	}
+
+	// LDAPPoolStats represents the utilization of the pool of LDAP connections
+	LDAPPoolStats struct {
+		// Maximum number of idle connections, 0 when the pool is disabled
+		Size int `json:"Size" example:"10"`
+		// Number of idle connections
+		Idle int `json:"Idle" example:"2"`
+		// Number of connections in use
+		InUse int `json:"InUse" example:"1"`
 	}
 
 	// LDAPUser represents a LDAP user
@@ -753,6 +800,12 @@ type (
 		SSO                  bool   `json:"SSO"`
 		LogoutURI            string `json:"LogoutURI"`
 		KubeSecretKey        []byte `json:"KubeSecretKey"`
+		// Whether the authorization code flow is protected with PKCE
+		UsePKCE bool `json:"UsePKCE"`
+		// PKCE code challenge method, S256 or plain
+		PKCEChallengeMethod string `json:"PKCEChallengeMethod,omitempty" example:"S256"`
+		// Redirect URIs accepted by the OAuth login, only RedirectURI is accepted when empty
+		AllowedRedirectURIs []string `json:"AllowedRedirectURIs" example:"https://portainer.mydomain.tld"`
 	}
 
 	// Pair defines a key/value string pair
@@ -785,6 +838,14 @@ type (
 		Quay                    QuayRegistryData                 `json:"Quay"`
 		Ecr                     EcrData                          `json:"Ecr"`
 		RegistryAccesses        RegistryAccesses                 `json:"RegistryAccesses"`
+		// Namespaces of the Kubernetes environments of a group with access to the registry, merged with the namespaces
+		// of each environment
+		GroupRegistryAccesses GroupRegistryAccesses `json:"GroupRegistryAccesses,omitempty"`
+		// Latest changes of the registry accesses, oldest first
+		AccessHistory []RegistryAccessChange `json:"AccessHistory,omitempty"`
+		// Template of the name of the Kubernetes secrets of the registry, {registryId} and {namespace} are substituted.
+		// The secrets are named registry-<registry ID> when it is empty
+		SecretNameTemplate string `json:"SecretNameTemplate,omitempty" example:"regcred-{registryId}"`
 
 		// Deprecated fields
 		// Deprecated in DBVersion == 31
@@ -808,6 +869,32 @@ type (
 		UserAccessPolicies UserAccessPolicies `json:"UserAccessPolicies"`
 		TeamAccessPolicies TeamAccessPolicies `json:"TeamAccessPolicies"`
 		Namespaces         []string           `json:"Namespaces"`
+		// Whether the namespaces of the group of the environment are ignored, only its own namespaces have access
+		ExcludeGroupAccess bool `json:"ExcludeGroupAccess,omitempty" example:"false"`
+	}
+
+	GroupRegistryAccesses map[EndpointGroupID]GroupRegistryAccess
+
+	// GroupRegistryAccess represents the access of the Kubernetes environments of a group to a registry
+	GroupRegistryAccess struct {
+		// Namespaces with access to the registry in each Kubernetes environment of the group
+		Namespaces []string `json:"Namespaces" example:"default"`
+	}
+
+	// RegistryAccessChange represents a change of the access policies of a registry for an environment
+	RegistryAccessChange struct {
+		// Environment(Endpoint) identifier, zero when the access granted to an environment group changed
+		EndpointID EndpointID `json:"EndpointId" example:"1"`
+		// Environment(Endpoint) group identifier, only set when the access granted to the group changed
+		EndpointGroupID EndpointGroupID `json:"EndpointGroupId,omitempty" example:"1"`
+		// Identifier of the user who made the change
+		UserID UserID `json:"UserId" example:"1"`
+		// Unix timestamp of the change
+		Timestamp int64                  `json:"Timestamp" example:"1587399600"`
+		Before    RegistryAccessPolicies `json:"Before"`
+		After     RegistryAccessPolicies `json:"After"`
+		// Whether the change restores the access before the previous change, it cannot be undone itself
+		Undo bool `json:"Undo,omitempty" example:"false"`
 	}
 
 	// RegistryID represents a registry identifier
@@ -884,6 +971,33 @@ type (
 	// APIKeyID represents an API key identifier
 	APIKeyID int
 
+	// AuditLogEntryID represents an audit log entry identifier
+	AuditLogEntryID int
+
+	// AuditLogEntry records a high-impact change
+	AuditLogEntry struct {
+		ID AuditLogEntryID `json:"Id" example:"1"`
+		// Kind of change
+		Action AuditLogAction `json:"Action" example:"authenticationMethodChange"`
+		// Identifier of the user who made the change
+		UserID UserID `json:"UserId" example:"1"`
+		// Unix timestamp of the change
+		Timestamp int64 `json:"Timestamp" example:"1587399600"`
+		// Value before the change
+		Before string `json:"Before" example:"internal"`
+		// Value after the change
+		After string `json:"After" example:"ldap"`
+	}
+
+	// AuditLogAction represents the kind of change recorded in the audit log
+	AuditLogAction string
+
+	// UIFeature represents a feature of the UI that can be disabled in the settings
+	UIFeature string
+
+	// HelmRepositoryType represents the kind of server hosting a Helm repository
+	HelmRepositoryType string
+
 	// APIKey represents an API key
 	APIKey struct {
 		ID          APIKeyID `json:"id" example:"1"`
@@ -928,6 +1042,8 @@ type (
 	Settings struct {
 		// URL to a logo that will be displayed on the login page as well as on top of the sidebar. Will use default Portainer logo when value is empty string
 		LogoURL string `json:"LogoURL" example:"https://mycompany.mydomain.tld/logo.png"`
+		// Whether the logo URL must be checked to serve an image when it is updated. Should stay disabled on air-gapped setups
+		EnforceLogoURLImage bool `json:"EnforceLogoURLImage" example:"false"`
 		// A list of label name & value that will be used to hide containers when querying containers
 		BlackListedLabels []Pair `json:"BlackListedLabels"`
 		// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, or 3 for oauth
@@ -966,6 +1082,65 @@ type (
 		AgentSecret string `json:"AgentSecret"`
 		// EdgePortainerURL is the URL that is exposed to edge agents
 		EdgePortainerURL string `json:"EdgePortainerUrl"`
+		// The maximum number of API keys a user can own, 0 means unlimited
+		MaxAPIKeysPerUser int `json:"MaxAPIKeysPerUser" example:"0"`
+		// Minimum duration between two changes of the authentication method, empty to disable the cooldown
+		AuthenticationMethodChangeCooldown string `json:"AuthenticationMethodChangeCooldown" example:"1h"`
+		// Unix timestamp of the last change of the authentication method
+		AuthenticationMethodChangedAt int64 `json:"AuthenticationMethodChangedAt" example:"1587399600"`
+		// Whether team leaders can manage the registry accesses of the environments their teams have access to
+		TeamLeadersManageRegistryAccess bool `json:"TeamLeadersManageRegistryAccess" example:"false"`
+		// Number of days before the expiry of the LDAP CA certificate from which a warning is raised, 0 to disable the check
+		LDAPCAExpiryWarningDays int `json:"LDAPCAExpiryWarningDays" example:"30"`
+		// URL notified when a warning about the expiry of the LDAP CA certificate is raised
+		LDAPCAExpiryWebhookURL string `json:"LDAPCAExpiryWebhookURL" example:"https://hooks.mycompany.tld/ldap"`
+		// Warning raised by the periodic check of the LDAP CA certificate expiry
+		LDAPCAExpiryWarning string `json:"LDAPCAExpiryWarning,omitempty" example:"The LDAP CA certificate expires on 2024-01-01T00:00:00Z"`
+		// The maximum number of user and team access policies in a registry access update, 0 means the default limit
+		MaxRegistryAccessPolicies int `json:"MaxRegistryAccessPolicies" example:"0"`
+		// The maximum number of namespaces in a registry access update, 0 means the default limit
+		MaxRegistryAccessNamespaces int `json:"MaxRegistryAccessNamespaces" example:"0"`
+		// Key used to sign the JWT tokens encrypted with the database encryption key, only persisted when the signing
+		// key mode is set to persist
+		JWTSigningKey []byte `json:"JWTSigningKey,omitempty"`
+		// URL of the proxy used for the outbound requests, the proxy defined by the environment is used when empty
+		OutboundProxyURL string `json:"OutboundProxyURL" example:"http://proxy.mycompany.tld:3128"`
+		// Whether the last administrator can change their own role away from administrator
+		AllowLastAdminSelfDemotion bool `json:"AllowLastAdminSelfDemotion" example:"false"`
+		// Whether the app templates are served from the uploaded templates file instead of TemplatesURL
+		UseLocalTemplatesFile bool `json:"UseLocalTemplatesFile" example:"false"`
+		// Maximum age of the session used to change a password, empty to disable the check
+		PasswordChangeReauthenticationWindow string `json:"PasswordChangeReauthenticationWindow" example:"15m"`
+		// Whether the Kubernetes registry secrets are left untouched when the credentials of a registry change, they are still renamed when the secret name template changes
+		DisableRegistrySecretRefresh bool `json:"DisableRegistrySecretRefresh" example:"false"`
+		// Helm repository URLs, the first one is also stored in HelmRepositoryURL
+		HelmRepositoryURLs []string `json:"HelmRepositoryURLs"`
+		// Unix timestamp, the user sessions started before it are closed once they exceed the user session timeout
+		TokenIssueFloor int64 `json:"TokenIssueFloor" example:"1587399600"`
+		// Whether administrators with a local password can still log in with it when the authentication method is LDAP or OAuth
+		EnableLocalAdminFallback bool `json:"EnableLocalAdminFallback" example:"false"`
+		// Number of environments snapshotted in parallel, the environments are snapshotted one at a time when it is 0
+		SnapshotWorkerCount int `json:"SnapshotWorkerCount" example:"4"`
+		// Unix timestamp, every token issued before it is rejected. Set when all the sessions are revoked
+		SessionRevocationFloor int64 `json:"SessionRevocationFloor" example:"1587399600"`
+		// Incremented by every settings update, exposed as the ETag of the settings
+		Revision int `json:"Revision" example:"3"`
+		// URL notified with the changed fields, secrets redacted, after each settings update. Empty to disable the notifications
+		SettingsChangeWebhookURL string `json:"SettingsChangeWebhookURL" example:"https://hooks.mycompany.tld/settings"`
+		// Features hidden from the UI
+		DisabledFeatures []UIFeature `json:"DisabledFeatures" example:"appTemplates,kubectlShell"`
+		// Type of the Helm repository of HelmRepositoryURL, empty in the settings saved before the support of the OCI registries
+		HelmRepositoryType HelmRepositoryType `json:"HelmRepositoryType" example:"classic"`
+		// Number of consecutive failed logins after which the account is locked, 0 to disable the lockout
+		MaxLoginAttempts int `json:"MaxLoginAttempts" example:"5"`
+		// Duration of the login lockout, 15 minutes when empty
+		LoginLockoutDuration string `json:"LoginLockoutDuration" example:"15m"`
+		// Whether the administrators are never locked out, so that they cannot be locked out of the instance
+		LoginLockoutExemptAdmins bool `json:"LoginLockoutExemptAdmins" example:"false"`
+		// Daily window during which the scheduled snapshots are skipped, disabled when Start and End are empty
+		SnapshotQuietHours SnapshotQuietHours `json:"SnapshotQuietHours"`
+		// Whether the administrators must provide a TOTP code of their second factor to change their password
+		RequireTwoFactorForAdmins bool `json:"RequireTwoFactorForAdmins" example:"false"`
 
 		Edge struct {
 			// The command list interval for edge agent - used in edge async mode (in seconds)
@@ -996,6 +1171,18 @@ type (
 		IsDockerDesktopExtension bool `json:"IsDockerDesktopExtension"`
 	}
 
+	// SettingsOverride represents a setting whose stored value is replaced at startup by an environment variable or a CLI flag
+	SettingsOverride struct {
+		// JSON path of the overridden setting
+		Field string `json:"field" example:"AgentSecret"`
+		// Source of the override, env or flag
+		Source string `json:"source" example:"env"`
+		// Name of the environment variable or of the CLI flag
+		Name string `json:"name" example:"AGENT_SECRET"`
+		// Effective value of the setting, redacted for secrets
+		Value any `json:"value"`
+	}
+
 	// SnapshotJob represents a scheduled job that can create environment(endpoint) snapshots
 	SnapshotJob struct{}
 
@@ -1269,6 +1456,8 @@ type (
 		Username            string
 		Role                UserRole
 		ForceChangePassword bool
+		// Unix timestamp of the authentication that created the session, 0 when authenticated with an API key
+		AuthenticatedAt int64
 	}
 
 	// TunnelDetails represents information associated to a tunnel
@@ -1295,6 +1484,24 @@ type (
 		Role          UserRole `json:"Role" example:"1"`
 		TokenIssueAt  int64    `json:"TokenIssueAt" example:"1"`
 		ThemeSettings UserThemeSettings
+		// Whether the current password was set by an administrator, e.g. a temporary password
+		PasswordSetByAdmin bool `json:"PasswordSetByAdmin" example:"false"`
+		// Unix timestamp of the last password change
+		PasswordUpdatedAt int64 `json:"PasswordUpdatedAt" example:"1587399600"`
+		// Whether the password expired and must be changed at the next login
+		PasswordExpired bool `json:"PasswordExpired" example:"false"`
+		// Whether an administrator requires the user to change the password at the next login
+		ForcePasswordChange bool `json:"ForcePasswordChange" example:"false"`
+		// Expiry of the kubeconfigs generated for the user, bounded by the global KubeconfigExpiry. The global expiry applies when empty
+		KubeconfigExpiry string `json:"KubeconfigExpiry,omitempty" example:"8h"`
+		// Hashes of the previous passwords, most recent last
+		PasswordHistory []string `json:"PasswordHistory,omitempty" swaggerignore:"true"`
+		// Unix timestamp of the last successful login
+		LastLoginAt int64 `json:"LastLoginAt" example:"1587399600"`
+		// Base32 secret of the TOTP second factor, empty when the user did not enroll one
+		TOTPSecret string `json:"TOTPSecret,omitempty" swaggerignore:"true"`
+		// Time step of the last TOTP code accepted, the codes of this step and of the previous ones cannot be used again
+		TOTPLastStep int64 `json:"TOTPLastStep,omitempty" swaggerignore:"true"`
 
 		// Deprecated fields
 
@@ -1370,7 +1577,8 @@ type (
 	// CryptoService represents a service for encrypting/hashing data
 	CryptoService interface {
 		Hash(data string) (string, error)
-		CompareHashAndData(hash string, data string) error
+		CompareHashAndData(hash string, data string) (needsRehash bool, err error)
+		SetHashCost(cost int)
 	}
 
 	// DigitalSignatureService represents a service to manage digital signatures
@@ -1434,6 +1642,13 @@ type (
 		CopySSLCertPair(certPath, keyPath string) (string, string, error)
 		CopySSLCACert(caCertPath string) (string, error)
 		StoreFDOProfileFileFromBytes(fdoProfileIdentifier string, data []byte) (string, error)
+		StoreTemplatesFile(data []byte) (string, error)
+		GetTemplatesFile() ([]byte, error)
+		StoreLogoFile(data []byte) (string, error)
+		GetLogoFile() ([]byte, error)
+		RemoveLogoFile() error
+		StoreSettingsProfileFile(name string, data []byte) error
+		GetSettingsProfileFile(name string) ([]byte, error)
 		StoreMTLSCertificates(cert, caCert, key []byte) (string, string, string, error)
 		GetDefaultChiselPrivateKeyPath() string
 		StoreChiselPrivateKey(privateKey []byte) error
@@ -1489,6 +1704,7 @@ type (
 		DeleteRegistrySecret(registry *Registry, namespace string) error
 		CreateRegistrySecret(registry *Registry, namespace string) error
 		IsRegistrySecret(namespace, secretName string) (bool, error)
+		GetRegistrySecret(registry *Registry, namespace string) (*models.K8sRegistrySecret, error)
 		ToggleSystemState(namespace string, isSystem bool) error
 	}
 
@@ -1508,14 +1724,18 @@ type (
 	LDAPService interface {
 		AuthenticateUser(username, password string, settings *LDAPSettings) error
 		TestConnectivity(settings *LDAPSettings) error
+		CheckSettings(settings *LDAPSettings) (int, error)
 		GetUserGroups(username string, settings *LDAPSettings) ([]string, error)
 		SearchGroups(settings *LDAPSettings) ([]LDAPUser, error)
 		SearchUsers(settings *LDAPSettings) ([]string, error)
+		PoolStats() LDAPPoolStats
+		ResetPool()
 	}
 
 	// OAuthService represents a service used to authenticate users using OAuth
 	OAuthService interface {
-		Authenticate(code string, configuration *OAuthSettings) (string, error)
+		Authenticate(code, codeVerifier string, configuration *OAuthSettings) (string, error)
+		CheckSettings(configuration *OAuthSettings) error
 	}
 
 	// ReverseTunnelService represents a service used to manage reverse tunnel connections.
@@ -1543,6 +1763,10 @@ type (
 	SnapshotService interface {
 		Start()
 		SetSnapshotInterval(snapshotInterval string) error
+		SetSnapshotWorkerCount(count int)
+		SetSnapshotQuietHours(quietHours SnapshotQuietHours) error
+		NextSnapshotTime() time.Time
+		SnapshotNow()
 		SnapshotEndpoint(endpoint *Endpoint) error
 		FillSnapshotData(endpoint *Endpoint) error
 	}
@@ -1599,6 +1823,33 @@ const (
 	DefaultUserSessionTimeout = "8h"
 	// DefaultUserSessionTimeout represents the default timeout after which the user session is cleared
 	DefaultKubeconfigExpiry = "0"
+	// OAuthPKCEMethodS256 is the PKCE code challenge method sending the SHA-256 hash of the code verifier
+	OAuthPKCEMethodS256 = "S256"
+	// OAuthPKCEMethodPlain is the PKCE code challenge method sending the code verifier itself
+	OAuthPKCEMethodPlain = "plain"
+	// DefaultMaxUserSessionTimeout is the longest user session timeout that can be configured in the settings
+	DefaultMaxUserSessionTimeout = 30 * 24 * time.Hour
+	// DefaultLoginLockoutDuration is the lockout duration used when it is not configured in the settings
+	DefaultLoginLockoutDuration = 15 * time.Minute
+	// JWTSigningKeyRegenerate makes Portainer generate a new JWT signing key on each start, which invalidates the sessions
+	JWTSigningKeyRegenerate = "regenerate"
+	// JWTSigningKeyPersist makes Portainer persist the JWT signing key, encrypted with the database encryption key, so the
+	// sessions survive a restart. It requires an encryption key, without one a new key is generated on each start.
+	// A persisted key stays valid across restarts, anyone holding the database and its encryption key can sign tokens
+	// until the mode is switched back to regenerate
+	JWTSigningKeyPersist = "persist"
+	// MaxPasswordHistoryDepth is the highest number of previous passwords that can be kept for a user
+	MaxPasswordHistoryDepth = 24
+	// MinPasswordHashCost is the lowest bcrypt cost accepted for the password hashes
+	MinPasswordHashCost = 4
+	// MaxPasswordHashCost is the highest bcrypt cost accepted for the password hashes
+	MaxPasswordHashCost = 31
+	// SettingsOverrideSourceEnv is the source of a setting overridden by an environment variable
+	SettingsOverrideSourceEnv = "env"
+	// SettingsOverrideSourceFlag is the source of a setting overridden by a CLI flag
+	SettingsOverrideSourceFlag = "flag"
+	// SettingsOverrideRedacted replaces the value of an overridden secret setting
+	SettingsOverrideRedacted = "[REDACTED]"
 	// DefaultKubectlShellImage represents the default image and tag for the kubectl shell
 	DefaultKubectlShellImage = "portainer/kubectl-shell"
 	// WebSocketKeepAlive web socket keep alive for edge environments
@@ -1607,7 +1858,9 @@ const (
 
 // List of supported features
 const (
-	FeatureFdo  = "fdo"
+	FeatureFdo = "fdo"
+	// Deprecated: the handlers update the database in a transaction, the non transactional paths
+	// enabled by this flag will be removed
 	FeatureNoTx = "noTx"
 )
 
@@ -1626,6 +1879,43 @@ const (
 	AuthenticationOAuth
 )
 
+const (
+	// AuditLogAuthenticationMethodChange records a change of the authentication method
+	AuditLogAuthenticationMethodChange AuditLogAction = "authenticationMethodChange"
+)
+
+const (
+	// UIFeatureAppTemplates is the application templates
+	UIFeatureAppTemplates UIFeature = "appTemplates"
+	// UIFeatureCustomTemplates is the custom templates
+	UIFeatureCustomTemplates UIFeature = "customTemplates"
+	// UIFeatureHelm is the deployment of Helm charts
+	UIFeatureHelm UIFeature = "helm"
+	// UIFeatureKubectlShell is the kubectl shell of the Kubernetes environments
+	UIFeatureKubectlShell UIFeature = "kubectlShell"
+	// UIFeatureContainerConsole is the console of the containers and pods
+	UIFeatureContainerConsole UIFeature = "containerConsole"
+	// UIFeatureStackGit is the deployment of stacks from a Git repository
+	UIFeatureStackGit UIFeature = "stackGit"
+)
+
+const (
+	// HelmRepositoryTypeClassic is a Helm repository served over HTTP with an index.yaml file
+	HelmRepositoryTypeClassic HelmRepositoryType = "classic"
+	// HelmRepositoryTypeOCI is a Helm repository stored in an OCI registry
+	HelmRepositoryTypeOCI HelmRepositoryType = "oci"
+)
+
+// UIFeatures lists the features of the UI that can be disabled
+var UIFeatures = []UIFeature{
+	UIFeatureAppTemplates,
+	UIFeatureCustomTemplates,
+	UIFeatureHelm,
+	UIFeatureKubectlShell,
+	UIFeatureContainerConsole,
+	UIFeatureStackGit,
+}
+
 const (
 	_ AgentPlatform = iota
 	// AgentPlatformDocker represent the Docker platform (Standalone/Swarm)
@@ -11,4 +11,9 @@ var d = document,
 g.type = 'text/javascript';
 g.async = true;
 g.src = '//cdn.matomo.cloud/portainer-ce.matomo.cloud/matomo.js';
+// the tracker is loaded by the browser, without it the events are only queued and nothing is sent
+g.onerror = function () {
+  // eslint-disable-next-line no-console
+  console.warn('The telemetry service cannot be reached, telemetry is disabled for this session');
+};
 s.parentNode.insertBefore(g, s);
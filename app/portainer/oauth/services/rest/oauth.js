@@ -14,6 +14,13 @@ angular.module('portainer.oauth').factory('OAuth', [
             action: 'validate',
           },
         },
+        pkce: {
+          method: 'POST',
+          ignoreLoadingBar: true,
+          params: {
+            action: 'pkce',
+          },
+        },
       }
     );
   },
@@ -53,6 +53,7 @@ class AuthenticationController {
     this.postLoginSteps = this.postLoginSteps.bind(this);
 
     this.oAuthLoginAsync = this.oAuthLoginAsync.bind(this);
+    this.startPKCEOAuthLoginAsync = this.startPKCEOAuthLoginAsync.bind(this);
     this.internalLoginAsync = this.internalLoginAsync.bind(this);
 
     this.authenticateUserAsync = this.authenticateUserAsync.bind(this);
@@ -162,9 +163,37 @@ class AuthenticationController {
    * LOGIN METHODS SECTION
    */
 
-  async oAuthLoginAsync(code) {
+  // the state and the code challenge are requested on click, the code verifier kept by Portainer expires after a few minutes
+  async startPKCEOAuthLoginAsync() {
     try {
-      await this.Authentication.OAuthLogin(code);
+      const pkce = await this.Authentication.OAuthPKCE();
+      this.LocalStorage.storeLoginStateUUID(pkce.State);
+      this.$window.location.assign(
+        this.state.OAuthLoginURI +
+          '&state=' +
+          encodeURIComponent(pkce.State) +
+          '&code_challenge=' +
+          encodeURIComponent(pkce.CodeChallenge) +
+          '&code_challenge_method=' +
+          encodeURIComponent(pkce.CodeChallengeMethod)
+      );
+    } catch (err) {
+      this.error(err, 'Unable to login via OAuth');
+    }
+  }
+
+  startOAuthLogin($event) {
+    if (!this.state.OAuthUsePKCE) {
+      return;
+    }
+
+    $event.preventDefault();
+    return this.$async(this.startPKCEOAuthLoginAsync);
+  }
+
+  async oAuthLoginAsync(code, state) {
+    try {
+      await this.Authentication.OAuthLogin(code, state);
       this.URLHelper.cleanParameters();
     } catch (err) {
       this.error(err, 'Unable to login via OAuth');
@@ -209,7 +238,7 @@ class AuthenticationController {
    */
   async manageOauthCodeReturn(code, state) {
     if (this.hasValidState(state)) {
-      await this.oAuthLoginAsync(code);
+      await this.oAuthLoginAsync(code, state);
     } else {
       this.error(null, 'Invalid OAuth state, try again.');
     }
@@ -236,6 +265,7 @@ class AuthenticationController {
       this.state.showOAuthLogin = settings.AuthenticationMethod === 3;
       this.state.showStandardLogin = !this.state.showOAuthLogin;
       this.state.OAuthLoginURI = settings.OAuthLoginURI;
+      this.state.OAuthUsePKCE = settings.OAuthUsePKCE;
       this.state.OAuthProvider = this.determineOauthProvider(settings.OAuthLoginURI);
 
       const code = this.URLHelper.getParameter('code');
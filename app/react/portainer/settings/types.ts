@@ -178,6 +178,8 @@ export interface PublicSettingsResponse {
   OAuthLoginURI: string;
   /** The URL used for oauth logout */
   OAuthLogoutURI: string;
+  /** Whether the oauth login must start with /auth/oauth/pkce */
+  OAuthUsePKCE: boolean;
   /** Whether portainer internal auth view will be hidden (only on BE) */
   OAuthHideInternalAuth: boolean;
   /** Whether telemetry is enabled */
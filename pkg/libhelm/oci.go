@@ -0,0 +1,65 @@
+package libhelm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OCIScheme is the scheme of the Helm repositories stored in an OCI registry
+const OCIScheme = "oci"
+
+// IsOCIRepositoryURL returns true when the Helm repository is stored in an OCI registry
+func IsOCIRepositoryURL(repoUrl string) bool {
+	return strings.HasPrefix(strings.ToLower(repoUrl), OCIScheme+"://")
+}
+
+// ParseOCIRepositoryURL parses an OCI Helm repository URL, e.g. oci://registry.example.com/charts
+func ParseOCIRepositoryURL(repoUrl string) (*url.URL, error) {
+	u, err := url.Parse(repoUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid OCI helm repository URL: %s", repoUrl)
+	}
+
+	if !strings.EqualFold(u.Scheme, OCIScheme) || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.Errorf("invalid OCI helm repository URL: %s", repoUrl)
+	}
+
+	return u, nil
+}
+
+// validateOCIRepositoryURL checks that the registry of the repository implements the OCI distribution API,
+// the charts themselves may require credentials so an unauthorized answer is accepted
+func validateOCIRepositoryURL(ctx context.Context, repoUrl string, client *http.Client) error {
+	u, err := ParseOCIRepositoryURL(repoUrl)
+	if err != nil {
+		return err
+	}
+
+	if client == nil {
+		client = &http.Client{
+			Timeout: time.Second * 10,
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+u.Host+"/v2/", nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid OCI helm repository URL: %s", repoUrl)
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, invalidChartRepo, repoUrl)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusUnauthorized {
+		return errors.Errorf(invalidChartRepo, repoUrl)
+	}
+
+	return nil
+}
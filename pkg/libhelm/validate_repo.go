@@ -1,6 +1,7 @@
 package libhelm
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -14,10 +15,20 @@ import (
 const invalidChartRepo = "%q is not a valid chart repository or cannot be reached"
 
 func ValidateHelmRepositoryURL(repoUrl string, client *http.Client) error {
+	return ValidateHelmRepositoryURLWithContext(context.Background(), repoUrl, client)
+}
+
+// ValidateHelmRepositoryURLWithContext validates the Helm repository like ValidateHelmRepositoryURL, the request
+// sent to the repository is cancelled with the context
+func ValidateHelmRepositoryURLWithContext(ctx context.Context, repoUrl string, client *http.Client) error {
 	if repoUrl == "" {
 		return errors.New("URL is required")
 	}
 
+	if IsOCIRepositoryURL(repoUrl) {
+		return validateOCIRepositoryURL(ctx, repoUrl, client)
+	}
+
 	url, err := url.ParseRequestURI(repoUrl)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("invalid helm chart URL: %s", repoUrl))
@@ -35,10 +46,16 @@ func ValidateHelmRepositoryURL(repoUrl string, client *http.Client) error {
 		}
 	}
 
-	response, err := client.Head(url.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("invalid helm chart URL: %s", repoUrl))
+	}
+
+	response, err := client.Do(req)
 	if err != nil {
 		return errors.Wrapf(err, invalidChartRepo, repoUrl)
 	}
+	defer response.Body.Close()
 
 	// Success is indicated with 2xx status codes
 	statusOK := response.StatusCode >= 200 && response.StatusCode < 300
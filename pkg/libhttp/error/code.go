@@ -0,0 +1,45 @@
+package error
+
+import "errors"
+
+// CodedError is an error carrying a stable machine-readable code, returned in the code field of the error responses
+// so that the clients do not have to match the messages
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode attaches a machine-readable code to the error
+func WithCode(code string, err error) error {
+	return &CodedError{Code: code, Err: err}
+}
+
+// WithCode sets the machine-readable code of the error response
+func (h *HandlerError) WithCode(code string) *HandlerError {
+	h.Code = code
+
+	return h
+}
+
+// ErrorCode returns the machine-readable code of the handler error, or the code of the error it wraps, empty when
+// neither has one
+func ErrorCode(h *HandlerError) string {
+	if h.Code != "" {
+		return h.Code
+	}
+
+	var codedErr *CodedError
+	if errors.As(h.Err, &codedErr) {
+		return codedErr.Code
+	}
+
+	return ""
+}
@@ -16,6 +16,7 @@ type (
 	errorResponse struct {
 		Message string `json:"message,omitempty"`
 		Details string `json:"details,omitempty"`
+		Code    string `json:"code,omitempty"`
 	}
 )
 
@@ -36,11 +37,11 @@ func writeErrorResponse(rw http.ResponseWriter, err *HandlerError) {
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(err.StatusCode)
 
-	json.NewEncoder(rw).Encode(&errorResponse{Message: err.Message, Details: err.Err.Error()})
+	json.NewEncoder(rw).Encode(&errorResponse{Message: err.Message, Details: err.Err.Error(), Code: ErrorCode(err)})
 }
 
 // WriteError is a convenience function that creates a new HandlerError before calling writeErrorResponse.
 // For use outside of the standard http handlers.
 func WriteError(rw http.ResponseWriter, code int, message string, err error) {
-	writeErrorResponse(rw, &HandlerError{code, message, err})
+	writeErrorResponse(rw, &HandlerError{StatusCode: code, Message: message, Err: err})
 }
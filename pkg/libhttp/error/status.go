@@ -7,6 +7,8 @@ type HandlerError struct {
 	StatusCode int
 	Message    string
 	Err        error
+	// Machine-readable code of the error, the code of Err is used when empty
+	Code string
 }
 
 func (h *HandlerError) Error() string {